@@ -1,8 +1,10 @@
 package main
 
 import (
+	_ "embed"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/bitrise-io/go-steputils/v2/ruby"
 	"github.com/bitrise-io/go-steputils/v2/stepconf"
@@ -16,29 +18,76 @@ import (
 	"github.com/bitrise-steplib/steps-xcode-archive/step"
 )
 
+//go:embed step.yml
+var stepYML []byte
+
 func main() {
 	os.Exit(run())
 }
 
 func run() int {
-	logger := log.NewLogger()
-	configParser := createConfigParser(logger)
+	redactor := step.NewIdentifierRedactor()
+	logger := step.NewRedactingLogger(log.NewLogger(), redactor)
+	phases := step.NewPhaseTracker()
+	insights := step.NewInsightsRecorder()
+
+	cancellation := step.NewCancellationHandler(command.NewFactory(env.NewRepository()), logger)
+	cancellation.Start()
+	defer cancellation.Stop()
+
+	phases.Begin("input processing")
+	configParser, err := createConfigParser(logger, redactor, os.Args[1:])
+	if err != nil {
+		logger.Errorf("%s", errorutil.FormattedError(fmt.Errorf("Failed to process Step inputs: %w", err)))
+		return 1
+	}
 	config, err := configParser.ProcessInputs()
 	if err != nil {
 		logger.Errorf("%s", errorutil.FormattedError(fmt.Errorf("Failed to process Step inputs: %w", err)))
 		return 1
 	}
+	cleanupInlineSecrets := func() {
+		if err := step.CleanupInlineSecrets(config); err != nil {
+			logger.Warnf("Failed to remove the decoded certificates and API key: %s", err)
+		}
+	}
+	cancellation.AddCleanup(cleanupInlineSecrets)
+	defer cleanupInlineSecrets()
+	if err := step.ExportInvocationCount(command.NewFactory(env.NewRepository()), config); err != nil {
+		logger.Warnf("Failed to export the Step invocation count: %s", err)
+	}
 
-	archiver, err := createXcodebuildArchiver(logger, config.LogFormatter)
+	phases.Begin("dependency install")
+	var structuredLog *step.StructuredLogRecorder
+	if config.StructuredRawLog {
+		structuredLog = step.NewStructuredLogRecorder()
+	}
+	archiver, err := createXcodebuildArchiver(logger, config, structuredLog)
 	if err != nil {
 		logger.Errorf("%s", errorutil.FormattedError(fmt.Errorf("Failed to process Step inputs: %w", err)))
 		return 1
 	}
 
+	if config.DryRun {
+		return dryRun(logger, configParser, archiver, config)
+	}
+
 	archiver.EnsureDependencies()
 
+	if len(config.MatrixEntries) > 0 {
+		exitCode := runMatrix(logger, configParser, archiver, config, phases, insights, structuredLog, cancellation)
+		exportPhaseTimings(logger, archiver, config, phases, exitCode)
+		exportBuildInsights(logger, archiver, config, phases, insights, cancellation, exitCode)
+		exportMetrics(archiver, config, phases, insights, cancellation, exitCode)
+		return cancellation.ExitCode(exitCode)
+	}
+
 	exitCode := 0
 	runOpts := createRunOptions(config)
+	runOpts.Phases = phases
+	runOpts.Cancellation = cancellation
+	runOpts.Insights = insights
+	runOpts.StructuredLog = structuredLog
 	result, err := archiver.Run(runOpts)
 	if err != nil {
 		logger.Errorf("%s", errorutil.FormattedError(fmt.Errorf("Failed to execute Step main logic: %w", err)))
@@ -46,8 +95,200 @@ func run() int {
 		// don't return as step outputs needs to be exported even in case of failure (for example the xcodebuild logs)
 	}
 
+	if exitCode != 0 {
+		phases.End(true)
+	}
+
+	phases.Begin("packaging")
 	exportOpts := createExportOptions(config, result)
+	exportOpts.RunFailed = exitCode != 0
 	if err := archiver.ExportOutput(exportOpts); err != nil {
+		logger.Errorf("%s", errorutil.FormattedError(fmt.Errorf("Failed to export Step outputs: %w", err)))
+		exitCode = 1
+	}
+
+	if exitCode == 0 && (config.ExportReleaseMetadata || config.ReleaseGitTag != "") {
+		phases.Begin("release metadata")
+		if err := archiver.ExportReleaseMetadata(step.ReleaseMetadataOpts{
+			OutputDir:       config.OutputDir,
+			OutputLayout:    config.OutputLayout,
+			ArtifactName:    result.ArtifactName,
+			Archive:         result.Archive,
+			ProjectPath:     config.ProjectPath,
+			IPAExportDir:    result.IPAExportDir,
+			Metadata:        config.ExportReleaseMetadata,
+			GitTag:          config.ReleaseGitTag,
+			GitTagPushToken: config.ReleaseGitTagPushToken,
+		}); err != nil {
+			logger.Errorf("%s", errorutil.FormattedError(fmt.Errorf("Failed to export the release metadata: %w", err)))
+			exitCode = 1
+		}
+	}
+
+	if exitCode == 0 && config.ExportDeployMetadata {
+		if err := archiver.ExportDeployMetadata(step.DeployMetadataOpts{
+			OutputDir:    config.OutputDir,
+			OutputLayout: config.OutputLayout,
+			ArtifactName: result.ArtifactName,
+			Compression:  config.Compression,
+			Archive:      result.Archive,
+		}); err != nil {
+			logger.Errorf("%s", errorutil.FormattedError(fmt.Errorf("Failed to export the deploy metadata: %w", err)))
+			exitCode = 1
+		}
+	}
+
+	exportPhaseTimings(logger, archiver, config, phases, exitCode)
+	exportBuildInsights(logger, archiver, config, phases, insights, cancellation, exitCode)
+	exportMetrics(archiver, config, phases, insights, cancellation, exitCode)
+	exportBuildSummary(logger, archiver, config, result, phases, exitCode)
+
+	return cancellation.ExitCode(exitCode)
+}
+
+// dryRun prints the archive and export commands of the Step, or of every matrix entry, without executing them.
+func dryRun(logger log.Logger, configParser step.XcodebuildArchiveConfigParser, archiver step.XcodebuildArchiver, config step.Config) int {
+	configs := []step.Config{config}
+	if len(config.MatrixEntries) > 0 {
+		configs = nil
+		var artifactNames []string
+		for _, entry := range config.MatrixEntries {
+			entryConfig, err := configParser.ConfigForMatrixEntry(config, entry)
+			if err != nil {
+				logger.Errorf("%s", errorutil.FormattedError(fmt.Errorf("Failed to process Step inputs for %s: %w", entry, err)))
+				return 1
+			}
+			entryConfig.ArtifactName = step.MatrixArtifactName(config.ArtifactName, entry, artifactNames)
+			artifactNames = append(artifactNames, entryConfig.ArtifactName)
+			configs = append(configs, entryConfig)
+		}
+	}
+
+	for _, entryConfig := range configs {
+		if _, err := archiver.Run(createRunOptions(entryConfig)); err != nil {
+			logger.Errorf("%s", errorutil.FormattedError(fmt.Errorf("Failed to execute the dry run: %w", err)))
+			return 1
+		}
+	}
+	return 0
+}
+
+func exportPhaseTimings(logger log.Logger, archiver step.XcodebuildArchiver, config step.Config, phases *step.PhaseTracker, exitCode int) {
+	phases.End(exitCode != 0)
+	if !config.PhaseTimings {
+		return
+	}
+
+	if err := archiver.ExportPhaseTimings(phases.Timings(), config.OutputDir, config.OutputLayout); err != nil {
+		logger.Warnf("Failed to export the Step phase timings: %s", err)
+	}
+}
+
+// exportBuildInsights expects the phase tracker to be ended by exportPhaseTimings.
+func exportBuildInsights(logger log.Logger, archiver step.XcodebuildArchiver, config step.Config, phases *step.PhaseTracker, insights *step.InsightsRecorder, cancellation *step.CancellationHandler, exitCode int) {
+	if err := archiver.ExportBuildInsights(step.BuildInsightsOpts{
+		Config:    config,
+		Timings:   phases.Timings(),
+		Recorder:  insights,
+		Succeeded: exitCode == 0 && !cancellation.Cancelled(),
+		Cancelled: cancellation.Cancelled(),
+		Send:      config.BuildInsights,
+	}); err != nil {
+		logger.Warnf("Failed to export the build insights: %s", err)
+	}
+}
+
+// exportMetrics expects the phase tracker to be ended by exportPhaseTimings.
+func exportMetrics(archiver step.XcodebuildArchiver, config step.Config, phases *step.PhaseTracker, insights *step.InsightsRecorder, cancellation *step.CancellationHandler, exitCode int) {
+	archiver.ExportMetrics(step.MetricsExportOpts{
+		BuildInsightsOpts: step.BuildInsightsOpts{
+			Config:    config,
+			Timings:   phases.Timings(),
+			Recorder:  insights,
+			Succeeded: exitCode == 0 && !cancellation.Cancelled(),
+			Cancelled: cancellation.Cancelled(),
+		},
+		URL:     config.MetricsExportURL,
+		Format:  config.MetricsExportFormat,
+		Headers: config.MetricsHeaders,
+		AppSlug: config.AppSlug,
+	})
+}
+
+// exportBuildSummary expects the phase tracker to be ended by exportPhaseTimings.
+func exportBuildSummary(logger log.Logger, archiver step.XcodebuildArchiver, config step.Config, result step.RunResult, phases *step.PhaseTracker, exitCode int) {
+	if !config.BuildSummary {
+		return
+	}
+
+	if err := archiver.ExportBuildSummary(step.BuildSummaryOpts{
+		OutputDir:         config.OutputDir,
+		OutputLayout:      config.OutputLayout,
+		ArtifactName:      result.ArtifactName,
+		Compression:       config.Compression,
+		Archive:           result.Archive,
+		ExportOptionsPath: result.ExportOptionsPath,
+		Timings:           phases.Timings(),
+		Succeeded:         exitCode == 0,
+	}); err != nil {
+		logger.Warnf("Failed to export the build summary: %s", err)
+	}
+}
+
+func runMatrix(logger log.Logger, configParser step.XcodebuildArchiveConfigParser, archiver step.XcodebuildArchiver, config step.Config, phases *step.PhaseTracker, insights *step.InsightsRecorder, structuredLog *step.StructuredLogRecorder, cancellation *step.CancellationHandler) int {
+	exitCode := 0
+	var results []step.MatrixResult
+	var artifactNames []string
+
+	for _, entry := range config.MatrixEntries {
+		if cancellation.Cancelled() {
+			logger.Warnf("The Step was cancelled, skipping matrix entry: %s", entry)
+			exitCode = 1
+			continue
+		}
+
+		logger.Println()
+		logger.Infof("Archiving matrix entry: %s", entry)
+
+		artifactName := step.MatrixArtifactName(config.ArtifactName, entry, artifactNames)
+		artifactNames = append(artifactNames, artifactName)
+
+		entryConfig, err := configParser.ConfigForMatrixEntry(config, entry)
+		if err != nil {
+			logger.Errorf("%s", errorutil.FormattedError(fmt.Errorf("Failed to process Step inputs for %s: %w", entry, err)))
+			exitCode = 1
+			results = append(results, step.MatrixResult{Entry: entry, ArtifactName: artifactName, Err: err})
+			continue
+		}
+		entryConfig.ArtifactName = artifactName
+
+		runOpts := createRunOptions(entryConfig)
+		runOpts.Phases = phases
+		runOpts.Cancellation = cancellation
+		runOpts.Insights = insights
+		runOpts.StructuredLog = structuredLog
+		result, runErr := archiver.Run(runOpts)
+		if runErr != nil {
+			logger.Errorf("%s", errorutil.FormattedError(fmt.Errorf("Failed to archive %s: %w", entry, runErr)))
+			phases.End(true)
+			exitCode = 1
+		}
+
+		phases.Begin("packaging")
+		exportOpts := createExportOptions(entryConfig, result)
+		exportOpts.RunFailed = runErr != nil
+		if err := archiver.ExportOutput(exportOpts); err != nil {
+			logger.Errorf("%s", errorutil.FormattedError(fmt.Errorf("Failed to export Step outputs for %s: %w", entry, err)))
+			exitCode = 1
+			if runErr == nil {
+				runErr = err
+			}
+		}
+
+		results = append(results, step.NewMatrixResult(entry, entryConfig.OutputDir, entryConfig.OutputLayout, artifactName, result, runErr))
+	}
+
+	if err := archiver.ExportMatrixOutputs(results); err != nil {
 		logger.Errorf("%s", errorutil.FormattedError(fmt.Errorf("Failed to export Step outputs: %w", err)))
 		return 1
 	}
@@ -55,30 +296,60 @@ func run() int {
 	return exitCode
 }
 
-func createConfigParser(logger log.Logger) step.XcodebuildArchiveConfigParser {
+// createConfigParser parses the inputs from the environment, or from the CLI flags of a local run (for example go run . --project App.xcodeproj --scheme App),
+// falling back to the input environment variables and the step.yml defaults.
+func createConfigParser(logger log.Logger, redactor *step.IdentifierRedactor, args []string) (step.XcodebuildArchiveConfigParser, error) {
 	envRepository := env.NewRepository()
-	inputParser := stepconf.NewInputParser(envRepository)
+	inputEnvRepository := envRepository
+	if len(args) > 0 {
+		defaults, err := step.StepYMLInputDefaults(stepYML)
+		if err != nil {
+			return step.XcodebuildArchiveConfigParser{}, fmt.Errorf("failed to parse step.yml: %w", err)
+		}
+		flags, err := step.ParseInputFlags(args, defaults)
+		if err != nil {
+			return step.XcodebuildArchiveConfigParser{}, err
+		}
+		inputEnvRepository = step.NewInputEnvRepository(envRepository, step.CLIInputValues(defaults, envRepository, flags))
+	}
+	inputParser := stepconf.NewInputParser(inputEnvRepository)
 	xcodeVersionProvider := step.NewXcodebuildXcodeVersionProvider()
 	fileManager := fileutil.NewFileManager()
 	cmdFactory := command.NewFactory(envRepository)
 
-	return step.NewXcodeArchiveConfigParser(inputParser, xcodeVersionProvider, fileManager, cmdFactory, logger)
+	configParser := step.NewXcodeArchiveConfigParser(inputParser, xcodeVersionProvider, fileManager, cmdFactory, logger)
+	configParser.SetIdentifierRedactor(redactor)
+	return configParser, nil
 }
 
-func createXcodebuildArchiver(logger log.Logger, logFormatter string) (step.XcodebuildArchiver, error) {
+func createXcodebuildArchiver(logger log.Logger, config step.Config, structuredLog *step.StructuredLogRecorder) (step.XcodebuildArchiver, error) {
+	logFormatter := config.LogFormatter
 	envRepository := env.NewRepository()
 	pathProvider := pathutil.NewPathProvider()
 	pathChecker := pathutil.NewPathChecker()
 	pathModifier := pathutil.NewPathModifier()
 	fileManager := fileutil.NewFileManager()
-	cmdFactory := command.NewFactory(envRepository)
+	cmdFactory := step.NewRedactingFactory(command.NewFactory(envRepository), config.IdentifierRedactor)
+	xcodebuildEnvRepository := step.NewXcodebuildEnvRepository(envRepository, config.XcodebuildUnsetEnvs, config.XcodebuildExtraEnvs)
+	xcodebuildBaseCmdFactory := step.NewRedactingFactory(command.NewFactory(xcodebuildEnvRepository), config.IdentifierRedactor)
+	if config.ForceARM64Xcodebuild {
+		xcodebuildBaseCmdFactory = step.NewARM64CommandFactory(xcodebuildBaseCmdFactory, "xcodebuild")
+	}
+	xcodebuildCmdFactory := step.NewXcodebuildWatchdog(xcodebuildBaseCmdFactory, step.XcodebuildWatchdogOpts{
+		HeartbeatInterval:           time.Duration(config.HeartbeatInterval) * time.Second,
+		NoOutputTimeout:             time.Duration(config.NoOutputTimeout) * time.Minute,
+		OutputVisible:               logFormatter != step.XcodebuildTool,
+		DiagnosticsRuntimeThreshold: time.Duration(config.DiagnosticsAfter) * time.Minute,
+		DiagnosticsDir:              config.XcodebuildDiagnosticsDir,
+	}, logger)
+	xcodebuildCmdFactory = step.NewStructuredLogFactory(xcodebuildCmdFactory, structuredLog)
 
 	xcodeCommandRunner := xcodecommand.Runner(nil)
 	switch logFormatter {
 	case step.XcodebuildTool:
-		xcodeCommandRunner = xcodecommand.NewRawCommandRunner(logger, cmdFactory)
+		xcodeCommandRunner = step.NewRawXcodebuildRunner(logger, xcodebuildCmdFactory)
 	case step.XcbeautifyTool:
-		xcodeCommandRunner = xcodecommand.NewXcbeautifyRunner(logger, cmdFactory)
+		xcodeCommandRunner = xcodecommand.NewXcbeautifyRunner(logger, xcodebuildCmdFactory)
 	case step.XcprettyTool:
 		commandLocator := env.NewCommandLocator()
 		rubyComamndFactory, err := ruby.NewCommandFactory(cmdFactory, commandLocator)
@@ -87,12 +358,15 @@ func createXcodebuildArchiver(logger log.Logger, logFormatter string) (step.Xcod
 		}
 		rubyEnv := ruby.NewEnvironment(rubyComamndFactory, commandLocator, logger)
 
-		xcodeCommandRunner = xcodecommand.NewXcprettyCommandRunner(logger, cmdFactory, pathChecker, fileManager, rubyComamndFactory, rubyEnv)
+		xcodeCommandRunner = xcodecommand.NewXcprettyCommandRunner(logger, xcodebuildCmdFactory, pathChecker, fileManager, rubyComamndFactory, rubyEnv)
 	default:
 		panic(fmt.Sprintf("Unknown log formatter: %s", logFormatter))
 	}
 
-	return step.NewXcodebuildArchiver(xcodeCommandRunner, logFormatter, pathProvider, pathChecker, pathModifier, fileManager, cmdFactory, logger), nil
+	archiver := step.NewXcodebuildArchiver(xcodeCommandRunner, logFormatter, pathProvider, pathChecker, pathModifier, fileManager, cmdFactory, logger)
+	archiver.SetOutputEnvKeys(step.OutputEnvKeys{Prefix: config.OutputEnvPrefix, Suffix: config.OutputEnvKeySuffix, SensitiveIdentifiers: config.RedactIdentifiers})
+	archiver.SetIdentifierRedactor(config.IdentifierRedactor)
+	return archiver, nil
 }
 
 func createRunOptions(config step.Config) step.RunOpts {
@@ -100,15 +374,44 @@ func createRunOptions(config step.Config) step.RunOpts {
 		ProjectPath:       config.ProjectPath,
 		Scheme:            config.Scheme,
 		Configuration:     config.Configuration,
+		Target:            config.Target,
 		XcodeMajorVersion: config.XcodeMajorVersion,
+		XcodeVersion:      config.XcodeVersion,
 		ArtifactName:      config.ArtifactName,
+		Toolchain:         config.Toolchain,
 
-		CodesignManager: config.CodesignManager,
+		CodesignManager:     config.CodesignManager,
+		CodesignRetryPolicy: config.CodesignRetryPolicy,
+
+		CodeSigningAuthSource:           config.CodeSigningAuthSource,
+		CertificateURLList:              config.CertificateURLList,
+		FallbackProvisioningProfileURLs: config.FallbackProvisioningProfileURLs,
 
 		PerformCleanAction:          config.PerformCleanAction,
+		SkipUnavailableActions:      config.SkipUnavailableActions,
 		XcconfigContent:             config.XcconfigContent,
 		XcodebuildAdditionalOptions: config.XcodebuildAdditionalOptions,
+		ForceTeamID:                 config.ForceTeamID,
+		CodesignKeychainPath:        config.CodesignKeychainPath,
+		CodesignKeychainPassword:    config.CodesignKeychainPassword,
+		KeychainPath:                config.KeychainPath,
+		KeychainPassword:            config.KeychainPassword,
+		MacCatalystArchive:          config.MacCatalystArchive,
+		ExportActivityLog:           config.ExportActivityLog,
+		BuildReport:                 config.BuildReport,
+		ExportBuildProducts:         config.BuildProductPaths != "",
 		CacheLevel:                  config.CacheLevel,
+		PrefetchSwiftPackages:       config.PrefetchSwiftPackages,
+		ArchiveCacheDir:             config.ArchiveCacheDir,
+		OutputDir:                   config.OutputDir,
+		ArchivePath:                 config.ArchivePath,
+		KeepTempDirs:                config.KeepTempDirs,
+		DryRun:                      config.DryRun,
+		ExistingArchive:             config.ExistingArchive,
+
+		CompilationCaching:            config.CompilationCaching,
+		CompilationCacheRemoteService: config.CompilationCacheRemoteService,
+		ApplySchemeRunEnvironment:     config.ApplySchemeRunEnvironment,
 
 		CustomExportOptionsPlistContent: config.ExportOptionsPlistContent,
 		ExportMethod:                    config.ExportMethod,
@@ -117,22 +420,82 @@ func createRunOptions(config step.Config) step.RunOpts {
 		ExportDevelopmentTeam:           config.ExportDevelopmentTeam,
 		UploadBitcode:                   config.UploadBitcode,
 		CompileBitcode:                  config.CompileBitcode,
+		ManageVersionAndBuildNumber:     config.ManageVersionAndBuildNumber,
+		UploadSymbols:                   config.UploadSymbols,
+		DistributionBundleIdentifier:    config.DistributionBundleIdentifier,
+		ExportTeamOverrides:             config.BundleIDTeamOverrides,
+		InfoPlistOverrides:              config.AppInfoPlistOverrides,
+		IconBadge:                       config.IconBadge,
+		WildcardProfiles:                config.WildcardProfiles,
+		CodeSigningStyleOverride:        config.CodeSigningStyleOverride,
+		SigningCertificate:              config.SigningCertificate,
+		InstallerSigningCertificate:     config.InstallerSigningCertificate,
+		MacCatalystExportOptions:        config.MacCatalystExportOptions,
+		MacCatalystNotarize:             config.MacCatalystNotarize,
+		NotarizationTimeout:             time.Duration(config.NotarizationTimeout) * time.Minute,
+		VerifyIPASignature:              config.VerifyIPASignature,
+		RequiredLocalizations:           step.ParseLocaleList(config.RequiredLocalizations),
+		FailOnMissingLocalizations:      config.FailOnMissingLocalizations,
+		VerifyNoTestBundles:             config.VerifyNoTestBundles,
+		IPAContentPolicy:                config.IPAContentPolicy,
+		IPAContentDenyPatterns:          step.ParsePathPatterns(config.IPAContentDenyPatterns),
 	}
 }
 
 func createExportOptions(config step.Config, result step.RunResult) step.ExportOpts {
 	return step.ExportOpts{
-		OutputDir:      config.OutputDir,
-		ArtifactName:   result.ArtifactName,
-		ExportAllDsyms: config.ExportAllDsyms,
+		OutputDir:        config.OutputDir,
+		OutputLayout:     config.OutputLayout,
+		ArtifactName:     result.ArtifactName,
+		ExportAllDsyms:   config.ExportAllDsyms,
+		DSYMZipMode:      config.DSYMZipMode,
+		CompressionLevel: config.CompressionLevel,
+		Compression:      config.Compression,
+
+		ExportXCArchiveZip: config.ExportXCArchiveZip,
+		ExportAppDir:       config.ExportAppDir,
+		ExportDSYMs:        config.ExportDSYMs,
+		ExportRawLogAlways: config.ExportRawLogAlways,
 
 		Archive: result.Archive,
 
-		ExportOptionsPath: result.ExportOptionsPath,
-		IPAExportDir:      result.IPAExportDir,
+		ExportOptionsPath:          result.ExportOptionsPath,
+		IPAExportDir:               result.IPAExportDir,
+		ExportTeamID:               result.ExportTeamID,
+		ICloudContainerEnvironment: result.ICloudContainerEnvironment,
+		SigningReport:              result.SigningReport,
+
+		ExpectedDeviceUDIDs:     step.ParseDeviceUDIDs(config.ExpectedDeviceUDIDs),
+		PrintProvisionedDevices: config.PrintProvisionedDevices,
+
+		XcodebuildArchiveStructuredLog:       result.XcodebuildArchiveStructuredLog,
+		XcodebuildExportArchiveStructuredLog: result.XcodebuildExportArchiveStructuredLog,
 
 		XcodebuildArchiveLog:       result.XcodebuildArchiveLog,
 		XcodebuildExportArchiveLog: result.XcodebuildExportArchiveLog,
 		IDEDistrubutionLogsDir:     result.IDEDistrubutionLogsDir,
+		XcodebuildExitCode:         result.XcodebuildExitCode,
+		ArchiveActivityLogPath:     result.ArchiveActivityLogPath,
+		ActivityLogJSON:            config.ActivityLogJSON,
+		BuildReport:                config.BuildReport,
+		BuildParallelism:           config.BuildParallelism,
+		BuildIssuesJUnit:           config.BuildIssuesJUnit,
+		TestResultDir:              config.TestResultDir,
+		ArchiveIntermediatesDir:    result.ArchiveIntermediatesDir,
+		BuildProductPatterns:       step.ParsePathPatterns(config.BuildProductPaths),
+		ExportMacOSZip:             config.ExportMacOSZip,
+		ExportEntitlements:         config.ExportEntitlements,
+		SparklePrivateKey:          config.SparklePrivateKey,
+		SparkleDownloadURLPrefix:   config.SparkleDownloadURLPrefix,
+		ResignSigningIdentity:      config.ResignSigningIdentity,
+		ResignProfilePaths:         step.ParsePathPatterns(config.ResignProfilePaths),
+
+		MacCatalyst: result.MacCatalyst,
+
+		XcodebuildDiagnosticsDir: config.XcodebuildDiagnosticsDir,
+		AdditionalLogPaths:       step.ParsePathPatterns(config.AdditionalLogPaths),
+
+		XcodeCloudEnvVars: config.XcodeCloudEnvVars,
+		Scheme:            config.Scheme,
 	}
 }
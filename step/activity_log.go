@@ -0,0 +1,221 @@
+package step
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	bitriseXCActivityLogPthEnvKey     = "BITRISE_XCACTIVITYLOG_PATH"
+	bitriseXCActivityLogJSONPthEnvKey = "BITRISE_XCACTIVITYLOG_JSON_PATH"
+
+	derivedDataPathOption = "-derivedDataPath"
+)
+
+// derivedDataDir returns the DerivedData directory of the project:
+// the -derivedDataPath option's value if set, otherwise the newest <project name>-<hash> directory of the default DerivedData location.
+func derivedDataDir(projectPath string, additionalOptions []string, homeDir string) (string, error) {
+	for i, option := range additionalOptions {
+		if option == derivedDataPathOption && i+1 < len(additionalOptions) {
+			return additionalOptions[i+1], nil
+		}
+	}
+
+	projectName := strings.TrimSuffix(filepath.Base(projectPath), filepath.Ext(projectPath))
+	pattern := filepath.Join(homeDir, "Library", "Developer", "Xcode", "DerivedData", projectName+"-*")
+	return newestPath(pattern)
+}
+
+// findActivityLog returns the newest .xcactivitylog of the DerivedData dir's build logs, modified after the given time.
+func findActivityLog(derivedDataDir string, since time.Time) (string, error) {
+	pth, err := newestPath(filepath.Join(derivedDataDir, "Logs", "Build", "*.xcactivitylog"))
+	if err != nil || pth == "" {
+		return "", err
+	}
+
+	info, err := os.Stat(pth)
+	if err != nil {
+		return "", err
+	}
+	if info.ModTime().Before(since) {
+		return "", nil
+	}
+	return pth, nil
+}
+
+func newestPath(pattern string) (string, error) {
+	pths, err := filepath.Glob(pattern)
+	if err != nil {
+		return "", err
+	}
+
+	var newest string
+	var newestModTime time.Time
+	for _, pth := range pths {
+		info, err := os.Stat(pth)
+		if err != nil {
+			return "", err
+		}
+		if newest == "" || info.ModTime().After(newestModTime) {
+			newest = pth
+			newestModTime = info.ModTime()
+		}
+	}
+	return newest, nil
+}
+
+// slfToken is a token of the SLF serialization format used by the gzipped .xcactivitylog files.
+type slfToken struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// parseSLF tokenizes the (uncompressed) content of an .xcactivitylog file.
+func parseSLF(r io.Reader) ([]slfToken, error) {
+	reader := bufio.NewReader(r)
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if string(header) != "SLF0" {
+		return nil, fmt.Errorf("not an SLF0 file, header: %q", header)
+	}
+
+	var tokens []slfToken
+	var payload []byte
+	for {
+		b, err := reader.ReadByte()
+		if err == io.EOF {
+			if len(payload) != 0 {
+				return nil, fmt.Errorf("unexpected end of file after: %s", payload)
+			}
+			return tokens, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case (b >= '0' && b <= '9') || (b >= 'a' && b <= 'f'):
+			payload = append(payload, b)
+			continue
+		case b == '-':
+			tokens = append(tokens, slfToken{Type: "null"})
+		case b == '^':
+			value, err := strconv.ParseUint(string(payload), 16, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid double: %s", payload)
+			}
+			tokens = append(tokens, slfToken{Type: "double", Value: math.Float64frombits(bits.ReverseBytes64(value))})
+		case b == '#' || b == '(' || b == '@' || b == '"' || b == '%' || b == '*':
+			value, err := strconv.ParseUint(string(payload), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid integer: %s", payload)
+			}
+
+			switch b {
+			case '#':
+				tokens = append(tokens, slfToken{Type: "int", Value: value})
+			case '(':
+				tokens = append(tokens, slfToken{Type: "array", Value: value})
+			case '@':
+				tokens = append(tokens, slfToken{Type: "classInstance", Value: value})
+			default:
+				content := make([]byte, value)
+				if _, err := io.ReadFull(reader, content); err != nil {
+					return nil, fmt.Errorf("failed to read string: %w", err)
+				}
+
+				tokenType := map[byte]string{'"': "string", '%': "className", '*': "json"}[b]
+				tokens = append(tokens, slfToken{Type: tokenType, Value: string(content)})
+			}
+		default:
+			return nil, fmt.Errorf("unexpected character: %q", b)
+		}
+		payload = nil
+	}
+}
+
+// convertActivityLogToJSON writes the tokens of the gzipped .xcactivitylog as a JSON array.
+func convertActivityLogToJSON(activityLogPath, jsonPath string) error {
+	f, err := os.Open(activityLogPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	gzipReader, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to decompress %s: %w", activityLogPath, err)
+	}
+
+	tokens, err := parseSLF(gzipReader)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", activityLogPath, err)
+	}
+
+	content, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(jsonPath, content, 0644)
+}
+
+func (s XcodebuildArchiver) findArchiveActivityLog(projectPath string, additionalOptions []string, since time.Time) string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		s.logger.Warnf("Failed to find the xcactivitylog: %s", err)
+		return ""
+	}
+
+	dir, err := derivedDataDir(projectPath, additionalOptions, homeDir)
+	if err == nil && dir != "" {
+		var pth string
+		if pth, err = findActivityLog(dir, since); err == nil && pth != "" {
+			return pth
+		}
+	}
+	if err != nil {
+		s.logger.Warnf("Failed to find the xcactivitylog: %s", err)
+	} else {
+		s.logger.Warnf("No xcactivitylog found for the archive action")
+	}
+	return ""
+}
+
+func (s XcodebuildArchiver) exportActivityLog(opts ExportOpts, outputDir string) {
+	activityLogPath := filepath.Join(outputDir, opts.ArtifactName+".xcactivitylog")
+	if err := ExportOutputFile(s.cmdFactory, s.outputEnvKeys, opts.ArchiveActivityLogPath, activityLogPath, bitriseXCActivityLogPthEnvKey); err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", s.outputEnvKey(bitriseXCActivityLogPthEnvKey), err)
+		return
+	}
+	s.logger.Donef("The xcactivitylog path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseXCActivityLogPthEnvKey), activityLogPath)
+
+	if !opts.ActivityLogJSON {
+		return
+	}
+
+	jsonPath := filepath.Join(outputDir, opts.ArtifactName+".xcactivitylog.json")
+	if err := convertActivityLogToJSON(activityLogPath, jsonPath); err != nil {
+		s.logger.Warnf("Failed to convert the xcactivitylog to JSON: %s", err)
+		return
+	}
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseXCActivityLogJSONPthEnvKey, jsonPath); err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", s.outputEnvKey(bitriseXCActivityLogJSONPthEnvKey), err)
+		return
+	}
+	s.logger.Donef("The xcactivitylog JSON path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseXCActivityLogJSONPthEnvKey), jsonPath)
+}
@@ -0,0 +1,71 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_derivedDataDir(t *testing.T) {
+	homeDir := t.TempDir()
+	derivedData := filepath.Join(homeDir, "Library", "Developer", "Xcode", "DerivedData")
+	older := filepath.Join(derivedData, "App-abcd")
+	newer := filepath.Join(derivedData, "App-efgh")
+	require.NoError(t, os.MkdirAll(older, 0755))
+	require.NoError(t, os.MkdirAll(newer, 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(derivedData, "Other-ijkl"), 0755))
+	require.NoError(t, os.Chtimes(older, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)))
+
+	got, err := derivedDataDir("/git/App.xcworkspace", []string{"-scmProvider", "system"}, homeDir)
+	require.NoError(t, err)
+	require.Equal(t, newer, got)
+
+	got, err = derivedDataDir("/git/App.xcworkspace", []string{"-derivedDataPath", "/tmp/dd"}, homeDir)
+	require.NoError(t, err)
+	require.Equal(t, "/tmp/dd", got)
+}
+
+func Test_findActivityLog(t *testing.T) {
+	derivedData := t.TempDir()
+	logsDir := filepath.Join(derivedData, "Logs", "Build")
+	require.NoError(t, os.MkdirAll(logsDir, 0755))
+
+	start := time.Now()
+	oldLog := filepath.Join(logsDir, "old.xcactivitylog")
+	require.NoError(t, os.WriteFile(oldLog, nil, 0644))
+	require.NoError(t, os.Chtimes(oldLog, start.Add(-time.Hour), start.Add(-time.Hour)))
+
+	got, err := findActivityLog(derivedData, start)
+	require.NoError(t, err)
+	require.Equal(t, "", got)
+
+	newLog := filepath.Join(logsDir, "new.xcactivitylog")
+	require.NoError(t, os.WriteFile(newLog, nil, 0644))
+	require.NoError(t, os.Chtimes(newLog, start.Add(time.Second), start.Add(time.Second)))
+
+	got, err = findActivityLog(derivedData, start)
+	require.NoError(t, err)
+	require.Equal(t, newLog, got)
+}
+
+func Test_parseSLF(t *testing.T) {
+	tokens, err := parseSLF(strings.NewReader(`SLF010#21%IDEActivityLogSection1@0#5"Build-2(000000000000f83f^`))
+	require.NoError(t, err)
+	require.Equal(t, []slfToken{
+		{Type: "int", Value: uint64(10)},
+		{Type: "className", Value: "IDEActivityLogSection"},
+		{Type: "classInstance", Value: uint64(1)},
+		{Type: "int", Value: uint64(0)},
+		{Type: "string", Value: "Build"},
+		{Type: "null"},
+		{Type: "array", Value: uint64(2)},
+		{Type: "double", Value: 1.5},
+	}, tokens)
+
+	_, err = parseSLF(strings.NewReader("XYZ0"))
+	require.Error(t, err)
+}
@@ -0,0 +1,110 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1command "github.com/bitrise-io/go-utils/command"
+	v1pathutil "github.com/bitrise-io/go-utils/pathutil"
+)
+
+const bitriseAdditionalLogsPthEnvKey = "BITRISE_ADDITIONAL_LOGS_PATH"
+
+// ParsePathPatterns parses newline separated glob patterns, like the additional log paths.
+func ParsePathPatterns(input string) []string {
+	var patterns []string
+	for _, line := range strings.Split(input, "\n") {
+		if pattern := strings.TrimSpace(line); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// expandLogPaths returns the distinct paths matching the glob patterns, a leading ~ is expanded to the home dir.
+func expandLogPaths(patterns []string, homeDir string) ([]string, error) {
+	var pths []string
+	seen := map[string]bool{}
+	for _, pattern := range patterns {
+		if pattern == "~" || strings.HasPrefix(pattern, "~/") {
+			pattern = filepath.Join(homeDir, strings.TrimPrefix(pattern, "~"))
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern (%s): %w", pattern, err)
+		}
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				pths = append(pths, match)
+			}
+		}
+	}
+	return pths, nil
+}
+
+// collectedLogName returns a unique name for the collected log in the collection dir.
+func collectedLogName(pth string, usedNames map[string]bool) string {
+	name := filepath.Base(pth)
+	for i := 2; usedNames[name]; i++ {
+		ext := filepath.Ext(filepath.Base(pth))
+		name = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(filepath.Base(pth), ext), i, ext)
+	}
+	usedNames[name] = true
+	return name
+}
+
+// exportAdditionalLogs collects the files and directories matching the patterns into a zip in the logs output dir.
+func (s XcodebuildArchiver) exportAdditionalLogs(patterns []string, zipPath string, compressionLevel int) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		s.logger.Warnf("Failed to collect the additional logs: %s", err)
+		return
+	}
+
+	pths, err := expandLogPaths(patterns, homeDir)
+	if err != nil {
+		s.logger.Warnf("Failed to collect the additional logs: %s", err)
+		return
+	}
+	if len(pths) == 0 {
+		s.logger.Warnf("No additional logs found matching: %s", strings.Join(patterns, ", "))
+		return
+	}
+
+	collectionDir, err := v1pathutil.NormalizedOSTempDirPath("additional-logs")
+	if err != nil {
+		s.logger.Warnf("Failed to create temp dir, error: %s", err)
+		return
+	}
+
+	usedNames := map[string]bool{}
+	for _, pth := range pths {
+		info, err := os.Stat(pth)
+		if err != nil {
+			s.logger.Warnf("Failed to collect %s: %s", pth, err)
+			continue
+		}
+
+		destination := filepath.Join(collectionDir, collectedLogName(pth, usedNames))
+		if info.IsDir() {
+			err = v1command.CopyDir(pth, destination, true)
+		} else {
+			err = v1command.CopyFile(pth, destination)
+		}
+		if err != nil {
+			s.logger.Warnf("Failed to collect %s: %s", pth, err)
+			continue
+		}
+		s.logger.Printf("Collected additional log: %s", pth)
+	}
+
+	if err := ExportOutputDirAsZip(s.cmdFactory, s.outputEnvKeys, collectionDir, zipPath, bitriseAdditionalLogsPthEnvKey, compressionLevel, s.logger); err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", s.outputEnvKey(bitriseAdditionalLogsPthEnvKey), err)
+		return
+	}
+	s.logger.Donef("The additional logs zip path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseAdditionalLogsPthEnvKey), zipPath)
+}
@@ -0,0 +1,41 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePathPatterns(t *testing.T) {
+	require.Equal(t, []string{"~/Library/Logs/gym/*", "build/*.log"}, ParsePathPatterns("~/Library/Logs/gym/*\n\n  build/*.log  \n"))
+	require.Nil(t, ParsePathPatterns(""))
+}
+
+func Test_expandLogPaths(t *testing.T) {
+	homeDir := t.TempDir()
+	createDirs(t, homeDir, "Library/Logs/gym", "build")
+	for _, pth := range []string{"Library/Logs/gym/App-App.log", "build/tool.log", "build/tool.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(homeDir, pth), nil, 0644))
+	}
+
+	pths, err := expandLogPaths([]string{"~/Library/Logs/gym/*", filepath.Join(homeDir, "build/*.log"), "~/build/tool.*", "~/missing/*"}, homeDir)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(homeDir, "Library/Logs/gym/App-App.log"),
+		filepath.Join(homeDir, "build/tool.log"),
+		filepath.Join(homeDir, "build/tool.txt"),
+	}, pths)
+
+	_, err = expandLogPaths([]string{"build/[.log"}, homeDir)
+	require.Error(t, err)
+}
+
+func Test_collectedLogName(t *testing.T) {
+	usedNames := map[string]bool{}
+	require.Equal(t, "build.log", collectedLogName("/tmp/a/build.log", usedNames))
+	require.Equal(t, "build-2.log", collectedLogName("/tmp/b/build.log", usedNames))
+	require.Equal(t, "build-3.log", collectedLogName("/tmp/c/build.log", usedNames))
+	require.Equal(t, "gym", collectedLogName("/tmp/gym", usedNames))
+}
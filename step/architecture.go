@@ -0,0 +1,149 @@
+package step
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+const (
+	archARM64  = "arm64"
+	archX86_64 = "x86_64"
+)
+
+// hostArchitecture describes the architecture the Step and the tools it runs execute on.
+type hostArchitecture struct {
+	// AppleSilicon is true on M-series machines, even if the Step runs under Rosetta
+	AppleSilicon bool
+	// Translated is true if the Step process runs under Rosetta
+	Translated bool
+	// ToolArchs are the architectures of the tool binaries, by tool name
+	ToolArchs map[string][]string
+}
+
+// architectureTools are the tools whose binaries should include arm64 on Apple Silicon.
+var architectureTools = []string{"xcodebuild", "ruby"}
+
+// sysctlFlag parses the output of `sysctl -n` for a boolean flag, which is missing on machines not supporting the flag.
+func sysctlFlag(out string) bool {
+	return strings.TrimSpace(out) == "1"
+}
+
+// toolsMissingARM64 returns the tools without an arm64 binary, which run under Rosetta on Apple Silicon.
+func (a hostArchitecture) toolsMissingARM64() []string {
+	var tools []string
+	for _, tool := range architectureTools {
+		archs, ok := a.ToolArchs[tool]
+		if !ok {
+			continue
+		}
+		hasARM64 := false
+		for _, arch := range archs {
+			if strings.HasPrefix(arch, archARM64) {
+				hasARM64 = true
+			}
+		}
+		if !hasARM64 {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+func (a hostArchitecture) processArch() string {
+	if a.AppleSilicon && !a.Translated {
+		return archARM64
+	}
+	return archX86_64
+}
+
+func (s XcodebuildArchiveConfigParser) runTrimmed(name string, args ...string) (string, error) {
+	return s.cmdFactory.Create(name, args, nil).RunAndReturnTrimmedOutput()
+}
+
+// detectHostArchitecture reads the hardware architecture, the Rosetta translation of the Step process
+// and the architectures of the tool binaries, failing checks are ignored.
+func (s XcodebuildArchiveConfigParser) detectHostArchitecture() hostArchitecture {
+	arch := hostArchitecture{ToolArchs: map[string][]string{}}
+
+	if out, err := s.runTrimmed("sysctl", "-n", "hw.optional.arm64"); err == nil {
+		arch.AppleSilicon = sysctlFlag(out)
+	}
+	if out, err := s.runTrimmed("sysctl", "-n", "sysctl.proc_translated"); err == nil {
+		arch.Translated = sysctlFlag(out)
+	}
+
+	for _, tool := range architectureTools {
+		pth, err := s.runTrimmed("xcrun", "--find", tool)
+		if err != nil {
+			if pth, err = s.runTrimmed("which", tool); err != nil {
+				continue
+			}
+		}
+		out, err := s.runTrimmed("lipo", "-archs", pth)
+		if err != nil {
+			continue
+		}
+		arch.ToolArchs[tool] = strings.Fields(out)
+	}
+
+	return arch
+}
+
+// warnArchitectureMismatch warns about the Rosetta translated processes on Apple Silicon, which are slower
+// and can produce DerivedData of the other architecture.
+func warnArchitectureMismatch(arch hostArchitecture, forceARM64 bool, logger log.Logger) {
+	if !arch.AppleSilicon {
+		return
+	}
+	if arch.Translated {
+		logger.Warnf("The Step runs under Rosetta on an Apple Silicon machine, the commands it starts run as x86_64 too.")
+		if !forceARM64 {
+			logger.Warnf("Set Force arm64 xcodebuild (force_arm64_xcodebuild) to run xcodebuild natively.")
+		}
+	}
+	if tools := arch.toolsMissingARM64(); len(tools) > 0 {
+		logger.Warnf("The following tools have no arm64 binary and run under Rosetta: %s", strings.Join(tools, ", "))
+	}
+}
+
+func printHostArchitecture(arch hostArchitecture, logger log.Logger) {
+	hardware := archX86_64
+	if arch.AppleSilicon {
+		hardware = "Apple Silicon (arm64)"
+	}
+	logger.Printf("Architecture: %s, Step process: %s", hardware, arch.processArch())
+	for _, tool := range architectureTools {
+		if archs, ok := arch.ToolArchs[tool]; ok {
+			logger.Printf("- %s: %s", tool, strings.Join(archs, ", "))
+		}
+	}
+}
+
+// archCommandFactory runs the given command with `arch -arm64`, so it runs natively even if the Step runs under Rosetta.
+type archCommandFactory struct {
+	command.Factory
+	name string
+}
+
+// NewARM64CommandFactory wraps the command factory to run the named command as arm64.
+func NewARM64CommandFactory(factory command.Factory, name string) command.Factory {
+	return archCommandFactory{Factory: factory, name: name}
+}
+
+// Create ...
+func (f archCommandFactory) Create(name string, args []string, opts *command.Opts) command.Command {
+	if name != f.name {
+		return f.Factory.Create(name, args, opts)
+	}
+	return f.Factory.Create("arch", append([]string{"-" + archARM64, name}, args...), opts)
+}
+
+func validateForceARM64(arch hostArchitecture) error {
+	if !arch.AppleSilicon {
+		return fmt.Errorf("xcodebuild can be forced to run as arm64 only on Apple Silicon machines")
+	}
+	return nil
+}
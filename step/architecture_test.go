@@ -0,0 +1,45 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSysctlFlag(t *testing.T) {
+	require.True(t, sysctlFlag("1\n"))
+	require.False(t, sysctlFlag("0"))
+	require.False(t, sysctlFlag(""))
+}
+
+func TestHostArchitecture_toolsMissingARM64(t *testing.T) {
+	arch := hostArchitecture{
+		AppleSilicon: true,
+		ToolArchs: map[string][]string{
+			"xcodebuild": {"x86_64", "arm64e"},
+			"ruby":       {"x86_64"},
+		},
+	}
+	require.Equal(t, []string{"ruby"}, arch.toolsMissingARM64())
+	require.Equal(t, archARM64, arch.processArch())
+
+	arch.Translated = true
+	require.Equal(t, archX86_64, arch.processArch())
+}
+
+func TestARM64CommandFactory(t *testing.T) {
+	factory := NewARM64CommandFactory(command.NewFactory(env.NewRepository()), "xcodebuild")
+
+	cmd := factory.Create("xcodebuild", []string{"-version"}, nil)
+	require.Equal(t, `arch "-arm64" "xcodebuild" "-version"`, cmd.PrintableCommandArgs())
+
+	cmd = factory.Create("xcrun", []string{"--find", "xcodebuild"}, nil)
+	require.Equal(t, `xcrun "--find" "xcodebuild"`, cmd.PrintableCommandArgs())
+}
+
+func TestValidateForceARM64(t *testing.T) {
+	require.NoError(t, validateForceARM64(hostArchitecture{AppleSilicon: true, Translated: true}))
+	require.Error(t, validateForceARM64(hostArchitecture{}))
+}
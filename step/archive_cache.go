@@ -0,0 +1,266 @@
+package step
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+// archiveCacheMaxEntries is the number of archives kept in the archive cache dir,
+// the least recently used ones are removed when a new archive is stored.
+const archiveCacheMaxEntries = 5
+
+// archiveCacheKeyPattern matches the dirs of the archive cache entries, named after their key,
+// other files of the archive cache dir are not removed.
+var archiveCacheKeyPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// archiveCacheSkippedDirs are not part of the project sources, or change on every build.
+var archiveCacheSkippedDirs = map[string]bool{
+	".git":        true,
+	".build":      true,
+	"build":       true,
+	"DerivedData": true,
+	"xcuserdata":  true,
+}
+
+type archiveCacheKeyOpts struct {
+	ProjectPath       string
+	Scheme            string
+	Configuration     string
+	XcodeVersion      string
+	XcconfigContent   string
+	AdditionalOptions []string
+	ForceTeamID       string
+	Toolchain         string
+
+	DistributionMethod      string
+	CodeSigningAuthSource   string
+	CertificateURLList      string
+	ProvisioningProfileURLs string
+	// CodesigningIdentities is the list of the installed code signing identities
+	CodesigningIdentities string
+
+	// ExcludedPaths are not hashed even if they are inside the project dir (like the archive cache and the output dir)
+	ExcludedPaths []string
+}
+
+// archiveCacheKey returns a hash of everything determining the archive's content:
+// the project sources (including the resolved Swift package versions), build settings, code signing inputs and the Xcode version.
+func archiveCacheKey(opts archiveCacheKeyOpts) (string, error) {
+	h := sha256.New()
+
+	writeField := func(name, value string) {
+		_, _ = fmt.Fprintf(h, "%s=%d:%s\n", name, len(value), value)
+	}
+	writeField("scheme", opts.Scheme)
+	writeField("configuration", opts.Configuration)
+	writeField("xcode_version", opts.XcodeVersion)
+	writeField("xcconfig", opts.XcconfigContent)
+	writeField("xcodebuild_options", strings.Join(opts.AdditionalOptions, "\x00"))
+	writeField("force_team_id", opts.ForceTeamID)
+	writeField("toolchain", opts.Toolchain)
+	writeField("project", filepath.Base(opts.ProjectPath))
+	writeField("distribution_method", opts.DistributionMethod)
+	writeField("automatic_code_signing", opts.CodeSigningAuthSource)
+	writeField("certificate_urls", signingAssetURLs(opts.CertificateURLList))
+	writeField("provisioning_profile_urls", signingAssetURLs(opts.ProvisioningProfileURLs))
+	writeField("codesigning_identities", opts.CodesigningIdentities)
+
+	if err := hashProjectSources(h, filepath.Dir(opts.ProjectPath), opts.ExcludedPaths); err != nil {
+		return "", fmt.Errorf("failed to hash project sources: %w", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// signingAssetURLs returns the pipe separated URL list without the URLs' query and fragment:
+// pre-signed download URLs get a new signature on every build, while the path identifies the uploaded file.
+func signingAssetURLs(list string) string {
+	var urls []string
+	for _, rawURL := range strings.Split(list, "|") {
+		rawURL = strings.TrimSpace(rawURL)
+		if rawURL == "" {
+			continue
+		}
+		if u, err := url.Parse(rawURL); err == nil {
+			u.RawQuery = ""
+			u.Fragment = ""
+			rawURL = u.String()
+		}
+		urls = append(urls, rawURL)
+	}
+	return strings.Join(urls, "|")
+}
+
+func hashProjectSources(h hash.Hash, rootDir string, excludedPaths []string) error {
+	rootDir, err := filepath.Abs(rootDir)
+	if err != nil {
+		return err
+	}
+
+	excluded := map[string]bool{}
+	for _, pth := range excludedPaths {
+		if pth == "" {
+			continue
+		}
+		absPth, err := filepath.Abs(pth)
+		if err != nil {
+			return err
+		}
+		excluded[absPth] = true
+	}
+
+	return filepath.WalkDir(rootDir, func(pth string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if pth != rootDir && excluded[pth] {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if entry.IsDir() {
+			if pth != rootDir && archiveCacheSkippedDirs[entry.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, err := filepath.Rel(rootDir, pth)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(h, "%s\n", filepath.ToSlash(relPath))
+
+		if entry.Type()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(pth)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(h, "-> %s\n", target)
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(pth)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+
+		_, err = io.Copy(h, f)
+		return err
+	})
+}
+
+// findCachedArchive returns the path of the archive stored for the given key, or an empty string if there is none.
+// The entry of the found archive is marked as recently used, so it is kept by the eviction.
+func findCachedArchive(cacheDir, key string) (string, error) {
+	archives, err := filepath.Glob(filepath.Join(cacheDir, key, "*.xcarchive"))
+	if err != nil {
+		return "", err
+	}
+	if len(archives) == 0 {
+		return "", nil
+	}
+	now := time.Now()
+	if err := os.Chtimes(filepath.Join(cacheDir, key), now, now); err != nil {
+		return "", err
+	}
+	return archives[0], nil
+}
+
+func storeArchiveInCache(cacheDir, key, archivePath string, logger log.Logger) error {
+	keyDir := filepath.Join(cacheDir, key)
+	if err := os.RemoveAll(keyDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(keyDir, 0755); err != nil {
+		return err
+	}
+
+	destination := filepath.Join(keyDir, filepath.Base(archivePath))
+	if err := copyDir(archivePath, destination, true); err != nil {
+		return err
+	}
+
+	logger.Printf("Archive stored in the archive cache: %s", destination)
+
+	evicted, err := evictArchiveCacheEntries(cacheDir, archiveCacheMaxEntries)
+	if err != nil {
+		return fmt.Errorf("failed to evict the old archives: %w", err)
+	}
+	if len(evicted) > 0 {
+		logger.Printf("Removed %d least recently used archive(s) from the archive cache", len(evicted))
+	}
+	return nil
+}
+
+// evictArchiveCacheEntries removes the least recently used entries of the archive cache dir, keeping the maxEntries most recent ones,
+// and returns the removed entries' keys.
+func evictArchiveCacheEntries(cacheDir string, maxEntries int) ([]string, error) {
+	dirEntries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	type cacheEntry struct {
+		key     string
+		modTime time.Time
+	}
+	var entries []cacheEntry
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() || !archiveCacheKeyPattern.MatchString(dirEntry.Name()) {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, cacheEntry{key: dirEntry.Name(), modTime: info.ModTime()})
+	}
+	if len(entries) <= maxEntries {
+		return nil, nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime.After(entries[j].modTime)
+	})
+	var evicted []string
+	for _, entry := range entries[maxEntries:] {
+		if err := os.RemoveAll(filepath.Join(cacheDir, entry.key)); err != nil {
+			return evicted, err
+		}
+		evicted = append(evicted, entry.key)
+	}
+	return evicted, nil
+}
+
+// codesigningIdentities returns the valid code signing identities of the keychain search list.
+func (s XcodebuildArchiver) codesigningIdentities() (string, error) {
+	cmd := s.cmdFactory.Create("security", []string{"find-identity", "-v", "-p", "codesigning"}, nil)
+	out, err := cmd.RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w: %s", cmd.PrintableCommandArgs(), err, out)
+	}
+	return out, nil
+}
@@ -0,0 +1,164 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_archiveCacheKey(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "Sample.xcodeproj"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "Sample.xcodeproj", "project.pbxproj"), []byte("project"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "main.swift"), []byte("print(1)"), 0644))
+
+	opts := archiveCacheKeyOpts{
+		ProjectPath:   filepath.Join(projectDir, "Sample.xcodeproj"),
+		Scheme:        "Sample",
+		Configuration: "Release",
+		XcodeVersion:  "Xcode 15.4 (15F31d)",
+	}
+
+	key, err := archiveCacheKey(opts)
+	require.NoError(t, err)
+
+	// Files outside of the sources don't change the key
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".git", "HEAD"), []byte("ref"), 0644))
+	sameKey, err := archiveCacheKey(opts)
+	require.NoError(t, err)
+	require.Equal(t, key, sameKey)
+
+	// Build inputs change the key
+	otherOpts := opts
+	otherOpts.XcodeVersion = "Xcode 16.0 (16A242d)"
+	otherKey, err := archiveCacheKey(otherOpts)
+	require.NoError(t, err)
+	require.NotEqual(t, key, otherKey)
+
+	// Source changes change the key
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "main.swift"), []byte("print(2)"), 0644))
+	changedKey, err := archiveCacheKey(opts)
+	require.NoError(t, err)
+	require.NotEqual(t, key, changedKey)
+}
+
+func Test_archiveCacheKey_signingInputs(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "Sample.xcodeproj"), 0755))
+
+	opts := archiveCacheKeyOpts{
+		ProjectPath:           filepath.Join(projectDir, "Sample.xcodeproj"),
+		Scheme:                "Sample",
+		DistributionMethod:    "development",
+		CodeSigningAuthSource: "api-key",
+		CertificateURLList:    "https://storage.example.com/certs/dev.p12?X-Amz-Signature=1",
+		CodesigningIdentities: `1) 0123ABCD "Apple Development: Bitrise Bot (72SA8V3WYL)"`,
+	}
+	key, err := archiveCacheKey(opts)
+	require.NoError(t, err)
+
+	// A new signature of the same pre-signed URL doesn't change the key
+	resignedURLOpts := opts
+	resignedURLOpts.CertificateURLList = "https://storage.example.com/certs/dev.p12?X-Amz-Signature=2"
+	sameKey, err := archiveCacheKey(resignedURLOpts)
+	require.NoError(t, err)
+	require.Equal(t, key, sameKey)
+
+	for name, modify := range map[string]func(*archiveCacheKeyOpts){
+		"distribution method":    func(o *archiveCacheKeyOpts) { o.DistributionMethod = "app-store" },
+		"automatic code signing": func(o *archiveCacheKeyOpts) { o.CodeSigningAuthSource = "off" },
+		"certificates":           func(o *archiveCacheKeyOpts) { o.CertificateURLList = "https://storage.example.com/certs/dist.p12" },
+		"profiles":               func(o *archiveCacheKeyOpts) { o.ProvisioningProfileURLs = "https://example.com/dist.mobileprovision" },
+		"codesign identity":      func(o *archiveCacheKeyOpts) { o.CodesigningIdentities = `1) 4567EFGH "Apple Distribution"` },
+	} {
+		otherOpts := opts
+		modify(&otherOpts)
+		otherKey, err := archiveCacheKey(otherOpts)
+		require.NoError(t, err)
+		require.NotEqual(t, key, otherKey, name)
+	}
+}
+
+func Test_archiveCacheKey_storedArchiveHits(t *testing.T) {
+	projectDir := t.TempDir()
+	cacheDir := filepath.Join(projectDir, "archive_cache")
+	outputDir := filepath.Join(projectDir, "deploy")
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "Sample.xcodeproj"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "main.swift"), []byte("print(1)"), 0644))
+
+	opts := archiveCacheKeyOpts{
+		ProjectPath:   filepath.Join(projectDir, "Sample.xcodeproj"),
+		Scheme:        "Sample",
+		ExcludedPaths: []string{cacheDir, outputDir},
+	}
+	key, err := archiveCacheKey(opts)
+	require.NoError(t, err)
+
+	// The first build stores its archive in the cache and exports its artifacts to the output dir
+	archivePath := filepath.Join(cacheDir, key, "Sample.xcarchive")
+	require.NoError(t, os.MkdirAll(archivePath, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(archivePath, "Info.plist"), []byte("archive"), 0644))
+	require.NoError(t, os.MkdirAll(outputDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "Sample.ipa"), []byte("ipa"), 0644))
+
+	// The next build gets the stored archive
+	nextKey, err := archiveCacheKey(opts)
+	require.NoError(t, err)
+	require.Equal(t, key, nextKey)
+
+	pth, err := findCachedArchive(cacheDir, nextKey)
+	require.NoError(t, err)
+	require.Equal(t, archivePath, pth)
+}
+
+func Test_findCachedArchive(t *testing.T) {
+	cacheDir := t.TempDir()
+
+	pth, err := findCachedArchive(cacheDir, "key")
+	require.NoError(t, err)
+	require.Empty(t, pth)
+
+	archivePath := filepath.Join(cacheDir, "key", "Sample.xcarchive")
+	require.NoError(t, os.MkdirAll(archivePath, 0755))
+
+	pth, err = findCachedArchive(cacheDir, "key")
+	require.NoError(t, err)
+	require.Equal(t, archivePath, pth)
+}
+
+func Test_evictArchiveCacheEntries(t *testing.T) {
+	cacheDir := t.TempDir()
+	now := time.Now()
+	var keys []string
+	for i := 0; i < 4; i++ {
+		key := strings.Repeat(strconv.Itoa(i), 64)
+		require.NoError(t, os.MkdirAll(filepath.Join(cacheDir, key, "Sample.xcarchive"), 0755))
+		modTime := now.Add(-time.Duration(i) * time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(cacheDir, key), modTime, modTime))
+		keys = append(keys, key)
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(cacheDir, "not-an-entry"), 0755))
+
+	// The oldest entry is used again
+	pth, err := findCachedArchive(cacheDir, keys[3])
+	require.NoError(t, err)
+	require.NotEmpty(t, pth)
+
+	evicted, err := evictArchiveCacheEntries(cacheDir, 2)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{keys[1], keys[2]}, evicted)
+
+	for _, name := range []string{keys[0], keys[3], "not-an-entry"} {
+		require.DirExists(t, filepath.Join(cacheDir, name))
+	}
+
+	evicted, err = evictArchiveCacheEntries(cacheDir, 2)
+	require.NoError(t, err)
+	require.Empty(t, evicted)
+}
@@ -0,0 +1,83 @@
+package step
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var buildSettingNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseArchiveEnvironment parses the newline separated list of KEY=VALUE variables of the archive action.
+func parseArchiveEnvironment(list string) ([]string, error) {
+	var variables []string
+	keys := map[string]bool{}
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || !buildSettingNamePattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid variable, expected KEY=VALUE format with a build setting name (letters, digits and underscores) as key: %s", line)
+		}
+		if keys[key] {
+			return nil, fmt.Errorf("variable set multiple times: %s", key)
+		}
+		keys[key] = true
+		variables = append(variables, key+"="+value)
+	}
+	return variables, nil
+}
+
+// buildSettingName returns the name of a NAME=value xcodebuild option, empty if the option is not a build setting.
+func buildSettingName(option string) string {
+	name, _, found := strings.Cut(option, "=")
+	if !found || !buildSettingNamePattern.MatchString(name) {
+		return ""
+	}
+	return name
+}
+
+// buildSettingNames returns the names of the build settings set by the xcodebuild options.
+func buildSettingNames(options []string) map[string]bool {
+	names := map[string]bool{}
+	for _, option := range options {
+		if name := buildSettingName(option); name != "" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// archiveEnvironmentOptions returns the variables as xcodebuild build settings, which are visible to the Run Script build phases
+// and to the scheme's pre and post actions (providing the build settings of a target) as environment variables.
+// A variable can't override a build setting of the xcodebuild options.
+func archiveEnvironmentOptions(variables []string, additionalOptions []string) ([]string, error) {
+	set := buildSettingNames(additionalOptions)
+	var options []string
+	for _, variable := range variables {
+		if name := buildSettingName(variable); set[name] {
+			return nil, fmt.Errorf("`%s` build setting found in XcodebuildOptions (`xcodebuild_options`), please remove it from Archive environment variables (`archive_environment`) input as only one can be set", name)
+		}
+		options = append(options, variable)
+	}
+	return options, nil
+}
+
+// schemeRunEnvironmentOptions returns the scheme's Run action environment variables as xcodebuild build settings,
+// except the variables set by the xcodebuild options (including the archive_environment input) and the ones not usable as a build setting.
+func schemeRunEnvironmentOptions(env schemeArchiveEnvironment, additionalOptions []string) (options []string, skipped []string) {
+	set := buildSettingNames(additionalOptions)
+	for _, variable := range env.LaunchEnvironment {
+		name := buildSettingName(variable)
+		if name == "" || set[name] {
+			key, _, _ := strings.Cut(variable, "=")
+			skipped = append(skipped, key)
+			continue
+		}
+		options = append(options, variable)
+	}
+	return options, skipped
+}
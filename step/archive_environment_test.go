@@ -0,0 +1,43 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseArchiveEnvironment(t *testing.T) {
+	variables, err := parseArchiveEnvironment("API_URL=https://api.example.com/v1?key=a\n\n FLAVOR=staging build\nEMPTY=\n")
+	require.NoError(t, err)
+	require.Equal(t, []string{"API_URL=https://api.example.com/v1?key=a", "FLAVOR=staging build", "EMPTY="}, variables)
+
+	_, err = parseArchiveEnvironment("API-URL=https://api.example.com")
+	require.Error(t, err)
+	_, err = parseArchiveEnvironment("FLAVOR")
+	require.Error(t, err)
+	_, err = parseArchiveEnvironment("FLAVOR=a\nFLAVOR=b")
+	require.EqualError(t, err, "variable set multiple times: FLAVOR")
+}
+
+func Test_buildSettingName(t *testing.T) {
+	require.Equal(t, "API_URL", buildSettingName("API_URL=https://api.example.com"))
+	require.Equal(t, "", buildSettingName("-destination"))
+	require.Equal(t, "", buildSettingName("-scmProvider=system"))
+}
+
+func Test_archiveEnvironmentOptions(t *testing.T) {
+	options, err := archiveEnvironmentOptions([]string{"API_URL=https://api.example.com"}, []string{"-destination", "generic/platform=iOS", "COMPILER_INDEX_STORE_ENABLE=NO"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"API_URL=https://api.example.com"}, options)
+
+	_, err = archiveEnvironmentOptions([]string{"API_URL=https://api.example.com"}, []string{"API_URL=https://other.example.com"})
+	require.Error(t, err)
+}
+
+func Test_schemeRunEnvironmentOptions(t *testing.T) {
+	env := schemeArchiveEnvironment{LaunchEnvironment: []string{"API_URL=https://example.com", "FLAVOR=local", "OS-ACTIVITY=disable"}}
+
+	options, skipped := schemeRunEnvironmentOptions(env, []string{"-quiet", "FLAVOR=staging"})
+	require.Equal(t, []string{"API_URL=https://example.com"}, options)
+	require.Equal(t, []string{"FLAVOR", "OS-ACTIVITY"}, skipped)
+}
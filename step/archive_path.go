@@ -0,0 +1,40 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	existingArchiveReplace = "replace"
+	existingArchiveFail    = "fail"
+)
+
+// resolveArchivePath validates the archive path input and returns its absolute path.
+func resolveArchivePath(pth string) (string, error) {
+	if filepath.Ext(pth) != ".xcarchive" {
+		return "", fmt.Errorf("the archive path should have .xcarchive extension: %s", pth)
+	}
+	return filepath.Abs(pth)
+}
+
+// prepareArchivePath creates the archive path's parent dir and handles an archive left at the path by an earlier build:
+// it is removed, as xcodebuild would merge the new archive into it, or the Step fails if existing is existingArchiveFail.
+func prepareArchivePath(pth, existing string) error {
+	if _, err := os.Lstat(pth); err == nil {
+		if existing == existingArchiveFail {
+			return fmt.Errorf("an archive already exists at the archive path: %s", pth)
+		}
+		if err := os.RemoveAll(pth); err != nil {
+			return fmt.Errorf("failed to remove the existing archive (%s): %w", pth, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+		return fmt.Errorf("failed to create the archive path's dir: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_resolveArchivePath(t *testing.T) {
+	pth, err := resolveArchivePath("/Volumes/SSD/archives/App.xcarchive")
+	require.NoError(t, err)
+	require.Equal(t, "/Volumes/SSD/archives/App.xcarchive", pth)
+
+	pth, err = resolveArchivePath("archives/App.xcarchive")
+	require.NoError(t, err)
+	require.True(t, filepath.IsAbs(pth))
+
+	_, err = resolveArchivePath("/Volumes/SSD/archives")
+	require.Error(t, err)
+}
+
+func Test_prepareArchivePath(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "archives", "App.xcarchive")
+
+	require.NoError(t, prepareArchivePath(pth, existingArchiveFail))
+	_, err := os.Stat(filepath.Dir(pth))
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(pth, "Products"), 0755))
+	require.Error(t, prepareArchivePath(pth, existingArchiveFail))
+
+	require.NoError(t, prepareArchivePath(pth, existingArchiveReplace))
+	_, err = os.Stat(pth)
+	require.True(t, os.IsNotExist(err))
+}
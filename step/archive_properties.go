@@ -0,0 +1,71 @@
+package step
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/plistutil"
+)
+
+const (
+	bitriseArchiveBundleIDEnvKey        = "BITRISE_ARCHIVE_BUNDLE_ID"
+	bitriseArchiveVersionEnvKey         = "BITRISE_ARCHIVE_VERSION"
+	bitriseArchiveBuildEnvKey           = "BITRISE_ARCHIVE_BUILD"
+	bitriseArchiveSigningIdentityEnvKey = "BITRISE_ARCHIVE_SIGNING_IDENTITY"
+	bitriseArchiveTeamIDEnvKey          = "BITRISE_ARCHIVE_TEAM_ID"
+	bitriseArchivePropertiesEnvKey      = "BITRISE_ARCHIVE_APPLICATION_PROPERTIES"
+)
+
+// archiveApplicationProperties describes the main application of the archive, as recorded by the ApplicationProperties of the xcarchive's Info.plist.
+type archiveApplicationProperties struct {
+	BundleID        string `json:"bundle_id"`
+	Version         string `json:"version"`
+	Build           string `json:"build"`
+	SigningIdentity string `json:"signing_identity"`
+	TeamID          string `json:"team_id"`
+}
+
+func readArchiveApplicationProperties(archiveInfoPlist plistutil.PlistData) (archiveApplicationProperties, error) {
+	properties, found := archiveInfoPlist.GetMapStringInterface("ApplicationProperties")
+	if !found {
+		return archiveApplicationProperties{}, fmt.Errorf("no ApplicationProperties found in the archive's Info.plist")
+	}
+
+	out := archiveApplicationProperties{}
+	out.BundleID, _ = properties.GetString("CFBundleIdentifier")
+	out.Version, _ = properties.GetString("CFBundleShortVersionString")
+	out.Build, _ = properties.GetString("CFBundleVersion")
+	out.SigningIdentity, _ = properties.GetString("SigningIdentity")
+	out.TeamID, _ = properties.GetString("Team")
+	return out, nil
+}
+
+func exportArchiveApplicationProperties(cmdFactory command.Factory, outputEnvKeys OutputEnvKeys, properties archiveApplicationProperties, logger log.Logger) error {
+	content, err := json.Marshal(properties)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the archive's application properties: %w", err)
+	}
+
+	outputs := []struct {
+		key   string
+		value string
+	}{
+		{bitriseArchiveBundleIDEnvKey, properties.BundleID},
+		{bitriseArchiveVersionEnvKey, properties.Version},
+		{bitriseArchiveBuildEnvKey, properties.Build},
+		{bitriseArchiveSigningIdentityEnvKey, properties.SigningIdentity},
+		{bitriseArchiveTeamIDEnvKey, properties.TeamID},
+		{bitriseArchivePropertiesEnvKey, string(content)},
+	}
+
+	for _, output := range outputs {
+		if err := exportEnvironmentWithEnvman(cmdFactory, outputEnvKeys, output.key, output.value); err != nil {
+			return fmt.Errorf("failed to export %s: %w", outputEnvKeys.key(output.key), err)
+		}
+		logger.Printf("- %s: %s", outputEnvKeys.key(output.key), output.value)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/plistutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_readArchiveApplicationProperties(t *testing.T) {
+	infoPlist, err := plistutil.NewPlistDataFromContent(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>ApplicationProperties</key>
+	<dict>
+		<key>ApplicationPath</key>
+		<string>Applications/App.app</string>
+		<key>CFBundleIdentifier</key>
+		<string>io.bitrise.app</string>
+		<key>CFBundleShortVersionString</key>
+		<string>1.2.0</string>
+		<key>CFBundleVersion</key>
+		<string>42</string>
+		<key>SigningIdentity</key>
+		<string>Apple Distribution: Bitrise (TEAMID)</string>
+		<key>Team</key>
+		<string>TEAMID</string>
+	</dict>
+	<key>ArchiveVersion</key>
+	<integer>2</integer>
+</dict>
+</plist>`)
+	require.NoError(t, err)
+
+	properties, err := readArchiveApplicationProperties(infoPlist)
+	require.NoError(t, err)
+	require.Equal(t, archiveApplicationProperties{
+		BundleID:        "io.bitrise.app",
+		Version:         "1.2.0",
+		Build:           "42",
+		SigningIdentity: "Apple Distribution: Bitrise (TEAMID)",
+		TeamID:          "TEAMID",
+	}, properties)
+
+	_, err = readArchiveApplicationProperties(plistutil.PlistData{})
+	require.EqualError(t, err, "no ApplicationProperties found in the archive's Info.plist")
+}
@@ -0,0 +1,90 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/sliceutil"
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+const (
+	archsBuildSetting          = "ARCHS"
+	excludedArchsBuildSetting  = "EXCLUDED_ARCHS"
+	onlyActiveArchBuildSetting = "ONLY_ACTIVE_ARCH"
+
+	onlyActiveArchDefault = "default"
+
+	appStoreRequiredArch = "arm64"
+	arm64eArch           = "arm64e"
+)
+
+var archNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// archSettings are the archived architecture build settings, empty fields keep the project's settings.
+type archSettings struct {
+	Archs          []string
+	ExcludedArchs  []string
+	OnlyActiveArch string
+}
+
+func (s archSettings) isSet() bool {
+	return len(s.Archs) > 0 || len(s.ExcludedArchs) > 0 || (s.OnlyActiveArch != "" && s.OnlyActiveArch != onlyActiveArchDefault)
+}
+
+// parseArchList parses the space or comma separated list of architectures.
+func parseArchList(list string) ([]string, error) {
+	var archs []string
+	for _, arch := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' }) {
+		if !archNamePattern.MatchString(arch) {
+			return nil, fmt.Errorf("invalid architecture: %s", arch)
+		}
+		archs = append(archs, arch)
+	}
+	return archs, nil
+}
+
+// validateAppStoreArchs checks that the archived architectures are accepted by App Store Connect.
+func validateAppStoreArchs(settings archSettings, logger log.Logger) error {
+	if len(settings.Archs) > 0 && !sliceutil.IsStringInSlice(appStoreRequiredArch, settings.Archs) {
+		return fmt.Errorf("App Store distribution requires the %s architecture, archived architectures: %s", appStoreRequiredArch, strings.Join(settings.Archs, " "))
+	}
+	if sliceutil.IsStringInSlice(appStoreRequiredArch, settings.ExcludedArchs) {
+		return fmt.Errorf("App Store distribution requires the %s architecture, it can't be excluded", appStoreRequiredArch)
+	}
+	if sliceutil.IsStringInSlice(arm64eArch, settings.Archs) {
+		logger.Warnf("The %s architecture is archived, App Store Connect can reject binaries containing it.", arm64eArch)
+	}
+	if settings.OnlyActiveArch == "yes" {
+		logger.Warnf("%s is set to YES, the archive might not contain every architecture required for App Store distribution.", onlyActiveArchBuildSetting)
+	}
+	return nil
+}
+
+// xcconfigWithArchSettings appends the architecture build settings to the xcconfig content,
+// an xcconfig file path is included, so the settings override the ones of the file.
+func xcconfigWithArchSettings(xcconfigContent string, settings archSettings) (string, error) {
+	var lines []string
+	if strings.HasSuffix(xcconfigContent, ".xcconfig") {
+		pth, err := filepath.Abs(xcconfigContent)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, fmt.Sprintf("#include \"%s\"", pth))
+	} else if xcconfigContent != "" {
+		lines = append(lines, strings.TrimRight(xcconfigContent, "\n"))
+	}
+
+	if len(settings.Archs) > 0 {
+		lines = append(lines, fmt.Sprintf("%s = %s", archsBuildSetting, strings.Join(settings.Archs, " ")))
+	}
+	if len(settings.ExcludedArchs) > 0 {
+		lines = append(lines, fmt.Sprintf("%s = %s", excludedArchsBuildSetting, strings.Join(settings.ExcludedArchs, " ")))
+	}
+	if settings.OnlyActiveArch != "" && settings.OnlyActiveArch != onlyActiveArchDefault {
+		lines = append(lines, fmt.Sprintf("%s = %s", onlyActiveArchBuildSetting, strings.ToUpper(settings.OnlyActiveArch)))
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
@@ -0,0 +1,58 @@
+package step
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArchList(t *testing.T) {
+	archs, err := parseArchList(" arm64  arm64e,x86_64\n")
+	require.NoError(t, err)
+	require.Equal(t, []string{"arm64", "arm64e", "x86_64"}, archs)
+
+	archs, err = parseArchList("")
+	require.NoError(t, err)
+	require.Nil(t, archs)
+
+	_, err = parseArchList("arm64 $(ARCHS_STANDARD)")
+	require.Error(t, err)
+}
+
+func TestValidateAppStoreArchs(t *testing.T) {
+	logger := log.NewLogger()
+
+	require.NoError(t, validateAppStoreArchs(archSettings{Archs: []string{"arm64", "arm64e"}, ExcludedArchs: []string{"armv7k"}}, logger))
+	require.EqualError(t, validateAppStoreArchs(archSettings{Archs: []string{"arm64e"}}, logger), "App Store distribution requires the arm64 architecture, archived architectures: arm64e")
+	require.EqualError(t, validateAppStoreArchs(archSettings{ExcludedArchs: []string{"arm64"}}, logger), "App Store distribution requires the arm64 architecture, it can't be excluded")
+}
+
+func TestXcconfigWithArchSettings(t *testing.T) {
+	settings := archSettings{Archs: []string{"arm64"}, ExcludedArchs: []string{"armv7k"}, OnlyActiveArch: "no"}
+
+	content, err := xcconfigWithArchSettings("COMPILER_INDEX_STORE_ENABLE = NO\n", settings)
+	require.NoError(t, err)
+	require.Equal(t, "COMPILER_INDEX_STORE_ENABLE = NO\nARCHS = arm64\nEXCLUDED_ARCHS = armv7k\nONLY_ACTIVE_ARCH = NO\n", content)
+
+	content, err = xcconfigWithArchSettings("", archSettings{OnlyActiveArch: "yes"})
+	require.NoError(t, err)
+	require.Equal(t, "ONLY_ACTIVE_ARCH = YES\n", content)
+
+	content, err = xcconfigWithArchSettings("/project/Release.xcconfig", archSettings{Archs: []string{"arm64"}})
+	require.NoError(t, err)
+	require.Equal(t, "#include \"/project/Release.xcconfig\"\nARCHS = arm64\n", content)
+
+	pth, err := filepath.Abs("Release.xcconfig")
+	require.NoError(t, err)
+	content, err = xcconfigWithArchSettings("Release.xcconfig", archSettings{Archs: []string{"arm64"}})
+	require.NoError(t, err)
+	require.Equal(t, "#include \""+pth+"\"\nARCHS = arm64\n", content)
+}
+
+func TestArchSettings_isSet(t *testing.T) {
+	require.False(t, archSettings{OnlyActiveArch: onlyActiveArchDefault}.isSet())
+	require.True(t, archSettings{OnlyActiveArch: "no"}.isSet())
+	require.True(t, archSettings{Archs: []string{"arm64"}}.isSet())
+}
@@ -0,0 +1,86 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+// Artifact compressions of the xcarchive and the dSYMs
+const (
+	artifactCompressionZip  = "zip"
+	artifactCompressionZstd = "zstd"
+	artifactCompressionNone = "none"
+)
+
+// artifactCompressionExtension returns the extension of the compressed artifact, appended to the artifact's file name.
+func artifactCompressionExtension(compression string) string {
+	switch compression {
+	case artifactCompressionZstd:
+		return ".tar.zst"
+	case artifactCompressionNone:
+		return ".tar"
+	}
+	return ".zip"
+}
+
+// zstdCompressionLevel maps the zip compression level (0-9) to a zstd level, zstd has no level without compression.
+func zstdCompressionLevel(compressionLevel int) int {
+	if compressionLevel < 1 {
+		return 1
+	}
+	return compressionLevel
+}
+
+// tarArgs returns the tar arguments creating the tarball of the directory, the tarball contains the directory itself,
+// like the zips of the Step.
+func tarArgs(sourceDir, destinationPth, compression string, compressionLevel int) []string {
+	var args []string
+	if compression == artifactCompressionZstd {
+		args = append(args, "--use-compress-program", fmt.Sprintf("zstd -T0 -%d", zstdCompressionLevel(compressionLevel)))
+	}
+	return append(args, "-cf", destinationPth, "-C", filepath.Dir(sourceDir), filepath.Base(sourceDir))
+}
+
+func tarDir(cmdFactory command.Factory, sourceDir, destinationPth, compression string, compressionLevel int, logger log.Logger) error {
+	logger.TPrintf("Will create a %s tarball of directory path: %s", compression, sourceDir)
+
+	cmd := cmdFactory.Create("tar", tarArgs(sourceDir, destinationPth, compression, compressionLevel), nil)
+	if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create tarball of dir: %s, error: %s, output: %s", sourceDir, err, out)
+	}
+
+	logger.TPrintf("Tarball created.")
+
+	return nil
+}
+
+// ExportOutputDirAsArchive compresses the directory with the artifact compression and exports the created file's path.
+func ExportOutputDirAsArchive(cmdFactory command.Factory, outputEnvKeys OutputEnvKeys, sourceDirPth, destinationPth, envKey, compression string, compressionLevel int, logger log.Logger) error {
+	if compression == "" || compression == artifactCompressionZip {
+		return ExportOutputDirAsZip(cmdFactory, outputEnvKeys, sourceDirPth, destinationPth, envKey, compressionLevel, logger)
+	}
+
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__export_tmp_dir__")
+	if err != nil {
+		return err
+	}
+
+	tmpPth := filepath.Join(tmpDir, filepath.Base(sourceDirPth)+artifactCompressionExtension(compression))
+	if err := tarDir(cmdFactory, sourceDirPth, tmpPth, compression, compressionLevel, logger); err != nil {
+		return err
+	}
+
+	return ExportOutputFile(cmdFactory, outputEnvKeys, tmpPth, destinationPth, envKey)
+}
+
+// checkZstdInstalled checks that the zstd command, used by the zstd artifact compression, is available.
+func (s XcodebuildArchiveConfigParser) checkZstdInstalled() error {
+	if _, err := s.runTrimmed("which", "zstd"); err != nil {
+		return fmt.Errorf("zstd is not installed, install it (brew install zstd) or use the %s compression", artifactCompressionZip)
+	}
+	return nil
+}
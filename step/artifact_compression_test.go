@@ -0,0 +1,23 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_artifactCompressionExtension(t *testing.T) {
+	require.Equal(t, ".zip", artifactCompressionExtension(artifactCompressionZip))
+	require.Equal(t, ".zip", artifactCompressionExtension(""))
+	require.Equal(t, ".tar.zst", artifactCompressionExtension(artifactCompressionZstd))
+	require.Equal(t, ".tar", artifactCompressionExtension(artifactCompressionNone))
+}
+
+func Test_tarArgs(t *testing.T) {
+	require.Equal(t, []string{"--use-compress-program", "zstd -T0 -6", "-cf", "/output/Bitrise.xcarchive.tar.zst", "-C", "/archives", "Bitrise.xcarchive"},
+		tarArgs("/archives/Bitrise.xcarchive", "/output/Bitrise.xcarchive.tar.zst", artifactCompressionZstd, 6))
+	require.Equal(t, []string{"--use-compress-program", "zstd -T0 -1", "-cf", "/output/dSYMs.tar.zst", "-C", "/tmp", "dSYMs"},
+		tarArgs("/tmp/dSYMs", "/output/dSYMs.tar.zst", artifactCompressionZstd, 0))
+	require.Equal(t, []string{"-cf", "/output/dSYMs.tar", "-C", "/tmp", "dSYMs"},
+		tarArgs("/tmp/dSYMs", "/output/dSYMs.tar", artifactCompressionNone, 6))
+}
@@ -0,0 +1,136 @@
+package step
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// sameFileContent returns if the destination is a regular file with the same size and hash as the source file.
+func sameFileContent(src, dst string) (bool, error) {
+	dstInfo, err := os.Stat(dst)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return false, err
+	}
+	if !dstInfo.Mode().IsRegular() || srcInfo.Size() != dstInfo.Size() {
+		return false, nil
+	}
+
+	srcHash, err := fileSHA256(src)
+	if err != nil {
+		return false, err
+	}
+	dstHash, err := fileSHA256(dst)
+	if err != nil {
+		return false, err
+	}
+	return srcHash == dstHash, nil
+}
+
+// writeFileAtomically writes the file through a temporary file of the destination's dir renamed to the destination,
+// so an interrupted or a concurrent write never leaves a partial file at the destination.
+func writeFileAtomically(dst string, mode os.FileMode, write func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPth := tmp.Name()
+	defer func() {
+		_ = os.Remove(tmpPth)
+	}()
+
+	if err := write(tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPth, mode); err != nil {
+		return err
+	}
+	return os.Rename(tmpPth, dst)
+}
+
+// atomicCopyFile copies the file atomically, an identical destination file is kept as is: returns false if the file was not copied.
+func atomicCopyFile(src, dst string) (bool, error) {
+	if same, err := sameFileContent(src, dst); err != nil {
+		return false, err
+	} else if same {
+		return false, nil
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return false, err
+	}
+	err = writeFileAtomically(dst, info.Mode().Perm(), func(w io.Writer) error {
+		return copyFileContent(w, src)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to copy %s to %s: %w", src, dst, err)
+	}
+	return true, nil
+}
+
+// atomicWriteFile writes the content atomically, an identical destination file is kept as is: returns false if the file was not written.
+func atomicWriteFile(dst string, content []byte) (bool, error) {
+	if existing, err := os.ReadFile(dst); err == nil && bytes.Equal(existing, content) {
+		return false, nil
+	}
+
+	err := writeFileAtomically(dst, 0644, func(w io.Writer) error {
+		_, err := w.Write(content)
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	return true, nil
+}
+
+// atomicCopyDir copies the dir's content into a temporary dir next to the destination, then replaces the destination with it,
+// so the destination is either the previous or the new complete copy.
+func atomicCopyDir(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	target := filepath.Join(tmpDir, filepath.Base(dst))
+	if err := copyDir(src, target, true); err != nil {
+		return err
+	}
+
+	if _, err := os.Lstat(dst); err == nil {
+		if err := os.Rename(dst, filepath.Join(tmpDir, "previous")); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", dst, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return os.Rename(target, dst)
+}
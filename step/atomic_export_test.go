@@ -0,0 +1,116 @@
+package step
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func requireNoTempFiles(t *testing.T, dir string) {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		require.NotRegexp(t, `\.tmp$`, entry.Name())
+	}
+}
+
+func Test_atomicCopyFile(t *testing.T) {
+	srcDir, outputDir := t.TempDir(), t.TempDir()
+	src := filepath.Join(srcDir, "App.ipa")
+	dst := filepath.Join(outputDir, "Sample.ipa")
+	require.NoError(t, os.WriteFile(src, []byte("ipa"), 0644))
+
+	copied, err := atomicCopyFile(src, dst)
+	require.NoError(t, err)
+	require.True(t, copied)
+	content, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, "ipa", string(content))
+
+	// An identical file is not copied again
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(dst, past, past))
+	copied, err = atomicCopyFile(src, dst)
+	require.NoError(t, err)
+	require.False(t, copied)
+	info, err := os.Stat(dst)
+	require.NoError(t, err)
+	require.True(t, info.ModTime().Equal(past))
+
+	// A different file of the same size is replaced
+	require.NoError(t, os.WriteFile(src, []byte("new"), 0644))
+	copied, err = atomicCopyFile(src, dst)
+	require.NoError(t, err)
+	require.True(t, copied)
+	content, err = os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(content))
+
+	requireNoTempFiles(t, outputDir)
+}
+
+func Test_atomicCopyFile_failure(t *testing.T) {
+	outputDir := t.TempDir()
+	dst := filepath.Join(outputDir, "Sample.ipa")
+	require.NoError(t, os.WriteFile(dst, []byte("previous"), 0644))
+
+	_, err := atomicCopyFile(filepath.Join(t.TempDir(), "missing.ipa"), dst)
+	require.Error(t, err)
+
+	// The previous file is kept
+	content, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, "previous", string(content))
+	requireNoTempFiles(t, outputDir)
+}
+
+func Test_atomicWriteFile(t *testing.T) {
+	outputDir := t.TempDir()
+	dst := filepath.Join(outputDir, "logs", "xcodebuild-archive.log")
+
+	written, err := atomicWriteFile(dst, []byte("** ARCHIVE SUCCEEDED **"))
+	require.NoError(t, err)
+	require.True(t, written)
+
+	written, err = atomicWriteFile(dst, []byte("** ARCHIVE SUCCEEDED **"))
+	require.NoError(t, err)
+	require.False(t, written)
+
+	written, err = atomicWriteFile(dst, []byte("** ARCHIVE FAILED **"))
+	require.NoError(t, err)
+	require.True(t, written)
+	content, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, "** ARCHIVE FAILED **", string(content))
+
+	requireNoTempFiles(t, filepath.Dir(dst))
+}
+
+func Test_atomicCopyDir(t *testing.T) {
+	if _, err := exec.LookPath("rsync"); err != nil {
+		t.Skip("rsync is not available")
+	}
+
+	src := filepath.Join(t.TempDir(), "App.app")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "Frameworks"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "Info.plist"), []byte("plist"), 0644))
+
+	outputDir := t.TempDir()
+	dst := filepath.Join(outputDir, "Sample.app")
+	require.NoError(t, os.MkdirAll(dst, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dst, "stale"), []byte("stale"), 0644))
+
+	require.NoError(t, atomicCopyDir(src, dst))
+
+	content, err := os.ReadFile(filepath.Join(dst, "Info.plist"))
+	require.NoError(t, err)
+	require.Equal(t, "plist", string(content))
+	require.DirExists(t, filepath.Join(dst, "Frameworks"))
+	// The previous dir is replaced, not merged
+	require.NoFileExists(t, filepath.Join(dst, "stale"))
+	requireNoTempFiles(t, outputDir)
+}
@@ -0,0 +1,92 @@
+package step
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	v1command "github.com/bitrise-io/go-utils/command"
+	v1pathutil "github.com/bitrise-io/go-utils/pathutil"
+)
+
+const (
+	bitriseBCSymbolMapsPthEnvKey = "BITRISE_BCSYMBOLMAPS_PATH"
+
+	bcSymbolMapExtension = ".bcsymbolmap"
+)
+
+// findBCSymbolMaps lists the BCSymbolMaps of the archive (bitcode builds) and the ones vendored in the application's bundle,
+// the maps are named after the UUID of the binary, so the same map found twice is listed once.
+func findBCSymbolMaps(archivePath, appPath string) ([]string, error) {
+	byName := map[string]string{}
+	for _, dir := range []string{filepath.Join(archivePath, "BCSymbolMaps"), appPath} {
+		if dir == "" {
+			continue
+		}
+		err := filepath.WalkDir(dir, func(pth string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && pth == dir {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if !entry.IsDir() && filepath.Ext(pth) == bcSymbolMapExtension {
+				if _, ok := byName[entry.Name()]; !ok {
+					byName[entry.Name()] = pth
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search for BCSymbolMaps in %s: %w", dir, err)
+		}
+	}
+
+	var pths []string
+	for _, pth := range byName {
+		pths = append(pths, pth)
+	}
+	sort.Strings(pths)
+	return pths, nil
+}
+
+// exportBCSymbolMaps collects the BCSymbolMaps into the dSYM output dir, some crash reporting services require them
+// to symbolicate the crashes of the bitcode enabled builds of older SDKs.
+func (s XcodebuildArchiver) exportBCSymbolMaps(opts ExportOpts) error {
+	bcSymbolMaps, err := findBCSymbolMaps(opts.Archive.Path, opts.Archive.Application.Path)
+	if err != nil {
+		return err
+	}
+	if len(bcSymbolMaps) == 0 {
+		return nil
+	}
+	s.logger.Printf("Found %d BCSymbolMaps.", len(bcSymbolMaps))
+
+	tmpDir, err := v1pathutil.NormalizedOSTempDirPath("__bcsymbolmaps__")
+	if err != nil {
+		return fmt.Errorf("failed to create tmp dir, error: %s", err)
+	}
+	bcSymbolMapsDir := filepath.Join(tmpDir, "BCSymbolMaps")
+	if err := os.MkdirAll(bcSymbolMapsDir, 0755); err != nil {
+		return err
+	}
+	for _, pth := range bcSymbolMaps {
+		if err := v1command.CopyFile(pth, filepath.Join(bcSymbolMapsDir, filepath.Base(pth))); err != nil {
+			return fmt.Errorf("failed to copy (%s) to directory (%s): %s", pth, bcSymbolMapsDir, err)
+		}
+	}
+
+	dsymOutputDir, err := outputDirForArtifact(opts.OutputDir, opts.OutputLayout, outputArtifactDSYM)
+	if err != nil {
+		return err
+	}
+	bcSymbolMapsZipPath := filepath.Join(dsymOutputDir, opts.ArtifactName+".BCSymbolMaps"+artifactCompressionExtension(opts.Compression))
+	if err := ExportOutputDirAsArchive(s.cmdFactory, s.outputEnvKeys, bcSymbolMapsDir, bcSymbolMapsZipPath, bitriseBCSymbolMapsPthEnvKey, opts.Compression, opts.CompressionLevel, s.logger); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseBCSymbolMapsPthEnvKey), err)
+	}
+	s.logger.Donef("The BCSymbolMaps zip path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseBCSymbolMapsPthEnvKey), bcSymbolMapsZipPath)
+
+	return nil
+}
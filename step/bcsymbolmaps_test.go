@@ -0,0 +1,37 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_findBCSymbolMaps(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "Sample.xcarchive")
+	appPath := filepath.Join(archivePath, "Products", "Applications", "Sample.app")
+	for _, pth := range []string{
+		filepath.Join(archivePath, "BCSymbolMaps", "A1B2.bcsymbolmap"),
+		filepath.Join(appPath, "Frameworks", "Vendor.framework", "BCSymbolMaps", "C3D4.bcsymbolmap"),
+		filepath.Join(appPath, "Frameworks", "Vendor.framework", "BCSymbolMaps", "A1B2.bcsymbolmap"),
+		filepath.Join(appPath, "Sample"),
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(pth), 0755))
+		require.NoError(t, os.WriteFile(pth, nil, 0644))
+	}
+
+	maps, err := findBCSymbolMaps(archivePath, appPath)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(archivePath, "BCSymbolMaps", "A1B2.bcsymbolmap"),
+		filepath.Join(appPath, "Frameworks", "Vendor.framework", "BCSymbolMaps", "C3D4.bcsymbolmap"),
+	}, maps)
+}
+
+func Test_findBCSymbolMaps_NoMaps(t *testing.T) {
+	archivePath := t.TempDir()
+	maps, err := findBCSymbolMaps(archivePath, filepath.Join(archivePath, "Products", "Applications", "Sample.app"))
+	require.NoError(t, err)
+	require.Nil(t, maps)
+}
@@ -0,0 +1,216 @@
+package step
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bitrise-io/go-utils/retry"
+	"github.com/bitrise-io/go-xcode/v2/devportalservice"
+)
+
+const (
+	bitriseBuildInsightsPthEnvKey = "BITRISE_BUILD_INSIGHTS_PATH"
+
+	buildInsightsFilename = "build-insights.json"
+	buildInsightsURL      = "https://step-analytics.bitrise.io/track"
+	buildInsightsEvent    = "step_xcode_archive_build_insights"
+	buildInsightsTimeout  = 10 * time.Second
+
+	errorCategoryCancelled = "cancelled"
+
+	retryOperationCodesigning    = "code_signing"
+	retryOperationArchive        = "archive"
+	retryOperationKeychainUnlock = "keychain_unlock"
+)
+
+// InsightsRecorder collects the build metrics not available from the phase timings: the retries and the compilation cache lookups,
+// a nil InsightsRecorder records nothing.
+type InsightsRecorder struct {
+	retries          map[string]int
+	compilationCache *compilationCacheStats
+	archiveCacheHit  *bool
+}
+
+// NewInsightsRecorder ...
+func NewInsightsRecorder() *InsightsRecorder {
+	return &InsightsRecorder{retries: map[string]int{}}
+}
+
+// RecordRetry counts a retry of the given operation.
+func (r *InsightsRecorder) RecordRetry(operation string) {
+	if r == nil {
+		return
+	}
+	r.retries[operation]++
+}
+
+// RecordCompilationCache adds the compilation cache lookups of an xcodebuild log.
+func (r *InsightsRecorder) RecordCompilationCache(xcodebuildLog string) {
+	if r == nil {
+		return
+	}
+	stats := parseCompilationCacheStats(xcodebuildLog)
+	if r.compilationCache == nil {
+		r.compilationCache = &compilationCacheStats{}
+	}
+	r.compilationCache.Hits += stats.Hits
+	r.compilationCache.Misses += stats.Misses
+}
+
+// RecordArchiveCache records the result of the archive cache lookup.
+func (r *InsightsRecorder) RecordArchiveCache(hit bool) {
+	if r == nil {
+		return
+	}
+	r.archiveCacheHit = &hit
+}
+
+// retryRecordingPreparer records the retries of the code signing preparation.
+type retryRecordingPreparer struct {
+	codesignPreparer
+	recorder *InsightsRecorder
+	attempts int
+}
+
+// PrepareCodesigning ...
+func (p *retryRecordingPreparer) PrepareCodesigning() (*devportalservice.APIKeyConnection, error) {
+	p.attempts++
+	if p.attempts > 1 {
+		p.recorder.RecordRetry(retryOperationCodesigning)
+	}
+	return p.codesignPreparer.PrepareCodesigning()
+}
+
+type buildInsightsCompilationCache struct {
+	Hits    int     `json:"hits"`
+	Misses  int     `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+// BuildInsights is the anonymized metrics payload of a Step run: it contains no project, scheme, path or bundle identifier.
+type BuildInsights struct {
+	Event            string                         `json:"event"`
+	Timestamp        time.Time                      `json:"timestamp"`
+	Succeeded        bool                           `json:"succeeded"`
+	ErrorCategory    string                         `json:"error_category,omitempty"`
+	DurationSeconds  float64                        `json:"duration_seconds"`
+	Phases           []PhaseTiming                  `json:"phases"`
+	Retries          map[string]int                 `json:"retries"`
+	CompilationCache *buildInsightsCompilationCache `json:"compilation_cache,omitempty"`
+	ArchiveCacheHit  *bool                          `json:"archive_cache_hit,omitempty"`
+	XcodeVersion     string                         `json:"xcode_version"`
+	ExportMethod     string                         `json:"export_method"`
+	LogFormatter     string                         `json:"log_formatter"`
+	CacheLevel       string                         `json:"cache_level"`
+	MatrixEntries    int                            `json:"matrix_entries,omitempty"`
+}
+
+// BuildInsightsOpts ...
+type BuildInsightsOpts struct {
+	Config    Config
+	Timings   []PhaseTiming
+	Recorder  *InsightsRecorder
+	Succeeded bool
+	Cancelled bool
+	Send      bool
+}
+
+// errorCategory returns the name of the phase failing the Step.
+func errorCategory(timings []PhaseTiming, succeeded, cancelled bool) string {
+	if succeeded {
+		return ""
+	}
+	if cancelled {
+		return errorCategoryCancelled
+	}
+	for _, timing := range timings {
+		if timing.Failed {
+			return timing.Name
+		}
+	}
+	return "unknown"
+}
+
+func newBuildInsights(opts BuildInsightsOpts, now time.Time) BuildInsights {
+	insights := BuildInsights{
+		Event:         buildInsightsEvent,
+		Timestamp:     now,
+		Succeeded:     opts.Succeeded,
+		ErrorCategory: errorCategory(opts.Timings, opts.Succeeded, opts.Cancelled),
+		Phases:        opts.Timings,
+		Retries:       map[string]int{},
+		XcodeVersion:  opts.Config.XcodeVersion,
+		ExportMethod:  opts.Config.ExportMethod,
+		LogFormatter:  opts.Config.LogFormatter,
+		CacheLevel:    opts.Config.CacheLevel,
+		MatrixEntries: len(opts.Config.MatrixEntries),
+	}
+	for _, timing := range opts.Timings {
+		insights.DurationSeconds += timing.DurationSeconds
+	}
+	if opts.Recorder != nil {
+		for operation, count := range opts.Recorder.retries {
+			insights.Retries[operation] = count
+		}
+		if stats := opts.Recorder.compilationCache; stats != nil {
+			insights.CompilationCache = &buildInsightsCompilationCache{Hits: stats.Hits, Misses: stats.Misses, HitRate: stats.HitRate()}
+		}
+		insights.ArchiveCacheHit = opts.Recorder.archiveCacheHit
+	}
+	return insights
+}
+
+func sendBuildInsights(client *http.Client, url string, content []byte) error {
+	resp, err := client.Post(url, "application/json", bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	return nil
+}
+
+// ExportBuildInsights writes the build insights as a JSON file to the logs output dir, so it can be shipped to any observability stack,
+// and sends the same payload to the Bitrise analytics if enabled.
+func (s XcodebuildArchiver) ExportBuildInsights(opts BuildInsightsOpts) error {
+	insights := newBuildInsights(opts, time.Now())
+	content, err := json.MarshalIndent(insights, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	logsOutputDir, err := outputDirForArtifact(opts.Config.OutputDir, opts.Config.OutputLayout, outputArtifactLogs)
+	if err != nil {
+		return err
+	}
+	pth := filepath.Join(logsOutputDir, buildInsightsFilename)
+	if err := os.WriteFile(pth, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", pth, err)
+	}
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseBuildInsightsPthEnvKey, pth); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseBuildInsightsPthEnvKey), err)
+	}
+	s.logger.Donef("The build insights path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseBuildInsightsPthEnvKey), pth)
+
+	if !opts.Send {
+		return nil
+	}
+
+	client := retry.NewHTTPClient().StandardClient()
+	client.Timeout = buildInsightsTimeout
+	if err := sendBuildInsights(client, buildInsightsURL, content); err != nil {
+		s.logger.Warnf("Failed to send the build insights: %s", err)
+		return nil
+	}
+	s.logger.Printf("Build insights sent")
+	return nil
+}
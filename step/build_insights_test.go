@@ -0,0 +1,87 @@
+package step
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsightsRecorder_nil(t *testing.T) {
+	var recorder *InsightsRecorder
+	recorder.RecordRetry(retryOperationArchive)
+	recorder.RecordCompilationCache("cache hit")
+	recorder.RecordArchiveCache(true)
+}
+
+func TestNewBuildInsights(t *testing.T) {
+	recorder := NewInsightsRecorder()
+	recorder.RecordRetry(retryOperationCodesigning)
+	recorder.RecordRetry(retryOperationCodesigning)
+	recorder.RecordCompilationCache("cache hit\ncache hit\ncache miss\n")
+	recorder.RecordCompilationCache("cache hit\n")
+	recorder.RecordArchiveCache(false)
+
+	timings := []PhaseTiming{
+		{Name: "code signing", DurationSeconds: 2},
+		{Name: "archive", DurationSeconds: 10, Failed: true},
+		{Name: "packaging", DurationSeconds: 1},
+	}
+	config := Config{XcodeVersion: "15.4", Inputs: Inputs{ExportMethod: "app-store", ProjectPath: "secret/App.xcodeproj"}}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	insights := newBuildInsights(BuildInsightsOpts{Config: config, Timings: timings, Recorder: recorder}, now)
+	archiveCacheHit := false
+	require.Equal(t, BuildInsights{
+		Event:            buildInsightsEvent,
+		Timestamp:        now,
+		ErrorCategory:    "archive",
+		DurationSeconds:  13,
+		Phases:           timings,
+		Retries:          map[string]int{retryOperationCodesigning: 2},
+		CompilationCache: &buildInsightsCompilationCache{Hits: 3, Misses: 1, HitRate: 75},
+		ArchiveCacheHit:  &archiveCacheHit,
+		XcodeVersion:     "15.4",
+		ExportMethod:     "app-store",
+	}, insights)
+}
+
+func TestErrorCategory(t *testing.T) {
+	timings := []PhaseTiming{{Name: "code signing", Failed: true}}
+	require.Equal(t, "", errorCategory(timings, true, false))
+	require.Equal(t, errorCategoryCancelled, errorCategory(timings, false, true))
+	require.Equal(t, "code signing", errorCategory(timings, false, false))
+	require.Equal(t, "unknown", errorCategory(nil, false, false))
+}
+
+func TestRetryRecordingPreparer(t *testing.T) {
+	recorder := NewInsightsRecorder()
+	preparer := &retryRecordingPreparer{codesignPreparer: &fakeCodesignPreparer{errs: []error{errors.New("failed"), errors.New("failed")}}, recorder: recorder}
+	for i := 0; i < 3; i++ {
+		_, _ = preparer.PrepareCodesigning()
+	}
+	require.Equal(t, map[string]int{retryOperationCodesigning: 2}, recorder.retries)
+}
+
+func TestSendBuildInsights(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	}))
+	defer server.Close()
+
+	require.NoError(t, sendBuildInsights(server.Client(), server.URL, []byte(`{"event":"test"}`)))
+	require.Equal(t, `{"event":"test"}`, received)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	require.Error(t, sendBuildInsights(failing.Client(), failing.URL, []byte(`{}`)))
+}
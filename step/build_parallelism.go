@@ -0,0 +1,54 @@
+package step
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+
+	"github.com/bitrise-io/go-utils/sliceutil"
+)
+
+const (
+	parallelizeTargetsOption = "-parallelizeTargets"
+	jobsOption               = "-jobs"
+)
+
+// buildParallelismOptions returns the xcodebuild options controlling the build parallelism,
+// an error is returned if an option is already set in the xcodebuild options.
+func buildParallelismOptions(parallelizeTargets bool, jobs int, xcodebuildOptions []string) ([]string, error) {
+	var options []string
+	if parallelizeTargets {
+		if sliceutil.IsStringInSlice(parallelizeTargetsOption, xcodebuildOptions) {
+			return nil, fmt.Errorf("`%s` option found in XcodebuildOptions (`xcodebuild_options`), please disable Parallelize targets (`parallelize_targets`) input as only one can be set", parallelizeTargetsOption)
+		}
+		options = append(options, parallelizeTargetsOption)
+	}
+	if jobs > 0 {
+		if sliceutil.IsStringInSlice(jobsOption, xcodebuildOptions) {
+			return nil, fmt.Errorf("`%s` option found in XcodebuildOptions (`xcodebuild_options`), please clear Build jobs (`build_jobs`) input as only one can be set", jobsOption)
+		}
+		options = append(options, jobsOption, strconv.Itoa(jobs))
+	}
+	return options, nil
+}
+
+// buildParallelismDescription describes the build parallelism set by the xcodebuild options.
+func buildParallelismDescription(xcodebuildOptions []string, cpuCount int) string {
+	targets := "scheme setting"
+	if sliceutil.IsStringInSlice(parallelizeTargetsOption, xcodebuildOptions) {
+		targets = "parallelized"
+	}
+
+	jobs := fmt.Sprintf("xcodebuild default (%d CPU cores)", cpuCount)
+	for i, option := range xcodebuildOptions {
+		if option == jobsOption && i+1 < len(xcodebuildOptions) {
+			jobs = xcodebuildOptions[i+1]
+		}
+	}
+
+	return fmt.Sprintf("targets: %s, jobs: %s", targets, jobs)
+}
+
+func defaultBuildParallelismDescription(xcodebuildOptions []string) string {
+	return buildParallelismDescription(xcodebuildOptions, runtime.NumCPU())
+}
@@ -0,0 +1,27 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_buildParallelismOptions(t *testing.T) {
+	options, err := buildParallelismOptions(false, 0, nil)
+	require.NoError(t, err)
+	require.Nil(t, options)
+
+	options, err = buildParallelismOptions(true, 8, []string{"-quiet"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"-parallelizeTargets", "-jobs", "8"}, options)
+
+	_, err = buildParallelismOptions(true, 0, []string{"-parallelizeTargets"})
+	require.Error(t, err)
+	_, err = buildParallelismOptions(false, 4, []string{"-jobs", "2"})
+	require.Error(t, err)
+}
+
+func Test_buildParallelismDescription(t *testing.T) {
+	require.Equal(t, "targets: scheme setting, jobs: xcodebuild default (10 CPU cores)", buildParallelismDescription(nil, 10))
+	require.Equal(t, "targets: parallelized, jobs: 4", buildParallelismDescription([]string{"-parallelizeTargets", "-jobs", "4"}, 10))
+}
@@ -0,0 +1,111 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const bitriseBuildProductsPthEnvKey = "BITRISE_BUILD_PRODUCTS_PATH"
+
+// archiveIntermediatesDir returns the DerivedData directory of the scheme's archive build products and intermediates.
+func archiveIntermediatesDir(derivedDataDir, scheme string) string {
+	return filepath.Join(derivedDataDir, "Build", "Intermediates.noindex", "ArchiveIntermediates", scheme)
+}
+
+// matchBuildProducts returns the distinct paths, relative to the base dir, matching the glob patterns (relative to the base dir).
+func matchBuildProducts(baseDir string, patterns []string) ([]string, error) {
+	var pths []string
+	seen := map[string]bool{}
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(baseDir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern (%s): %w", pattern, err)
+		}
+		for _, match := range matches {
+			rel, err := filepath.Rel(baseDir, match)
+			if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+				continue
+			}
+			if !seen[rel] {
+				seen[rel] = true
+				pths = append(pths, rel)
+			}
+		}
+	}
+	return pths, nil
+}
+
+// copyBuildProduct copies the build product to the destination, the products in BuildProductsPath are often symlinks
+// to the installed or uninstalled products, so the link target is copied.
+func copyBuildProduct(pth, destination string) error {
+	resolved, err := filepath.EvalSymlinks(pth)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destination), 0777); err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return atomicCopyDir(resolved, destination)
+	}
+	_, err = atomicCopyFile(resolved, destination)
+	return err
+}
+
+func (s XcodebuildArchiver) findArchiveIntermediatesDir(projectPath, scheme string, additionalOptions []string) string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		s.logger.Warnf("Failed to find the build products: %s", err)
+		return ""
+	}
+
+	dir, err := derivedDataDir(projectPath, additionalOptions, homeDir)
+	if err != nil {
+		s.logger.Warnf("Failed to find the build products: %s", err)
+		return ""
+	}
+	if dir == "" {
+		s.logger.Warnf("No DerivedData found for the project, the build products are not exported")
+		return ""
+	}
+	return archiveIntermediatesDir(dir, scheme)
+}
+
+// exportBuildProducts copies the build products matching the patterns from DerivedData into the output dir,
+// keeping their path relative to the scheme's archive intermediates dir.
+func (s XcodebuildArchiver) exportBuildProducts(intermediatesDir string, patterns []string, outputDir string) {
+	pths, err := matchBuildProducts(intermediatesDir, patterns)
+	if err != nil {
+		s.logger.Warnf("Failed to export the build products: %s", err)
+		return
+	}
+	if len(pths) == 0 {
+		s.logger.Warnf("No build products found in %s matching: %s", intermediatesDir, strings.Join(patterns, ", "))
+		return
+	}
+
+	if err := os.RemoveAll(outputDir); err != nil {
+		s.logger.Warnf("Failed to remove path (%s), error: %s", outputDir, err)
+		return
+	}
+	for _, pth := range pths {
+		if err := copyBuildProduct(filepath.Join(intermediatesDir, pth), filepath.Join(outputDir, pth)); err != nil {
+			s.logger.Warnf("Failed to copy build product %s: %s", pth, err)
+			continue
+		}
+		s.logger.Printf("Exported build product: %s", pth)
+	}
+
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseBuildProductsPthEnvKey, outputDir); err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", s.outputEnvKey(bitriseBuildProductsPthEnvKey), err)
+		return
+	}
+	s.logger.Donef("The build products dir path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseBuildProductsPthEnvKey), outputDir)
+}
@@ -0,0 +1,55 @@
+package step
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_archiveIntermediatesDir(t *testing.T) {
+	require.Equal(t, "/DerivedData/App-abc/Build/Intermediates.noindex/ArchiveIntermediates/App", archiveIntermediatesDir("/DerivedData/App-abc", "App"))
+}
+
+func Test_matchBuildProducts(t *testing.T) {
+	baseDir := t.TempDir()
+	createDirs(t, baseDir,
+		"BuildProductsPath/Release-iphoneos/App.app",
+		"BuildProductsPath/Release-iphoneos/Core.swiftmodule",
+		"BuildProductsPath/Release-iphoneos/include",
+	)
+
+	pths, err := matchBuildProducts(baseDir, []string{"BuildProductsPath/*/*.app", "BuildProductsPath/*/*.swiftmodule", "BuildProductsPath/*/*.app", "../*"})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join("BuildProductsPath", "Release-iphoneos", "App.app"),
+		filepath.Join("BuildProductsPath", "Release-iphoneos", "Core.swiftmodule"),
+	}, pths)
+
+	_, err = matchBuildProducts(baseDir, []string{"["})
+	require.Error(t, err)
+}
+
+func Test_copyBuildProduct(t *testing.T) {
+	if _, err := exec.LookPath("rsync"); err != nil {
+		t.Skip("rsync is not available")
+	}
+
+	dir := t.TempDir()
+	installed := filepath.Join(dir, "UninstalledProducts", "libCore.a")
+	createDirs(t, dir, "UninstalledProducts", "BuildProductsPath")
+	require.NoError(t, os.WriteFile(installed, []byte("archive"), 0644))
+	require.NoError(t, os.Symlink(installed, filepath.Join(dir, "BuildProductsPath", "libCore.a")))
+
+	destination := filepath.Join(dir, "output", "BuildProductsPath", "libCore.a")
+	require.NoError(t, copyBuildProduct(filepath.Join(dir, "BuildProductsPath", "libCore.a"), destination))
+
+	info, err := os.Lstat(destination)
+	require.NoError(t, err)
+	require.True(t, info.Mode().IsRegular())
+	content, err := os.ReadFile(destination)
+	require.NoError(t, err)
+	require.Equal(t, "archive", string(content))
+}
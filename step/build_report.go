@@ -0,0 +1,151 @@
+package step
+
+import (
+	"html/template"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	bitriseBuildReportPthEnvKey = "BITRISE_BUILD_REPORT_PATH"
+
+	showBuildTimingSummaryOption = "-showBuildTimingSummary"
+	buildReportMaxWarnings       = 100
+)
+
+var (
+	buildTimingPattern   = regexp.MustCompile(`^(\S+) \((\d+) tasks?\) \| ([\d.]+) seconds?$`)
+	buildDurationPattern = regexp.MustCompile(`^\*\* [A-Z ]+ (?:SUCCEEDED|FAILED) \*\* \[([\d.]+) sec\]`)
+	buildWarningPattern  = regexp.MustCompile(`(^|:\s*)warning:\s`)
+)
+
+type buildStepTiming struct {
+	Name    string
+	Tasks   int
+	Seconds float64
+}
+
+type buildMessage struct {
+	Message string
+	Count   int
+}
+
+type buildReport struct {
+	Title           string
+	DurationSeconds float64
+	Parallelism     string
+	Steps           []buildStepTiming
+	Warnings        []buildMessage
+	Errors          []buildMessage
+}
+
+// parseBuildReport collects the build step timings (printed by xcodebuild's -showBuildTimingSummary option),
+// the warnings and the errors of a raw xcodebuild log.
+func parseBuildReport(title, xcodebuildLog string) buildReport {
+	report := buildReport{Title: title}
+
+	warningCounts := map[string]int{}
+	errorCounts := map[string]int{}
+	for _, line := range strings.Split(xcodebuildLog, "\n") {
+		line = strings.TrimSpace(line)
+
+		if match := buildTimingPattern.FindStringSubmatch(line); match != nil {
+			tasks, _ := strconv.Atoi(match[2])
+			seconds, _ := strconv.ParseFloat(match[3], 64)
+			report.Steps = append(report.Steps, buildStepTiming{Name: match[1], Tasks: tasks, Seconds: seconds})
+		} else if match := buildDurationPattern.FindStringSubmatch(line); match != nil {
+			report.DurationSeconds, _ = strconv.ParseFloat(match[1], 64)
+		} else if buildWarningPattern.MatchString(line) {
+			warningCounts[line]++
+		} else if errorLinePattern.MatchString(line) {
+			errorCounts[line]++
+		}
+	}
+
+	sort.SliceStable(report.Steps, func(i, j int) bool {
+		return report.Steps[i].Seconds > report.Steps[j].Seconds
+	})
+	report.Warnings = sortedBuildMessages(warningCounts, buildReportMaxWarnings)
+	report.Errors = sortedBuildMessages(errorCounts, 0)
+
+	return report
+}
+
+// sortedBuildMessages orders the messages by their number of occurrences, limit is the maximum number of messages (0 means no limit).
+func sortedBuildMessages(counts map[string]int, limit int) []buildMessage {
+	var messages []buildMessage
+	for message, count := range counts {
+		messages = append(messages, buildMessage{Message: message, Count: count})
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].Count != messages[j].Count {
+			return messages[i].Count > messages[j].Count
+		}
+		return messages[i].Message < messages[j].Message
+	})
+
+	if limit > 0 && len(messages) > limit {
+		messages = messages[:limit]
+	}
+	return messages
+}
+
+var buildReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, Helvetica, Arial, sans-serif; margin: 2em; color: #2b0e3f; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border-bottom: 1px solid #ddd; padding: 6px 8px; text-align: left; vertical-align: top; }
+td.number { text-align: right; white-space: nowrap; }
+td.message { font-family: Menlo, monospace; font-size: 12px; word-break: break-all; }
+.warning { color: #a36c00; }
+.error { color: #c41e1e; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .DurationSeconds}}<p>Build duration: {{printf "%.1f" .DurationSeconds}} seconds</p>{{end}}
+{{if .Parallelism}}<p>Build parallelism: {{.Parallelism}}</p>{{end}}
+<p>{{len .Errors}} distinct errors, {{len .Warnings}} distinct warnings</p>
+
+<h2>Slowest build steps</h2>
+{{if .Steps}}<table>
+<tr><th>Build step</th><th>Tasks</th><th>Duration (seconds)</th></tr>
+{{range .Steps}}<tr><td>{{.Name}}</td><td class="number">{{.Tasks}}</td><td class="number">{{printf "%.3f" .Seconds}}</td></tr>
+{{end}}</table>
+{{else}}<p>No build timing summary found in the log.</p>
+{{end}}
+{{if .Errors}}<h2 class="error">Errors</h2>
+<table>
+<tr><th>Count</th><th>Error</th></tr>
+{{range .Errors}}<tr><td class="number">{{.Count}}</td><td class="message">{{.Message}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2 class="warning">Warnings</h2>
+{{if .Warnings}}<table>
+<tr><th>Count</th><th>Warning</th></tr>
+{{range .Warnings}}<tr><td class="number">{{.Count}}</td><td class="message">{{.Message}}</td></tr>
+{{end}}</table>
+{{else}}<p>No warnings.</p>
+{{end}}
+</body>
+</html>
+`))
+
+func writeBuildReport(report buildReport, pth string) error {
+	f, err := os.Create(pth)
+	if err != nil {
+		return err
+	}
+	if err := buildReportTemplate.Execute(f, report); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,56 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const buildReportTestLog = `/git/App/View.swift:10:9: warning: variable 'x' was never mutated; consider changing to 'let' constant
+/git/App/View.swift:10:9: warning: variable 'x' was never mutated; consider changing to 'let' constant
+warning: Run script build phase 'Lint' will be run during every build
+/git/App/Model.swift:3:1: error: expected declaration
+
+Build Timing Summary
+
+SwiftCompile (12 tasks) | 30.512 seconds
+
+CompileAssetCatalog (1 task) | 2.100 seconds
+
+CompileSwiftSources (3 tasks) | 45.123 seconds
+
+** ARCHIVE FAILED ** [67.890 sec]`
+
+func Test_parseBuildReport(t *testing.T) {
+	report := parseBuildReport("App", buildReportTestLog)
+
+	require.Equal(t, buildReport{
+		Title:           "App",
+		DurationSeconds: 67.89,
+		Steps: []buildStepTiming{
+			{Name: "CompileSwiftSources", Tasks: 3, Seconds: 45.123},
+			{Name: "SwiftCompile", Tasks: 12, Seconds: 30.512},
+			{Name: "CompileAssetCatalog", Tasks: 1, Seconds: 2.1},
+		},
+		Warnings: []buildMessage{
+			{Message: "/git/App/View.swift:10:9: warning: variable 'x' was never mutated; consider changing to 'let' constant", Count: 2},
+			{Message: "warning: Run script build phase 'Lint' will be run during every build", Count: 1},
+		},
+		Errors: []buildMessage{
+			{Message: "/git/App/Model.swift:3:1: error: expected declaration", Count: 1},
+		},
+	}, report)
+}
+
+func Test_writeBuildReport(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "report.html")
+	require.NoError(t, writeBuildReport(parseBuildReport("App <Release>", buildReportTestLog), pth))
+
+	content, err := os.ReadFile(pth)
+	require.NoError(t, err)
+	require.Contains(t, string(content), "<title>App &lt;Release&gt;</title>")
+	require.Contains(t, string(content), "<td>CompileSwiftSources</td><td class=\"number\">3</td><td class=\"number\">45.123</td>")
+	require.Contains(t, string(content), "Build duration: 67.9 seconds")
+}
@@ -0,0 +1,207 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/bitrise-io/go-xcode/plistutil"
+	"github.com/bitrise-io/go-xcode/xcarchive"
+)
+
+const (
+	bitriseBuildSummaryPthEnvKey = "BITRISE_BUILD_SUMMARY_PATH"
+
+	buildSummaryFilename = "build-summary.md"
+)
+
+// BuildSummaryOpts ...
+type BuildSummaryOpts struct {
+	OutputDir         string
+	OutputLayout      string
+	ArtifactName      string
+	Compression       string
+	Archive           *xcarchive.IosArchive
+	ExportOptionsPath string
+	Timings           []PhaseTiming
+	Succeeded         bool
+}
+
+type buildSummaryArtifact struct {
+	Name   string
+	EnvKey string
+	Path   string
+	Size   int64
+}
+
+type buildSummary struct {
+	Succeeded       bool
+	AppName         string
+	BundleID        string
+	Version         string
+	Build           string
+	ExportMethod    string
+	DurationSeconds float64
+	Phases          []PhaseTiming
+	Artifacts       []buildSummaryArtifact
+}
+
+// appDisplayName returns the name shown on the home screen, falling back to the bundle name.
+func appDisplayName(infoPlist plistutil.PlistData) string {
+	for _, key := range []string{"CFBundleDisplayName", "CFBundleName"} {
+		if name, _ := infoPlist.GetString(key); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// exportMethodOf reads the distribution method of the export options used for the export.
+func exportMethodOf(exportOptionsPath string) string {
+	if exportOptionsPath == "" {
+		return ""
+	}
+	options, err := plistutil.NewPlistDataFromFile(exportOptionsPath)
+	if err != nil {
+		return ""
+	}
+	method, _ := options.GetString(exportoptions.MethodKey)
+	return method
+}
+
+// buildSummaryArtifacts returns the existing main artifacts of the Step run.
+func buildSummaryArtifacts(outputDir, outputLayout, artifactName, compression string) []buildSummaryArtifact {
+	candidates := []buildSummaryArtifact{
+		{Name: "IPA", EnvKey: bitriseIPAPthEnvKey, Path: filepath.Join(artifactOutputDir(outputDir, outputLayout, outputArtifactIPA), artifactName+".ipa")},
+		{Name: "Archive", EnvKey: bitriseXCArchiveZipPthEnvKey, Path: filepath.Join(artifactOutputDir(outputDir, outputLayout, outputArtifactArchive), artifactName+".xcarchive"+artifactCompressionExtension(compression))},
+		{Name: "dSYMs", EnvKey: bitriseDSYMPthEnvKey, Path: filepath.Join(artifactOutputDir(outputDir, outputLayout, outputArtifactDSYM), artifactName+".dSYM"+artifactCompressionExtension(compression))},
+	}
+
+	var artifacts []buildSummaryArtifact
+	for _, artifact := range candidates {
+		info, err := os.Stat(artifact.Path)
+		if err != nil {
+			continue
+		}
+		artifact.Size = info.Size()
+		artifacts = append(artifacts, artifact)
+	}
+	return artifacts
+}
+
+func newBuildSummary(opts BuildSummaryOpts) buildSummary {
+	summary := buildSummary{
+		Succeeded:    opts.Succeeded,
+		AppName:      opts.ArtifactName,
+		ExportMethod: exportMethodOf(opts.ExportOptionsPath),
+		Phases:       opts.Timings,
+		Artifacts:    buildSummaryArtifacts(opts.OutputDir, opts.OutputLayout, opts.ArtifactName, opts.Compression),
+	}
+	for _, timing := range opts.Timings {
+		summary.DurationSeconds += timing.DurationSeconds
+	}
+
+	if opts.Archive != nil {
+		if name := appDisplayName(opts.Archive.Application.InfoPlist); name != "" {
+			summary.AppName = name
+		}
+		if properties, err := readArchiveApplicationProperties(opts.Archive.InfoPlist); err == nil {
+			summary.BundleID = properties.BundleID
+			summary.Version = properties.Version
+			summary.Build = properties.Build
+		}
+	}
+
+	return summary
+}
+
+func formatSummaryDuration(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second).String()
+}
+
+func formatSummarySize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	size, suffix := float64(bytes)/unit, "KB"
+	for _, next := range []string{"MB", "GB"} {
+		if size < unit {
+			break
+		}
+		size, suffix = size/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", size, suffix)
+}
+
+// renderBuildSummary formats the summary as plain markdown (headings, bold text, lists and inline code),
+// which Slack and Microsoft Teams messages can display without tables or HTML.
+func renderBuildSummary(summary buildSummary, outputEnvKeys OutputEnvKeys) string {
+	var b strings.Builder
+
+	status := "succeeded"
+	if !summary.Succeeded {
+		status = "failed"
+	}
+	title := summary.AppName
+	if summary.Version != "" {
+		title += " " + summary.Version
+	}
+	if summary.Build != "" {
+		title += " (" + summary.Build + ")"
+	}
+	fmt.Fprintf(&b, "### %s: archive %s\n\n", title, status)
+
+	if summary.BundleID != "" {
+		fmt.Fprintf(&b, "- **Bundle ID:** `%s`\n", summary.BundleID)
+	}
+	if summary.ExportMethod != "" {
+		fmt.Fprintf(&b, "- **Export method:** %s\n", summary.ExportMethod)
+	}
+	for _, artifact := range summary.Artifacts {
+		if artifact.EnvKey == bitriseIPAPthEnvKey {
+			fmt.Fprintf(&b, "- **IPA size:** %s\n", formatSummarySize(artifact.Size))
+		}
+	}
+	fmt.Fprintf(&b, "- **Duration:** %s\n", formatSummaryDuration(summary.DurationSeconds))
+
+	if len(summary.Phases) > 0 {
+		var phases []string
+		for _, phase := range summary.Phases {
+			failed := ""
+			if phase.Failed {
+				failed = " (failed)"
+			}
+			phases = append(phases, fmt.Sprintf("%s %s%s", phase.Name, formatSummaryDuration(phase.DurationSeconds), failed))
+		}
+		fmt.Fprintf(&b, "- **Phases:** %s\n", strings.Join(phases, ", "))
+	}
+
+	if len(summary.Artifacts) > 0 {
+		b.WriteString("\n**Artifacts:**\n")
+		for _, artifact := range summary.Artifacts {
+			fmt.Fprintf(&b, "- %s: `%s` (%s, `$%s`)\n", artifact.Name, filepath.Base(artifact.Path), formatSummarySize(artifact.Size), outputEnvKeys.key(artifact.EnvKey))
+		}
+	}
+
+	return b.String()
+}
+
+// ExportBuildSummary writes a short markdown summary of the Step run to the output dir,
+// so notification Steps can embed it as is.
+func (s XcodebuildArchiver) ExportBuildSummary(opts BuildSummaryOpts) error {
+	content := renderBuildSummary(newBuildSummary(opts), s.outputEnvKeys)
+
+	pth := filepath.Join(opts.OutputDir, buildSummaryFilename)
+	if err := os.WriteFile(pth, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", pth, err)
+	}
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseBuildSummaryPthEnvKey, pth); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseBuildSummaryPthEnvKey), err)
+	}
+	s.logger.Donef("The build summary path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseBuildSummaryPthEnvKey), pth)
+	return nil
+}
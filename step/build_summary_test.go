@@ -0,0 +1,83 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderBuildSummary(t *testing.T) {
+	summary := buildSummary{
+		Succeeded:       true,
+		AppName:         "Bitrise",
+		BundleID:        "io.bitrise.app",
+		Version:         "1.2.0",
+		Build:           "42",
+		ExportMethod:    "app-store",
+		DurationSeconds: 312.4,
+		Phases: []PhaseTiming{
+			{Name: "archive", DurationSeconds: 250.2},
+			{Name: "export", DurationSeconds: 62.2},
+		},
+		Artifacts: []buildSummaryArtifact{
+			{Name: "IPA", EnvKey: bitriseIPAPthEnvKey, Path: "/deploy/Bitrise.ipa", Size: 25 * 1024 * 1024},
+			{Name: "dSYMs", EnvKey: bitriseDSYMPthEnvKey, Path: "/deploy/Bitrise.dSYM.zip", Size: 1536},
+		},
+	}
+
+	require.Equal(t, "### Bitrise 1.2.0 (42): archive succeeded\n\n"+
+		"- **Bundle ID:** `io.bitrise.app`\n"+
+		"- **Export method:** app-store\n"+
+		"- **IPA size:** 25.0 MB\n"+
+		"- **Duration:** 5m12s\n"+
+		"- **Phases:** archive 4m10s, export 1m2s\n"+
+		"\n**Artifacts:**\n"+
+		"- IPA: `Bitrise.ipa` (25.0 MB, `$BITRISE_IPA_PATH`)\n"+
+		"- dSYMs: `Bitrise.dSYM.zip` (1.5 KB, `$BITRISE_DSYM_PATH`)\n", renderBuildSummary(summary, OutputEnvKeys{}))
+}
+
+func TestRenderBuildSummary_failed(t *testing.T) {
+	summary := buildSummary{
+		AppName:         "Bitrise",
+		DurationSeconds: 20,
+		Phases:          []PhaseTiming{{Name: "archive", DurationSeconds: 20, Failed: true}},
+	}
+
+	require.Equal(t, "### Bitrise: archive failed\n\n"+
+		"- **Duration:** 20s\n"+
+		"- **Phases:** archive 20s (failed)\n", renderBuildSummary(summary, OutputEnvKeys{}))
+}
+
+func TestFormatSummarySize(t *testing.T) {
+	require.Equal(t, "512 B", formatSummarySize(512))
+	require.Equal(t, "2.0 KB", formatSummarySize(2048))
+	require.Equal(t, "1.5 MB", formatSummarySize(1536*1024))
+	require.Equal(t, "3.0 GB", formatSummarySize(3*1024*1024*1024))
+}
+
+func TestBuildSummaryArtifacts(t *testing.T) {
+	outputDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(outputDir, string(outputArtifactIPA)), 0777))
+	ipaPath := filepath.Join(outputDir, string(outputArtifactIPA), "Bitrise.ipa")
+	require.NoError(t, os.WriteFile(ipaPath, []byte("ipa"), 0644))
+
+	artifacts := buildSummaryArtifacts(outputDir, outputLayoutByType, "Bitrise", artifactCompressionZip)
+	require.Equal(t, []buildSummaryArtifact{{Name: "IPA", EnvKey: bitriseIPAPthEnvKey, Path: ipaPath, Size: 3}}, artifacts)
+}
+
+func TestExportMethodOf(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "export_options.plist")
+	require.NoError(t, os.WriteFile(pth, []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>method</key>
+	<string>ad-hoc</string>
+</dict>
+</plist>`), 0644))
+
+	require.Equal(t, "ad-hoc", exportMethodOf(pth))
+	require.Equal(t, "", exportMethodOf(""))
+}
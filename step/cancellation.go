@@ -0,0 +1,118 @@
+package step
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+var errCancelled = errors.New("the Step was cancelled")
+
+// CancellationHandler traps the workflow abort signals (SIGTERM, SIGINT) and stops the running xcodebuild commands,
+// so the Step can still export the logs and the partial artifacts before exiting.
+// A second signal exits the Step immediately.
+type CancellationHandler struct {
+	logger              log.Logger
+	killChildProcesses  func(signal os.Signal) error
+	exit                func(code int)
+	signals             chan os.Signal
+	mu                  sync.Mutex
+	cancelledWithSignal os.Signal
+	cleanups            []func()
+}
+
+// NewCancellationHandler ...
+func NewCancellationHandler(cmdFactory command.Factory, logger log.Logger) *CancellationHandler {
+	return &CancellationHandler{
+		logger: logger,
+		killChildProcesses: func(sig os.Signal) error {
+			signalNumber := strconv.Itoa(int(sig.(syscall.Signal)))
+			cmd := cmdFactory.Create("pkill", []string{"-" + signalNumber, "-P", strconv.Itoa(os.Getpid())}, nil)
+			return cmd.Run()
+		},
+		exit:    os.Exit,
+		signals: make(chan os.Signal, 2),
+	}
+}
+
+// Start starts listening for the abort signals.
+func (h *CancellationHandler) Start() {
+	signal.Notify(h.signals, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		for sig := range h.signals {
+			h.handle(sig)
+		}
+	}()
+}
+
+// Stop stops listening for the abort signals.
+func (h *CancellationHandler) Stop() {
+	signal.Stop(h.signals)
+}
+
+// AddCleanup registers a function run before exiting immediately on a second signal, when the deferred cleanups are not run.
+func (h *CancellationHandler) AddCleanup(cleanup func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cleanups = append(h.cleanups, cleanup)
+}
+
+// Cancelled returns true if an abort signal was received, a nil CancellationHandler is never cancelled.
+func (h *CancellationHandler) Cancelled() bool {
+	if h == nil {
+		return false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.cancelledWithSignal != nil
+}
+
+// ExitCode returns the exit code of a Step cancelled by a signal (128 + the signal number), or the given exit code if the Step was not cancelled.
+func (h *CancellationHandler) ExitCode(exitCode int) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cancelledWithSignal == nil {
+		return exitCode
+	}
+	return signalExitCode(h.cancelledWithSignal)
+}
+
+func (h *CancellationHandler) handle(sig os.Signal) {
+	h.mu.Lock()
+	alreadyCancelled := h.cancelledWithSignal != nil
+	if !alreadyCancelled {
+		h.cancelledWithSignal = sig
+	}
+	cleanups := h.cleanups
+	h.mu.Unlock()
+
+	if alreadyCancelled {
+		h.logger.Errorf("Received %s again, exiting without exporting the Step outputs", sig)
+		for _, cleanup := range cleanups {
+			cleanup()
+		}
+		h.exit(signalExitCode(sig))
+		return
+	}
+
+	h.logger.Println()
+	h.logger.Warnf("Received %s, stopping the running commands and exporting the available logs and artifacts...", sig)
+	if err := h.killChildProcesses(sig); err != nil {
+		h.logger.Warnf("Failed to stop the running commands: %s", err)
+	}
+}
+
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
@@ -0,0 +1,59 @@
+package step
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancellationHandler_handle(t *testing.T) {
+	var killedWith []os.Signal
+	var exitCodes []int
+	handler := &CancellationHandler{
+		logger: log.NewLogger(),
+		killChildProcesses: func(sig os.Signal) error {
+			killedWith = append(killedWith, sig)
+			return nil
+		},
+		exit: func(code int) {
+			exitCodes = append(exitCodes, code)
+		},
+	}
+
+	require.False(t, handler.Cancelled())
+	require.Equal(t, 0, handler.ExitCode(0))
+
+	handler.handle(syscall.SIGTERM)
+	require.True(t, handler.Cancelled())
+	require.Equal(t, []os.Signal{syscall.SIGTERM}, killedWith)
+	require.Nil(t, exitCodes)
+	require.Equal(t, 143, handler.ExitCode(1))
+
+	handler.handle(syscall.SIGINT)
+	require.Equal(t, []os.Signal{syscall.SIGTERM}, killedWith)
+	require.Equal(t, []int{130}, exitCodes)
+}
+
+func TestCancellationHandler_nil(t *testing.T) {
+	var handler *CancellationHandler
+	require.False(t, handler.Cancelled())
+}
+
+func TestCancellationHandler_cleanups(t *testing.T) {
+	cleanups := 0
+	handler := &CancellationHandler{
+		logger:             log.NewLogger(),
+		killChildProcesses: func(os.Signal) error { return nil },
+		exit:               func(int) {},
+	}
+	handler.AddCleanup(func() { cleanups++ })
+
+	handler.handle(syscall.SIGTERM)
+	require.Equal(t, 0, cleanups)
+
+	handler.handle(syscall.SIGTERM)
+	require.Equal(t, 1, cleanups)
+}
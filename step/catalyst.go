@@ -0,0 +1,253 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	v1fileutil "github.com/bitrise-io/go-utils/fileutil"
+	v1pathutil "github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xcode/v2/xcconfig"
+	"github.com/bitrise-io/go-xcode/xcodebuild"
+)
+
+const (
+	macCatalystDestination     = "generic/platform=macOS,variant=Mac Catalyst"
+	macCatalystArtifactSuffix  = "-maccatalyst"
+	macCatalystExportExtension = ".pkg"
+
+	bitriseMacCatalystXCArchivePthEnvKey    = "BITRISE_MAC_CATALYST_XCARCHIVE_PATH"
+	bitriseMacCatalystXCArchiveZipPthEnvKey = "BITRISE_MAC_CATALYST_XCARCHIVE_ZIP_PATH"
+	bitriseMacCatalystExportPthEnvKey       = "BITRISE_MAC_CATALYST_EXPORT_PATH"
+)
+
+type macCatalystOpts struct {
+	ProjectPath               string
+	Scheme                    string
+	Configuration             string
+	ArtifactName              string
+	XcconfigContent           string
+	AdditionalOptions         []string
+	Toolchain                 string
+	XcodeAuthOptions          *xcodebuild.AuthenticationParams
+	ExportOptionsPlistContent string
+	Notarize                  bool
+	NotarizationTimeout       time.Duration
+}
+
+// MacCatalystResult ...
+type MacCatalystResult struct {
+	ArchivePath string
+	ExportPath  string
+
+	XcodebuildArchiveLog       string
+	XcodebuildExportArchiveLog string
+}
+
+// withoutOption removes the given option and its value from the xcodebuild options.
+func withoutOption(options []string, option string) []string {
+	var filtered []string
+	for i := 0; i < len(options); i++ {
+		if options[i] == option {
+			i++
+			continue
+		}
+		filtered = append(filtered, options[i])
+	}
+	return filtered
+}
+
+// archiveMacCatalyst archives the scheme for the Mac Catalyst destination and, if export options are provided, exports the archive.
+func (s XcodebuildArchiver) archiveMacCatalyst(opts macCatalystOpts) (MacCatalystResult, error) {
+	out := MacCatalystResult{}
+
+	s.logger.Println()
+	s.logger.TInfof("Creating the Mac Catalyst Archive ...")
+
+	archiveCmd := xcodebuild.NewCommandBuilder(opts.ProjectPath, "archive")
+	archiveCmd.SetScheme(opts.Scheme)
+	archiveCmd.SetConfiguration(opts.Configuration)
+
+	if opts.XcconfigContent != "" {
+		xcconfigWriter := xcconfig.NewWriter(s.pathProvider, s.fileManager, s.pathChecker, s.pathModifier)
+		xcconfigPath, err := xcconfigWriter.Write(opts.XcconfigContent)
+		if err != nil {
+			return out, fmt.Errorf("failed to write xcconfig file contents: %w", err)
+		}
+		archiveCmd.SetXCConfigPath(xcconfigPath)
+	}
+
+	tmpDir, err := v1pathutil.NormalizedOSTempDirPath("xcodeMacCatalystArchive")
+	if err != nil {
+		return out, fmt.Errorf("failed to create temp dir, error: %s", err)
+	}
+	archivePth := filepath.Join(tmpDir, opts.ArtifactName+macCatalystArtifactSuffix+".xcarchive")
+	archiveCmd.SetArchivePath(archivePth)
+	if opts.XcodeAuthOptions != nil {
+		archiveCmd.SetAuthentication(*opts.XcodeAuthOptions)
+	}
+
+	additionalOptions := append([]string{"-destination", macCatalystDestination}, withoutOption(opts.AdditionalOptions, "-destination")...)
+	additionalOptions = append(additionalOptions, toolchainOptions(opts.Toolchain)...)
+	archiveCmd.SetCustomOptions(additionalOptions)
+
+	xcodebuildLog, err := s.runner().Archive(archiveCmd.CommandArgs())
+	out.XcodebuildArchiveLog = xcodebuildLog
+	if err != nil {
+		return out, fmt.Errorf("failed to archive the project for Mac Catalyst: %w", err)
+	}
+
+	if exist, err := v1pathutil.IsDirExists(archivePth); err != nil {
+		return out, fmt.Errorf("failed to check if archive exist, error: %s", err)
+	} else if !exist {
+		return out, fmt.Errorf("no archive generated at: %s", archivePth)
+	}
+	out.ArchivePath = archivePth
+
+	if opts.ExportOptionsPlistContent == "" {
+		s.logger.Printf("No Mac Catalyst export options provided, skipping the Mac Catalyst export.")
+		return out, nil
+	}
+
+	s.logger.Println()
+	s.logger.Infof("Exporting the Mac Catalyst archive...")
+
+	exportOptionsPath := filepath.Join(tmpDir, "export_options.plist")
+	if err := v1fileutil.WriteStringToFile(exportOptionsPath, opts.ExportOptionsPlistContent); err != nil {
+		return out, fmt.Errorf("failed to write export options to file, error: %s", err)
+	}
+
+	exportDir := filepath.Join(tmpDir, "exported")
+	exportCmd := xcodebuild.NewExportCommand()
+	exportCmd.SetArchivePath(archivePth)
+	exportCmd.SetExportDir(exportDir)
+	exportCmd.SetExportOptionsPlist(exportOptionsPath)
+	if opts.XcodeAuthOptions != nil {
+		exportCmd.SetAuthentication(*opts.XcodeAuthOptions)
+	}
+
+	exportLog, err := s.runner().ExportArchive(append(exportCmd.CommandArgs(), toolchainOptions(opts.Toolchain)...))
+	out.XcodebuildExportArchiveLog = exportLog
+	if err != nil {
+		return out, fmt.Errorf("failed to export the Mac Catalyst archive: %w", err)
+	}
+
+	if opts.Notarize {
+		notarizedAppPath, err := s.waitForNotarizedApp(archivePth, filepath.Join(tmpDir, "notarized"), opts.XcodeAuthOptions, opts.NotarizationTimeout, time.Sleep)
+		if err != nil {
+			return out, fmt.Errorf("failed to export the notarized Mac Catalyst app: %w", err)
+		}
+		out.ExportPath = notarizedAppPath
+		return out, nil
+	}
+
+	exportPath, err := findMacCatalystExport(exportDir)
+	if err != nil {
+		return out, err
+	}
+	out.ExportPath = exportPath
+
+	return out, nil
+}
+
+// findMacCatalystExport returns the exported installer package, or the exported app if the export method does not create a package.
+func findMacCatalystExport(exportDir string) (string, error) {
+	entries, err := os.ReadDir(exportDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to list the export dir: %w", err)
+	}
+
+	var app string
+	for _, entry := range entries {
+		switch {
+		case strings.HasSuffix(entry.Name(), macCatalystExportExtension):
+			return filepath.Join(exportDir, entry.Name()), nil
+		case strings.HasSuffix(entry.Name(), ".app") && app == "":
+			app = filepath.Join(exportDir, entry.Name())
+		}
+	}
+	if app != "" {
+		return app, nil
+	}
+	return "", fmt.Errorf("no .pkg or .app found in the export dir: %s", exportDir)
+}
+
+func (s XcodebuildArchiver) exportMacCatalystOutputs(opts ExportOpts) error {
+	catalyst := opts.MacCatalyst
+	artifactName := opts.ArtifactName + macCatalystArtifactSuffix
+
+	logsOutputDir, err := outputDirForArtifact(opts.OutputDir, opts.OutputLayout, outputArtifactLogs)
+	if err != nil {
+		return err
+	}
+	for filename, content := range map[string]string{
+		"xcodebuild-archive" + macCatalystArtifactSuffix + ".log":        catalyst.XcodebuildArchiveLog,
+		"xcodebuild-export-archive" + macCatalystArtifactSuffix + ".log": catalyst.XcodebuildExportArchiveLog,
+	} {
+		if content == "" || !opts.exportRawLogs() {
+			continue
+		}
+		if err := v1fileutil.WriteStringToFile(filepath.Join(logsOutputDir, filename), content); err != nil {
+			s.logger.Warnf("Failed to write %s, error: %s", filename, err)
+		}
+	}
+
+	if catalyst.ArchivePath == "" {
+		return nil
+	}
+
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseMacCatalystXCArchivePthEnvKey, catalyst.ArchivePath); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseMacCatalystXCArchivePthEnvKey), err)
+	}
+	s.logger.Donef("The Mac Catalyst xcarchive path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseMacCatalystXCArchivePthEnvKey), catalyst.ArchivePath)
+
+	if opts.ExportXCArchiveZip {
+		archiveOutputDir, err := outputDirForArtifact(opts.OutputDir, opts.OutputLayout, outputArtifactArchive)
+		if err != nil {
+			return err
+		}
+		archiveZipPath := filepath.Join(archiveOutputDir, artifactName+".xcarchive"+artifactCompressionExtension(opts.Compression))
+		if err := os.RemoveAll(archiveZipPath); err != nil {
+			return fmt.Errorf("failed to remove path (%s), error: %s", archiveZipPath, err)
+		}
+		if err := ExportOutputDirAsArchive(s.cmdFactory, s.outputEnvKeys, catalyst.ArchivePath, archiveZipPath, bitriseMacCatalystXCArchiveZipPthEnvKey, opts.Compression, opts.CompressionLevel, s.logger); err != nil {
+			return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseMacCatalystXCArchiveZipPthEnvKey), err)
+		}
+		s.logger.Donef("The Mac Catalyst xcarchive zip path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseMacCatalystXCArchiveZipPthEnvKey), archiveZipPath)
+	}
+
+	if catalyst.ExportPath != "" {
+		exportOutputDir, err := outputDirForArtifact(opts.OutputDir, opts.OutputLayout, outputArtifactIPA)
+		if err != nil {
+			return err
+		}
+		exportPath := filepath.Join(exportOutputDir, artifactName+filepath.Ext(catalyst.ExportPath))
+		if err := os.RemoveAll(exportPath); err != nil {
+			return fmt.Errorf("failed to remove path (%s), error: %s", exportPath, err)
+		}
+
+		if filepath.Ext(catalyst.ExportPath) == macCatalystExportExtension {
+			err = ExportOutputFile(s.cmdFactory, s.outputEnvKeys, catalyst.ExportPath, exportPath, bitriseMacCatalystExportPthEnvKey)
+		} else {
+			err = ExportOutputDir(s.cmdFactory, s.outputEnvKeys, catalyst.ExportPath, exportPath, bitriseMacCatalystExportPthEnvKey, s.logger)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseMacCatalystExportPthEnvKey), err)
+		}
+		s.logger.Donef("The Mac Catalyst export path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseMacCatalystExportPthEnvKey), exportPath)
+
+		if opts.ExportMacOSZip && filepath.Ext(exportPath) == ".app" {
+			zipPath := filepath.Join(exportOutputDir, artifactName+".zip")
+			if err := s.exportMacOSZip(exportPath, zipPath); err != nil {
+				return err
+			}
+			if opts.SparklePrivateKey != "" {
+				s.exportSparkleAppcastItem(zipPath, exportPath, opts.SparkleDownloadURLPrefix, opts.SparklePrivateKey)
+			}
+		}
+	}
+
+	return nil
+}
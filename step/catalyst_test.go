@@ -0,0 +1,35 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_withoutOption(t *testing.T) {
+	require.Nil(t, withoutOption(nil, "-destination"))
+	require.Equal(t,
+		[]string{"-scmProvider", "system", "COMPILER_INDEX_STORE_ENABLE=NO"},
+		withoutOption([]string{"-scmProvider", "system", "-destination", "generic/platform=iOS", "COMPILER_INDEX_STORE_ENABLE=NO"}, "-destination"),
+	)
+}
+
+func Test_findMacCatalystExport(t *testing.T) {
+	exportDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(exportDir, "App.app"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(exportDir, "ExportOptions.plist"), nil, 0644))
+
+	got, err := findMacCatalystExport(exportDir)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(exportDir, "App.app"), got)
+
+	require.NoError(t, os.WriteFile(filepath.Join(exportDir, "App.pkg"), nil, 0644))
+	got, err = findMacCatalystExport(exportDir)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(exportDir, "App.pkg"), got)
+
+	_, err = findMacCatalystExport(t.TempDir())
+	require.Error(t, err)
+}
@@ -0,0 +1,213 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-steputils/v2/stepconf"
+	"github.com/bitrise-io/go-utils/v2/env"
+	"gopkg.in/yaml.v3"
+)
+
+// inputFlagAliases are the short CLI flag names of the most used inputs.
+var inputFlagAliases = map[string]string{
+	"project": "project_path",
+	"method":  "distribution_method",
+}
+
+// inputEnvRepository serves the Step inputs from a map instead of the environment,
+// the other variables (for example the hidden BITRISE_ inputs) are read from the wrapped environment, if any.
+type inputEnvRepository struct {
+	env.Repository
+	inputs map[string]string
+}
+
+// NewInputEnvRepository ...
+func NewInputEnvRepository(repository env.Repository, inputs map[string]string) env.Repository {
+	return inputEnvRepository{Repository: repository, inputs: inputs}
+}
+
+// List ...
+func (r inputEnvRepository) List() []string {
+	var envs []string
+	if r.Repository != nil {
+		for _, variable := range r.Repository.List() {
+			key, _, _ := strings.Cut(variable, "=")
+			if _, ok := r.inputs[key]; !ok {
+				envs = append(envs, variable)
+			}
+		}
+	}
+	for key, value := range r.inputs {
+		envs = append(envs, key+"="+value)
+	}
+	return envs
+}
+
+// Get ...
+func (r inputEnvRepository) Get(key string) string {
+	if value, ok := r.inputs[key]; ok {
+		return value
+	}
+	if r.Repository == nil {
+		return ""
+	}
+	return r.Repository.Get(key)
+}
+
+// StepYMLInputDefaults returns the default value of every input of the step.yml.
+func StepYMLInputDefaults(stepYML []byte) (map[string]string, error) {
+	var s struct {
+		Inputs []map[string]interface{} `yaml:"inputs"`
+	}
+	if err := yaml.Unmarshal(stepYML, &s); err != nil {
+		return nil, err
+	}
+
+	defaults := map[string]string{}
+	for _, input := range s.Inputs {
+		for key, value := range input {
+			if key == "opts" {
+				continue
+			}
+			if value == nil {
+				defaults[key] = ""
+			} else {
+				defaults[key] = fmt.Sprint(value)
+			}
+		}
+	}
+	return defaults, nil
+}
+
+// ParseInputFlags parses the --input_name value and --input_name=value CLI flags to input values,
+// the dashes of the flag names can be used in place of the underscores, a flag without a value sets the input to yes.
+func ParseInputFlags(args []string, inputNames map[string]string) (map[string]string, error) {
+	values := map[string]string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "--") || arg == "--" {
+			return nil, fmt.Errorf("unexpected argument: %s", arg)
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		name = strings.ReplaceAll(name, "-", "_")
+		if alias, ok := inputFlagAliases[name]; ok {
+			name = alias
+		}
+		if _, ok := inputNames[name]; !ok {
+			return nil, fmt.Errorf("unknown input: %s", name)
+		}
+
+		if !hasValue {
+			value = "yes"
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+				value = args[i+1]
+				i++
+			}
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// CLIInputValues returns the input values of a local Step run: the step.yml defaults,
+// overridden by the input environment variables set, overridden by the CLI flags.
+func CLIInputValues(defaults map[string]string, repository env.Repository, flags map[string]string) map[string]string {
+	values := map[string]string{}
+	for name, value := range defaults {
+		if envValue := repository.Get(name); envValue != "" {
+			value = envValue
+		}
+		values[name] = value
+	}
+	for name, value := range flags {
+		values[name] = value
+	}
+	return values
+}
+
+// inputValues returns the inputs as the input environment variables they are parsed from.
+func inputValues(inputs Inputs) map[string]string {
+	values := map[string]string{}
+	v := reflect.ValueOf(inputs)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := t.Field(i).Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		field := v.Field(i)
+		switch field.Kind() { //nolint:exhaustive
+		case reflect.Bool:
+			values[name] = "no"
+			if field.Bool() {
+				values[name] = "yes"
+			}
+		case reflect.Int:
+			values[name] = strconv.FormatInt(field.Int(), 10)
+		default:
+			values[name] = field.String()
+		}
+	}
+	return values
+}
+
+// validateInputs applies the input constraints (required, value options, ranges) to inputs not parsed from the environment.
+func validateInputs(inputs Inputs) error {
+	var parsed Inputs
+	return stepconf.NewInputParser(NewInputEnvRepository(nil, inputValues(inputs))).Parse(&parsed)
+}
+
+// DefaultInputs returns the inputs set to their step.yml default values, with the environment variable references expanded.
+// Library callers set the project specific inputs (for example ProjectPath, Scheme and, outside of a Bitrise build, OutputDir) on it before calling ProcessConfig,
+// as the zero value of the Inputs struct doesn't pass the input validation and turns off the outputs enabled by default.
+func DefaultInputs() Inputs {
+	return Inputs{
+		ProjectPath:                  os.Getenv("BITRISE_PROJECT_PATH"),
+		Scheme:                       os.Getenv("BITRISE_SCHEME"),
+		ExportMethod:                 "development",
+		XcodeVersionFile:             xcodeVersionFileOff,
+		XcconfigContent:              "COMPILER_INDEX_STORE_ENABLE = NO",
+		OnlyActiveArch:               "default",
+		Indexing:                     "default",
+		UnsetEnvVars:                 "GEM_HOME\nGEM_PATH\nRUBYLIB\nRUBYOPT\nBUNDLE_BIN_PATH\n_ORIGINAL_GEM_PATH\nBUNDLE_GEMFILE",
+		LogFormatter:                 XcprettyTool,
+		HeartbeatInterval:            60,
+		CodeSigningAuthSource:        codeSignSourceOff,
+		CertificateURLList:           os.Getenv("BITRISE_CERTIFICATE_URL"),
+		CertificatePassphraseList:    stepconf.Secret(os.Getenv("BITRISE_CERTIFICATE_PASSPHRASE")),
+		KeychainPath:                 os.ExpandEnv("$HOME/Library/Keychains/login.keychain"),
+		KeychainPassword:             stepconf.Secret(os.Getenv("BITRISE_KEYCHAIN_PASSWORD")),
+		CodesigningRetryCount:        1,
+		CodesigningRetryBackoff:      30,
+		CodesigningRetryableStatuses: "429,502,503,504",
+		CompileBitcode:               true,
+		UploadBitcode:                true,
+		UploadSymbols:                true,
+		WildcardProfiles:             wildcardProfilesAllow,
+		CodeSigningStyleOverride:     codeSigningStyleAutoDetect,
+		NotarizationTimeout:          60,
+		FailOnMissingLocalizations:   true,
+		IPAContentPolicy:             ipaContentPolicyOff,
+		OutputDir:                    os.Getenv("BITRISE_DEPLOY_DIR"),
+		ExistingArchive:              existingArchiveReplace,
+		OutputLayout:                 outputLayoutFlat,
+		OutputEnvPrefix:              "BITRISE_",
+		ExportAllDsyms:               true,
+		ExportXCArchiveZip:           true,
+		ExportAppDir:                 true,
+		ExportDSYMs:                  true,
+		ExportRawLogAlways:           true,
+		DSYMZipMode:                  "combined",
+		CompressionLevel:             6,
+		Compression:                  artifactCompressionZip,
+		MetricsExportFormat:          metricsFormatPushgateway,
+		CacheLevel:                   swiftPackagesCacheLevel,
+	}
+}
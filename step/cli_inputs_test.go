@@ -0,0 +1,124 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-steputils/v2/stepconf"
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepYMLInputDefaults(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("..", "step.yml"))
+	require.NoError(t, err)
+
+	defaults, err := StepYMLInputDefaults(content)
+	require.NoError(t, err)
+	require.Equal(t, thisStepInputs(t), defaults)
+	require.Equal(t, "xcpretty", defaults["log_formatter"])
+}
+
+func TestParseInputFlags(t *testing.T) {
+	inputNames := map[string]string{"project_path": "", "scheme": "", "distribution_method": "", "verbose_log": "", "xcodebuild_options": ""}
+
+	values, err := ParseInputFlags([]string{"--project", "App.xcodeproj", "--scheme=My App", "--method", "ad-hoc", "--verbose-log", "--xcodebuild_options", "-quiet=yes"}, inputNames)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"project_path":        "App.xcodeproj",
+		"scheme":              "My App",
+		"distribution_method": "ad-hoc",
+		"verbose_log":         "yes",
+		"xcodebuild_options":  "-quiet=yes",
+	}, values)
+
+	_, err = ParseInputFlags([]string{"--configuration", "Release"}, inputNames)
+	require.EqualError(t, err, "unknown input: configuration")
+	_, err = ParseInputFlags([]string{"App.xcodeproj"}, inputNames)
+	require.EqualError(t, err, "unexpected argument: App.xcodeproj")
+}
+
+func TestCLIInputValues(t *testing.T) {
+	defaults := map[string]string{"scheme": "", "configuration": "", "verbose_log": "no"}
+	envRepository := MockEnvRepository{envs: map[string]string{"scheme": "Env Scheme", "configuration": "Release"}}
+
+	values := CLIInputValues(defaults, envRepository, map[string]string{"scheme": "Flag Scheme"})
+	require.Equal(t, map[string]string{"scheme": "Flag Scheme", "configuration": "Release", "verbose_log": "no"}, values)
+}
+
+func TestInputEnvRepository(t *testing.T) {
+	repository := NewInputEnvRepository(MockEnvRepository{envs: map[string]string{"scheme": "Env Scheme", "BITRISE_BUILD_URL": "https://app.bitrise.io/build/slug"}}, map[string]string{"scheme": "App"})
+	require.Equal(t, "App", repository.Get("scheme"))
+	require.Equal(t, "https://app.bitrise.io/build/slug", repository.Get("BITRISE_BUILD_URL"))
+	require.ElementsMatch(t, []string{"scheme=App", "BITRISE_BUILD_URL=https://app.bitrise.io/build/slug"}, repository.List())
+
+	require.Equal(t, "", NewInputEnvRepository(nil, nil).Get("scheme"))
+}
+
+func TestInputValues(t *testing.T) {
+	var inputs Inputs
+	require.NoError(t, stepconf.NewInputParser(MockEnvRepository{envs: override(thisStepInputs(t), map[string]string{
+		"scheme":               "App",
+		"perform_clean_action": "yes",
+		"build_jobs":           "4",
+	})}).Parse(&inputs))
+
+	var parsed Inputs
+	require.NoError(t, stepconf.NewInputParser(NewInputEnvRepository(nil, inputValues(inputs))).Parse(&parsed))
+	require.Equal(t, inputs, parsed)
+}
+
+func TestDefaultInputs(t *testing.T) {
+	t.Setenv("BITRISE_PROJECT_PATH", "App.xcodeproj")
+	t.Setenv("BITRISE_SCHEME", "App")
+	t.Setenv("BITRISE_DEPLOY_DIR", "/deploy")
+	t.Setenv("BITRISE_CERTIFICATE_URL", "file:///certificate.p12")
+	t.Setenv("BITRISE_CERTIFICATE_PASSPHRASE", "passphrase")
+
+	defaults := thisStepInputs(t)
+	for name, value := range defaults {
+		defaults[name] = os.ExpandEnv(value)
+	}
+	var inputs Inputs
+	require.NoError(t, stepconf.NewInputParser(MockEnvRepository{envs: defaults}).Parse(&inputs))
+
+	require.Equal(t, inputs, DefaultInputs())
+}
+
+func TestXcodeArchiveStep_ProcessConfig(t *testing.T) {
+	s := XcodebuildArchiveConfigParser{
+		xcodeVersionProvider: NewMockXcodeVersionProvider(models.XcodebuildVersionModel{MajorVersion: 11}),
+		logger:               log.NewLogger(),
+	}
+
+	_, err := s.ProcessConfig(Inputs{ProjectPath: "App.xcodeproj"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "OutputDir: required variable is not present")
+
+	var inputs Inputs
+	require.NoError(t, stepconf.NewInputParser(MockEnvRepository{envs: thisStepInputs(t)}).Parse(&inputs))
+	inputs.ProjectPath = "."
+	inputs.Scheme = "My Scheme"
+	_, err = s.ProcessConfig(inputs)
+	require.EqualError(t, err, "issue with input ProjectPath: should be and .xcodeproj or .xcworkspace path")
+
+	// A library caller sets only the project specific inputs on the defaults
+	inputs = DefaultInputs()
+	inputs.ProjectPath = "."
+	inputs.Scheme = "My Scheme"
+	inputs.OutputDir = t.TempDir()
+	require.NoError(t, validateInputs(inputs))
+	_, err = s.ProcessConfig(inputs)
+	require.EqualError(t, err, "issue with input ProjectPath: should be and .xcodeproj or .xcworkspace path")
+
+	// The Scheme is required only without a Scheme and Configuration matrix
+	inputs.Scheme = ""
+	_, err = s.ProcessConfig(inputs)
+	require.EqualError(t, err, "issue with input Scheme: required variable is not present, set it or the Scheme and Configuration matrix (`scheme_configuration_matrix`)")
+
+	inputs.SchemeConfigurationMatrix = "App:Release"
+	_, err = s.ProcessConfig(inputs)
+	require.EqualError(t, err, "issue with input ProjectPath: should be and .xcodeproj or .xcworkspace path")
+}
@@ -0,0 +1,35 @@
+package step
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	v1command "github.com/bitrise-io/go-utils/command"
+)
+
+var errCloneUnsupported = errors.New("cloning is not supported on this platform")
+
+// copyDirTarget returns the path the dir is copied to by CopyDir: the destination itself if only the content is copied,
+// otherwise the source dir's name in the destination.
+func copyDirTarget(src, dst string, isOnlyContent bool) string {
+	if isOnlyContent {
+		return dst
+	}
+	return filepath.Join(dst, filepath.Base(src))
+}
+
+// copyDir copies the dir like CopyDir, but clones it (clonefile) if the target does not exist yet and the source's volume supports cloning (APFS):
+// cloning a multi-GB archive or app is instant and takes no additional disk space.
+// It falls back to the byte-by-byte copy on other file systems, across volumes and if the target exists.
+func copyDir(src, dst string, isOnlyContent bool) error {
+	target := copyDirTarget(src, dst, isOnlyContent)
+	if _, err := os.Lstat(target); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err == nil {
+			if err := cloneDir(src, target); err == nil {
+				return nil
+			}
+		}
+	}
+	return v1command.CopyDir(src, dst, isOnlyContent)
+}
@@ -0,0 +1,10 @@
+//go:build darwin
+
+package step
+
+import "golang.org/x/sys/unix"
+
+// cloneDir clones the dir tree with clonefile(2), it fails if the file system is not APFS or the target is on a different volume.
+func cloneDir(src, dst string) error {
+	return unix.Clonefile(src, dst, unix.CLONE_NOFOLLOW)
+}
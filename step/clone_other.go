@@ -0,0 +1,7 @@
+//go:build !darwin
+
+package step
+
+func cloneDir(_, _ string) error {
+	return errCloneUnsupported
+}
@@ -0,0 +1,36 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_copyDirTarget(t *testing.T) {
+	require.Equal(t, "/deploy/App.app", copyDirTarget("/tmp/archive/App.app", "/deploy/App.app", true))
+	require.Equal(t, "/deploy/dSYMs/App.app.dSYM", copyDirTarget("/tmp/archive/App.app.dSYM", "/deploy/dSYMs", false))
+}
+
+func Test_copyDir_clone(t *testing.T) {
+	if runtime.GOOS != "darwin" {
+		t.Skip("cloning is only supported on macOS")
+	}
+
+	src := filepath.Join(t.TempDir(), "App.app")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "Frameworks"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "Frameworks", "binary"), []byte("content"), 0755))
+	require.NoError(t, os.Symlink("Frameworks/binary", filepath.Join(src, "link")))
+
+	dst := filepath.Join(t.TempDir(), "deploy", "App.app")
+	require.NoError(t, copyDir(src, dst, true))
+
+	content, err := os.ReadFile(filepath.Join(dst, "Frameworks", "binary"))
+	require.NoError(t, err)
+	require.Equal(t, "content", string(content))
+	link, err := os.Readlink(filepath.Join(dst, "link"))
+	require.NoError(t, err)
+	require.Equal(t, "Frameworks/binary", link)
+}
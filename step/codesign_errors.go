@@ -0,0 +1,66 @@
+package step
+
+import (
+	"errors"
+	"strings"
+)
+
+// appleIDSessionExpiredPatterns are fragments of the Developer Portal (spaceship) errors
+// returned when the Apple ID two-factor login session is expired or invalid.
+var appleIDSessionExpiredPatterns = []string{
+	"your session has expired",
+	"need to login first",
+	"unauthorized access",
+	"401 unauthorized",
+	"two-factor session has expired",
+}
+
+const appleIDSessionExpiredGuidance = `The Apple ID two-factor authentication session used by the Developer Portal connection has expired or is invalid.
+To fix this:
+- Refresh the two-factor session of the Apple ID connection in the Bitrise App settings (Team/Account settings > Apple Service connection), then rebuild.
+- Or switch to App Store Connect API key authentication (automatic_code_signing: api-key), which does not require a two-factor session.`
+
+type appleIDSessionExpiredError struct {
+	err error
+}
+
+func (e appleIDSessionExpiredError) Error() string {
+	return e.err.Error() + "\n\n" + appleIDSessionExpiredGuidance
+}
+
+func (e appleIDSessionExpiredError) Unwrap() error {
+	return e.err
+}
+
+func isAppleIDSessionExpiredError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var sessionErr appleIDSessionExpiredError
+	if errors.As(err, &sessionErr) {
+		return true
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, pattern := range appleIDSessionExpiredPatterns {
+		if strings.Contains(message, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// wrapCodesignError adds actionable guidance to the code signing errors caused by an expired Apple ID session,
+// instead of surfacing the generic Developer Portal query failure.
+func wrapCodesignError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var sessionErr appleIDSessionExpiredError
+	if !errors.As(err, &sessionErr) && isAppleIDSessionExpiredError(err) {
+		return appleIDSessionExpiredError{err: err}
+	}
+	return err
+}
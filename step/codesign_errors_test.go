@@ -0,0 +1,57 @@
+package step
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_wrapCodesignError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantWrapped bool
+	}{
+		{
+			name:        "nil error",
+			err:         nil,
+			wantWrapped: false,
+		},
+		{
+			name:        "expired session",
+			err:         errors.New("failed to query Developer Portal: Your session has expired. Please log in., stacktrace: ..."),
+			wantWrapped: true,
+		},
+		{
+			name:        "unauthorized",
+			err:         errors.New("failed to query Developer Portal: 401 Unauthorized"),
+			wantWrapped: true,
+		},
+		{
+			name:        "unrelated error",
+			err:         errors.New("failed to query Developer Portal: Bundle ID not found"),
+			wantWrapped: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrapCodesignError(tt.err)
+			require.Equal(t, tt.wantWrapped, isAppleIDSessionExpiredError(got))
+			if tt.wantWrapped {
+				require.ErrorIs(t, got, tt.err)
+				require.Contains(t, got.Error(), appleIDSessionExpiredGuidance)
+			} else {
+				require.Equal(t, tt.err, got)
+			}
+		})
+	}
+}
+
+func Test_wrapCodesignError_wrapsOnce(t *testing.T) {
+	err := wrapCodesignError(errors.New("Your session has expired"))
+	got := wrapCodesignError(fmt.Errorf("retry failed: %w", err))
+	require.Equal(t, 1, strings.Count(got.Error(), appleIDSessionExpiredGuidance))
+}
@@ -0,0 +1,88 @@
+package step
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/v2/devportalservice"
+)
+
+// CodesignRetryPolicy configures the retries of the code signing asset preparation,
+// on top of the Developer Portal client's own retry logic.
+type CodesignRetryPolicy struct {
+	Attempts          int
+	Backoff           time.Duration
+	RetryableStatuses []int
+}
+
+type codesignPreparer interface {
+	PrepareCodesigning() (*devportalservice.APIKeyConnection, error)
+}
+
+func parseRetryableStatuses(list string) ([]int, error) {
+	var statuses []int
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		status, err := strconv.Atoi(item)
+		if err != nil || status < 100 || status > 599 {
+			return nil, fmt.Errorf("invalid HTTP status code: %s", item)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// isRetryable reports whether the error message refers to any of the retryable HTTP statuses.
+func (p CodesignRetryPolicy) isRetryable(err error) bool {
+	if err == nil || isAppleIDSessionExpiredError(err) {
+		return false
+	}
+
+	for _, status := range p.RetryableStatuses {
+		pattern := regexp.MustCompile(`\b` + strconv.Itoa(status) + `\b`)
+		if pattern.MatchString(err.Error()) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the exponentially increasing wait time before the given (1-based) retry.
+func (p CodesignRetryPolicy) backoff(retry int) time.Duration {
+	return p.Backoff * time.Duration(1<<(retry-1))
+}
+
+func prepareCodesigningWithRetry(preparer codesignPreparer, policy CodesignRetryPolicy, logger log.Logger, sleep func(time.Duration)) (*devportalservice.APIKeyConnection, error) {
+	attempts := policy.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var authParams *devportalservice.APIKeyConnection
+		authParams, err = preparer.PrepareCodesigning()
+		if err == nil {
+			return authParams, nil
+		}
+
+		if attempt == attempts || !policy.isRetryable(err) {
+			break
+		}
+
+		wait := policy.backoff(attempt)
+		logger.Warnf("Code signing preparation failed (attempt %d/%d): %s", attempt, attempts, err)
+		logger.Warnf("Retrying in %s...", wait)
+		sleep(wait)
+	}
+
+	return nil, err
+}
@@ -0,0 +1,92 @@
+package step
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/v2/devportalservice"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCodesignPreparer struct {
+	errs  []error
+	calls int
+}
+
+func (p *fakeCodesignPreparer) PrepareCodesigning() (*devportalservice.APIKeyConnection, error) {
+	p.calls++
+	if len(p.errs) >= p.calls {
+		return nil, p.errs[p.calls-1]
+	}
+	return &devportalservice.APIKeyConnection{KeyID: "key-id"}, nil
+}
+
+func Test_parseRetryableStatuses(t *testing.T) {
+	statuses, err := parseRetryableStatuses("429, 502,503,,")
+	require.NoError(t, err)
+	require.Equal(t, []int{429, 502, 503}, statuses)
+
+	_, err = parseRetryableStatuses("429,abc")
+	require.Error(t, err)
+
+	_, err = parseRetryableStatuses("42")
+	require.Error(t, err)
+}
+
+func Test_prepareCodesigningWithRetry(t *testing.T) {
+	policy := CodesignRetryPolicy{
+		Attempts:          3,
+		Backoff:           time.Second,
+		RetryableStatuses: []int{429, 502},
+	}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantWaits []time.Duration
+		wantErr   bool
+	}{
+		{
+			name:      "succeeds first",
+			wantCalls: 1,
+		},
+		{
+			name:      "retries retryable statuses with backoff",
+			errs:      []error{errors.New("spaceship command exited with status 1, output: 429 Too Many Requests"), errors.New("Unexpected response: 502")},
+			wantCalls: 3,
+			wantWaits: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:      "gives up after attempts",
+			errs:      []error{errors.New("429"), errors.New("429"), errors.New("429")},
+			wantCalls: 3,
+			wantWaits: []time.Duration{time.Second, 2 * time.Second},
+			wantErr:   true,
+		},
+		{
+			name:      "does not retry other errors",
+			errs:      []error{errors.New("HTTP 4290 is not a status"), errors.New("429")},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preparer := &fakeCodesignPreparer{errs: tt.errs}
+			var waits []time.Duration
+
+			got, err := prepareCodesigningWithRetry(preparer, policy, log.NewLogger(), func(d time.Duration) { waits = append(waits, d) })
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, got)
+			}
+			require.Equal(t, tt.wantCalls, preparer.calls)
+			require.Equal(t, tt.wantWaits, waits)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package step
+
+import (
+	"regexp"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+var (
+	compilationCacheHitPattern  = regexp.MustCompile(`(?im)\bcache hit\b`)
+	compilationCacheMissPattern = regexp.MustCompile(`(?im)\bcache miss\b`)
+)
+
+// compilationCachingBuildSettings returns the xcodebuild build settings enabling Xcode's compilation caching,
+// optionally backed by a remote cache service.
+func compilationCachingBuildSettings(remoteService string) []string {
+	settings := []string{"COMPILATION_CACHE_ENABLE_CACHING=YES"}
+	if remoteService != "" {
+		settings = append(settings,
+			"COMPILATION_CACHE_ENABLE_PLUGIN=YES",
+			"COMPILATION_CACHE_REMOTE_SERVICE_PATH="+remoteService,
+		)
+	}
+	return settings
+}
+
+type compilationCacheStats struct {
+	Hits   int
+	Misses int
+}
+
+// HitRate returns the ratio of cache hits to all cache lookups, in percent.
+func (s compilationCacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total) * 100
+}
+
+func parseCompilationCacheStats(xcodebuildLog string) compilationCacheStats {
+	return compilationCacheStats{
+		Hits:   len(compilationCacheHitPattern.FindAllStringIndex(xcodebuildLog, -1)),
+		Misses: len(compilationCacheMissPattern.FindAllStringIndex(xcodebuildLog, -1)),
+	}
+}
+
+func printCompilationCacheSummary(xcodebuildLog string, logger log.Logger) {
+	stats := parseCompilationCacheStats(xcodebuildLog)
+
+	logger.Println()
+	logger.Infof("Compilation cache summary:")
+	if stats.Hits+stats.Misses == 0 {
+		logger.Printf("no compilation cache lookups found in the xcodebuild log")
+		return
+	}
+	logger.Printf("hits: %d", stats.Hits)
+	logger.Printf("misses: %d", stats.Misses)
+	logger.Printf("hit rate: %.1f%%", stats.HitRate())
+}
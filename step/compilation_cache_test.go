@@ -0,0 +1,47 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_compilationCachingBuildSettings(t *testing.T) {
+	require.Equal(t, []string{"COMPILATION_CACHE_ENABLE_CACHING=YES"}, compilationCachingBuildSettings(""))
+	require.Equal(t, []string{
+		"COMPILATION_CACHE_ENABLE_CACHING=YES",
+		"COMPILATION_CACHE_ENABLE_PLUGIN=YES",
+		"COMPILATION_CACHE_REMOTE_SERVICE_PATH=/tmp/cache.sock",
+	}, compilationCachingBuildSettings("/tmp/cache.sock"))
+}
+
+func Test_parseCompilationCacheStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		log      string
+		want     compilationCacheStats
+		wantRate float64
+	}{
+		{
+			name: "no lookups",
+			log:  "** ARCHIVE SUCCEEDED **",
+			want: compilationCacheStats{},
+		},
+		{
+			name: "hits and misses",
+			log: `SwiftCompile normal arm64 AppDelegate.swift (cache hit)
+SwiftCompile normal arm64 ContentView.swift (cache miss)
+CompileC main.o main.m (Cache Hit)
+CompileC util.o util.m (cache hit)`,
+			want:     compilationCacheStats{Hits: 3, Misses: 1},
+			wantRate: 75,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseCompilationCacheStats(tt.log)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantRate, got.HitRate())
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package step
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+// xcodeFeatureSupport describes the Xcode versions supporting a feature configured by the Step's inputs.
+type xcodeFeatureSupport struct {
+	// MinXcodeMajorVersion is the first Xcode major version supporting the feature, 0 if there is no lower bound.
+	MinXcodeMajorVersion int
+	// RemovedInXcodeMajorVersion is the first Xcode major version not supporting the feature anymore, 0 if it is still supported.
+	RemovedInXcodeMajorVersion int
+}
+
+func (f xcodeFeatureSupport) isSupported(xcodeMajorVersion int) bool {
+	if f.MinXcodeMajorVersion != 0 && xcodeMajorVersion < f.MinXcodeMajorVersion {
+		return false
+	}
+	if f.RemovedInXcodeMajorVersion != 0 && xcodeMajorVersion >= f.RemovedInXcodeMajorVersion {
+		return false
+	}
+	return true
+}
+
+var (
+	bitcodeSupport                       = xcodeFeatureSupport{RemovedInXcodeMajorVersion: 14}
+	manageAppVersionSupport              = xcodeFeatureSupport{MinXcodeMajorVersion: 13}
+	testFlightInternalTestingOnlySupport = xcodeFeatureSupport{MinXcodeMajorVersion: 15}
+	compilationCachingSupport            = xcodeFeatureSupport{MinXcodeMajorVersion: 16}
+)
+
+var legacyProvisioningProfileSettingPattern = regexp.MustCompile(`(?m)\bPROVISIONING_PROFILE\s*=`)
+
+// configurationAdvice lists the configured inputs which are no-ops or harmful with the given Xcode version.
+func configurationAdvice(config Config) []string {
+	xcodeMajorVersion := config.XcodeMajorVersion
+
+	var advice []string
+	usesCustomExportOptions := config.ExportOptionsPlistContent != ""
+
+	if !usesCustomExportOptions && (config.CompileBitcode || config.UploadBitcode) && !bitcodeSupport.isSupported(xcodeMajorVersion) {
+		advice = append(advice, fmt.Sprintf("Bitcode is not supported since Xcode %d, `compile_bitcode` and `upload_bitcode` have no effect, consider setting them to `no`.", bitcodeSupport.RemovedInXcodeMajorVersion))
+	}
+	if !usesCustomExportOptions && config.ManageVersionAndBuildNumber && !manageAppVersionSupport.isSupported(xcodeMajorVersion) {
+		advice = append(advice, fmt.Sprintf("`manage_version_and_build_number` requires Xcode %d or later, it is ignored with Xcode %d.", manageAppVersionSupport.MinXcodeMajorVersion, xcodeMajorVersion))
+	}
+	if !usesCustomExportOptions && config.TestFlightInternalTestingOnly && !testFlightInternalTestingOnlySupport.isSupported(xcodeMajorVersion) {
+		advice = append(advice, fmt.Sprintf("`testflight_internal_testing_only` requires Xcode %d or later, it is ignored with Xcode %d.", testFlightInternalTestingOnlySupport.MinXcodeMajorVersion, xcodeMajorVersion))
+	}
+	if config.CompilationCaching && !compilationCachingSupport.isSupported(xcodeMajorVersion) {
+		advice = append(advice, fmt.Sprintf("`compilation_caching` requires Xcode %d or later, it is ignored with Xcode %d.", compilationCachingSupport.MinXcodeMajorVersion, xcodeMajorVersion))
+	}
+	if legacyProvisioningProfileSettingPattern.MatchString(config.XcodebuildOptions) || legacyProvisioningProfileSettingPattern.MatchString(config.XcconfigContent) {
+		advice = append(advice, "The `PROVISIONING_PROFILE` build setting is deprecated since Xcode 8, use `PROVISIONING_PROFILE_SPECIFIER` or automatic code signing instead.")
+	}
+
+	return advice
+}
+
+func printConfigurationAdvice(advice []string, xcodeMajorVersion int, logger log.Logger) {
+	if len(advice) == 0 {
+		return
+	}
+
+	logger.Println()
+	logger.Warnf("Configuration advice for Xcode %d:", xcodeMajorVersion)
+	for _, a := range advice {
+		logger.Warnf("- %s", a)
+	}
+	logger.Println()
+}
@@ -0,0 +1,73 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_xcodeFeatureSupport_isSupported(t *testing.T) {
+	require.True(t, bitcodeSupport.isSupported(13))
+	require.False(t, bitcodeSupport.isSupported(14))
+	require.False(t, testFlightInternalTestingOnlySupport.isSupported(14))
+	require.True(t, testFlightInternalTestingOnlySupport.isSupported(15))
+}
+
+func Test_configurationAdvice(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   int
+	}{
+		{
+			name: "no advice",
+			config: Config{
+				XcodeMajorVersion: 15,
+				Inputs:            Inputs{TestFlightInternalTestingOnly: true, ManageVersionAndBuildNumber: true},
+			},
+			want: 0,
+		},
+		{
+			name: "bitcode on Xcode 14",
+			config: Config{
+				XcodeMajorVersion: 14,
+				Inputs:            Inputs{CompileBitcode: true, UploadBitcode: true},
+			},
+			want: 1,
+		},
+		{
+			name: "export inputs are not checked with custom export options",
+			config: Config{
+				XcodeMajorVersion: 14,
+				Inputs:            Inputs{CompileBitcode: true, TestFlightInternalTestingOnly: true, ExportOptionsPlistContent: "<plist/>"},
+			},
+			want: 0,
+		},
+		{
+			name: "legacy provisioning profile setting and unsupported features",
+			config: Config{
+				XcodeMajorVersion: 12,
+				Inputs: Inputs{
+					XcodebuildOptions:             "PROVISIONING_PROFILE=1234",
+					TestFlightInternalTestingOnly: true,
+					ManageVersionAndBuildNumber:   true,
+					CompilationCaching:            true,
+				},
+			},
+			want: 4,
+		},
+		{
+			name: "profile specifier is not legacy",
+			config: Config{
+				XcodeMajorVersion: 15,
+				Inputs:            Inputs{XcconfigContent: "PROVISIONING_PROFILE_SPECIFIER = Profile"},
+			},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Len(t, configurationAdvice(tt.config), tt.want)
+		})
+	}
+}
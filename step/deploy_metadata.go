@@ -0,0 +1,175 @@
+package step
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/bitrise-io/go-xcode/xcarchive"
+)
+
+const (
+	bitriseDeployMetadataPthEnvKey = "BITRISE_DEPLOY_METADATA_PATH"
+
+	deployMetadataFilename = "deploy-metadata.json"
+
+	deployArtifactTypeIPA       = "ipa"
+	deployArtifactTypeApp       = "app"
+	deployArtifactTypeXCArchive = "xcarchive"
+	deployArtifactTypeDSYM      = "dsym"
+	deployArtifactTypeSymbols   = "symbols"
+)
+
+// DeployMetadataOpts ...
+type DeployMetadataOpts struct {
+	OutputDir    string
+	OutputLayout string
+	ArtifactName string
+	Compression  string
+	Archive      *xcarchive.IosArchive
+}
+
+type deployAppInfo struct {
+	AppTitle           string   `json:"app_title,omitempty"`
+	BundleID           string   `json:"bundle_id"`
+	Version            string   `json:"version"`
+	Build              string   `json:"build"`
+	MinOSVersion       string   `json:"min_os_version,omitempty"`
+	TeamID             string   `json:"team_id,omitempty"`
+	ExportMethod       string   `json:"export_method,omitempty"`
+	ProvisionedDevices []string `json:"provisioned_devices,omitempty"`
+}
+
+type deployArtifact struct {
+	Path                string        `json:"path"`
+	ArtifactType        string        `json:"artifact_type"`
+	FileSizeBytes       int64         `json:"file_size_bytes"`
+	IsPublicPageEnabled bool          `json:"is_public_page_enabled"`
+	AppInfo             deployAppInfo `json:"app_info"`
+}
+
+type deployMetadata struct {
+	Artifacts []deployArtifact `json:"artifacts"`
+}
+
+// deployArtifactType returns the platform prefixed artifact type, for example ios-ipa or macos-dsym.
+func deployArtifactType(artifactType string, macOS bool) string {
+	if macOS {
+		return "macos-" + artifactType
+	}
+	return "ios-" + artifactType
+}
+
+// installableExportMethod returns if the app of the export method can be installed from the public install page,
+// App Store exports can only be installed through TestFlight or the App Store.
+func installableExportMethod(method string) bool {
+	switch exportoptions.Method(method) {
+	case exportoptions.MethodAdHoc, exportoptions.MethodEnterprise, exportoptions.MethodDevelopment:
+		return true
+	default:
+		return false
+	}
+}
+
+func newDeployAppInfo(properties archiveApplicationProperties, metadata *ipaMetadata) deployAppInfo {
+	info := deployAppInfo{
+		BundleID: properties.BundleID,
+		Version:  properties.Version,
+		Build:    properties.Build,
+		TeamID:   properties.TeamID,
+	}
+	if metadata != nil {
+		info.AppTitle = metadata.DisplayName
+		info.MinOSVersion = metadata.MinOSVersion
+		info.ExportMethod = string(metadata.ExportMethod)
+		info.ProvisionedDevices = metadata.ProvisionedDevices
+		if metadata.TeamID != "" {
+			info.TeamID = metadata.TeamID
+		}
+	}
+	return info
+}
+
+// deployMetadataArtifacts returns the existing artifacts of the Step run, typed and labeled for the deployment.
+func deployMetadataArtifacts(opts DeployMetadataOpts, macOS bool, appInfo deployAppInfo) []deployArtifact {
+	ext := artifactCompressionExtension(opts.Compression)
+	ipaDir := artifactOutputDir(opts.OutputDir, opts.OutputLayout, outputArtifactIPA)
+	archiveDir := artifactOutputDir(opts.OutputDir, opts.OutputLayout, outputArtifactArchive)
+	dsymDir := artifactOutputDir(opts.OutputDir, opts.OutputLayout, outputArtifactDSYM)
+
+	candidates := []deployArtifact{
+		{Path: filepath.Join(ipaDir, opts.ArtifactName+".ipa"), ArtifactType: deployArtifactTypeIPA},
+		{Path: filepath.Join(ipaDir, opts.ArtifactName+".zip"), ArtifactType: deployArtifactTypeApp},
+		{Path: filepath.Join(archiveDir, opts.ArtifactName+".xcarchive"+ext), ArtifactType: deployArtifactTypeXCArchive},
+		{Path: filepath.Join(dsymDir, opts.ArtifactName+".dSYM"+ext), ArtifactType: deployArtifactTypeDSYM},
+		{Path: filepath.Join(dsymDir, opts.ArtifactName+"."+symbolsDirName+ext), ArtifactType: deployArtifactTypeSymbols},
+	}
+
+	var artifacts []deployArtifact
+	for _, artifact := range candidates {
+		info, err := os.Stat(artifact.Path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if artifact.ArtifactType == deployArtifactTypeIPA && installableExportMethod(appInfo.ExportMethod) {
+			artifact.IsPublicPageEnabled = true
+		}
+		artifact.ArtifactType = deployArtifactType(artifact.ArtifactType, macOS)
+		artifact.FileSizeBytes = info.Size()
+		artifact.AppInfo = appInfo
+		artifacts = append(artifacts, artifact)
+	}
+	return artifacts
+}
+
+// ExportDeployMetadata writes the metadata of the Step's artifacts (artifact type, app info and install page flags),
+// so the Deploy to Bitrise.io Step types and labels them in the Artifacts tab without parsing them again.
+func (s XcodebuildArchiver) ExportDeployMetadata(opts DeployMetadataOpts) error {
+	if opts.Archive == nil {
+		return fmt.Errorf("no archive found")
+	}
+
+	s.logger.Println()
+	s.logger.Infof("Exporting the deploy metadata...")
+
+	properties, err := readArchiveApplicationProperties(opts.Archive.InfoPlist)
+	if err != nil {
+		return err
+	}
+
+	var metadata *ipaMetadata
+	ipaPath := filepath.Join(artifactOutputDir(opts.OutputDir, opts.OutputLayout, outputArtifactIPA), opts.ArtifactName+".ipa")
+	if _, err := os.Stat(ipaPath); err == nil {
+		if read, err := readIPAMetadata(ipaPath); err != nil {
+			s.logger.Warnf("Failed to read ipa metadata: %s", err)
+		} else {
+			metadata = &read
+		}
+	}
+
+	macOS := false
+	if _, err := os.Stat(filepath.Join(opts.Archive.Application.Path, "Contents")); err == nil {
+		macOS = true
+	}
+
+	artifacts := deployMetadataArtifacts(opts, macOS, newDeployAppInfo(properties, metadata))
+	for _, artifact := range artifacts {
+		s.logger.Printf("- %s: %s", artifact.ArtifactType, filepath.Base(artifact.Path))
+	}
+
+	content, err := json.MarshalIndent(deployMetadata{Artifacts: artifacts}, "", "  ")
+	if err != nil {
+		return err
+	}
+	pth := filepath.Join(opts.OutputDir, deployMetadataFilename)
+	if _, err := atomicWriteFile(pth, content); err != nil {
+		return err
+	}
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseDeployMetadataPthEnvKey, pth); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseDeployMetadataPthEnvKey), err)
+	}
+	s.logger.Donef("The deploy metadata path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseDeployMetadataPthEnvKey), pth)
+	return nil
+}
@@ -0,0 +1,64 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_installableExportMethod(t *testing.T) {
+	require.True(t, installableExportMethod("ad-hoc"))
+	require.True(t, installableExportMethod("enterprise"))
+	require.True(t, installableExportMethod("development"))
+	require.False(t, installableExportMethod("app-store"))
+	require.False(t, installableExportMethod(""))
+}
+
+func Test_newDeployAppInfo(t *testing.T) {
+	properties := archiveApplicationProperties{BundleID: "io.bitrise.Sample", Version: "1.2", Build: "42", TeamID: "ARCHIVETEAM"}
+
+	require.Equal(t, deployAppInfo{BundleID: "io.bitrise.Sample", Version: "1.2", Build: "42", TeamID: "ARCHIVETEAM"}, newDeployAppInfo(properties, nil))
+
+	metadata := ipaMetadata{DisplayName: "Sample", MinOSVersion: "15.0", TeamID: "EXPORTTEAM", ExportMethod: exportoptions.MethodAdHoc, ProvisionedDevices: []string{"00008030-001A2B3C4D5E6F70"}}
+	require.Equal(t, deployAppInfo{
+		AppTitle:           "Sample",
+		BundleID:           "io.bitrise.Sample",
+		Version:            "1.2",
+		Build:              "42",
+		MinOSVersion:       "15.0",
+		TeamID:             "EXPORTTEAM",
+		ExportMethod:       "ad-hoc",
+		ProvisionedDevices: []string{"00008030-001A2B3C4D5E6F70"},
+	}, newDeployAppInfo(properties, &metadata))
+}
+
+func Test_deployMetadataArtifacts(t *testing.T) {
+	outputDir := t.TempDir()
+	for name, content := range map[string]string{
+		"Sample.ipa":           "ipa",
+		"Sample.xcarchive.zip": "archive",
+		"Sample.dSYM.zip":      "dsym",
+		"Other.ipa":            "other",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(outputDir, name), []byte(content), 0644))
+	}
+	opts := DeployMetadataOpts{OutputDir: outputDir, ArtifactName: "Sample", Compression: artifactCompressionZip}
+
+	appInfo := deployAppInfo{BundleID: "io.bitrise.Sample", ExportMethod: "ad-hoc"}
+	artifacts := deployMetadataArtifacts(opts, false, appInfo)
+	require.Equal(t, []deployArtifact{
+		{Path: filepath.Join(outputDir, "Sample.ipa"), ArtifactType: "ios-ipa", FileSizeBytes: 3, IsPublicPageEnabled: true, AppInfo: appInfo},
+		{Path: filepath.Join(outputDir, "Sample.xcarchive.zip"), ArtifactType: "ios-xcarchive", FileSizeBytes: 7, AppInfo: appInfo},
+		{Path: filepath.Join(outputDir, "Sample.dSYM.zip"), ArtifactType: "ios-dsym", FileSizeBytes: 4, AppInfo: appInfo},
+	}, artifacts)
+
+	appInfo.ExportMethod = "app-store"
+	artifacts = deployMetadataArtifacts(opts, false, appInfo)
+	require.False(t, artifacts[0].IsPublicPageEnabled)
+
+	artifacts = deployMetadataArtifacts(opts, true, appInfo)
+	require.Equal(t, "macos-xcarchive", artifacts[1].ArtifactType)
+}
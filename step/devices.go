@@ -0,0 +1,82 @@
+package step
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/v2/devportalservice"
+)
+
+// ParseDeviceUDIDs parses a comma or newline separated list of device UDIDs.
+func ParseDeviceUDIDs(list string) []string {
+	var udids []string
+	for _, udid := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == '\n' }) {
+		udid = strings.TrimSpace(udid)
+		if udid != "" {
+			udids = append(udids, udid)
+		}
+	}
+	return udids
+}
+
+func missingDevices(expected, provisioned []string) []string {
+	provisionedByID := map[string]bool{}
+	for _, udid := range provisioned {
+		provisionedByID[strings.ToLower(udid)] = true
+	}
+
+	var missing []string
+	for _, udid := range expected {
+		if !provisionedByID[strings.ToLower(udid)] {
+			missing = append(missing, udid)
+		}
+	}
+	return missing
+}
+
+func reportProvisionedDevices(provisioned, expected []string, printUDIDs bool, logger log.Logger) {
+	logger.Println()
+	logger.Infof("The ad-hoc provisioning profile includes %d device(s)", len(provisioned))
+	if printUDIDs {
+		for _, udid := range provisioned {
+			logger.Printf("- %s", udid)
+		}
+	}
+
+	if len(expected) == 0 {
+		return
+	}
+
+	missing := missingDevices(expected, provisioned)
+	if len(missing) == 0 {
+		logger.Donef("All of the %d expected device(s) are included in the provisioning profile", len(expected))
+		return
+	}
+
+	logger.Warnf("%d of the expected device(s) are missing from the provisioning profile, the app can't be installed on them:", len(missing))
+	for _, udid := range missing {
+		logger.Warnf("- %s", udid)
+	}
+}
+
+// appendExpectedTestDevices adds the expected devices to the devices to register on the Apple Developer Portal,
+// if they are not already present.
+func appendExpectedTestDevices(testDevices []devportalservice.TestDevice, expected []string, currentTime time.Time) []devportalservice.TestDevice {
+	var known []string
+	for _, device := range testDevices {
+		known = append(known, device.DeviceID)
+	}
+
+	for i, udid := range missingDevices(expected, known) {
+		testDevices = append(testDevices, devportalservice.TestDevice{
+			DeviceID:   udid,
+			Title:      fmt.Sprintf("Expected Device %d", i+1),
+			CreatedAt:  currentTime,
+			UpdatedAt:  currentTime,
+			DeviceType: "unknown",
+		})
+	}
+	return testDevices
+}
@@ -0,0 +1,30 @@
+package step
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-xcode/v2/devportalservice"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDeviceUDIDs(t *testing.T) {
+	require.Nil(t, ParseDeviceUDIDs(""))
+	require.Equal(t, []string{"udid-1", "udid-2", "udid-3"}, ParseDeviceUDIDs(" udid-1,udid-2\nudid-3 ,\n"))
+}
+
+func Test_missingDevices(t *testing.T) {
+	require.Nil(t, missingDevices([]string{"ABC", "def"}, []string{"abc", "DEF", "ghi"}))
+	require.Equal(t, []string{"xyz"}, missingDevices([]string{"abc", "xyz"}, []string{"ABC"}))
+}
+
+func Test_appendExpectedTestDevices(t *testing.T) {
+	now := time.Now()
+	existing := []devportalservice.TestDevice{{DeviceID: "udid-1", Title: "Device 1"}}
+
+	got := appendExpectedTestDevices(existing, []string{"UDID-1", "udid-2"}, now)
+	require.Equal(t, []devportalservice.TestDevice{
+		{DeviceID: "udid-1", Title: "Device 1"},
+		{DeviceID: "udid-2", Title: "Expected Device 1", CreatedAt: now, UpdatedAt: now, DeviceType: "unknown"},
+	}, got)
+}
@@ -0,0 +1,83 @@
+package step
+
+import (
+	"path/filepath"
+
+	v1command "github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/bitrise-io/go-xcode/v2/exportoptionsgenerator"
+	"github.com/bitrise-io/go-xcode/xcodebuild"
+)
+
+const dryRunExportDirPlaceholder = "<export temp dir>"
+
+// dryRunExportOptions generates the export options from the project and the inputs only:
+// without an archive the export method, the team, the signing style and the iCloud container environment are not read from the archive,
+// so they can differ from the ones of a real run.
+func (s XcodebuildArchiver) dryRunExportOptions(opts xcodeIPAExportOpts) (string, error) {
+	if opts.CustomExportOptionsPlistContent != "" {
+		return opts.CustomExportOptionsPlistContent, nil
+	}
+
+	exportMethod, err := exportoptions.ParseMethod(opts.ExportMethod)
+	if err != nil {
+		return "", err
+	}
+
+	xcodeProj, scheme, configuration, err := OpenArchivableProject(opts.ProjectPath, opts.Scheme, opts.Configuration, opts.Target)
+	if err != nil {
+		return "", err
+	}
+
+	signingStyle := exportoptions.SigningStyleManual
+	isSigningStyleOverridden := opts.CodeSigningStyleOverride != "" && opts.CodeSigningStyleOverride != codeSigningStyleAutoDetect
+	if isSigningStyleOverridden {
+		signingStyle = exportoptions.SigningStyle(opts.CodeSigningStyleOverride)
+	}
+
+	generator := exportoptionsgenerator.New(xcodeProj, scheme, configuration, s.logger)
+	exportOptions, err := generator.GenerateApplicationExportOptions(exportMethod, opts.ICloudContainerEnvironment, opts.ExportDevelopmentTeam,
+		opts.UploadBitcode, opts.CompileBitcode, signingStyle == exportoptions.SigningStyleAutomatic, signingStyle, int64(opts.XcodeMajorVersion), opts.TestFlightInternalTestingOnly)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.XcodeMajorVersion >= 13 {
+		exportOptions = setManageAppVersion(exportOptions, opts.ManageVersionAndBuildNumber)
+	}
+	exportOptions = setUploadSymbols(exportOptions, opts.UploadSymbols)
+	if isSigningStyleOverridden {
+		exportOptions = setSigningStyle(exportOptions, signingStyle)
+	}
+	exportOptions = setSigningCertificates(exportOptions, opts.SigningCertificate, opts.InstallerSigningCertificate)
+
+	return exportOptions.String()
+}
+
+// dryRunExport prints the export options and the export command instead of exporting the archive.
+func (s XcodebuildArchiver) dryRunExport(opts xcodeIPAExportOpts) {
+	s.logger.Println()
+	s.logger.Infof("Export options (dry run):")
+	if opts.CustomExportOptionsPlistContent == "" {
+		s.logger.Warnf("Generated without an archive, the values read from the archive (export team, signing style, iCloud container environment) can differ in a real run.")
+	}
+	exportOptions, err := s.dryRunExportOptions(opts)
+	if err != nil {
+		s.logger.Warnf("Failed to generate the export options: %s", err)
+	} else {
+		s.logger.Printf("%s", exportOptions)
+	}
+
+	exportCmd := xcodebuild.NewExportCommand()
+	exportCmd.SetArchivePath(opts.Archive.Path)
+	exportCmd.SetExportDir(filepath.Join(dryRunExportDirPlaceholder, "exported"))
+	exportCmd.SetExportOptionsPlist(filepath.Join(dryRunExportDirPlaceholder, "export_options.plist"))
+	if opts.XcodeAuthOptions != nil {
+		exportCmd.SetAuthentication(*opts.XcodeAuthOptions)
+	}
+
+	s.logger.Println()
+	s.logger.Infof("Export command (dry run):")
+	args := append([]string{"xcodebuild"}, exportCmd.CommandArgs()...)
+	s.logger.Printf("%s", v1command.PrintableCommandArgs(false, append(args, toolchainOptions(opts.Toolchain)...)))
+}
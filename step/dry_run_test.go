@@ -0,0 +1,20 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXcodebuildArchiver_dryRunExportOptions(t *testing.T) {
+	archiver := XcodebuildArchiver{logger: log.NewLogger()}
+
+	content := `<plist version="1.0"><dict><key>method</key><string>ad-hoc</string></dict></plist>`
+	got, err := archiver.dryRunExportOptions(xcodeIPAExportOpts{CustomExportOptionsPlistContent: content, ExportMethod: "app-store"})
+	require.NoError(t, err)
+	require.Equal(t, content, got)
+
+	_, err = archiver.dryRunExportOptions(xcodeIPAExportOpts{ExportMethod: "auto-detect"})
+	require.Error(t, err)
+}
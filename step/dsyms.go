@@ -0,0 +1,127 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	v1pathutil "github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+// findFrameworksMissingDSYMs lists the frameworks embedded in the application which have no matching dSYM.
+func findFrameworksMissingDSYMs(appPath string, dsymPaths []string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(appPath, "Frameworks"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list embedded frameworks: %w", err)
+	}
+
+	dsymNames := map[string]bool{}
+	for _, dsymPath := range dsymPaths {
+		// Framework dSYMs are named after the framework bundle: <name>.framework.dSYM
+		dsymNames[strings.TrimSuffix(filepath.Base(dsymPath), ".dSYM")] = true
+	}
+
+	var missing []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if filepath.Ext(name) != ".framework" {
+			continue
+		}
+		if !dsymNames[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+
+	return missing, nil
+}
+
+func warnFrameworksMissingDSYMs(appPath string, dsymPaths []string, logger log.Logger) {
+	missing, err := findFrameworksMissingDSYMs(appPath, dsymPaths)
+	if err != nil {
+		logger.Warnf("Failed to check embedded frameworks for dSYMs: %s", err)
+		return
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	logger.Warnf("%d embedded framework(s) have no dSYM in the archive, crashes in them can't be symbolicated:", len(missing))
+	for _, framework := range missing {
+		logger.Warnf("- %s", framework)
+	}
+	logger.Warnf("Make sure these frameworks are built with DEBUG_INFORMATION_FORMAT = dwarf-with-dsym, or get their dSYMs from the framework vendor.")
+}
+
+// exportDSYMs collects the archive's dSYMs into a directory and exports it, zipped according to the dSYM zip mode.
+func (s XcodebuildArchiver) exportDSYMs(opts ExportOpts) error {
+	s.logger.Printf("Looking for app and framework dSYMs.")
+
+	appDSYMPaths, frameworkDSYMPaths, err := opts.Archive.FindDSYMs()
+	if err != nil {
+		return fmt.Errorf("failed to export dSYMs, error: %s", err)
+	}
+
+	appDSYMPathsCount := len(appDSYMPaths)
+	frameworkDSYMPathsCount := len(frameworkDSYMPaths)
+
+	s.logger.Printf("Found %d app dSYMs and %d framework dSYMs.", appDSYMPathsCount, frameworkDSYMPathsCount)
+
+	warnFrameworksMissingDSYMs(opts.Archive.Application.Path, frameworkDSYMPaths, s.logger)
+
+	if appDSYMPathsCount > 0 || frameworkDSYMPathsCount > 0 {
+		dsymDir, err := v1pathutil.NormalizedOSTempDirPath("__dsyms__")
+		if err != nil {
+			return fmt.Errorf("failed to create tmp dir, error: %s", err)
+		}
+
+		if appDSYMPathsCount > 0 {
+			if err := ExportDSYMs(dsymDir, appDSYMPaths); err != nil {
+				return fmt.Errorf("failed to export dSYMs: %v", err)
+			}
+		} else {
+			s.logger.Warnf("No app dSYMs found to export")
+		}
+
+		if opts.ExportAllDsyms && frameworkDSYMPathsCount > 0 {
+			if err := ExportDSYMs(dsymDir, frameworkDSYMPaths); err != nil {
+				return fmt.Errorf("failed to export dSYMs: %v", err)
+			}
+		}
+
+		if err := ExportOutputDir(s.cmdFactory, s.outputEnvKeys, dsymDir, dsymDir, bitriseDSYMDirPthEnvKey, s.logger); err != nil {
+			return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseDSYMDirPthEnvKey), err)
+		}
+		s.logger.Donef("The dSYM dir path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseDSYMDirPthEnvKey), dsymDir)
+
+		dsymOutputDir, err := outputDirForArtifact(opts.OutputDir, opts.OutputLayout, outputArtifactDSYM)
+		if err != nil {
+			return err
+		}
+
+		switch opts.DSYMZipMode {
+		case dsymZipModeNone:
+			s.logger.Printf("Skipping dSYM zip generation.")
+		case dsymZipModeSeparate:
+			dsymZipPaths, err := ExportDSYMsAsSeparateZips(s.cmdFactory, s.outputEnvKeys, dsymDir, dsymOutputDir, bitriseDSYMZipPthListEnvKey, opts.Compression, opts.CompressionLevel, s.logger)
+			if err != nil {
+				return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseDSYMZipPthListEnvKey), err)
+			}
+			s.logger.Donef("The dSYM zip paths are now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseDSYMZipPthListEnvKey), strings.Join(dsymZipPaths, "|"))
+		default:
+			dsymZipPath := filepath.Join(dsymOutputDir, opts.ArtifactName+".dSYM"+artifactCompressionExtension(opts.Compression))
+			if err := ExportOutputDirAsArchive(s.cmdFactory, s.outputEnvKeys, dsymDir, dsymZipPath, bitriseDSYMPthEnvKey, opts.Compression, opts.CompressionLevel, s.logger); err != nil {
+				return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseDSYMPthEnvKey), err)
+			}
+			s.logger.Donef("The dSYM zip path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseDSYMPthEnvKey), dsymZipPath)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_findFrameworksMissingDSYMs(t *testing.T) {
+	appPath := filepath.Join(t.TempDir(), "Sample.app")
+	for _, framework := range []string{"Alamofire.framework", "Vendor.framework", "Analytics.framework"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(appPath, "Frameworks", framework), 0755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(appPath, "Frameworks", "libswiftCore.dylib"), nil, 0644))
+
+	dsyms := []string{
+		"/archive/dSYMs/Sample.app.dSYM",
+		"/archive/dSYMs/Alamofire.framework.dSYM",
+	}
+
+	missing, err := findFrameworksMissingDSYMs(appPath, dsyms)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Analytics.framework", "Vendor.framework"}, missing)
+}
+
+func Test_findFrameworksMissingDSYMs_NoFrameworks(t *testing.T) {
+	missing, err := findFrameworksMissingDSYMs(t.TempDir(), nil)
+	require.NoError(t, err)
+	require.Nil(t, missing)
+}
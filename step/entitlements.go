@@ -0,0 +1,194 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-xcode/plistutil"
+	"github.com/bitrise-io/go-xcode/profileutil"
+	"howett.net/plist"
+)
+
+const (
+	bitriseAppSignedEntitlementsPthEnvKey  = "BITRISE_APP_SIGNED_ENTITLEMENTS_PATH"
+	bitriseProfileEntitlementsPthEnvKey    = "BITRISE_PROFILE_ENTITLEMENTS_PATH"
+	bitriseEntitlementsDiffPthEnvKey       = "BITRISE_ENTITLEMENTS_DIFF_PATH"
+	iosEmbeddedProfileName                 = "embedded.mobileprovision"
+	macOSEmbeddedProfileRelativePath       = "Contents/embedded.provisionprofile"
+	entitlementsPlistArtifactSuffix        = ".entitlements.plist"
+	profileEntitlementsPlistArtifactSuffix = ".profile-entitlements.plist"
+	entitlementsDiffArtifactSuffix         = ".entitlements-diff.txt"
+)
+
+// extractIPAApp extracts the ipa into the given dir and returns the path of its application.
+func extractIPAApp(ipaPath, dir string) (string, error) {
+	if err := unzip(ipaPath, dir); err != nil {
+		return "", fmt.Errorf("failed to extract %s: %w", ipaPath, err)
+	}
+
+	appPaths, err := filepath.Glob(filepath.Join(dir, "Payload", "*.app"))
+	if err != nil {
+		return "", err
+	}
+	if len(appPaths) == 0 {
+		return "", fmt.Errorf("no application found in the ipa: %s", ipaPath)
+	}
+	return appPaths[0], nil
+}
+
+// signedEntitlements returns the entitlements plist the app is signed with.
+func signedEntitlements(cmdFactory command.Factory, appPath string) (string, error) {
+	cmd := cmdFactory.Create("codesign", []string{"-d", "--entitlements", ":-", appPath}, nil)
+	out, err := cmd.RunAndReturnTrimmedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the entitlements of %s: %s: %w", appPath, out, err)
+	}
+	return out, nil
+}
+
+// embeddedProfileEntitlements returns the entitlements of the app's embedded provisioning profile,
+// nil if the app has no embedded profile.
+func embeddedProfileEntitlements(appPath string) (plistutil.PlistData, error) {
+	profilePath := filepath.Join(appPath, iosEmbeddedProfileName)
+	if _, err := os.Stat(profilePath); err != nil {
+		profilePath = filepath.Join(appPath, macOSEmbeddedProfileRelativePath)
+		if _, err := os.Stat(profilePath); err != nil {
+			return nil, nil
+		}
+	}
+
+	profile, err := profileutil.ProvisioningProfileFromFile(profilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", profilePath, err)
+	}
+	profileInfo, err := profileutil.NewProvisioningProfileInfo(*profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", profilePath, err)
+	}
+	return profileInfo.Entitlements, nil
+}
+
+// entitlementsDiff lists the entitlements differing between the signed app and its provisioning profile.
+// Profiles usually grant wildcard values (for example for keychain-access-groups), so differences are not necessarily errors.
+func entitlementsDiff(signed, profile plistutil.PlistData) []string {
+	keys := map[string]bool{}
+	for key := range signed {
+		keys[key] = true
+	}
+	for key := range profile {
+		keys[key] = true
+	}
+
+	var sortedKeys []string
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	var diff []string
+	for _, key := range sortedKeys {
+		signedValue, inSigned := signed[key]
+		profileValue, inProfile := profile[key]
+		switch {
+		case !inProfile:
+			diff = append(diff, fmt.Sprintf("+ %s: %v (only in the signed app)", key, signedValue))
+		case !inSigned:
+			diff = append(diff, fmt.Sprintf("- %s: %v (only in the provisioning profile)", key, profileValue))
+		case !reflect.DeepEqual(signedValue, profileValue):
+			diff = append(diff, fmt.Sprintf("~ %s: %v (signed app), %v (provisioning profile)", key, signedValue, profileValue))
+		}
+	}
+	return diff
+}
+
+// exportEntitlements exports the entitlements the app is signed with, the entitlements of its embedded provisioning profile and their differences.
+func (s XcodebuildArchiver) exportEntitlements(appPath, outputDir, artifactName string) error {
+	s.logger.Println()
+	s.logger.Infof("Exporting the entitlements of the exported app...")
+
+	signedContent, err := signedEntitlements(s.cmdFactory, appPath)
+	if err != nil {
+		return err
+	}
+	signed := plistutil.PlistData{}
+	if signedContent != "" {
+		if signed, err = plistutil.NewPlistDataFromContent(signedContent); err != nil {
+			return fmt.Errorf("failed to parse the entitlements of %s: %w", appPath, err)
+		}
+	}
+
+	signedPath := filepath.Join(outputDir, artifactName+entitlementsPlistArtifactSuffix)
+	if err := os.WriteFile(signedPath, []byte(signedContent), 0644); err != nil {
+		return err
+	}
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseAppSignedEntitlementsPthEnvKey, signedPath); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseAppSignedEntitlementsPthEnvKey), err)
+	}
+	s.logger.Donef("The signed entitlements path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseAppSignedEntitlementsPthEnvKey), signedPath)
+
+	profile, err := embeddedProfileEntitlements(appPath)
+	if err != nil {
+		return err
+	}
+	if profile == nil {
+		s.logger.Printf("No embedded provisioning profile found in the app, skipping the profile entitlements export.")
+		return nil
+	}
+
+	profileContent, err := plist.MarshalIndent(map[string]interface{}(profile), plist.XMLFormat, "\t")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the profile entitlements: %w", err)
+	}
+	profilePath := filepath.Join(outputDir, artifactName+profileEntitlementsPlistArtifactSuffix)
+	if err := os.WriteFile(profilePath, profileContent, 0644); err != nil {
+		return err
+	}
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseProfileEntitlementsPthEnvKey, profilePath); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseProfileEntitlementsPthEnvKey), err)
+	}
+	s.logger.Donef("The provisioning profile entitlements path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseProfileEntitlementsPthEnvKey), profilePath)
+
+	diff := entitlementsDiff(signed, profile)
+	diffPath := filepath.Join(outputDir, artifactName+entitlementsDiffArtifactSuffix)
+	diffContent := strings.Join(diff, "\n")
+	if diffContent != "" {
+		diffContent += "\n"
+	}
+	if err := os.WriteFile(diffPath, []byte(diffContent), 0644); err != nil {
+		return err
+	}
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseEntitlementsDiffPthEnvKey, diffPath); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseEntitlementsDiffPthEnvKey), err)
+	}
+	s.logger.Donef("The entitlements diff path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseEntitlementsDiffPthEnvKey), diffPath)
+
+	if len(diff) > 0 {
+		s.logger.Printf("Entitlements differing between the signed app and its provisioning profile:")
+		for _, line := range diff {
+			s.logger.Printf("%s", line)
+		}
+	}
+	return nil
+}
+
+// exportIPAEntitlements extracts the ipa's application and exports its entitlements.
+func (s XcodebuildArchiver) exportIPAEntitlements(ipaPath, outputDir, artifactName string) error {
+	tmpDir, err := os.MkdirTemp("", "exportEntitlements")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	appPath, err := extractIPAApp(ipaPath, tmpDir)
+	if err != nil {
+		return err
+	}
+	return s.exportEntitlements(appPath, outputDir, artifactName)
+}
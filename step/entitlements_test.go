@@ -0,0 +1,38 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/plistutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_entitlementsDiff(t *testing.T) {
+	signed := plistutil.PlistData{
+		"application-identifier":  "TEAM.io.bitrise.app",
+		"aps-environment":         "production",
+		"keychain-access-groups":  []interface{}{"TEAM.io.bitrise.app"},
+		"get-task-allow":          false,
+		"com.apple.developer.foo": true,
+	}
+	profile := plistutil.PlistData{
+		"application-identifier": "TEAM.io.bitrise.app",
+		"aps-environment":        "development",
+		"keychain-access-groups": []interface{}{"TEAM.io.bitrise.app"},
+		"get-task-allow":         false,
+		"com.apple.team-id":      "TEAM",
+	}
+
+	require.Equal(t, []string{
+		"~ aps-environment: production (signed app), development (provisioning profile)",
+		"+ com.apple.developer.foo: true (only in the signed app)",
+		"- com.apple.team-id: TEAM (only in the provisioning profile)",
+	}, entitlementsDiff(signed, profile))
+	require.Empty(t, entitlementsDiff(profile, profile))
+}
+
+func Test_embeddedProfileEntitlements_noProfile(t *testing.T) {
+	entitlements, err := embeddedProfileEntitlements(t.TempDir())
+	require.NoError(t, err)
+	require.Nil(t, entitlements)
+}
@@ -2,25 +2,21 @@ package step
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
-	v1command "github.com/bitrise-io/go-utils/command"
-	"github.com/bitrise-io/go-utils/fileutil"
 	"github.com/bitrise-io/go-utils/pathutil"
 	"github.com/bitrise-io/go-utils/v2/command"
 	"github.com/bitrise-io/go-utils/v2/log"
 )
 
-func zip(cmdFactory command.Factory, sourceDir, destinationZipPth string, logger log.Logger) error {
+func zip(sourceDir, destinationZipPth string, compressionLevel int, logger log.Logger) error {
 	logger.TPrintf("Will zip directory path: %s", sourceDir)
 
-	parentDir := filepath.Dir(sourceDir)
-	dirName := filepath.Base(sourceDir)
-	cmd := cmdFactory.Create("/usr/bin/zip", []string{"-rTy", destinationZipPth, dirName}, &command.Opts{Dir: parentDir})
-	out, err := cmd.RunAndReturnTrimmedCombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to zip dir: %s, output: %s, error: %s", sourceDir, out, err)
+	if err := zipDir(sourceDir, destinationZipPth, compressionLevel); err != nil {
+		return fmt.Errorf("failed to zip dir: %s, error: %s", sourceDir, err)
 	}
 
 	logger.TPrintf("Directory zipped.")
@@ -28,48 +24,58 @@ func zip(cmdFactory command.Factory, sourceDir, destinationZipPth string, logger
 	return nil
 }
 
-func exportEnvironmentWithEnvman(cmdFactory command.Factory, keyStr, valueStr string) error {
-	cmd := cmdFactory.Create("envman", []string{"add", "--key", keyStr}, &command.Opts{Stdin: strings.NewReader(valueStr)})
+// envmanLock serializes the envman calls, as outputs might be exported concurrently and envman is not safe for concurrent writes.
+var envmanLock sync.Mutex
+
+func exportEnvironmentWithEnvman(cmdFactory command.Factory, outputEnvKeys OutputEnvKeys, keyStr, valueStr string) error {
+	envmanLock.Lock()
+	defer envmanLock.Unlock()
+
+	args := []string{"add", "--key", outputEnvKeys.key(keyStr)}
+	if identifierOutputEnvKeys[keyStr] && outputEnvKeys.SensitiveIdentifiers {
+		args = append(args, "--sensitive")
+	}
+	cmd := cmdFactory.Create("envman", args, &command.Opts{Stdin: strings.NewReader(valueStr)})
 	return cmd.Run()
 }
 
-// ExportOutputDir ...
-func ExportOutputDir(cmdFactory command.Factory, sourceDirPth, destinationDirPth, envKey string, logger log.Logger) error {
+// ExportOutputDir copies the dir atomically, replacing the destination dir.
+func ExportOutputDir(cmdFactory command.Factory, outputEnvKeys OutputEnvKeys, sourceDirPth, destinationDirPth, envKey string, logger log.Logger) error {
 	if sourceDirPth != destinationDirPth {
 		logger.TPrintf("Copying export output")
 
-		if err := v1command.CopyDir(sourceDirPth, destinationDirPth, true); err != nil {
+		if err := atomicCopyDir(sourceDirPth, destinationDirPth); err != nil {
 			return err
 		}
 
 		logger.TPrintf("Copied export output to %s", destinationDirPth)
 	}
 
-	return exportEnvironmentWithEnvman(cmdFactory, envKey, destinationDirPth)
+	return exportEnvironmentWithEnvman(cmdFactory, outputEnvKeys, envKey, destinationDirPth)
 }
 
-// ExportOutputFile ...
-func ExportOutputFile(cmdFactory command.Factory, sourcePth, destinationPth, envKey string) error {
+// ExportOutputFile copies the file atomically, an identical destination file (a retried Step's output) is not copied again.
+func ExportOutputFile(cmdFactory command.Factory, outputEnvKeys OutputEnvKeys, sourcePth, destinationPth, envKey string) error {
 	if sourcePth != destinationPth {
-		if err := v1command.CopyFile(sourcePth, destinationPth); err != nil {
+		if _, err := atomicCopyFile(sourcePth, destinationPth); err != nil {
 			return err
 		}
 	}
 
-	return exportEnvironmentWithEnvman(cmdFactory, envKey, destinationPth)
+	return exportEnvironmentWithEnvman(cmdFactory, outputEnvKeys, envKey, destinationPth)
 }
 
-// ExportOutputFileContent ...
-func ExportOutputFileContent(cmdFactory command.Factory, content, destinationPth, envKey string) error {
-	if err := fileutil.WriteStringToFile(destinationPth, content); err != nil {
+// ExportOutputFileContent writes the file atomically, an identical destination file is not written again.
+func ExportOutputFileContent(cmdFactory command.Factory, outputEnvKeys OutputEnvKeys, content, destinationPth, envKey string) error {
+	if _, err := atomicWriteFile(destinationPth, []byte(content)); err != nil {
 		return err
 	}
 
-	return ExportOutputFile(cmdFactory, destinationPth, destinationPth, envKey)
+	return exportEnvironmentWithEnvman(cmdFactory, outputEnvKeys, envKey, destinationPth)
 }
 
 // ExportOutputDirAsZip ...
-func ExportOutputDirAsZip(cmdFactory command.Factory, sourceDirPth, destinationPth, envKey string, logger log.Logger) error {
+func ExportOutputDirAsZip(cmdFactory command.Factory, outputEnvKeys OutputEnvKeys, sourceDirPth, destinationPth, envKey string, compressionLevel int, logger log.Logger) error {
 	tmpDir, err := pathutil.NormalizedOSTempDirPath("__export_tmp_dir__")
 	if err != nil {
 		return err
@@ -78,19 +84,57 @@ func ExportOutputDirAsZip(cmdFactory command.Factory, sourceDirPth, destinationP
 	base := filepath.Base(sourceDirPth)
 	tmpZipFilePth := filepath.Join(tmpDir, base+".zip")
 
-	if err := zip(cmdFactory, sourceDirPth, tmpZipFilePth, logger); err != nil {
+	if err := zip(sourceDirPth, tmpZipFilePth, compressionLevel, logger); err != nil {
 		return err
 	}
 
-	return ExportOutputFile(cmdFactory, tmpZipFilePth, destinationPth, envKey)
+	return ExportOutputFile(cmdFactory, outputEnvKeys, tmpZipFilePth, destinationPth, envKey)
 }
 
 // ExportDSYMs ...
 func ExportDSYMs(dsymDir string, dsyms []string) error {
 	for _, dsym := range dsyms {
-		if err := v1command.CopyDir(dsym, dsymDir, false); err != nil {
+		if err := copyDir(dsym, dsymDir, false); err != nil {
 			return fmt.Errorf("could not copy (%s) to directory (%s): %s", dsym, dsymDir, err)
 		}
 	}
 	return nil
 }
+
+// ExportDSYMsAsSeparateZips compresses every dSYM of the given directory separately into the destination directory.
+func ExportDSYMsAsSeparateZips(cmdFactory command.Factory, outputEnvKeys OutputEnvKeys, dsymDir, destinationDir, envKey, compression string, compressionLevel int, logger log.Logger) ([]string, error) {
+	dsyms, err := pathutil.ListEntries(dsymDir, pathutil.ExtensionFilter(".dsym", true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dSYMs: %s", err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "dsymZips")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	var zipPaths []string
+	for _, dsym := range dsyms {
+		zipName := filepath.Base(dsym) + artifactCompressionExtension(compression)
+		tmpZipPath := filepath.Join(tmpDir, zipName)
+		if compression == "" || compression == artifactCompressionZip {
+			err = zip(dsym, tmpZipPath, compressionLevel, logger)
+		} else {
+			err = tarDir(cmdFactory, dsym, tmpZipPath, compression, compressionLevel, logger)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		zipPath := filepath.Join(destinationDir, zipName)
+		if _, err := atomicCopyFile(tmpZipPath, zipPath); err != nil {
+			return nil, err
+		}
+		zipPaths = append(zipPaths, zipPath)
+	}
+
+	return zipPaths, exportEnvironmentWithEnvman(cmdFactory, outputEnvKeys, envKey, strings.Join(zipPaths, "|"))
+}
@@ -0,0 +1,315 @@
+package step
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"howett.net/plist"
+)
+
+const manageAppVersionAndBuildNumberKey = "manageAppVersionAndBuildNumber"
+
+// knownExportOptionKeys maps the export options keys supported by `xcodebuild -exportArchive`
+// to the minimum Xcode major version supporting them.
+var knownExportOptionKeys = map[string]int{
+	exportoptions.CompileBitcodeKey:                           0,
+	exportoptions.DestinationKey:                              0,
+	"distributionBundleIdentifier":                            0,
+	exportoptions.EmbedOnDemandResourcesAssetPacksInBundleKey: 0,
+	"generateAppStoreInformation":                             0,
+	exportoptions.ICloudContainerEnvironmentKey:               0,
+	exportoptions.InstallerSigningCertificateKey:              0,
+	manageAppVersionAndBuildNumberKey:                         13,
+	exportoptions.ManifestKey:                                 0,
+	exportoptions.MethodKey:                                   0,
+	exportoptions.OnDemandResourcesAssetPacksBaseURLKey:       0,
+	exportoptions.ProvisioningProfilesKey:                     0,
+	exportoptions.SigningCertificateKey:                       0,
+	exportoptions.SigningStyleKey:                             0,
+	"stripSwiftSymbols":                                       0,
+	exportoptions.TeamIDKey:                                   0,
+	exportoptions.TestFlightInternalTestingOnlyKey:            15,
+	exportoptions.ThinningKey:                                 0,
+	exportoptions.UploadBitcodeKey:                            0,
+	exportoptions.UploadSymbolsKey:                            0,
+}
+
+// knownExportMethods are the accepted export methods, including the ones introduced in Xcode 15.3.
+var knownExportMethods = []string{
+	string(exportoptions.MethodAppStore), string(exportoptions.MethodAdHoc), string(exportoptions.MethodPackage),
+	string(exportoptions.MethodEnterprise), string(exportoptions.MethodDevelopment), string(exportoptions.MethodDeveloperID),
+	"app-store-connect", "release-testing", "debugging", "mac-application", "validation",
+}
+
+func setManageAppVersion(exportOpts exportoptions.ExportOptions, manageAppVersion bool) exportoptions.ExportOptions {
+	switch options := exportOpts.(type) {
+	case exportoptions.AppStoreOptionsModel:
+		options.ManageAppVersion = manageAppVersion // Only available for app-store exports
+		return options
+	}
+
+	return exportOpts
+}
+
+// setUploadSymbols sets the uploadSymbols export option, only available for app-store exports.
+func setUploadSymbols(exportOpts exportoptions.ExportOptions, uploadSymbols bool) exportoptions.ExportOptions {
+	switch options := exportOpts.(type) {
+	case exportoptions.AppStoreOptionsModel:
+		options.UploadSymbols = uploadSymbols
+		return options
+	}
+
+	return exportOpts
+}
+
+// setDistributionBundleIdentifier rewrites the main application's bundle ID at export, only available for non app-store exports.
+// The manual signing profile of the original bundle ID is replaced with the given profile of the new bundle ID.
+func setDistributionBundleIdentifier(exportOpts exportoptions.ExportOptions, bundleID, originalBundleID, profileName string) exportoptions.ExportOptions {
+	switch options := exportOpts.(type) {
+	case exportoptions.NonAppStoreOptionsModel:
+		options.DistributionBundleIdentifier = bundleID
+		if _, ok := options.BundleIDProvisioningProfileMapping[originalBundleID]; ok && profileName != "" {
+			mapping := map[string]string{}
+			for id, profile := range options.BundleIDProvisioningProfileMapping {
+				if id != originalBundleID {
+					mapping[id] = profile
+				}
+			}
+			mapping[bundleID] = profileName
+			options.BundleIDProvisioningProfileMapping = mapping
+		}
+		return options
+	}
+
+	return exportOpts
+}
+
+// bundleIDProvisioningProfilesOf returns the manual signing provisioning profiles set in the export options.
+func bundleIDProvisioningProfilesOf(exportOpts exportoptions.ExportOptions) map[string]string {
+	switch options := exportOpts.(type) {
+	case exportoptions.AppStoreOptionsModel:
+		return options.BundleIDProvisioningProfileMapping
+	case exportoptions.NonAppStoreOptionsModel:
+		return options.BundleIDProvisioningProfileMapping
+	}
+
+	return nil
+}
+
+// setBundleIDProvisioningProfiles replaces the manual signing provisioning profiles of the export options.
+func setBundleIDProvisioningProfiles(exportOpts exportoptions.ExportOptions, profiles map[string]string) exportoptions.ExportOptions {
+	switch options := exportOpts.(type) {
+	case exportoptions.AppStoreOptionsModel:
+		options.BundleIDProvisioningProfileMapping = profiles
+		return options
+	case exportoptions.NonAppStoreOptionsModel:
+		options.BundleIDProvisioningProfileMapping = profiles
+		return options
+	}
+
+	return exportOpts
+}
+
+// iCloudContainerEnvironmentOf returns the iCloud container environment set in the export options.
+func iCloudContainerEnvironmentOf(exportOpts exportoptions.ExportOptions) string {
+	switch options := exportOpts.(type) {
+	case exportoptions.AppStoreOptionsModel:
+		return string(options.ICloudContainerEnvironment)
+	case exportoptions.NonAppStoreOptionsModel:
+		return string(options.ICloudContainerEnvironment)
+	}
+
+	return ""
+}
+
+// setSigningStyle forces the signingStyle of the generated export options.
+func setSigningStyle(exportOpts exportoptions.ExportOptions, signingStyle exportoptions.SigningStyle) exportoptions.ExportOptions {
+	switch options := exportOpts.(type) {
+	case exportoptions.AppStoreOptionsModel:
+		options.SigningStyle = signingStyle
+		return options
+	case exportoptions.NonAppStoreOptionsModel:
+		options.SigningStyle = signingStyle
+		return options
+	}
+
+	return exportOpts
+}
+
+var sha1FingerprintRegexp = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+
+// normalizeSigningCertificate returns the certificate selector accepted by xcodebuild:
+// either a certificate name (or name prefix, like "Apple Distribution") or its SHA-1 fingerprint.
+// SHA-1 fingerprints are accepted with space or colon separators too and are normalized to upper case hex.
+func normalizeSigningCertificate(certificate string) string {
+	certificate = strings.TrimSpace(certificate)
+
+	fingerprint := strings.NewReplacer(" ", "", ":", "").Replace(certificate)
+	if sha1FingerprintRegexp.MatchString(fingerprint) {
+		return strings.ToUpper(fingerprint)
+	}
+	return certificate
+}
+
+// setSigningCertificates overrides the signing identities of the generated export options, empty values are ignored.
+// The installer signing certificate is only available for app-store exports.
+func setSigningCertificates(exportOpts exportoptions.ExportOptions, signingCertificate, installerSigningCertificate string) exportoptions.ExportOptions {
+	signingCertificate = normalizeSigningCertificate(signingCertificate)
+	installerSigningCertificate = normalizeSigningCertificate(installerSigningCertificate)
+
+	switch options := exportOpts.(type) {
+	case exportoptions.AppStoreOptionsModel:
+		if signingCertificate != "" {
+			options.SigningCertificate = signingCertificate
+		}
+		if installerSigningCertificate != "" {
+			options.InstallerSigningCertificate = installerSigningCertificate
+		}
+		return options
+	case exportoptions.NonAppStoreOptionsModel:
+		if signingCertificate != "" {
+			options.SigningCertificate = signingCertificate
+		}
+		return options
+	}
+
+	return exportOpts
+}
+
+// validateExportOptions checks the semantics of a custom export options plist.
+// Issues preventing the export are returned as error, the suspicious ones as warnings.
+func validateExportOptions(options map[string]interface{}, xcodeMajorVersion int) ([]string, error) {
+	var warnings []string
+
+	for _, key := range sortedKeys(options) {
+		minXcodeVersion, known := knownExportOptionKeys[key]
+		if !known {
+			warnings = append(warnings, fmt.Sprintf("unknown key: %s", key))
+		} else if xcodeMajorVersion < minXcodeVersion {
+			warnings = append(warnings, fmt.Sprintf("%s requires Xcode %d or later, it is ignored by Xcode %d", key, minXcodeVersion, xcodeMajorVersion))
+		}
+	}
+
+	if value, ok := options[exportoptions.MethodKey]; ok {
+		method, isString := value.(string)
+		if !isString {
+			return warnings, fmt.Errorf("%s should be a string, got: %v", exportoptions.MethodKey, value)
+		}
+		if !isKnownExportMethod(method) {
+			return warnings, fmt.Errorf("invalid %s: %s, available values: %v", exportoptions.MethodKey, method, knownExportMethods)
+		}
+	} else {
+		warnings = append(warnings, fmt.Sprintf("%s is not set, xcodebuild defaults to %s", exportoptions.MethodKey, exportoptions.MethodDefault))
+	}
+
+	if value, ok := options[exportoptions.ProvisioningProfilesKey]; ok {
+		profiles, isDict := value.(map[string]interface{})
+		if !isDict {
+			return warnings, fmt.Errorf("%s should be a dictionary of bundle ID - provisioning profile pairs, got: %v", exportoptions.ProvisioningProfilesKey, value)
+		}
+		for bundleID, profile := range profiles {
+			if _, isString := profile.(string); !isString {
+				return warnings, fmt.Errorf("%s: provisioning profile of %s should be a string (name or UUID), got: %v", exportoptions.ProvisioningProfilesKey, bundleID, profile)
+			}
+		}
+	}
+
+	signingStyle := ""
+	if value, ok := options[exportoptions.SigningStyleKey]; ok {
+		style, isString := value.(string)
+		if !isString || (style != string(exportoptions.SigningStyleManual) && style != string(exportoptions.SigningStyleAutomatic)) {
+			return warnings, fmt.Errorf("invalid %s: %v, available values: %s, %s", exportoptions.SigningStyleKey, value, exportoptions.SigningStyleManual, exportoptions.SigningStyleAutomatic)
+		}
+		signingStyle = style
+	}
+
+	if signingStyle == string(exportoptions.SigningStyleAutomatic) {
+		if _, ok := options[exportoptions.ProvisioningProfilesKey]; ok {
+			warnings = append(warnings, fmt.Sprintf("%s is ignored when %s is %s", exportoptions.ProvisioningProfilesKey, exportoptions.SigningStyleKey, signingStyle))
+		}
+	} else if _, ok := options[exportoptions.SigningCertificateKey]; ok {
+		if _, ok := options[exportoptions.ProvisioningProfilesKey]; !ok {
+			warnings = append(warnings, fmt.Sprintf("%s is set without %s, manual signing requires both", exportoptions.SigningCertificateKey, exportoptions.ProvisioningProfilesKey))
+		}
+	}
+
+	return warnings, nil
+}
+
+func isKnownExportMethod(method string) bool {
+	for _, known := range knownExportMethods {
+		if method == known {
+			return true
+		}
+	}
+	return false
+}
+
+// exportOptionsDiff lists the differences between the custom and the generated export options.
+func exportOptionsDiff(custom, generated map[string]interface{}) []string {
+	keys := map[string]bool{}
+	for key := range custom {
+		keys[key] = true
+	}
+	for key := range generated {
+		keys[key] = true
+	}
+
+	var diff []string
+	for _, key := range sortedKeys(keys) {
+		customValue, inCustom := custom[key]
+		generatedValue, inGenerated := generated[key]
+
+		switch {
+		case !inGenerated:
+			diff = append(diff, fmt.Sprintf("+ %s: %v", key, customValue))
+		case !inCustom:
+			diff = append(diff, fmt.Sprintf("- %s: %v", key, generatedValue))
+		case fmt.Sprintf("%v", customValue) != fmt.Sprintf("%v", generatedValue):
+			diff = append(diff, fmt.Sprintf("~ %s: %v (generated: %v)", key, customValue, generatedValue))
+		}
+	}
+	return diff
+}
+
+// printCustomExportOptionsDiff prints the differences between the custom export options and the ones the Step would
+// have generated, to help debugging export issues.
+func (s XcodebuildArchiver) printCustomExportOptionsDiff(opts xcodeIPAExportOpts) {
+	var custom map[string]interface{}
+	if _, err := plist.Unmarshal([]byte(opts.CustomExportOptionsPlistContent), &custom); err != nil {
+		s.logger.Warnf("Failed to parse custom export options: %s", err)
+		return
+	}
+
+	s.logger.Println()
+	s.logger.Printf("Generating export options for comparison...")
+	generated, _, err := s.generateExportOptions(opts)
+	if err != nil {
+		s.logger.Warnf("Failed to generate export options for comparison: %s", err)
+		return
+	}
+
+	diff := exportOptionsDiff(custom, generated.Hash())
+	if len(diff) == 0 {
+		s.logger.Printf("The custom export options match the generated ones.")
+		return
+	}
+
+	s.logger.Println()
+	s.logger.Printf("Differences from the generated export options (+ custom only, - generated only, ~ different):")
+	for _, line := range diff {
+		s.logger.Printf("%s", line)
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
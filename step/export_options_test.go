@@ -0,0 +1,209 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_setManageAppVersion(t *testing.T) {
+	tests := []struct {
+		name             string
+		exportOpts       exportoptions.ExportOptions
+		manageAppVersion bool
+		want             map[string]interface{}
+	}{
+		{
+			name:             "app-store export, managed",
+			exportOpts:       exportoptions.NewAppStoreOptions(),
+			manageAppVersion: true,
+			want:             map[string]interface{}{"method": exportoptions.MethodAppStore},
+		},
+		{
+			name:             "app-store export, not managed",
+			exportOpts:       exportoptions.NewAppStoreOptions(),
+			manageAppVersion: false,
+			want:             map[string]interface{}{"method": exportoptions.MethodAppStore, "manageAppVersionAndBuildNumber": false},
+		},
+		{
+			name:             "development export",
+			exportOpts:       exportoptions.NewNonAppStoreOptions(exportoptions.MethodDevelopment),
+			manageAppVersion: false,
+			want:             map[string]interface{}{"method": exportoptions.MethodDevelopment},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := setManageAppVersion(tt.exportOpts, tt.manageAppVersion)
+
+			require.Equal(t, tt.want, got.Hash())
+		})
+	}
+}
+
+func Test_validateExportOptions(t *testing.T) {
+	tests := []struct {
+		name              string
+		options           map[string]interface{}
+		xcodeMajorVersion int
+		wantWarnings      []string
+		wantErr           bool
+	}{
+		{
+			name: "valid options",
+			options: map[string]interface{}{
+				"method":               "app-store",
+				"signingStyle":         "manual",
+				"signingCertificate":   "Apple Distribution",
+				"provisioningProfiles": map[string]interface{}{"io.bitrise.sample": "Sample Profile"},
+			},
+			xcodeMajorVersion: 15,
+		},
+		{
+			name: "unknown and too new keys",
+			options: map[string]interface{}{
+				"method":                        "ad-hoc",
+				"testFlightInternalTestingOnly": true,
+				"uploadBitcodes":                false,
+			},
+			xcodeMajorVersion: 14,
+			wantWarnings: []string{
+				"testFlightInternalTestingOnly requires Xcode 15 or later, it is ignored by Xcode 14",
+				"unknown key: uploadBitcodes",
+			},
+		},
+		{
+			name:              "invalid method",
+			options:           map[string]interface{}{"method": "appstore"},
+			xcodeMajorVersion: 15,
+			wantErr:           true,
+		},
+		{
+			name: "provisioning profiles is not a dictionary of strings",
+			options: map[string]interface{}{
+				"method":               "development",
+				"provisioningProfiles": map[string]interface{}{"io.bitrise.sample": []interface{}{"Sample Profile"}},
+			},
+			xcodeMajorVersion: 15,
+			wantErr:           true,
+		},
+		{
+			name: "signing certificate without profiles",
+			options: map[string]interface{}{
+				"method":             "development",
+				"signingCertificate": "Apple Development",
+			},
+			xcodeMajorVersion: 15,
+			wantWarnings:      []string{"signingCertificate is set without provisioningProfiles, manual signing requires both"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := validateExportOptions(tt.options, tt.xcodeMajorVersion)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantWarnings, warnings)
+		})
+	}
+}
+
+func Test_exportOptionsDiff(t *testing.T) {
+	custom := map[string]interface{}{
+		"method":         "app-store",
+		"teamID":         "TEAM1",
+		"uploadSymbols":  false,
+		"compileBitcode": false,
+	}
+	generated := map[string]interface{}{
+		"method":         exportoptions.MethodAppStore,
+		"teamID":         "TEAM2",
+		"compileBitcode": false,
+		"signingStyle":   "manual",
+	}
+
+	require.Equal(t, []string{
+		"- signingStyle: manual",
+		"~ teamID: TEAM1 (generated: TEAM2)",
+		"+ uploadSymbols: false",
+	}, exportOptionsDiff(custom, generated))
+}
+
+func Test_normalizeSigningCertificate(t *testing.T) {
+	require.Equal(t, "", normalizeSigningCertificate(" "))
+	require.Equal(t, "Apple Distribution", normalizeSigningCertificate("Apple Distribution"))
+	require.Equal(t, "Apple Distribution: Bitrise Ltd. (TEAM123)", normalizeSigningCertificate(" Apple Distribution: Bitrise Ltd. (TEAM123) "))
+	require.Equal(t, "0123456789ABCDEF0123456789ABCDEF01234567", normalizeSigningCertificate("0123456789abcdef0123456789abcdef01234567"))
+	require.Equal(t, "0123456789ABCDEF0123456789ABCDEF01234567", normalizeSigningCertificate("01:23:45:67:89:AB:CD:EF:01:23:45:67:89:AB:CD:EF:01:23:45:67"))
+}
+
+func Test_setSigningCertificates(t *testing.T) {
+	appStore := setSigningCertificates(exportoptions.NewAppStoreOptions(), "Apple Distribution", "3rd Party Mac Developer Installer")
+	require.Equal(t, "Apple Distribution", appStore.Hash()[exportoptions.SigningCertificateKey])
+	require.Equal(t, "3rd Party Mac Developer Installer", appStore.Hash()[exportoptions.InstallerSigningCertificateKey])
+
+	adHoc := setSigningCertificates(exportoptions.NewNonAppStoreOptions(exportoptions.MethodAdHoc), "Apple Distribution", "3rd Party Mac Developer Installer")
+	require.Equal(t, "Apple Distribution", adHoc.Hash()[exportoptions.SigningCertificateKey])
+	require.NotContains(t, adHoc.Hash(), exportoptions.InstallerSigningCertificateKey)
+
+	unchanged := exportoptions.NewNonAppStoreOptions(exportoptions.MethodDevelopment)
+	unchanged.SigningCertificate = "Apple Development"
+	require.Equal(t, unchanged.Hash(), setSigningCertificates(unchanged, "", "").Hash())
+}
+
+func Test_setSigningStyle(t *testing.T) {
+	appStore := setSigningStyle(exportoptions.NewAppStoreOptions(), exportoptions.SigningStyleManual)
+	require.Equal(t, exportoptions.SigningStyleManual, appStore.Hash()[exportoptions.SigningStyleKey])
+
+	development := setSigningStyle(exportoptions.NewNonAppStoreOptions(exportoptions.MethodDevelopment), exportoptions.SigningStyleAutomatic)
+	require.Equal(t, exportoptions.SigningStyleAutomatic, development.Hash()[exportoptions.SigningStyleKey])
+}
+
+func Test_setUploadSymbols(t *testing.T) {
+	got := setUploadSymbols(exportoptions.NewAppStoreOptions(), false)
+	require.Equal(t, map[string]interface{}{"method": exportoptions.MethodAppStore, "uploadSymbols": false}, got.Hash())
+
+	got = setUploadSymbols(exportoptions.NewAppStoreOptions(), true)
+	require.Equal(t, map[string]interface{}{"method": exportoptions.MethodAppStore}, got.Hash())
+
+	got = setUploadSymbols(exportoptions.NewNonAppStoreOptions(exportoptions.MethodAdHoc), false)
+	require.Equal(t, map[string]interface{}{"method": exportoptions.MethodAdHoc}, got.Hash())
+}
+
+func Test_setDistributionBundleIdentifier(t *testing.T) {
+	adHocOptions := exportoptions.NewNonAppStoreOptions(exportoptions.MethodAdHoc)
+	adHocOptions.BundleIDProvisioningProfileMapping = map[string]string{
+		"io.bitrise.app":        "App AdHoc",
+		"io.bitrise.app.widget": "Widget AdHoc",
+	}
+
+	got := setDistributionBundleIdentifier(adHocOptions, "io.bitrise.rebadged", "io.bitrise.app", "Rebadged AdHoc")
+	require.Equal(t, map[string]interface{}{
+		"method":                       exportoptions.MethodAdHoc,
+		"distributionBundleIdentifier": "io.bitrise.rebadged",
+		"provisioningProfiles": map[string]string{
+			"io.bitrise.rebadged":   "Rebadged AdHoc",
+			"io.bitrise.app.widget": "Widget AdHoc",
+		},
+	}, got.Hash())
+	require.Equal(t, "App AdHoc", adHocOptions.BundleIDProvisioningProfileMapping["io.bitrise.app"])
+
+	got = setDistributionBundleIdentifier(exportoptions.NewAppStoreOptions(), "io.bitrise.rebadged", "io.bitrise.app", "")
+	require.Equal(t, map[string]interface{}{"method": exportoptions.MethodAppStore}, got.Hash())
+}
+
+func Test_setBundleIDProvisioningProfiles(t *testing.T) {
+	appStoreOptions := exportoptions.NewAppStoreOptions()
+	appStoreOptions.BundleIDProvisioningProfileMapping = map[string]string{"io.bitrise.app": "App Store"}
+	profiles := map[string]string{
+		"io.bitrise.app":        "App Store",
+		"io.bitrise.app.widget": "Partner App Store",
+	}
+
+	got := setBundleIDProvisioningProfiles(appStoreOptions, profiles)
+	require.Equal(t, profiles, bundleIDProvisioningProfilesOf(got))
+	require.Equal(t, map[string]string{"io.bitrise.app": "App Store"}, bundleIDProvisioningProfilesOf(appStoreOptions))
+}
@@ -0,0 +1,134 @@
+package step
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/bitrise-io/go-xcode/profileutil"
+)
+
+// forceTeamIDBuildSetting returns the build setting overriding the development team of the archive action.
+func forceTeamIDBuildSetting(teamID string) string {
+	return "DEVELOPMENT_TEAM=" + teamID
+}
+
+// bundleIDsMissingExportProfile returns the bundle IDs without a provisioning profile of the given team and export method.
+func bundleIDsMissingExportProfile(teamID string, exportMethod exportoptions.Method, bundleIDs []string, profiles []profileutil.ProvisioningProfileInfoModel) []string {
+	var missing []string
+	for _, bundleID := range bundleIDs {
+		found := false
+		for _, profile := range profiles {
+			if profile.TeamID != teamID || profile.ExportType != exportMethod {
+				continue
+			}
+			if profileBundleIDMatches(profile.BundleID, bundleID) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, bundleID)
+		}
+	}
+	return missing
+}
+
+// findExportProfile returns the provisioning profile of the given team and export method for the bundle ID,
+// preferring explicit profiles to wildcard ones.
+func findExportProfile(teamID string, exportMethod exportoptions.Method, bundleID string, profiles []profileutil.ProvisioningProfileInfoModel) (profileutil.ProvisioningProfileInfoModel, bool) {
+	var wildcard *profileutil.ProvisioningProfileInfoModel
+	for i, profile := range profiles {
+		if profile.TeamID != teamID || profile.ExportType != exportMethod || !profileBundleIDMatches(profile.BundleID, bundleID) {
+			continue
+		}
+		if profile.BundleID == bundleID {
+			return profile, true
+		}
+		if wildcard == nil {
+			wildcard = &profiles[i]
+		}
+	}
+	if wildcard != nil {
+		return *wildcard, true
+	}
+	return profileutil.ProvisioningProfileInfoModel{}, false
+}
+
+func profileBundleIDMatches(profileBundleID, bundleID string) bool {
+	if strings.HasSuffix(profileBundleID, "*") {
+		return strings.HasPrefix(bundleID, strings.TrimSuffix(profileBundleID, "*"))
+	}
+	return profileBundleID == bundleID
+}
+
+// parseBundleIDTeamOverrides parses the newline separated list of bundle ID=team ID pairs.
+func parseBundleIDTeamOverrides(list string) (map[string]string, error) {
+	overrides := map[string]string{}
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		bundleID, teamID, found := strings.Cut(line, "=")
+		bundleID, teamID = strings.TrimSpace(bundleID), strings.TrimSpace(teamID)
+		if !found || bundleID == "" || teamID == "" {
+			return nil, fmt.Errorf("invalid team override, expected bundle ID=team ID format: %s", line)
+		}
+		if _, ok := overrides[bundleID]; ok {
+			return nil, fmt.Errorf("multiple team overrides for bundle ID: %s", bundleID)
+		}
+		overrides[bundleID] = teamID
+	}
+	return overrides, nil
+}
+
+// teamOverrideProfiles returns the manual signing provisioning profile names of the archive's bundle IDs:
+// the overridden bundle IDs are signed with a profile of their own team, the other bundle IDs keep the generated profile,
+// or get a profile of the export team if the generator found none (it only considers the export team's code signing group).
+func teamOverrideProfiles(exportTeamID string, overrides map[string]string, exportMethod exportoptions.Method, bundleIDs []string, generated map[string]string, profiles []profileutil.ProvisioningProfileInfoModel) (map[string]string, error) {
+	mapping := map[string]string{}
+	var missing []string
+	for _, bundleID := range bundleIDs {
+		teamID, overridden := overrides[bundleID]
+		if !overridden {
+			if profile, ok := generated[bundleID]; ok {
+				mapping[bundleID] = profile
+				continue
+			}
+			teamID = exportTeamID
+		}
+
+		profile, found := findExportProfile(teamID, exportMethod, bundleID, profiles)
+		if !found {
+			missing = append(missing, fmt.Sprintf("%s (team: %s)", bundleID, teamID))
+			continue
+		}
+		mapping[bundleID] = profile.Name
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no installed %s provisioning profile found for: %s", exportMethod, strings.Join(missing, ", "))
+	}
+	return mapping, nil
+}
+
+// withoutBundleIDs returns the bundle IDs not in the excluded set.
+func withoutBundleIDs(bundleIDs []string, excluded map[string]string) []string {
+	var filtered []string
+	for _, bundleID := range bundleIDs {
+		if _, ok := excluded[bundleID]; !ok {
+			filtered = append(filtered, bundleID)
+		}
+	}
+	return filtered
+}
+
+func validateExportTeamProfiles(teamID string, exportMethod exportoptions.Method, bundleIDs []string, profiles []profileutil.ProvisioningProfileInfoModel) error {
+	sort.Strings(bundleIDs)
+	missing := bundleIDsMissingExportProfile(teamID, exportMethod, bundleIDs, profiles)
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("no installed %s provisioning profile found for the export team (%s) and bundle IDs: %s", exportMethod, teamID, strings.Join(missing, ", "))
+}
@@ -0,0 +1,113 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/bitrise-io/go-xcode/profileutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_bundleIDsMissingExportProfile(t *testing.T) {
+	profiles := []profileutil.ProvisioningProfileInfoModel{
+		{TeamID: "EXPORTTEAM", ExportType: exportoptions.MethodAppStore, BundleID: "io.bitrise.app"},
+		{TeamID: "EXPORTTEAM", ExportType: exportoptions.MethodAdHoc, BundleID: "io.bitrise.*"},
+		{TeamID: "ARCHIVETEAM", ExportType: exportoptions.MethodAppStore, BundleID: "io.bitrise.app.widget"},
+	}
+
+	tests := []struct {
+		name         string
+		exportMethod exportoptions.Method
+		bundleIDs    []string
+		want         []string
+	}{
+		{
+			name:         "explicit profile found",
+			exportMethod: exportoptions.MethodAppStore,
+			bundleIDs:    []string{"io.bitrise.app"},
+		},
+		{
+			name:         "profile of an other team is not used",
+			exportMethod: exportoptions.MethodAppStore,
+			bundleIDs:    []string{"io.bitrise.app", "io.bitrise.app.widget"},
+			want:         []string{"io.bitrise.app.widget"},
+		},
+		{
+			name:         "wildcard profile found",
+			exportMethod: exportoptions.MethodAdHoc,
+			bundleIDs:    []string{"io.bitrise.app", "io.bitrise.app.widget"},
+		},
+		{
+			name:         "export method mismatch",
+			exportMethod: exportoptions.MethodEnterprise,
+			bundleIDs:    []string{"io.bitrise.app"},
+			want:         []string{"io.bitrise.app"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bundleIDsMissingExportProfile("EXPORTTEAM", tt.exportMethod, tt.bundleIDs, profiles)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_findExportProfile(t *testing.T) {
+	profiles := []profileutil.ProvisioningProfileInfoModel{
+		{Name: "Wildcard", TeamID: "TEAM", ExportType: exportoptions.MethodEnterprise, BundleID: "io.bitrise.*"},
+		{Name: "Explicit", TeamID: "TEAM", ExportType: exportoptions.MethodEnterprise, BundleID: "io.bitrise.rebadged"},
+		{Name: "Other team", TeamID: "OTHER", ExportType: exportoptions.MethodEnterprise, BundleID: "com.example.app"},
+	}
+
+	profile, found := findExportProfile("TEAM", exportoptions.MethodEnterprise, "io.bitrise.rebadged", profiles)
+	require.True(t, found)
+	require.Equal(t, "Explicit", profile.Name)
+
+	profile, found = findExportProfile("TEAM", exportoptions.MethodEnterprise, "io.bitrise.other", profiles)
+	require.True(t, found)
+	require.Equal(t, "Wildcard", profile.Name)
+
+	_, found = findExportProfile("TEAM", exportoptions.MethodEnterprise, "com.example.app", profiles)
+	require.False(t, found)
+}
+
+func Test_parseBundleIDTeamOverrides(t *testing.T) {
+	overrides, err := parseBundleIDTeamOverrides("io.bitrise.app.widget = PARTNERTEAM\n\nio.bitrise.app.watch=WATCHTEAM\n")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"io.bitrise.app.widget": "PARTNERTEAM", "io.bitrise.app.watch": "WATCHTEAM"}, overrides)
+
+	_, err = parseBundleIDTeamOverrides("io.bitrise.app.widget")
+	require.Error(t, err)
+
+	_, err = parseBundleIDTeamOverrides("io.bitrise.app.widget=")
+	require.Error(t, err)
+
+	_, err = parseBundleIDTeamOverrides("io.bitrise.app.widget=PARTNERTEAM\nio.bitrise.app.widget=OTHERTEAM")
+	require.Error(t, err)
+}
+
+func Test_teamOverrideProfiles(t *testing.T) {
+	profiles := []profileutil.ProvisioningProfileInfoModel{
+		{Name: "App", TeamID: "EXPORTTEAM", ExportType: exportoptions.MethodAppStore, BundleID: "io.bitrise.app"},
+		{Name: "Watch", TeamID: "EXPORTTEAM", ExportType: exportoptions.MethodAppStore, BundleID: "io.bitrise.app.watch"},
+		{Name: "Partner widget", TeamID: "PARTNERTEAM", ExportType: exportoptions.MethodAppStore, BundleID: "io.bitrise.app.widget"},
+	}
+	bundleIDs := []string{"io.bitrise.app", "io.bitrise.app.watch", "io.bitrise.app.widget"}
+	overrides := map[string]string{"io.bitrise.app.widget": "PARTNERTEAM"}
+
+	mapping, err := teamOverrideProfiles("EXPORTTEAM", overrides, exportoptions.MethodAppStore, bundleIDs, map[string]string{"io.bitrise.app": "Generated app"}, profiles)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"io.bitrise.app":        "Generated app",
+		"io.bitrise.app.watch":  "Watch",
+		"io.bitrise.app.widget": "Partner widget",
+	}, mapping)
+
+	_, err = teamOverrideProfiles("EXPORTTEAM", map[string]string{"io.bitrise.app.watch": "PARTNERTEAM"}, exportoptions.MethodAppStore, bundleIDs, nil, profiles)
+	require.EqualError(t, err, "no installed app-store provisioning profile found for: io.bitrise.app.watch (team: PARTNERTEAM), io.bitrise.app.widget (team: EXPORTTEAM)")
+}
+
+func Test_withoutBundleIDs(t *testing.T) {
+	got := withoutBundleIDs([]string{"io.bitrise.app", "io.bitrise.app.widget"}, map[string]string{"io.bitrise.app.widget": "PARTNERTEAM"})
+	require.Equal(t, []string{"io.bitrise.app"}, got)
+}
@@ -0,0 +1,122 @@
+package step
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/sliceutil"
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/bitrise-io/go-xcode/plistutil"
+	"github.com/bitrise-io/go-xcode/profileutil"
+	"github.com/bitrise-io/go-xcode/xcarchive"
+)
+
+const (
+	iCloudServicesEntitlementKey             = "com.apple.developer.icloud-services"
+	iCloudContainerEnvironmentEntitlementKey = "com.apple.developer.icloud-container-environment"
+	iCloudContainerEnvironmentDevelopment    = "Development"
+	iCloudContainerEnvironmentProduction     = "Production"
+)
+
+func usesCloudKit(entitlementsByBundleID map[string]plistutil.PlistData) bool {
+	for _, entitlements := range entitlementsByBundleID {
+		services, ok := entitlements.GetStringArray(iCloudServicesEntitlementKey)
+		if !ok {
+			continue
+		}
+		if sliceutil.IsStringInSlice("CloudKit", services) || sliceutil.IsStringInSlice("CloudDocuments", services) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedICloudContainerEnvironments returns the container environments every provisioning profile allows.
+func allowedICloudContainerEnvironments(profileByBundleID map[string]profileutil.ProvisioningProfileInfoModel) []string {
+	var allowed []string
+	first := true
+	for _, profile := range profileByBundleID {
+		environments, ok := profile.Entitlements.GetStringArray(iCloudContainerEnvironmentEntitlementKey)
+		if !ok {
+			if environment, ok := profile.Entitlements.GetString(iCloudContainerEnvironmentEntitlementKey); ok {
+				environments = []string{environment}
+			} else {
+				continue
+			}
+		}
+
+		if first {
+			allowed = environments
+			first = false
+			continue
+		}
+
+		var common []string
+		for _, environment := range allowed {
+			if sliceutil.IsStringInSlice(environment, environments) {
+				common = append(common, environment)
+			}
+		}
+		allowed = common
+	}
+
+	sort.Strings(allowed)
+	return allowed
+}
+
+// defaultICloudContainerEnvironment picks the container environment for the export method:
+// Production for distribution exports and Development for development exports, if the profiles allow it.
+func defaultICloudContainerEnvironment(exportMethod exportoptions.Method, allowed []string) string {
+	preferred := iCloudContainerEnvironmentProduction
+	if exportMethod == exportoptions.MethodDevelopment {
+		preferred = iCloudContainerEnvironmentDevelopment
+	}
+
+	if exportMethod == exportoptions.MethodAppStore || len(allowed) == 0 || sliceutil.IsStringInSlice(preferred, allowed) {
+		return preferred
+	}
+	return allowed[0]
+}
+
+// detectICloudContainerEnvironment returns the container environment to use if the archived app uses CloudKit
+// and no environment was provided, otherwise an empty string.
+func detectICloudContainerEnvironment(archive xcarchive.IosArchive, exportMethod exportoptions.Method, logger log.Logger) string {
+	if !usesCloudKit(archive.BundleIDEntitlementsMap()) {
+		return ""
+	}
+
+	allowed := allowedICloudContainerEnvironments(archive.BundleIDProfileInfoMap())
+	environment := defaultICloudContainerEnvironment(exportMethod, allowed)
+
+	logger.Printf("The app uses CloudKit, but no iCloud container environment provided, using %s for the %s export.", environment, exportMethod)
+	if len(allowed) > 0 {
+		logger.Printf("Container environments available in the provisioning profiles: %s", strings.Join(allowed, ", "))
+	}
+
+	return environment
+}
+
+// validateICloudContainerEnvironment checks the provided container environment against the archived app's iCloud entitlements,
+// the export method and the environments allowed by the provisioning profiles, as xcodebuild fails with an opaque IDEDistribution error otherwise.
+func validateICloudContainerEnvironment(environment string, exportMethod exportoptions.Method, entitlementsByBundleID map[string]plistutil.PlistData, profileByBundleID map[string]profileutil.ProvisioningProfileInfoModel, logger log.Logger) error {
+	validEnvironments := []string{iCloudContainerEnvironmentDevelopment, iCloudContainerEnvironmentProduction}
+	if !sliceutil.IsStringInSlice(environment, validEnvironments) {
+		return fmt.Errorf("invalid iCloud container environment: %s, available values: %s", environment, strings.Join(validEnvironments, ", "))
+	}
+
+	if !usesCloudKit(entitlementsByBundleID) {
+		logger.Warnf("iCloud container environment (%s) provided, but the app has no CloudKit or iCloud Documents entitlement, it has no effect.", environment)
+		return nil
+	}
+
+	if exportMethod == exportoptions.MethodAppStore && environment != iCloudContainerEnvironmentProduction {
+		return fmt.Errorf("the %s iCloud container environment is not available for %s exports, use %s", environment, exportMethod, iCloudContainerEnvironmentProduction)
+	}
+
+	if allowed := allowedICloudContainerEnvironments(profileByBundleID); len(allowed) > 0 && !sliceutil.IsStringInSlice(environment, allowed) {
+		return fmt.Errorf("the %s iCloud container environment is not allowed by the provisioning profiles, available environments: %s", environment, strings.Join(allowed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/bitrise-io/go-xcode/plistutil"
+	"github.com/bitrise-io/go-xcode/profileutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_usesCloudKit(t *testing.T) {
+	require.False(t, usesCloudKit(map[string]plistutil.PlistData{
+		"io.bitrise.app": {"aps-environment": "production"},
+	}))
+	require.True(t, usesCloudKit(map[string]plistutil.PlistData{
+		"io.bitrise.app":        {},
+		"io.bitrise.app.widget": {iCloudServicesEntitlementKey: []interface{}{"CloudKit"}},
+	}))
+}
+
+func Test_allowedICloudContainerEnvironments(t *testing.T) {
+	profiles := map[string]profileutil.ProvisioningProfileInfoModel{
+		"io.bitrise.app": {Entitlements: plistutil.PlistData{
+			iCloudContainerEnvironmentEntitlementKey: []interface{}{"Production", "Development"},
+		}},
+		"io.bitrise.app.widget": {Entitlements: plistutil.PlistData{
+			iCloudContainerEnvironmentEntitlementKey: "Production",
+		}},
+		"io.bitrise.app.watch": {Entitlements: plistutil.PlistData{}},
+	}
+
+	require.Equal(t, []string{"Production"}, allowedICloudContainerEnvironments(profiles))
+	require.Nil(t, allowedICloudContainerEnvironments(nil))
+}
+
+func Test_defaultICloudContainerEnvironment(t *testing.T) {
+	tests := []struct {
+		name         string
+		exportMethod exportoptions.Method
+		allowed      []string
+		want         string
+	}{
+		{
+			name:         "app-store",
+			exportMethod: exportoptions.MethodAppStore,
+			allowed:      []string{"Development"},
+			want:         "Production",
+		},
+		{
+			name:         "ad-hoc",
+			exportMethod: exportoptions.MethodAdHoc,
+			allowed:      []string{"Development", "Production"},
+			want:         "Production",
+		},
+		{
+			name:         "development",
+			exportMethod: exportoptions.MethodDevelopment,
+			want:         "Development",
+		},
+		{
+			name:         "development, profile allows production only",
+			exportMethod: exportoptions.MethodDevelopment,
+			allowed:      []string{"Production"},
+			want:         "Production",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, defaultICloudContainerEnvironment(tt.exportMethod, tt.allowed))
+		})
+	}
+}
+
+func Test_validateICloudContainerEnvironment(t *testing.T) {
+	logger := log.NewLogger()
+	cloudKitEntitlements := map[string]plistutil.PlistData{
+		"io.bitrise.app": {iCloudServicesEntitlementKey: []interface{}{"CloudKit"}},
+	}
+	profiles := map[string]profileutil.ProvisioningProfileInfoModel{
+		"io.bitrise.app": {Entitlements: plistutil.PlistData{
+			iCloudContainerEnvironmentEntitlementKey: []interface{}{"Production"},
+		}},
+	}
+
+	require.NoError(t, validateICloudContainerEnvironment("Production", exportoptions.MethodAppStore, cloudKitEntitlements, profiles, logger))
+	require.NoError(t, validateICloudContainerEnvironment("Development", exportoptions.MethodAdHoc, map[string]plistutil.PlistData{"io.bitrise.app": {}}, profiles, logger))
+
+	require.EqualError(t, validateICloudContainerEnvironment("production", exportoptions.MethodAppStore, cloudKitEntitlements, profiles, logger),
+		"invalid iCloud container environment: production, available values: Development, Production")
+	require.EqualError(t, validateICloudContainerEnvironment("Development", exportoptions.MethodAppStore, cloudKitEntitlements, nil, logger),
+		"the Development iCloud container environment is not available for app-store exports, use Production")
+	require.EqualError(t, validateICloudContainerEnvironment("Development", exportoptions.MethodAdHoc, cloudKitEntitlements, profiles, logger),
+		"the Development iCloud container environment is not allowed by the provisioning profiles, available environments: Production")
+}
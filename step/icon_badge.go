@@ -0,0 +1,260 @@
+package step
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"howett.net/plist"
+)
+
+const (
+	maxIconBadgeLength = 10
+
+	iconBadgeGlyphWidth  = 5
+	iconBadgeGlyphHeight = 7
+)
+
+var (
+	iconBadgeBandColor = color.NRGBA{R: 0xE6, G: 0x00, B: 0x1A, A: 0xE6}
+	iconBadgeTextColor = color.White
+)
+
+// iconBadgeGlyphs is the bitmap font of the icon badge text, a # is a lit pixel.
+var iconBadgeGlyphs = map[rune][iconBadgeGlyphHeight]string{
+	'0': {".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
+	'1': {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'2': {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+	'3': {"#####", "...#.", "..#..", "...#.", "....#", "#...#", ".###."},
+	'4': {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	'5': {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	'6': {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+	'7': {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	'8': {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+	'9': {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+	'A': {".###.", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'B': {"####.", "#...#", "#...#", "####.", "#...#", "#...#", "####."},
+	'C': {".###.", "#...#", "#....", "#....", "#....", "#...#", ".###."},
+	'D': {"####.", "#...#", "#...#", "#...#", "#...#", "#...#", "####."},
+	'E': {"#####", "#....", "#....", "####.", "#....", "#....", "#####"},
+	'F': {"#####", "#....", "#....", "####.", "#....", "#....", "#...."},
+	'G': {".###.", "#...#", "#....", "#.###", "#...#", "#...#", ".####"},
+	'H': {"#...#", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'I': {".###.", "..#..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'J': {"..###", "...#.", "...#.", "...#.", "...#.", "#..#.", ".##.."},
+	'K': {"#...#", "#..#.", "#.#..", "##...", "#.#..", "#..#.", "#...#"},
+	'L': {"#....", "#....", "#....", "#....", "#....", "#....", "#####"},
+	'M': {"#...#", "##.##", "#.#.#", "#.#.#", "#...#", "#...#", "#...#"},
+	'N': {"#...#", "#...#", "##..#", "#.#.#", "#..##", "#...#", "#...#"},
+	'O': {".###.", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'P': {"####.", "#...#", "#...#", "####.", "#....", "#....", "#...."},
+	'Q': {".###.", "#...#", "#...#", "#...#", "#.#.#", "#..#.", ".##.#"},
+	'R': {"####.", "#...#", "#...#", "####.", "#.#..", "#..#.", "#...#"},
+	'S': {".####", "#....", "#....", ".###.", "....#", "....#", "####."},
+	'T': {"#####", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
+	'U': {"#...#", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'V': {"#...#", "#...#", "#...#", "#...#", "#...#", ".#.#.", "..#.."},
+	'W': {"#...#", "#...#", "#...#", "#.#.#", "#.#.#", "#.#.#", ".#.#."},
+	'X': {"#...#", "#...#", ".#.#.", "..#..", ".#.#.", "#...#", "#...#"},
+	'Y': {"#...#", "#...#", ".#.#.", "..#..", "..#..", "..#..", "..#.."},
+	'Z': {"#####", "....#", "...#.", "..#..", ".#...", "#....", "#####"},
+	' ': {".....", ".....", ".....", ".....", ".....", ".....", "....."},
+	'.': {".....", ".....", ".....", ".....", ".....", ".##..", ".##.."},
+	'-': {".....", ".....", ".....", "#####", ".....", ".....", "....."},
+	'_': {".....", ".....", ".....", ".....", ".....", ".....", "#####"},
+	'+': {".....", "..#..", "..#..", "#####", "..#..", "..#..", "....."},
+	'/': {".....", "....#", "...#.", "..#..", ".#...", "#....", "....."},
+	'#': {".#.#.", ".#.#.", "#####", ".#.#.", "#####", ".#.#.", ".#.#."},
+	'(': {"...#.", "..#..", ".#...", ".#...", ".#...", "..#..", "...#."},
+	')': {".#...", "..#..", "...#.", "...#.", "...#.", "..#..", ".#..."},
+}
+
+// parseIconBadge returns the upper-cased badge text, the text can contain letters, digits, spaces and the . - _ + / # ( ) characters.
+func parseIconBadge(text string) (string, error) {
+	text = strings.ToUpper(strings.TrimSpace(text))
+	if len([]rune(text)) > maxIconBadgeLength {
+		return "", fmt.Errorf("the badge text is longer than %d characters: %s", maxIconBadgeLength, text)
+	}
+	for _, r := range text {
+		if _, ok := iconBadgeGlyphs[r]; !ok {
+			return "", fmt.Errorf("unsupported character in the badge text: %q", r)
+		}
+	}
+	return text, nil
+}
+
+// badgeIcon draws the text on a band over the bottom of the icon.
+func badgeIcon(icon image.Image, text string) *image.RGBA {
+	bounds := icon.Bounds()
+	badged := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(badged, badged.Bounds(), icon, bounds.Min, draw.Src)
+
+	width, height := badged.Bounds().Dx(), badged.Bounds().Dy()
+	bandHeight := max(height*3/10, 1)
+	bandTop := height - bandHeight
+	draw.Draw(badged, image.Rect(0, bandTop, width, height), image.NewUniform(iconBadgeBandColor), image.Point{}, draw.Over)
+
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return badged
+	}
+	// the glyphs are separated by a blank column
+	columns := len(runes)*(iconBadgeGlyphWidth+1) - 1
+	scale := max(min(bandHeight*6/10/iconBadgeGlyphHeight, width*9/10/columns), 1)
+	left := (width - columns*scale) / 2
+	top := bandTop + (bandHeight-iconBadgeGlyphHeight*scale)/2
+
+	textColor := image.NewUniform(iconBadgeTextColor)
+	for i, r := range runes {
+		glyph := iconBadgeGlyphs[r]
+		for row, pixels := range glyph {
+			for column, pixel := range pixels {
+				if pixel != '#' {
+					continue
+				}
+				x := left + (i*(iconBadgeGlyphWidth+1)+column)*scale
+				y := top + row*scale
+				draw.Draw(badged, image.Rect(x, y, x+scale, y+scale), textColor, image.Point{}, draw.Src)
+			}
+		}
+	}
+	return badged
+}
+
+func infoPlistDict(value interface{}) map[string]interface{} {
+	dict, _ := value.(map[string]interface{})
+	return dict
+}
+
+// primaryIconDicts returns the iPhone and iPad primary icon dicts of the Info.plist.
+func primaryIconDicts(infoPlist map[string]interface{}) []map[string]interface{} {
+	var dicts []map[string]interface{}
+	for _, key := range []string{"CFBundleIcons", "CFBundleIcons~ipad"} {
+		if primaryIcon := infoPlistDict(infoPlistDict(infoPlist[key])["CFBundlePrimaryIcon"]); primaryIcon != nil {
+			dicts = append(dicts, primaryIcon)
+		}
+	}
+	return dicts
+}
+
+// appIconFiles returns the PNG app icon files of the app bundle listed in its Info.plist.
+func appIconFiles(appPath string, infoPlist map[string]interface{}) ([]string, error) {
+	var iconFileLists []interface{}
+	for _, primaryIcon := range primaryIconDicts(infoPlist) {
+		iconFileLists = append(iconFileLists, primaryIcon["CFBundleIconFiles"])
+	}
+	iconFileLists = append(iconFileLists, infoPlist["CFBundleIconFiles"])
+
+	files := map[string]bool{}
+	for _, list := range iconFileLists {
+		names, _ := list.([]interface{})
+		for _, name := range names {
+			base, ok := name.(string)
+			if !ok || base == "" {
+				continue
+			}
+			// the listed names are without the scale and device suffixes, for example AppIcon60x60 for AppIcon60x60@2x.png
+			matches, err := filepath.Glob(filepath.Join(appPath, strings.TrimSuffix(base, ".png")+"*.png"))
+			if err != nil {
+				return nil, err
+			}
+			for _, match := range matches {
+				files[match] = true
+			}
+		}
+	}
+	return sortedKeys(files), nil
+}
+
+// isCgBIPNG returns if the PNG is optimized for the iPhone by Xcode (the CgBI chunk precedes the header chunk),
+// these PNGs can't be decoded by standard PNG decoders.
+func isCgBIPNG(content []byte) bool {
+	return len(content) >= 16 && string(content[12:16]) == "CgBI"
+}
+
+// decodeIconPNG decodes the icon, reverting the Xcode iPhone optimization of the PNG with pngcrush first, if needed.
+func (s XcodebuildArchiver) decodeIconPNG(pth string) (image.Image, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return nil, err
+	}
+	if isCgBIPNG(content) {
+		tmpDir, err := os.MkdirTemp("", "icon-badge")
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = os.RemoveAll(tmpDir)
+		}()
+
+		reverted := filepath.Join(tmpDir, filepath.Base(pth))
+		cmd := s.cmdFactory.Create("xcrun", []string{"pngcrush", "-revert-iphone-optimizations", "-q", pth, reverted}, nil)
+		if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to revert the iPhone optimization of %s: %s: %w", pth, out, err)
+		}
+		if content, err = os.ReadFile(reverted); err != nil {
+			return nil, err
+		}
+	}
+	return png.Decode(bytes.NewReader(content))
+}
+
+// badgeAppIcons draws the badge on the app icon files of the app bundle. The asset catalog's icon (CFBundleIconName)
+// is removed from the Info.plist, so the system uses the badged icon files instead of the compiled asset catalog.
+func (s XcodebuildArchiver) badgeAppIcons(appPath, text string) error {
+	s.logger.Println()
+	s.logger.Infof("Adding the %s badge to the app icon", text)
+
+	if _, err := os.Stat(filepath.Join(appPath, "Contents")); err == nil {
+		s.logger.Warnf("Only iOS app icons can be badged, the app icon is kept as is")
+		return nil
+	}
+
+	infoPlistPath := appInfoPlistPath(appPath)
+	content, err := os.ReadFile(infoPlistPath)
+	if err != nil {
+		return err
+	}
+	var infoPlist map[string]interface{}
+	if _, err := plist.Unmarshal(content, &infoPlist); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", infoPlistPath, err)
+	}
+
+	iconFiles, err := appIconFiles(appPath, infoPlist)
+	if err != nil {
+		return err
+	}
+	if len(iconFiles) == 0 {
+		s.logger.Warnf("No app icon file found in the app bundle (the icon is only in the compiled asset catalog), the app icon is kept as is")
+		return nil
+	}
+
+	for _, pth := range iconFiles {
+		icon, err := s.decodeIconPNG(pth)
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %w", pth, err)
+		}
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, badgeIcon(icon, text)); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", pth, err)
+		}
+		if err := os.WriteFile(pth, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+		s.logger.Printf("- %s", filepath.Base(pth))
+	}
+
+	return updateInfoPlist(infoPlistPath, func(infoPlist map[string]interface{}) {
+		delete(infoPlist, "CFBundleIconName")
+		for _, primaryIcon := range primaryIconDicts(infoPlist) {
+			delete(primaryIcon, "CFBundleIconName")
+		}
+	})
+}
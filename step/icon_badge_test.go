@@ -0,0 +1,88 @@
+package step
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseIconBadge(t *testing.T) {
+	text, err := parseIconBadge(" qa 1.2 (4) ")
+	require.NoError(t, err)
+	require.Equal(t, "QA 1.2 (4)", text)
+
+	text, err = parseIconBadge("")
+	require.NoError(t, err)
+	require.Equal(t, "", text)
+
+	_, err = parseIconBadge("STAGING-BUILD")
+	require.Error(t, err)
+	_, err = parseIconBadge("QA!")
+	require.EqualError(t, err, `unsupported character in the badge text: '!'`)
+}
+
+func Test_iconBadgeGlyphs(t *testing.T) {
+	for r, glyph := range iconBadgeGlyphs {
+		for _, row := range glyph {
+			require.Len(t, row, iconBadgeGlyphWidth, "glyph: %q", r)
+		}
+	}
+}
+
+func Test_badgeIcon(t *testing.T) {
+	icon := image.NewRGBA(image.Rect(0, 0, 120, 120))
+	blue := color.RGBA{B: 0xFF, A: 0xFF}
+	for x := 0; x < 120; x++ {
+		for y := 0; y < 120; y++ {
+			icon.SetRGBA(x, y, blue)
+		}
+	}
+
+	badged := badgeIcon(icon, "QA")
+	require.Equal(t, icon.Bounds(), badged.Bounds())
+	require.Equal(t, blue, badged.RGBAAt(60, 10), "the top of the icon is kept")
+	require.NotEqual(t, blue, badged.RGBAAt(2, 118), "the band covers the bottom of the icon")
+
+	white := 0
+	for x := 0; x < 120; x++ {
+		for y := 84; y < 120; y++ {
+			if badged.RGBAAt(x, y) == (color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}) {
+				white++
+			}
+		}
+	}
+	require.NotZero(t, white, "the text is drawn on the band")
+}
+
+func Test_appIconFiles(t *testing.T) {
+	appPath := t.TempDir()
+	for _, name := range []string{"AppIcon60x60@2x.png", "AppIcon60x60@3x.png", "AppIcon76x76@2x~ipad.png", "Launch.png"} {
+		require.NoError(t, os.WriteFile(filepath.Join(appPath, name), []byte{}, 0644))
+	}
+	infoPlist := map[string]interface{}{
+		"CFBundleIcons": map[string]interface{}{
+			"CFBundlePrimaryIcon": map[string]interface{}{"CFBundleIconFiles": []interface{}{"AppIcon60x60"}, "CFBundleIconName": "AppIcon"},
+		},
+		"CFBundleIcons~ipad": map[string]interface{}{
+			"CFBundlePrimaryIcon": map[string]interface{}{"CFBundleIconFiles": []interface{}{"AppIcon60x60", "AppIcon76x76"}, "CFBundleIconName": "AppIcon"},
+		},
+	}
+
+	files, err := appIconFiles(appPath, infoPlist)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(appPath, "AppIcon60x60@2x.png"),
+		filepath.Join(appPath, "AppIcon60x60@3x.png"),
+		filepath.Join(appPath, "AppIcon76x76@2x~ipad.png"),
+	}, files)
+	require.Len(t, primaryIconDicts(infoPlist), 2)
+}
+
+func Test_isCgBIPNG(t *testing.T) {
+	require.True(t, isCgBIPNG([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x04CgBI\x50\x00\x20\x02")))
+	require.False(t, isCgBIPNG([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR\x00\x00\x00\x78")))
+}
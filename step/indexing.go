@@ -0,0 +1,42 @@
+package step
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	indexingDefault  = "default"
+	indexingDisabled = "disabled"
+	indexingEnabled  = "enabled"
+)
+
+// indexingBuildSettings are the build settings controlling the index-while-building functionality:
+// the clang and swift compilers' index store emission and the build system's index data store.
+var indexingBuildSettings = []string{"COMPILER_INDEX_STORE_ENABLE", "INDEX_ENABLE_DATA_STORE"}
+
+// indexingXcodebuildOptions returns the build settings of the indexing mode, passed as xcodebuild options,
+// so they also apply to the Swift package targets, which don't use the project's or the xcconfig's build settings.
+// An error is returned if an indexing build setting is already set in the xcodebuild options.
+func indexingXcodebuildOptions(indexing string, xcodebuildOptions []string) ([]string, error) {
+	var value string
+	switch indexing {
+	case indexingDisabled:
+		value = "NO"
+	case indexingEnabled:
+		value = "YES"
+	default:
+		return nil, nil
+	}
+
+	var options []string
+	for _, setting := range indexingBuildSettings {
+		for _, option := range xcodebuildOptions {
+			if strings.HasPrefix(option, setting+"=") {
+				return nil, fmt.Errorf("`%s` build setting found in XcodebuildOptions (`xcodebuild_options`), please set Indexing (`indexing`) input to %s as only one can be set", setting, indexingDefault)
+			}
+		}
+		options = append(options, setting+"="+value)
+	}
+	return options, nil
+}
@@ -0,0 +1,24 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_indexingXcodebuildOptions(t *testing.T) {
+	options, err := indexingXcodebuildOptions(indexingDefault, []string{"COMPILER_INDEX_STORE_ENABLE=YES"})
+	require.NoError(t, err)
+	require.Nil(t, options)
+
+	options, err = indexingXcodebuildOptions(indexingDisabled, []string{"-quiet"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"COMPILER_INDEX_STORE_ENABLE=NO", "INDEX_ENABLE_DATA_STORE=NO"}, options)
+
+	options, err = indexingXcodebuildOptions(indexingEnabled, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"COMPILER_INDEX_STORE_ENABLE=YES", "INDEX_ENABLE_DATA_STORE=YES"}, options)
+
+	_, err = indexingXcodebuildOptions(indexingDisabled, []string{"INDEX_ENABLE_DATA_STORE=YES"})
+	require.EqualError(t, err, "`INDEX_ENABLE_DATA_STORE` build setting found in XcodebuildOptions (`xcodebuild_options`), please set Indexing (`indexing`) input to default as only one can be set")
+}
@@ -0,0 +1,190 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"howett.net/plist"
+)
+
+const (
+	infoPlistValueTypeString  = "string"
+	infoPlistValueTypeBool    = "bool"
+	infoPlistValueTypeInteger = "integer"
+
+	codesignAdHocIdentity = "-"
+)
+
+// infoPlistProtectedKeys can't be overridden, as the provisioning profiles and the export are bound to them.
+var infoPlistProtectedKeys = []string{"CFBundleIdentifier", "CFBundleExecutable", "CFBundlePackageType"}
+
+// InfoPlistOverride is an Info.plist key set in the archived app.
+type InfoPlistOverride struct {
+	Key   string
+	Value interface{}
+}
+
+// parseInfoPlistOverrides parses the newline separated list of Key=Value or Key:type=Value Info.plist overrides,
+// the type is string (the default), bool or integer.
+func parseInfoPlistOverrides(list string) ([]InfoPlistOverride, error) {
+	var overrides []InfoPlistOverride
+	keys := map[string]bool{}
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		keyAndType, value, found := strings.Cut(line, "=")
+		key, valueType, typed := strings.Cut(strings.TrimSpace(keyAndType), ":")
+		if !found || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("invalid Info.plist override, expected Key=Value or Key:type=Value format: %s", line)
+		}
+		for _, protected := range infoPlistProtectedKeys {
+			if key == protected {
+				return nil, fmt.Errorf("the %s Info.plist key can not be overridden", key)
+			}
+		}
+		if keys[key] {
+			return nil, fmt.Errorf("Info.plist key set multiple times: %s", key)
+		}
+		keys[key] = true
+
+		if !typed {
+			valueType = infoPlistValueTypeString
+		}
+		typedValue, err := infoPlistValue(valueType, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of the %s Info.plist override: %w", key, err)
+		}
+		overrides = append(overrides, InfoPlistOverride{Key: key, Value: typedValue})
+	}
+	return overrides, nil
+}
+
+func infoPlistValue(valueType, value string) (interface{}, error) {
+	switch valueType {
+	case infoPlistValueTypeString:
+		return value, nil
+	case infoPlistValueTypeBool:
+		switch strings.ToLower(value) {
+		case "yes", "true":
+			return true, nil
+		case "no", "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("expected yes, no, true or false: %s", value)
+	case infoPlistValueTypeInteger:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected an integer: %s", value)
+		}
+		return i, nil
+	default:
+		return nil, fmt.Errorf("unknown type, expected %s, %s or %s: %s", infoPlistValueTypeString, infoPlistValueTypeBool, infoPlistValueTypeInteger, valueType)
+	}
+}
+
+// appInfoPlistPath returns the Info.plist of an iOS (flat) or a macOS (Contents dir) app bundle.
+func appInfoPlistPath(appPath string) string {
+	if pth := filepath.Join(appPath, "Contents", "Info.plist"); isRegularFile(pth) {
+		return pth
+	}
+	return filepath.Join(appPath, "Info.plist")
+}
+
+func isRegularFile(pth string) bool {
+	info, err := os.Stat(pth)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// updateInfoPlist modifies the Info.plist, keeping its format (the built app's Info.plist is usually binary).
+func updateInfoPlist(infoPlistPath string, update func(infoPlist map[string]interface{})) error {
+	content, err := os.ReadFile(infoPlistPath)
+	if err != nil {
+		return err
+	}
+	var infoPlist map[string]interface{}
+	format, err := plist.Unmarshal(content, &infoPlist)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", infoPlistPath, err)
+	}
+
+	update(infoPlist)
+
+	content, err = plist.Marshal(infoPlist, format)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", infoPlistPath, err)
+	}
+	info, err := os.Stat(infoPlistPath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(infoPlistPath, content, info.Mode().Perm())
+}
+
+// applyInfoPlistOverrides sets the keys in the Info.plist.
+func applyInfoPlistOverrides(infoPlistPath string, overrides []InfoPlistOverride) error {
+	return updateInfoPlist(infoPlistPath, func(infoPlist map[string]interface{}) {
+		for _, override := range overrides {
+			infoPlist[override.Key] = override.Value
+		}
+	})
+}
+
+// signingIdentity returns the identity the code is signed with: the common name of its leaf certificate, or - if it is ad-hoc signed.
+func signingIdentity(cmdFactory command.Factory, pth string) (string, error) {
+	cmd := cmdFactory.Create("codesign", []string{"-d", "--verbose=2", pth}, nil)
+	out, err := cmd.RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the signature of %s: %s: %w", pth, out, err)
+	}
+	return parseSigningIdentity(out)
+}
+
+func parseSigningIdentity(codesignOutput string) (string, error) {
+	for _, line := range strings.Split(codesignOutput, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "Signature=adhoc" {
+			return codesignAdHocIdentity, nil
+		}
+		if authority, found := strings.CutPrefix(line, "Authority="); found {
+			return authority, nil
+		}
+	}
+	return "", fmt.Errorf("no signing identity found")
+}
+
+// overrideAppInfoPlist sets the Info.plist keys of the app, the nested bundles are not modified.
+func (s XcodebuildArchiver) overrideAppInfoPlist(appPath string, overrides []InfoPlistOverride) error {
+	s.logger.Println()
+	s.logger.Infof("Overriding the Info.plist keys of the archived app")
+	for _, override := range overrides {
+		s.logger.Printf("- %s: %v", override.Key, override.Value)
+	}
+	return applyInfoPlistOverrides(appInfoPlistPath(appPath), overrides)
+}
+
+// modifyArchivedApp runs the modification of the archived app, then re-signs the app with its own identity,
+// entitlements and flags, as modifying the app's resources breaks its signature.
+func (s XcodebuildArchiver) modifyArchivedApp(appPath string, modify func() error) error {
+	identity, err := signingIdentity(s.cmdFactory, appPath)
+	if err != nil {
+		return err
+	}
+
+	if err := modify(); err != nil {
+		return err
+	}
+
+	args := []string{"--force", "--sign", identity, "--preserve-metadata=identifier,entitlements,flags,runtime", "--generate-entitlement-der", "--timestamp=none", appPath}
+	cmd := s.cmdFactory.Create("codesign", args, nil)
+	if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		return fmt.Errorf("failed to re-sign %s: %s: %w", appPath, out, err)
+	}
+	s.logger.Donef("The app is re-signed with: %s", identity)
+	return nil
+}
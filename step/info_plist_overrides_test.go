@@ -0,0 +1,81 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"howett.net/plist"
+)
+
+func Test_parseInfoPlistOverrides(t *testing.T) {
+	overrides, err := parseInfoPlistOverrides("GitCommitHash=a1b2c3\n\n APIBaseURL=https://example.com/?a=b\nNewOnboarding:bool=yes\nBuildNumber:integer=42\nEmpty=\n")
+	require.NoError(t, err)
+	require.Equal(t, []InfoPlistOverride{
+		{Key: "GitCommitHash", Value: "a1b2c3"},
+		{Key: "APIBaseURL", Value: "https://example.com/?a=b"},
+		{Key: "NewOnboarding", Value: true},
+		{Key: "BuildNumber", Value: int64(42)},
+		{Key: "Empty", Value: ""},
+	}, overrides)
+
+	_, err = parseInfoPlistOverrides("GitCommitHash")
+	require.Error(t, err)
+	_, err = parseInfoPlistOverrides("NewOnboarding:bool=maybe")
+	require.Error(t, err)
+	_, err = parseInfoPlistOverrides("BuildNumber:date=42")
+	require.Error(t, err)
+	_, err = parseInfoPlistOverrides("CFBundleIdentifier=io.bitrise.other")
+	require.EqualError(t, err, "the CFBundleIdentifier Info.plist key can not be overridden")
+	_, err = parseInfoPlistOverrides("A=1\nA=2")
+	require.EqualError(t, err, "Info.plist key set multiple times: A")
+}
+
+func Test_applyInfoPlistOverrides(t *testing.T) {
+	for _, format := range []int{plist.BinaryFormat, plist.XMLFormat} {
+		pth := filepath.Join(t.TempDir(), "Info.plist")
+		content, err := plist.Marshal(map[string]interface{}{"CFBundleIdentifier": "io.bitrise.app", "GitCommitHash": "old"}, format)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(pth, content, 0644))
+
+		require.NoError(t, applyInfoPlistOverrides(pth, []InfoPlistOverride{{Key: "GitCommitHash", Value: "a1b2c3"}, {Key: "NewOnboarding", Value: true}}))
+
+		content, err = os.ReadFile(pth)
+		require.NoError(t, err)
+		var infoPlist map[string]interface{}
+		parsedFormat, err := plist.Unmarshal(content, &infoPlist)
+		require.NoError(t, err)
+		require.Equal(t, format, parsedFormat)
+		require.Equal(t, map[string]interface{}{"CFBundleIdentifier": "io.bitrise.app", "GitCommitHash": "a1b2c3", "NewOnboarding": true}, infoPlist)
+	}
+}
+
+func Test_appInfoPlistPath(t *testing.T) {
+	iosApp := t.TempDir()
+	require.Equal(t, filepath.Join(iosApp, "Info.plist"), appInfoPlistPath(iosApp))
+
+	macApp := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(macApp, "Contents"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(macApp, "Contents", "Info.plist"), []byte{}, 0644))
+	require.Equal(t, filepath.Join(macApp, "Contents", "Info.plist"), appInfoPlistPath(macApp))
+}
+
+func Test_parseSigningIdentity(t *testing.T) {
+	identity, err := parseSigningIdentity(`Executable=/tmp/Sample.app/Sample
+Identifier=io.bitrise.Sample
+Format=app bundle with Mach-O thin (arm64)
+Authority=Apple Distribution: Bitrise Inc (72SA8V3WYL)
+Authority=Apple Worldwide Developer Relations Certification Authority
+Authority=Apple Root CA
+TeamIdentifier=72SA8V3WYL`)
+	require.NoError(t, err)
+	require.Equal(t, "Apple Distribution: Bitrise Inc (72SA8V3WYL)", identity)
+
+	identity, err = parseSigningIdentity("Executable=/tmp/Sample.app/Sample\nSignature=adhoc\nTeamIdentifier=not set")
+	require.NoError(t, err)
+	require.Equal(t, "-", identity)
+
+	_, err = parseSigningIdentity("code object is not signed at all")
+	require.Error(t, err)
+}
@@ -0,0 +1,102 @@
+package step
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-steputils/v2/stepconf"
+)
+
+const (
+	inlineCertificateFilenameFormat = "certificate_%d.p12"
+	inlineAPIKeyFilename            = "api_key.p8"
+)
+
+// decodeBase64Secret decodes base64 content, ignoring the whitespace and line breaks added when wrapping the encoded content.
+func decodeBase64Secret(content string) ([]byte, error) {
+	content = strings.Join(strings.Fields(content), "")
+	decoded, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 content: %w", err)
+	}
+	if len(decoded) == 0 {
+		return nil, fmt.Errorf("empty content")
+	}
+	return decoded, nil
+}
+
+// writeSecretFile writes the secret content to a new file, readable only by the current user.
+func writeSecretFile(pth string, content []byte) error {
+	f, err := os.OpenFile(pth, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(content); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeInlineSecrets decodes the base64 certificate and API key inputs to files in a private temp dir
+// and points the certificate URL list and API key path inputs to them, so the secret content is never passed on the command line.
+// The files are removed by CleanupInlineSecrets.
+func writeInlineSecrets(config *Config) error {
+	if config.CertificateBase64List == "" && config.APIKeyBase64 == "" {
+		return nil
+	}
+	if config.APIKeyBase64 != "" && config.APIKeyPath != "" {
+		return fmt.Errorf("both App Store Connect API private key (`api_key_path`) and App Store Connect API private key content (`api_key_base64`) inputs are set, only one can be set")
+	}
+
+	dir, err := os.MkdirTemp("", "inlineSecrets")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	config.InlineSecretsDir = dir
+
+	var certificateURLs []string
+	if strings.TrimSpace(config.CertificateURLList) != "" {
+		certificateURLs = append(certificateURLs, config.CertificateURLList)
+	}
+	for i, content := range strings.Split(string(config.CertificateBase64List), "|") {
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		certificate, err := decodeBase64Secret(content)
+		if err != nil {
+			return fmt.Errorf("issue with input CertificateBase64List, certificate #%d: %w", i+1, err)
+		}
+		pth := filepath.Join(dir, fmt.Sprintf(inlineCertificateFilenameFormat, i+1))
+		if err := writeSecretFile(pth, certificate); err != nil {
+			return fmt.Errorf("failed to write certificate: %w", err)
+		}
+		certificateURLs = append(certificateURLs, "file://"+pth)
+	}
+	config.CertificateURLList = strings.Join(certificateURLs, "|")
+
+	if config.APIKeyBase64 != "" {
+		key, err := decodeBase64Secret(string(config.APIKeyBase64))
+		if err != nil {
+			return fmt.Errorf("issue with input APIKeyBase64: %w", err)
+		}
+		pth := filepath.Join(dir, inlineAPIKeyFilename)
+		if err := writeSecretFile(pth, key); err != nil {
+			return fmt.Errorf("failed to write App Store Connect API private key: %w", err)
+		}
+		config.APIKeyPath = stepconf.Secret(pth)
+	}
+
+	return nil
+}
+
+// CleanupInlineSecrets removes the files of the decoded certificate and API key inputs.
+func CleanupInlineSecrets(config Config) error {
+	if config.InlineSecretsDir == "" {
+		return nil
+	}
+	return os.RemoveAll(config.InlineSecretsDir)
+}
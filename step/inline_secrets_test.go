@@ -0,0 +1,67 @@
+package step
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/go-steputils/v2/stepconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeBase64Secret(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte("certificate content"))
+	wrapped := encoded[:8] + "\n" + encoded[8:] + "\n"
+
+	decoded, err := decodeBase64Secret(wrapped)
+	require.NoError(t, err)
+	require.Equal(t, "certificate content", string(decoded))
+
+	_, err = decodeBase64Secret("not base64!")
+	require.Error(t, err)
+}
+
+func TestWriteInlineSecrets(t *testing.T) {
+	config := Config{Inputs: Inputs{
+		CertificateURLList:    "https://example.com/cert.p12",
+		CertificateBase64List: stepconf.Secret(base64.StdEncoding.EncodeToString([]byte("p12")) + "|" + base64.StdEncoding.EncodeToString([]byte("other p12"))),
+		APIKeyBase64:          stepconf.Secret(base64.StdEncoding.EncodeToString([]byte("p8"))),
+	}}
+
+	require.NoError(t, writeInlineSecrets(&config))
+	require.NotEmpty(t, config.InlineSecretsDir)
+
+	urls := strings.Split(config.CertificateURLList, "|")
+	require.Equal(t, 3, len(urls))
+	require.Equal(t, "https://example.com/cert.p12", urls[0])
+	for i, expected := range []string{"p12", "other p12"} {
+		pth := strings.TrimPrefix(urls[i+1], "file://")
+		content, err := os.ReadFile(pth)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(content))
+
+		info, err := os.Stat(pth)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	require.Equal(t, filepath.Join(config.InlineSecretsDir, inlineAPIKeyFilename), string(config.APIKeyPath))
+	content, err := os.ReadFile(string(config.APIKeyPath))
+	require.NoError(t, err)
+	require.Equal(t, "p8", string(content))
+
+	require.NoError(t, CleanupInlineSecrets(config))
+	_, err = os.Stat(config.InlineSecretsDir)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestWriteInlineSecrets_conflictingAPIKeyInputs(t *testing.T) {
+	config := Config{Inputs: Inputs{
+		APIKeyPath:   "key.p8",
+		APIKeyBase64: stepconf.Secret(base64.StdEncoding.EncodeToString([]byte("p8"))),
+	}}
+	require.Error(t, writeInlineSecrets(&config))
+	require.Empty(t, config.InlineSecretsDir)
+}
@@ -0,0 +1,167 @@
+package step
+
+import (
+	archivezip "archive/zip"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IPA content policies
+const (
+	ipaContentPolicyOff  = "off"
+	ipaContentPolicyWarn = "warn"
+	ipaContentPolicyFail = "fail"
+)
+
+// defaultIPAContentDenyPatterns are the contents App Review rejects or which have no place in a release ipa,
+// used if no deny patterns are given.
+var defaultIPAContentDenyPatterns = []string{
+	"**/*.framework/**/*.mobileprovision",
+	"*.dSYM",
+	"*.bcsymbolmap",
+	"Headers",
+	"PrivateHeaders",
+	"*.swiftmodule",
+	"*.a",
+}
+
+type ipaContentViolation struct {
+	Path    string
+	Pattern string
+}
+
+// validateIPAContentDenyPatterns checks the syntax of the deny patterns.
+func validateIPAContentDenyPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		for _, segment := range strings.Split(pattern, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return fmt.Errorf("invalid pattern (%s): %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// matchIPAContentPattern matches the path relative to the app bundle against the glob pattern, ** matches any number of directories
+// and a pattern without a slash matches the name of the file or directory at any depth.
+func matchIPAContentPattern(pattern, pth string) bool {
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	return matchPathSegments(strings.Split(pattern, "/"), strings.Split(pth, "/"))
+}
+
+func matchPathSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchPathSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// findIPAContentViolations returns the contents of the main application (and the nested bundles) matching the deny patterns,
+// a matching directory is reported once, instead of every file in it.
+func findIPAContentViolations(files []string, patterns []string) []ipaContentViolation {
+	var violations []ipaContentViolation
+	reported := map[string]bool{}
+	for _, file := range files {
+		// Payload/<name>.app/<path>
+		components := strings.Split(strings.TrimSuffix(file, "/"), "/")
+		if len(components) < 3 || components[0] != "Payload" || path.Ext(components[1]) != ".app" {
+			continue
+		}
+		rel := components[2:]
+
+		for i := 1; i <= len(rel); i++ {
+			pth := strings.Join(rel[:i], "/")
+			if reported[pth] {
+				break
+			}
+			matched := ""
+			for _, pattern := range patterns {
+				if matchIPAContentPattern(pattern, pth) {
+					matched = pattern
+					break
+				}
+			}
+			if matched != "" {
+				reported[pth] = true
+				violations = append(violations, ipaContentViolation{Path: pth, Pattern: matched})
+				break
+			}
+		}
+	}
+	return violations
+}
+
+func listIPAFiles(ipaPath string) ([]string, error) {
+	reader, err := archivezip.OpenReader(ipaPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ipa: %w", err)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	var files []string
+	for _, file := range reader.File {
+		files = append(files, file.Name)
+	}
+	return files, nil
+}
+
+// checkIPAContentPolicy scans the exported ipa for the contents matching the deny patterns, the matches fail the Step
+// with the fail policy, otherwise they are reported as a warning.
+func (s XcodebuildArchiver) checkIPAContentPolicy(ipaExportDir, policy string, patterns []string) error {
+	s.logger.Println()
+	s.logger.Infof("Scanning the exported ipa for disallowed content...")
+
+	ipaPaths, err := filepath.Glob(filepath.Join(ipaExportDir, "*.ipa"))
+	if err != nil {
+		return err
+	}
+	if len(ipaPaths) == 0 {
+		s.logger.Printf("No .ipa file found at export dir: %s", ipaExportDir)
+		return nil
+	}
+
+	if len(patterns) == 0 {
+		patterns = defaultIPAContentDenyPatterns
+	}
+	files, err := listIPAFiles(ipaPaths[0])
+	if err != nil {
+		return err
+	}
+
+	violations := findIPAContentViolations(files, patterns)
+	if len(violations) == 0 {
+		s.logger.Donef("The ipa contains no disallowed content")
+		return nil
+	}
+
+	var details []string
+	for _, violation := range violations {
+		details = append(details, fmt.Sprintf("%s (%s)", violation.Path, violation.Pattern))
+	}
+	err = fmt.Errorf("the ipa contains disallowed content: %s", strings.Join(details, ", "))
+	if policy == ipaContentPolicyFail {
+		return err
+	}
+	s.logger.Warnf("%s", err)
+	return nil
+}
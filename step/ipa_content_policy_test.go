@@ -0,0 +1,41 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_matchIPAContentPattern(t *testing.T) {
+	require.True(t, matchIPAContentPattern("Headers", "Frameworks/Vendor.framework/Headers"))
+	require.True(t, matchIPAContentPattern("*.dSYM", "Sample.app.dSYM"))
+	require.True(t, matchIPAContentPattern("**/*.framework/**/*.mobileprovision", "Frameworks/Vendor.framework/embedded.mobileprovision"))
+	require.True(t, matchIPAContentPattern("**/*.framework/**/*.mobileprovision", "PlugIns/Widget.appex/Frameworks/Vendor.framework/Resources/other.mobileprovision"))
+	require.True(t, matchIPAContentPattern("Frameworks/libVendor.dylib", "Frameworks/libVendor.dylib"))
+
+	require.False(t, matchIPAContentPattern("**/*.framework/**/*.mobileprovision", "embedded.mobileprovision"))
+	require.False(t, matchIPAContentPattern("Frameworks/libVendor.dylib", "PlugIns/Widget.appex/Frameworks/libVendor.dylib"))
+}
+
+func Test_findIPAContentViolations(t *testing.T) {
+	files := []string{
+		"Payload/",
+		"Payload/Sample.app/Info.plist",
+		"Payload/Sample.app/embedded.mobileprovision",
+		"Payload/Sample.app/Frameworks/Vendor.framework/Vendor",
+		"Payload/Sample.app/Frameworks/Vendor.framework/embedded.mobileprovision",
+		"Payload/Sample.app/Frameworks/Vendor.framework/Headers/Vendor.h",
+		"Payload/Sample.app/Frameworks/Vendor.framework/Headers/Vendor-Swift.h",
+		"Symbols/A1B2.symbols",
+	}
+
+	require.Equal(t, []ipaContentViolation{
+		{Path: "Frameworks/Vendor.framework/embedded.mobileprovision", Pattern: "**/*.framework/**/*.mobileprovision"},
+		{Path: "Frameworks/Vendor.framework/Headers", Pattern: "Headers"},
+	}, findIPAContentViolations(files, defaultIPAContentDenyPatterns))
+}
+
+func Test_validateIPAContentDenyPatterns(t *testing.T) {
+	require.NoError(t, validateIPAContentDenyPatterns(defaultIPAContentDenyPatterns))
+	require.EqualError(t, validateIPAContentDenyPatterns([]string{"Frameworks/[.framework"}), "invalid pattern (Frameworks/[.framework): syntax error in pattern")
+}
@@ -0,0 +1,151 @@
+package step
+
+import (
+	archivezip "archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/bitrise-io/go-xcode/plistutil"
+	"github.com/bitrise-io/go-xcode/profileutil"
+)
+
+const (
+	bitriseAppBundleIDEnvKey     = "BITRISE_APP_BUNDLE_ID"
+	bitriseAppVersionEnvKey      = "BITRISE_APP_VERSION"
+	bitriseAppBuildEnvKey        = "BITRISE_APP_BUILD"
+	bitriseAppMinOSEnvKey        = "BITRISE_APP_MIN_OS"
+	bitriseAppDisplayNameEnvKey  = "BITRISE_APP_DISPLAY_NAME"
+	bitriseAppTeamIDEnvKey       = "BITRISE_APP_TEAM_ID"
+	bitriseAppEntitlementsEnvKey = "BITRISE_APP_ENTITLEMENTS"
+)
+
+type ipaMetadata struct {
+	BundleID     string
+	Version      string
+	Build        string
+	MinOSVersion string
+	DisplayName  string
+	TeamID       string
+	Entitlements plistutil.PlistData
+
+	ExportMethod       exportoptions.Method
+	ProvisionedDevices []string
+}
+
+// readIPAMetadata reads the main application's Info.plist and embedded provisioning profile from the given .ipa file.
+func readIPAMetadata(ipaPath string) (ipaMetadata, error) {
+	reader, err := archivezip.OpenReader(ipaPath)
+	if err != nil {
+		return ipaMetadata{}, fmt.Errorf("failed to open ipa: %w", err)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	var infoPlistContent, profileContent []byte
+	for _, file := range reader.File {
+		// Only the main application's files are considered: Payload/<name>.app/<file>
+		components := strings.Split(file.Name, "/")
+		if len(components) != 3 || components[0] != "Payload" || path.Ext(components[1]) != ".app" {
+			continue
+		}
+
+		switch components[2] {
+		case "Info.plist":
+			infoPlistContent, err = readZipFile(file)
+		case "embedded.mobileprovision":
+			profileContent, err = readZipFile(file)
+		}
+		if err != nil {
+			return ipaMetadata{}, fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+	}
+
+	if infoPlistContent == nil {
+		return ipaMetadata{}, fmt.Errorf("no application Info.plist found in ipa")
+	}
+
+	infoPlist, err := plistutil.NewPlistDataFromContent(string(infoPlistContent))
+	if err != nil {
+		return ipaMetadata{}, fmt.Errorf("failed to parse Info.plist: %w", err)
+	}
+
+	metadata := ipaMetadata{}
+	metadata.BundleID, _ = infoPlist.GetString("CFBundleIdentifier")
+	metadata.Version, _ = infoPlist.GetString("CFBundleShortVersionString")
+	metadata.Build, _ = infoPlist.GetString("CFBundleVersion")
+	metadata.MinOSVersion, _ = infoPlist.GetString("MinimumOSVersion")
+	metadata.DisplayName, _ = infoPlist.GetString("CFBundleDisplayName")
+	if metadata.DisplayName == "" {
+		metadata.DisplayName, _ = infoPlist.GetString("CFBundleName")
+	}
+
+	if profileContent != nil {
+		profile, err := profileutil.ProvisioningProfileFromContent(profileContent)
+		if err != nil {
+			return ipaMetadata{}, fmt.Errorf("failed to parse embedded.mobileprovision: %w", err)
+		}
+		profileInfo, err := profileutil.NewProvisioningProfileInfo(*profile)
+		if err != nil {
+			return ipaMetadata{}, fmt.Errorf("failed to parse embedded.mobileprovision: %w", err)
+		}
+
+		metadata.TeamID = profileInfo.TeamID
+		metadata.Entitlements = profileInfo.Entitlements
+		metadata.ExportMethod = profileInfo.ExportType
+		metadata.ProvisionedDevices = profileInfo.ProvisionedDevices
+	}
+
+	return metadata, nil
+}
+
+func readZipFile(file *archivezip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	return io.ReadAll(rc)
+}
+
+func exportIPAMetadata(cmdFactory command.Factory, outputEnvKeys OutputEnvKeys, metadata ipaMetadata, logger log.Logger) error {
+	entitlements := ""
+	if len(metadata.Entitlements) > 0 {
+		content, err := json.Marshal(metadata.Entitlements)
+		if err != nil {
+			return fmt.Errorf("failed to marshal entitlements: %w", err)
+		}
+		entitlements = string(content)
+	}
+
+	outputs := []struct {
+		key   string
+		value string
+	}{
+		{bitriseAppBundleIDEnvKey, metadata.BundleID},
+		{bitriseAppVersionEnvKey, metadata.Version},
+		{bitriseAppBuildEnvKey, metadata.Build},
+		{bitriseAppMinOSEnvKey, metadata.MinOSVersion},
+		{bitriseAppDisplayNameEnvKey, metadata.DisplayName},
+		{bitriseAppTeamIDEnvKey, metadata.TeamID},
+		{bitriseAppEntitlementsEnvKey, entitlements},
+	}
+
+	for _, output := range outputs {
+		if err := exportEnvironmentWithEnvman(cmdFactory, outputEnvKeys, output.key, output.value); err != nil {
+			return fmt.Errorf("failed to export %s: %w", outputEnvKeys.key(output.key), err)
+		}
+		logger.Printf("- %s: %s", outputEnvKeys.key(output.key), output.value)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package step
+
+import (
+	archivezip "archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testIPAInfoPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleIdentifier</key>
+	<string>io.bitrise.sample</string>
+	<key>CFBundleShortVersionString</key>
+	<string>1.2.3</string>
+	<key>CFBundleVersion</key>
+	<string>42</string>
+	<key>MinimumOSVersion</key>
+	<string>15.0</string>
+	<key>CFBundleName</key>
+	<string>Sample</string>
+</dict>
+</plist>`
+
+func Test_readIPAMetadata(t *testing.T) {
+	ipaPath := filepath.Join(t.TempDir(), "sample.ipa")
+	createTestIPA(t, ipaPath, map[string]string{
+		"Payload/Sample.app/Info.plist":                      testIPAInfoPlist,
+		"Payload/Sample.app/PlugIns/Widget.appex/Info.plist": "invalid",
+	})
+
+	metadata, err := readIPAMetadata(ipaPath)
+	require.NoError(t, err)
+	require.Equal(t, ipaMetadata{
+		BundleID:     "io.bitrise.sample",
+		Version:      "1.2.3",
+		Build:        "42",
+		MinOSVersion: "15.0",
+		DisplayName:  "Sample",
+	}, metadata)
+}
+
+func Test_readIPAMetadata_MissingInfoPlist(t *testing.T) {
+	ipaPath := filepath.Join(t.TempDir(), "sample.ipa")
+	createTestIPA(t, ipaPath, map[string]string{
+		"Payload/Sample.app/Sample": "binary",
+	})
+
+	_, err := readIPAMetadata(ipaPath)
+	require.Error(t, err)
+}
+
+func createTestIPA(t *testing.T, pth string, files map[string]string) {
+	f, err := os.Create(pth)
+	require.NoError(t, err)
+
+	w := archivezip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+}
@@ -0,0 +1,228 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-xcode/plistutil"
+	"github.com/bitrise-io/go-xcode/profileutil"
+	"howett.net/plist"
+)
+
+const (
+	bitriseResignedIPAPthEnvKey = "BITRISE_RESIGNED_IPA_PATH"
+	resignedIPAArtifactSuffix   = "-resigned"
+
+	teamIdentifierEntitlementKey        = "com.apple.developer.team-identifier"
+	applicationIdentifierEntitlementKey = "application-identifier"
+	keychainAccessGroupsEntitlementKey  = "keychain-access-groups"
+	getTaskAllowEntitlementKey          = "get-task-allow"
+	apsEnvironmentEntitlementKey        = "aps-environment"
+)
+
+type resignProfile struct {
+	Path string
+	Info profileutil.ProvisioningProfileInfoModel
+}
+
+func readResignProfiles(pths []string) ([]resignProfile, error) {
+	var profiles []resignProfile
+	for _, pth := range pths {
+		info, err := profileutil.NewProvisioningProfileInfoFromFile(pth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse provisioning profile (%s): %w", pth, err)
+		}
+		profiles = append(profiles, resignProfile{Path: pth, Info: info})
+	}
+	return profiles, nil
+}
+
+// findResignProfile returns the profile of the bundle ID, preferring explicit profiles to wildcard ones.
+func findResignProfile(bundleID string, profiles []resignProfile) (resignProfile, bool) {
+	var wildcard *resignProfile
+	for i, profile := range profiles {
+		if !profileBundleIDMatches(profile.Info.BundleID, bundleID) {
+			continue
+		}
+		if profile.Info.BundleID == bundleID {
+			return profile, true
+		}
+		if wildcard == nil {
+			wildcard = &profiles[i]
+		}
+	}
+	if wildcard != nil {
+		return *wildcard, true
+	}
+	return resignProfile{}, false
+}
+
+// resignEntitlements returns the entitlements of a bundle re-signed with the given profile:
+// the entitlements not granted by the profile are dropped, the team and environment specific values are taken from the profile.
+func resignEntitlements(signed, profileEntitlements plistutil.PlistData, teamID, bundleID string) plistutil.PlistData {
+	oldTeamID, _ := signed[teamIdentifierEntitlementKey].(string)
+
+	entitlements := plistutil.PlistData{}
+	for key, value := range signed {
+		if _, granted := profileEntitlements[key]; granted {
+			entitlements[key] = value
+		}
+	}
+
+	entitlements[applicationIdentifierEntitlementKey] = teamID + "." + bundleID
+	entitlements[teamIdentifierEntitlementKey] = teamID
+	if value, ok := profileEntitlements[getTaskAllowEntitlementKey]; ok {
+		entitlements[getTaskAllowEntitlementKey] = value
+	}
+	if _, ok := entitlements[apsEnvironmentEntitlementKey]; ok {
+		entitlements[apsEnvironmentEntitlementKey] = profileEntitlements[apsEnvironmentEntitlementKey]
+	}
+
+	if groups, ok := entitlements[keychainAccessGroupsEntitlementKey].([]interface{}); ok && oldTeamID != "" {
+		var resigned []interface{}
+		for _, group := range groups {
+			if s, ok := group.(string); ok && strings.HasPrefix(s, oldTeamID+".") {
+				group = teamID + strings.TrimPrefix(s, oldTeamID)
+			}
+			resigned = append(resigned, group)
+		}
+		entitlements[keychainAccessGroupsEntitlementKey] = resigned
+	}
+
+	return entitlements
+}
+
+// resignOrder returns the code of the app to sign, the nested code first, as signing a bundle seals its nested code's signature.
+func resignOrder(appPath string) ([]string, error) {
+	bundles, err := nestedCodeBundles(appPath)
+	if err != nil {
+		return nil, err
+	}
+	bundles = append([]string{appPath}, bundles...)
+
+	var order []string
+	for i := len(bundles) - 1; i >= 0; i-- {
+		dylibs, err := filepath.Glob(filepath.Join(bundles[i], "Frameworks", "*.dylib"))
+		if err != nil {
+			return nil, err
+		}
+		order = append(order, dylibs...)
+		order = append(order, bundles[i])
+	}
+	return order, nil
+}
+
+// isProvisionedBundle returns true if the code needs a provisioning profile: applications and app extensions.
+func isProvisionedBundle(pth string) bool {
+	ext := filepath.Ext(pth)
+	return ext == ".app" || ext == ".appex"
+}
+
+func (s XcodebuildArchiver) resignBundle(pth, identity string, profiles []resignProfile, tmpDir string) error {
+	args := []string{"--force", "--sign", identity, "--timestamp=none", "--generate-entitlement-der"}
+
+	if isProvisionedBundle(pth) {
+		infoPlist, err := plistutil.NewPlistDataFromFile(filepath.Join(pth, "Info.plist"))
+		if err != nil {
+			return fmt.Errorf("failed to read the Info.plist of %s: %w", pth, err)
+		}
+		bundleID, _ := infoPlist.GetString("CFBundleIdentifier")
+
+		profile, found := findResignProfile(bundleID, profiles)
+		if !found {
+			return fmt.Errorf("no provisioning profile provided for bundle ID: %s", bundleID)
+		}
+		if err := copyFile(profile.Path, filepath.Join(pth, iosEmbeddedProfileName)); err != nil {
+			return fmt.Errorf("failed to embed the provisioning profile: %w", err)
+		}
+
+		signed := plistutil.PlistData{}
+		if content, err := signedEntitlements(s.cmdFactory, pth); err != nil {
+			return err
+		} else if content != "" {
+			if signed, err = plistutil.NewPlistDataFromContent(content); err != nil {
+				return fmt.Errorf("failed to parse the entitlements of %s: %w", pth, err)
+			}
+		}
+
+		entitlements := resignEntitlements(signed, profile.Info.Entitlements, profile.Info.TeamID, bundleID)
+		content, err := plist.MarshalIndent(map[string]interface{}(entitlements), plist.XMLFormat, "\t")
+		if err != nil {
+			return fmt.Errorf("failed to marshal the entitlements: %w", err)
+		}
+		entitlementsPath := filepath.Join(tmpDir, bundleID+".entitlements")
+		if err := os.WriteFile(entitlementsPath, content, 0644); err != nil {
+			return err
+		}
+		args = append(args, "--entitlements", entitlementsPath)
+	}
+
+	cmd := s.cmdFactory.Create("codesign", append(args, pth), nil)
+	if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		return fmt.Errorf("failed to sign %s: %s: %w", pth, out, err)
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, content, 0644)
+}
+
+// resignIPA re-signs the ipa's application with the given identity and provisioning profiles, for example to get a device installable
+// development or ad-hoc variant of an app-store ipa without archiving twice.
+func (s XcodebuildArchiver) resignIPA(ipaPath, resignedIPAPath, identity string, profilePaths []string, compressionLevel int) error {
+	s.logger.Println()
+	s.logger.Infof("Re-signing the exported ipa...")
+
+	profiles, err := readResignProfiles(profilePaths)
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "resignIPA")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	extractDir := filepath.Join(tmpDir, "extracted")
+	appPath, err := extractIPAApp(ipaPath, extractDir)
+	if err != nil {
+		return err
+	}
+
+	order, err := resignOrder(appPath)
+	if err != nil {
+		return fmt.Errorf("failed to list the nested bundles: %w", err)
+	}
+	for _, pth := range order {
+		if err := s.resignBundle(pth, identity, profiles, tmpDir); err != nil {
+			return err
+		}
+	}
+
+	resignedIPAPath, err = filepath.Abs(resignedIPAPath)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(resignedIPAPath); err != nil {
+		return fmt.Errorf("failed to remove path (%s), error: %s", resignedIPAPath, err)
+	}
+	if err := zipDirContent(extractDir, resignedIPAPath, compressionLevel); err != nil {
+		return fmt.Errorf("failed to compress the re-signed ipa: %w", err)
+	}
+
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseResignedIPAPthEnvKey, resignedIPAPath); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseResignedIPAPthEnvKey), err)
+	}
+	s.logger.Donef("The re-signed ipa path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseResignedIPAPthEnvKey), resignedIPAPath)
+	return nil
+}
@@ -0,0 +1,74 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/plistutil"
+	"github.com/bitrise-io/go-xcode/profileutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_findResignProfile(t *testing.T) {
+	profiles := []resignProfile{
+		{Path: "wildcard.mobileprovision", Info: profileutil.ProvisioningProfileInfoModel{BundleID: "io.bitrise.*"}},
+		{Path: "app.mobileprovision", Info: profileutil.ProvisioningProfileInfoModel{BundleID: "io.bitrise.app"}},
+	}
+
+	profile, found := findResignProfile("io.bitrise.app", profiles)
+	require.True(t, found)
+	require.Equal(t, "app.mobileprovision", profile.Path)
+
+	profile, found = findResignProfile("io.bitrise.app.widget", profiles)
+	require.True(t, found)
+	require.Equal(t, "wildcard.mobileprovision", profile.Path)
+
+	_, found = findResignProfile("com.example.app", profiles)
+	require.False(t, found)
+}
+
+func Test_resignEntitlements(t *testing.T) {
+	signed := plistutil.PlistData{
+		"application-identifier":                 "OLDTEAM.io.bitrise.app",
+		"com.apple.developer.team-identifier":    "OLDTEAM",
+		"aps-environment":                        "production",
+		"get-task-allow":                         false,
+		"keychain-access-groups":                 []interface{}{"OLDTEAM.io.bitrise.app", "com.apple.token"},
+		"com.apple.developer.associated-domains": []interface{}{"applinks:bitrise.io"},
+	}
+	profile := plistutil.PlistData{
+		"application-identifier":              "NEWTEAM.io.bitrise.*",
+		"com.apple.developer.team-identifier": "NEWTEAM",
+		"aps-environment":                     "development",
+		"get-task-allow":                      true,
+		"keychain-access-groups":              []interface{}{"NEWTEAM.*"},
+	}
+
+	require.Equal(t, plistutil.PlistData{
+		"application-identifier":              "NEWTEAM.io.bitrise.app",
+		"com.apple.developer.team-identifier": "NEWTEAM",
+		"aps-environment":                     "development",
+		"get-task-allow":                      true,
+		"keychain-access-groups":              []interface{}{"NEWTEAM.io.bitrise.app", "com.apple.token"},
+	}, resignEntitlements(signed, profile, "NEWTEAM", "io.bitrise.app"))
+}
+
+func Test_resignOrder(t *testing.T) {
+	appPath := filepath.Join(t.TempDir(), "App.app")
+	createDirs(t, appPath,
+		"Frameworks/Core.framework",
+		"PlugIns/Widget.appex/Frameworks/WidgetKit.framework",
+	)
+	require.NoError(t, os.WriteFile(filepath.Join(appPath, "Frameworks", "libswiftCore.dylib"), nil, 0644))
+
+	order, err := resignOrder(appPath)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(appPath, "PlugIns/Widget.appex/Frameworks/WidgetKit.framework"),
+		filepath.Join(appPath, "PlugIns/Widget.appex"),
+		filepath.Join(appPath, "Frameworks/Core.framework"),
+		filepath.Join(appPath, "Frameworks/libswiftCore.dylib"),
+		appPath,
+	}, order)
+}
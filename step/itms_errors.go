@@ -0,0 +1,91 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+const bitriseITMSErrorCodesEnvKey = "BITRISE_ITMS_ERROR_CODES"
+
+var itmsErrorPattern = regexp.MustCompile(`ITMS-(\d{5})`)
+
+// itmsRemediations maps the common App Store Connect validation error codes to their remediation.
+var itmsRemediations = map[string]string{
+	"90022": "A required app icon is missing. Add every required icon size to the app's asset catalog, or use a single size app icon.",
+	"90034": "The app's code signature is missing or invalid. Make sure the app and every embedded framework is signed with a distribution certificate.",
+	"90035": "The app's code signature is invalid. Make sure the app and every embedded framework and extension is signed with the same distribution certificate, enable the verify_ipa_signature input to find the invalid bundle.",
+	"90046": "The app's entitlements are invalid. Make sure the entitlements match the capabilities of the App Store provisioning profile.",
+	"90062": "The version (CFBundleShortVersionString) must be higher than the previously approved version. Increase the app's version.",
+	"90161": "The provisioning profile is invalid. Make sure the app is signed with an App Store distribution profile.",
+	"90186": "The pre-release train of the version is closed. Increase the app's version (CFBundleShortVersionString).",
+	"90189": "A build with the same build number (CFBundleVersion) was already uploaded. Increase the build number, for example to the $BITRISE_BUILD_NUMBER.",
+	"90208": "The minimum OS version of the app is lower than the minimum OS version of an embedded framework. Align the deployment targets.",
+	"90338": "The app references non-public APIs. Update or remove the dependency using the private API.",
+	"90474": "The iPad multitasking requirements are not met. Support all interface orientations, or set UIRequiresFullScreen to YES in the Info.plist.",
+	"90683": "A purpose string (NS...UsageDescription) is missing from the Info.plist. Add a purpose string for every protected resource the app or its dependencies access.",
+	"90713": "The CFBundleIconName key is missing from the Info.plist. Set it to the name of the app icon set of the asset catalog.",
+	"90725": "The app was built with an SDK that is no longer accepted by App Store Connect. Archive with a newer Xcode version.",
+	"91053": "A required reason API is used without a declaration in the privacy manifest (PrivacyInfo.xcprivacy). Add the declaration to the app's or the dependency's privacy manifest.",
+}
+
+// parseITMSErrorCodes returns the distinct ITMS error codes of the logs, in the order of their first occurrence.
+func parseITMSErrorCodes(logs ...string) []string {
+	var codes []string
+	seen := map[string]bool{}
+	for _, content := range logs {
+		for _, match := range itmsErrorPattern.FindAllStringSubmatch(content, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				codes = append(codes, match[1])
+			}
+		}
+	}
+	return codes
+}
+
+// readDistributionLogs returns the concatenated content of the IDEDistribution logs.
+func readDistributionLogs(ideDistributionLogsDir string) string {
+	if ideDistributionLogsDir == "" {
+		return ""
+	}
+
+	pths, err := filepath.Glob(filepath.Join(ideDistributionLogsDir, "*.log"))
+	if err != nil {
+		return ""
+	}
+
+	var content strings.Builder
+	for _, pth := range pths {
+		if b, err := os.ReadFile(pth); err == nil {
+			content.Write(b)
+			content.WriteString("\n")
+		}
+	}
+	return content.String()
+}
+
+func printITMSErrors(codes []string, logger log.Logger) {
+	logger.Println()
+	logger.Errorf("App Store Connect validation errors found:")
+	for _, code := range codes {
+		remediation, ok := itmsRemediations[code]
+		if !ok {
+			remediation = "See the xcodebuild and the IDEDistribution logs for details."
+		}
+		logger.Errorf("- ITMS-%s: %s", code, remediation)
+	}
+}
+
+func exportITMSErrorCodes(cmdFactory command.Factory, outputEnvKeys OutputEnvKeys, codes []string, logger log.Logger) error {
+	value := strings.Join(codes, ",")
+	if err := exportEnvironmentWithEnvman(cmdFactory, outputEnvKeys, bitriseITMSErrorCodesEnvKey, value); err != nil {
+		return err
+	}
+	logger.Donef("The ITMS error codes are now available in the Environment Variable: %s (value: %s)", outputEnvKeys.key(bitriseITMSErrorCodesEnvKey), value)
+	return nil
+}
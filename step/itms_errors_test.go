@@ -0,0 +1,29 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseITMSErrorCodes(t *testing.T) {
+	exportLog := `error: exportArchive: ERROR ITMS-90189: "Redundant Binary Upload. You've already uploaded a build with build number '42' for version number '1.0'."
+error: exportArchive: ERROR ITMS-90062: "This bundle is invalid. The value for key CFBundleShortVersionString [1.0] must contain a higher version."`
+	distributionLog := `2024-06-01 12:00:00 +0000  [MT] ERROR ITMS-90189: "Redundant Binary Upload."
+2024-06-01 12:00:00 +0000  [MT] ERROR ITMS-91053: "Missing API declaration"`
+
+	require.Equal(t, []string{"90189", "90062", "91053"}, parseITMSErrorCodes(exportLog, distributionLog))
+	require.Nil(t, parseITMSErrorCodes("** EXPORT FAILED **"))
+}
+
+func Test_readDistributionLogs(t *testing.T) {
+	require.Equal(t, "", readDistributionLogs(""))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "IDEDistribution.standard.log"), []byte("ERROR ITMS-90035"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "IDEDistributionOptionsStep.plist"), []byte("ERROR ITMS-90022"), 0644))
+
+	require.Equal(t, []string{"90035"}, parseITMSErrorCodes(readDistributionLogs(dir)))
+}
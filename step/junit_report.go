@@ -0,0 +1,129 @@
+package step
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+const (
+	bitriseBuildIssuesJUnitPthEnvKey = "BITRISE_BUILD_ISSUES_JUNIT_PATH"
+
+	junitBuildIssuesTestName = "archive-build-issues"
+)
+
+var buildIssueLocationPattern = regexp.MustCompile(`^(.*?):\s*(?:fatal )?(?:error|warning):\s*(.*)$`)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Content string `xml:",chardata"`
+}
+
+// buildIssueTestCase converts an xcodebuild error or warning line to a failed test case,
+// named after the issue's location (file:line:column) if the line has one.
+func buildIssueTestCase(className string, issue buildMessage) junitTestCase {
+	name, message := issue.Message, issue.Message
+	if match := buildIssueLocationPattern.FindStringSubmatch(issue.Message); match != nil && match[1] != "" {
+		name, message = match[1], match[2]
+	}
+
+	content := issue.Message
+	if issue.Count > 1 {
+		content = fmt.Sprintf("%s\n(occurred %d times)", issue.Message, issue.Count)
+	}
+
+	return junitTestCase{
+		Name:      name,
+		ClassName: className,
+		Failure:   &junitFailure{Message: message, Content: content},
+	}
+}
+
+// buildIssuesJUnitReport converts the errors and warnings of the build report to a JUnit report,
+// each error and warning is a failed test case.
+func buildIssuesJUnitReport(report buildReport) junitTestSuites {
+	suites := junitTestSuites{}
+	for _, group := range []struct {
+		name   string
+		issues []buildMessage
+	}{
+		{name: "Errors", issues: report.Errors},
+		{name: "Warnings", issues: report.Warnings},
+	} {
+		suite := junitTestSuite{Name: report.Title + " " + group.name, Tests: len(group.issues), Failures: len(group.issues)}
+		for _, issue := range group.issues {
+			suite.TestCases = append(suite.TestCases, buildIssueTestCase(group.name, issue))
+		}
+		suites.Suites = append(suites.Suites, suite)
+	}
+	return suites
+}
+
+// exportBuildIssuesJUnitReport writes the JUnit report of the build issues to the Bitrise test results dir,
+// in the layout expected by the Test Reports add-on: <test result dir>/<test name>/{test-info.json,<report>.xml}.
+func exportBuildIssuesJUnitReport(report buildReport, testResultDir, artifactName string) (string, error) {
+	content, err := xml.MarshalIndent(buildIssuesJUnitReport(report), "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	testName := artifactName + "-" + junitBuildIssuesTestName
+	exportDir := filepath.Join(testResultDir, testName)
+	if err := os.MkdirAll(exportDir, 0755); err != nil {
+		return "", err
+	}
+
+	testInfo, err := json.Marshal(map[string]string{"test-name": testName})
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(exportDir, "test-info.json"), testInfo, 0644); err != nil {
+		return "", err
+	}
+
+	pth := filepath.Join(exportDir, testName+".xml")
+	if err := os.WriteFile(pth, append([]byte(xml.Header), content...), 0644); err != nil {
+		return "", err
+	}
+	return pth, nil
+}
+
+func (s XcodebuildArchiver) exportBuildIssuesJUnit(opts ExportOpts) {
+	if opts.TestResultDir == "" {
+		s.logger.Warnf("BITRISE_TEST_RESULT_DIR is not set, skipping the build issues JUnit report export")
+		return
+	}
+
+	report := parseBuildReport(opts.ArtifactName+" archive", opts.XcodebuildArchiveLog)
+	pth, err := exportBuildIssuesJUnitReport(report, opts.TestResultDir, opts.ArtifactName)
+	if err != nil {
+		s.logger.Warnf("Failed to write the build issues JUnit report: %s", err)
+		return
+	}
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseBuildIssuesJUnitPthEnvKey, pth); err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", s.outputEnvKey(bitriseBuildIssuesJUnitPthEnvKey), err)
+		return
+	}
+	s.logger.Donef("The build issues JUnit report path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseBuildIssuesJUnitPthEnvKey), pth)
+}
@@ -0,0 +1,67 @@
+package step
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_buildIssueTestCase(t *testing.T) {
+	tests := []struct {
+		name  string
+		issue buildMessage
+		want  junitTestCase
+	}{
+		{
+			name:  "issue with location",
+			issue: buildMessage{Message: "/git/App/Model.swift:3:1: error: expected declaration", Count: 1},
+			want: junitTestCase{
+				Name:      "/git/App/Model.swift:3:1",
+				ClassName: "Errors",
+				Failure:   &junitFailure{Message: "expected declaration", Content: "/git/App/Model.swift:3:1: error: expected declaration"},
+			},
+		},
+		{
+			name:  "issue without location",
+			issue: buildMessage{Message: "warning: Run script build phase 'Lint' will be run during every build", Count: 2},
+			want: junitTestCase{
+				Name:      "warning: Run script build phase 'Lint' will be run during every build",
+				ClassName: "Errors",
+				Failure: &junitFailure{
+					Message: "warning: Run script build phase 'Lint' will be run during every build",
+					Content: "warning: Run script build phase 'Lint' will be run during every build\n(occurred 2 times)",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, buildIssueTestCase("Errors", tt.issue))
+		})
+	}
+}
+
+func Test_exportBuildIssuesJUnitReport(t *testing.T) {
+	testResultDir := t.TempDir()
+	report := parseBuildReport("App archive", buildReportTestLog)
+
+	pth, err := exportBuildIssuesJUnitReport(report, testResultDir, "App")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(testResultDir, "App-archive-build-issues", "App-archive-build-issues.xml"), pth)
+
+	testInfo, err := os.ReadFile(filepath.Join(testResultDir, "App-archive-build-issues", "test-info.json"))
+	require.NoError(t, err)
+	var info map[string]string
+	require.NoError(t, json.Unmarshal(testInfo, &info))
+	require.Equal(t, map[string]string{"test-name": "App-archive-build-issues"}, info)
+
+	content, err := os.ReadFile(pth)
+	require.NoError(t, err)
+	require.Contains(t, string(content), `<testsuite name="App archive Errors" tests="1" failures="1">`)
+	require.Contains(t, string(content), `<testsuite name="App archive Warnings" tests="2" failures="2">`)
+	require.Contains(t, string(content), `<testcase name="/git/App/Model.swift:3:1" classname="Errors">`)
+	require.Contains(t, string(content), `<failure message="expected declaration">`)
+}
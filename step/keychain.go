@@ -0,0 +1,117 @@
+package step
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/go-steputils/v2/stepconf"
+	"github.com/kballard/go-shellquote"
+)
+
+const otherCodeSignFlagsSetting = "OTHER_CODE_SIGN_FLAGS"
+
+// keychainLockedPatterns are fragments of the codesign errors printed when the signing identity's keychain is locked
+// or its private key can't be accessed without user interaction, typical on self-hosted Macs without a login session.
+var keychainLockedPatterns = []string{
+	"errSecInternalComponent",
+	"User interaction is not allowed",
+}
+
+const keychainLockedGuidance = `Code signing failed because the keychain of the signing identity is locked or its private key can't be accessed without user interaction.
+This is typical on self-hosted Macs, where the keychain locks on sleep or after a timeout, or the runner has no login session.
+To fix this:
+- Set Codesign keychain path (codesign_keychain_path) and Codesign keychain password (codesign_keychain_password), so the Step unlocks the keychain before signing.
+- Or unlock the keychain before the Step: security unlock-keychain -p <password> <keychain>
+- Disable the automatic locking of the keychain: security set-keychain-settings <keychain>
+- Allow codesign to access the private key without a prompt: security set-key-partition-list -S apple-tool:,apple:,codesign: -s -k <password> <keychain>`
+
+type keychainLockedError struct {
+	err error
+}
+
+func (e keychainLockedError) Error() string {
+	return e.err.Error() + "\n\n" + keychainLockedGuidance
+}
+
+func (e keychainLockedError) Unwrap() error {
+	return e.err
+}
+
+// isKeychainLockedFailure returns true if the xcodebuild log contains a codesign failure caused by a locked keychain.
+func isKeychainLockedFailure(xcodebuildLog string) bool {
+	for _, pattern := range keychainLockedPatterns {
+		if strings.Contains(xcodebuildLog, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// keychainCodeSignFlagsSetting returns the build setting making codesign use the given keychain.
+func keychainCodeSignFlagsSetting(keychainPath string) string {
+	return fmt.Sprintf("%s=--keychain %s", otherCodeSignFlagsSetting, shellquote.Join(keychainPath))
+}
+
+func (s XcodebuildArchiver) unlockKeychain(keychainPath string, password stepconf.Secret) error {
+	cmd := s.cmdFactory.Create("security", []string{"unlock-keychain", "-p", string(password), keychainPath}, nil)
+	if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		return fmt.Errorf("failed to unlock keychain (%s): %s: %w", keychainPath, out, err)
+	}
+	return nil
+}
+
+// unlockLockedKeychain unlocks the keychain and disables its automatic locking, so it does not lock again during the build.
+func (s XcodebuildArchiver) unlockLockedKeychain(keychainPath string, password stepconf.Secret) error {
+	if err := s.unlockKeychain(keychainPath, password); err != nil {
+		return err
+	}
+	cmd := s.cmdFactory.Create("security", []string{"set-keychain-settings", keychainPath}, nil)
+	if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		s.logger.Warnf("Failed to disable the automatic locking of the keychain (%s): %s: %s", keychainPath, out, err)
+	}
+	return nil
+}
+
+// retryArchiveWithUnlockedKeychain unlocks the signing keychain and reruns the archive that failed because the keychain was locked,
+// the codesign keychain is unlocked if set, otherwise the keychain of the installed code signing certificates.
+func (s XcodebuildArchiver) retryArchiveWithUnlockedKeychain(opts xcodeArchiveOpts, archiveArgs []string, xcodebuildLog string, archiveErr error) (string, error) {
+	keychainPath, password := opts.KeychainPath, opts.KeychainPassword
+	if opts.CodesignKeychainPath != "" {
+		keychainPath, password = opts.CodesignKeychainPath, opts.CodesignKeychainPassword
+	}
+	if keychainPath == "" || password == "" {
+		return xcodebuildLog, keychainLockedError{err: archiveErr}
+	}
+
+	s.logger.Println()
+	s.logger.Warnf("Code signing failed because the keychain is locked, unlocking the keychain (%s) and retrying the archive", keychainPath)
+	if err := s.unlockLockedKeychain(keychainPath, password); err != nil {
+		return xcodebuildLog, keychainLockedError{err: fmt.Errorf("%w, %s", archiveErr, err)}
+	}
+	opts.Insights.RecordRetry(retryOperationKeychainUnlock)
+
+	retryLog, err := s.runner().Archive(archiveArgs)
+	if err != nil && isKeychainLockedFailure(retryLog) {
+		return retryLog, keychainLockedError{err: err}
+	}
+	return retryLog, err
+}
+
+// setsOtherCodeSignFlags returns true if the OTHER_CODE_SIGN_FLAGS build setting is set by the xcodebuild options or the xcconfig content.
+func setsOtherCodeSignFlags(xcodebuildOptions []string, xcconfigContent string) bool {
+	for _, option := range xcodebuildOptions {
+		if strings.HasPrefix(option, otherCodeSignFlagsSetting+"=") {
+			return true
+		}
+	}
+	for _, line := range strings.Split(xcconfigContent, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, otherCodeSignFlagsSetting) {
+			rest := strings.TrimSpace(strings.TrimPrefix(line, otherCodeSignFlagsSetting))
+			if strings.HasPrefix(rest, "=") || strings.HasPrefix(rest, "[") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package step
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_keychainCodeSignFlagsSetting(t *testing.T) {
+	require.Equal(t, "OTHER_CODE_SIGN_FLAGS=--keychain /Users/vagrant/Library/Keychains/signing.keychain-db", keychainCodeSignFlagsSetting("/Users/vagrant/Library/Keychains/signing.keychain-db"))
+	require.Equal(t, "OTHER_CODE_SIGN_FLAGS=--keychain '/Users/vagrant/My Keychains/signing.keychain-db'", keychainCodeSignFlagsSetting("/Users/vagrant/My Keychains/signing.keychain-db"))
+}
+
+func Test_setsOtherCodeSignFlags(t *testing.T) {
+	tests := []struct {
+		name              string
+		xcodebuildOptions []string
+		xcconfigContent   string
+		want              bool
+	}{
+		{
+			name:              "not set",
+			xcodebuildOptions: []string{"-scmProvider", "system", "CODE_SIGN_IDENTITY=Apple Distribution"},
+			xcconfigContent:   "CODE_SIGN_STYLE = Manual",
+			want:              false,
+		},
+		{
+			name:              "set by xcodebuild options",
+			xcodebuildOptions: []string{"OTHER_CODE_SIGN_FLAGS=--deep"},
+			want:              true,
+		},
+		{
+			name:            "set by xcconfig",
+			xcconfigContent: "CODE_SIGN_STYLE = Manual\nOTHER_CODE_SIGN_FLAGS = --deep",
+			want:            true,
+		},
+		{
+			name:            "conditionally set by xcconfig",
+			xcconfigContent: "OTHER_CODE_SIGN_FLAGS[config=Release] = --deep",
+			want:            true,
+		},
+		{
+			name:            "similar setting in xcconfig",
+			xcconfigContent: "OTHER_CODE_SIGN_FLAGS_RELEASE = --deep",
+			want:            false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, setsOtherCodeSignFlags(tt.xcodebuildOptions, tt.xcconfigContent))
+		})
+	}
+}
+
+func Test_isKeychainLockedFailure(t *testing.T) {
+	require.True(t, isKeychainLockedFailure("/path/App.app: errSecInternalComponent\nCommand CodeSign failed with a nonzero exit code"))
+	require.True(t, isKeychainLockedFailure("Warning: unable to build chain to self-signed root for signer\nUser interaction is not allowed."))
+	require.False(t, isKeychainLockedFailure("error: No signing certificate \"iOS Distribution\" found"))
+}
+
+func Test_keychainLockedError(t *testing.T) {
+	cause := newXcodebuildExitError(65, errors.New("exit status 65"))
+	err := fmt.Errorf("failed to archive the project: %w", keychainLockedError{err: cause})
+
+	require.Contains(t, err.Error(), "exit status 65")
+	require.Contains(t, err.Error(), "security unlock-keychain")
+	require.Equal(t, 65, xcodebuildExitCode(err))
+}
+
+func TestXcodebuildArchiver_retryArchiveWithUnlockedKeychain_noCredentials(t *testing.T) {
+	archiver := XcodebuildArchiver{logger: log.NewLogger()}
+	archiveErr := errors.New("exit status 65")
+
+	xcodebuildLog, err := archiver.retryArchiveWithUnlockedKeychain(xcodeArchiveOpts{KeychainPath: "login.keychain"}, nil, "errSecInternalComponent", archiveErr)
+	require.Equal(t, "errSecInternalComponent", xcodebuildLog)
+	var lockedErr keychainLockedError
+	require.True(t, errors.As(err, &lockedErr))
+	require.ErrorIs(t, err, archiveErr)
+}
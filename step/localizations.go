@@ -0,0 +1,76 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const lprojExtension = ".lproj"
+
+// normalizeLocale makes the locale identifiers comparable: en_GB and en-gb are the same locale.
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+}
+
+// ParseLocaleList parses the space or comma separated list of locale identifiers.
+func ParseLocaleList(list string) []string {
+	return strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' })
+}
+
+// appLocalizations returns the locales of the .lproj directories of the app bundle's resources.
+func appLocalizations(appPath string) ([]string, error) {
+	entries, err := os.ReadDir(appPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the app bundle: %w", err)
+	}
+
+	var locales []string
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasSuffix(entry.Name(), lprojExtension) {
+			locales = append(locales, strings.TrimSuffix(entry.Name(), lprojExtension))
+		}
+	}
+	sort.Strings(locales)
+	return locales, nil
+}
+
+// missingLocalizations returns the required locales not found in the app's locales.
+func missingLocalizations(required, locales []string) []string {
+	found := map[string]bool{}
+	for _, locale := range locales {
+		found[normalizeLocale(locale)] = true
+	}
+
+	var missing []string
+	for _, locale := range required {
+		if !found[normalizeLocale(locale)] {
+			missing = append(missing, locale)
+		}
+	}
+	return missing
+}
+
+// checkRequiredLocalizations checks that the archived app contains the required localizations,
+// the missing ones fail the Step if failOnMissing is set, otherwise they are reported as a warning.
+func (s XcodebuildArchiver) checkRequiredLocalizations(appPath string, required []string, failOnMissing bool) error {
+	locales, err := appLocalizations(appPath)
+	if err != nil {
+		return err
+	}
+	s.logger.Printf("App localizations: %s", strings.Join(locales, ", "))
+
+	missing := missingLocalizations(required, locales)
+	if len(missing) == 0 {
+		s.logger.Donef("The app contains every required localization")
+		return nil
+	}
+
+	err = fmt.Errorf("the app is missing the required localizations: %s", strings.Join(missing, ", "))
+	if failOnMissing {
+		return err
+	}
+	s.logger.Warnf("%s", err)
+	return nil
+}
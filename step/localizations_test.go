@@ -0,0 +1,33 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocaleList(t *testing.T) {
+	require.Equal(t, []string{"en", "de", "zh-Hans"}, ParseLocaleList(" en, de\nzh-Hans "))
+	require.Empty(t, ParseLocaleList(""))
+}
+
+func TestAppLocalizations(t *testing.T) {
+	appPath := filepath.Join(t.TempDir(), "App.app")
+	for _, dir := range []string{"en.lproj", "Base.lproj", "zh-Hans.lproj", "Assets.bundle"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(appPath, dir), 0777))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(appPath, "de.lproj"), nil, 0644))
+
+	locales, err := appLocalizations(appPath)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Base", "en", "zh-Hans"}, locales)
+}
+
+func TestMissingLocalizations(t *testing.T) {
+	locales := []string{"Base", "en", "en-GB", "zh-Hans"}
+
+	require.Empty(t, missingLocalizations([]string{"en", "en_GB", "ZH-hans"}, locales))
+	require.Equal(t, []string{"de", "fr"}, missingLocalizations([]string{"en", "de", "fr"}, locales))
+}
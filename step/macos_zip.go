@@ -0,0 +1,49 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const bitriseMacOSZipPthEnvKey = "BITRISE_MACOS_ZIP_PATH"
+
+// dittoZipArgs returns the ditto arguments creating a zip of the app, the way Finder does:
+// unlike a plain zip, it keeps the resource forks, extended attributes and symlinks, so the app's signature stays valid and the zip can be notarized.
+func dittoZipArgs(appPath, zipPath string) []string {
+	return []string{"-c", "-k", "--sequesterRsrc", "--keepParent", appPath, zipPath}
+}
+
+// findExportedApp returns the .app exported into the export dir (for example by a Developer ID export of a macOS app),
+// an empty string if the export dir contains no app.
+func findExportedApp(exportDir string) (string, error) {
+	entries, err := os.ReadDir(exportDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to list the export dir: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasSuffix(entry.Name(), ".app") {
+			return filepath.Join(exportDir, entry.Name()), nil
+		}
+	}
+	return "", nil
+}
+
+// exportMacOSZip creates a notarization ready zip of the exported macOS app with ditto.
+func (s XcodebuildArchiver) exportMacOSZip(appPath, zipPath string) error {
+	if err := os.RemoveAll(zipPath); err != nil {
+		return fmt.Errorf("failed to remove path (%s), error: %s", zipPath, err)
+	}
+
+	cmd := s.cmdFactory.Create("ditto", dittoZipArgs(appPath, zipPath), nil)
+	if out, err := cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		return fmt.Errorf("failed to zip %s: %s: %w", appPath, out, err)
+	}
+
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseMacOSZipPthEnvKey, zipPath); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseMacOSZipPthEnvKey), err)
+	}
+	s.logger.Donef("The macOS app zip path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseMacOSZipPthEnvKey), zipPath)
+	return nil
+}
@@ -0,0 +1,30 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_dittoZipArgs(t *testing.T) {
+	require.Equal(t, []string{"-c", "-k", "--sequesterRsrc", "--keepParent", "/export/App.app", "/output/App.zip"}, dittoZipArgs("/export/App.app", "/output/App.zip"))
+}
+
+func Test_findExportedApp(t *testing.T) {
+	exportDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(exportDir, "ExportOptions.plist"), []byte{}, 0644))
+
+	app, err := findExportedApp(exportDir)
+	require.NoError(t, err)
+	require.Equal(t, "", app)
+
+	createDirs(t, exportDir, "App.app/Contents")
+	app, err = findExportedApp(exportDir)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(exportDir, "App.app"), app)
+
+	_, err = findExportedApp(filepath.Join(exportDir, "missing"))
+	require.Error(t, err)
+}
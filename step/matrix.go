@@ -0,0 +1,184 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	bitriseIPAPthListEnvKey       = "BITRISE_IPA_PATH_LIST"
+	bitriseXCArchivePthListEnvKey = "BITRISE_XCARCHIVE_PATH_LIST"
+)
+
+// MatrixEntry is a scheme and build configuration pair to archive.
+type MatrixEntry struct {
+	Scheme        string
+	Configuration string
+}
+
+// String ...
+func (e MatrixEntry) String() string {
+	if e.Configuration == "" {
+		return e.Scheme
+	}
+	return e.Scheme + ":" + e.Configuration
+}
+
+// MatrixResult ...
+type MatrixResult struct {
+	Entry        MatrixEntry
+	ArtifactName string
+	ArchivePath  string
+	IPAPath      string
+	Err          error
+}
+
+func parseSchemeConfigurationMatrix(matrix string) ([]MatrixEntry, error) {
+	var entries []MatrixEntry
+	for _, line := range strings.Split(matrix, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		entry := MatrixEntry{Scheme: line}
+		if idx := strings.LastIndex(line, ":"); idx != -1 {
+			entry.Scheme = strings.TrimSpace(line[:idx])
+			entry.Configuration = strings.TrimSpace(line[idx+1:])
+		}
+		if entry.Scheme == "" {
+			return nil, fmt.Errorf("invalid matrix entry (%s): scheme is empty", line)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// matrixUnsupportedInputs returns the set inputs describing a single archive, which can't be used with a matrix:
+// the release metadata, the release git tag, the deploy metadata and the build summary.
+func matrixUnsupportedInputs(inputs Inputs) []string {
+	var unsupported []string
+	if inputs.ExportReleaseMetadata {
+		unsupported = append(unsupported, "Export release metadata (`export_release_metadata`)")
+	}
+	if inputs.ReleaseGitTag != "" {
+		unsupported = append(unsupported, "Release git tag (`release_git_tag`)")
+	}
+	if inputs.ExportDeployMetadata {
+		unsupported = append(unsupported, "Export deploy metadata (`export_deploy_metadata`)")
+	}
+	if inputs.BuildSummary {
+		unsupported = append(unsupported, "Export a markdown build summary (`export_build_summary`)")
+	}
+	return unsupported
+}
+
+// MatrixArtifactName returns a unique artifact name for the given matrix entry, by suffixing the artifact name
+// (or the scheme if no artifact name is set) with the build configuration and an index if the name was already
+// taken by a previous entry.
+func MatrixArtifactName(artifactName string, entry MatrixEntry, taken []string) string {
+	name := artifactName
+	if name == "" {
+		name = entry.Scheme
+	}
+	if entry.Configuration != "" {
+		name += "-" + strings.ReplaceAll(entry.Configuration, " ", "-")
+	}
+
+	isTaken := func(name string) bool {
+		for _, t := range taken {
+			if t == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	unique := name
+	for i := 2; isTaken(unique); i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+
+	return unique
+}
+
+// ConfigForMatrixEntry returns a copy of the config targeting the given matrix entry.
+func (s XcodebuildArchiveConfigParser) ConfigForMatrixEntry(config Config, entry MatrixEntry) (Config, error) {
+	config.Scheme = entry.Scheme
+	config.Configuration = entry.Configuration
+	config.MatrixEntries = nil
+
+	if config.CodeSigningAuthSource != codeSignSourceOff {
+		codesignManager, err := s.createCodesignManager(config)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to prepare automatic code signing: %w", err)
+		}
+		config.CodesignManager = &codesignManager
+	}
+
+	return config, nil
+}
+
+// NewMatrixResult ...
+func NewMatrixResult(entry MatrixEntry, outputDir, outputLayout string, artifactName string, result RunResult, err error) MatrixResult {
+	matrixResult := MatrixResult{
+		Entry:        entry,
+		ArtifactName: artifactName,
+		Err:          err,
+	}
+
+	if result.Archive != nil {
+		matrixResult.ArchivePath = result.Archive.Path
+	}
+
+	ipaPath := filepath.Join(artifactOutputDir(outputDir, outputLayout, outputArtifactIPA), artifactName+".ipa")
+	if _, statErr := os.Stat(ipaPath); statErr == nil {
+		matrixResult.IPAPath = ipaPath
+	}
+
+	return matrixResult
+}
+
+// ExportMatrixOutputs prints a summary of the matrix archiving and exports the aggregated outputs.
+func (s XcodebuildArchiver) ExportMatrixOutputs(results []MatrixResult) error {
+	s.logger.Println()
+	s.logger.Infof("Scheme/Configuration matrix summary:")
+
+	var ipaPaths, archivePaths []string
+	for _, result := range results {
+		configuration := result.Entry.Configuration
+		if configuration == "" {
+			configuration = "(scheme default)"
+		}
+
+		if result.Err != nil {
+			s.logger.Errorf("- %s | %s | failed: %s", result.Entry.Scheme, configuration, result.Err)
+		} else {
+			s.logger.Donef("- %s | %s | succeeded (%s)", result.Entry.Scheme, configuration, result.ArtifactName)
+		}
+
+		if result.IPAPath != "" {
+			ipaPaths = append(ipaPaths, result.IPAPath)
+		}
+		if result.ArchivePath != "" {
+			archivePaths = append(archivePaths, result.ArchivePath)
+		}
+	}
+	s.logger.Println()
+
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseIPAPthListEnvKey, strings.Join(ipaPaths, "|")); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseIPAPthListEnvKey), err)
+	}
+	s.logger.Donef("The ipa paths are now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseIPAPthListEnvKey), strings.Join(ipaPaths, "|"))
+
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseXCArchivePthListEnvKey, strings.Join(archivePaths, "|")); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseXCArchivePthListEnvKey), err)
+	}
+	s.logger.Donef("The xcarchive paths are now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseXCArchivePthListEnvKey), strings.Join(archivePaths, "|"))
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseSchemeConfigurationMatrix(t *testing.T) {
+	tests := []struct {
+		name    string
+		matrix  string
+		want    []MatrixEntry
+		wantErr bool
+	}{
+		{
+			name:   "empty",
+			matrix: "",
+			want:   nil,
+		},
+		{
+			name:   "scheme and configuration pairs",
+			matrix: "App:Debug\nApp:Release\n\n  Widget  \n",
+			want: []MatrixEntry{
+				{Scheme: "App", Configuration: "Debug"},
+				{Scheme: "App", Configuration: "Release"},
+				{Scheme: "Widget"},
+			},
+		},
+		{
+			name:   "scheme containing colon",
+			matrix: "App: Staging:Release",
+			want: []MatrixEntry{
+				{Scheme: "App: Staging", Configuration: "Release"},
+			},
+		},
+		{
+			name:    "missing scheme",
+			matrix:  ":Release",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSchemeConfigurationMatrix(tt.matrix)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMatrixArtifactName(t *testing.T) {
+	require.Equal(t, "App-Debug", MatrixArtifactName("", MatrixEntry{Scheme: "App", Configuration: "Debug"}, nil))
+	require.Equal(t, "MyApp-App-Store", MatrixArtifactName("MyApp", MatrixEntry{Scheme: "App", Configuration: "App Store"}, nil))
+	require.Equal(t, "MyApp-Debug-2", MatrixArtifactName("MyApp", MatrixEntry{Scheme: "Other", Configuration: "Debug"}, []string{"MyApp-Debug"}))
+}
+
+func Test_matrixUnsupportedInputs(t *testing.T) {
+	require.Empty(t, matrixUnsupportedInputs(DefaultInputs()))
+
+	inputs := DefaultInputs()
+	inputs.ReleaseGitTag = "v1.0.0"
+	inputs.BuildSummary = true
+	require.Equal(t, []string{
+		"Release git tag (`release_git_tag`)",
+		"Export a markdown build summary (`export_build_summary`)",
+	}, matrixUnsupportedInputs(inputs))
+}
@@ -0,0 +1,329 @@
+package step
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/retry"
+)
+
+const (
+	metricsFormatPushgateway = "pushgateway"
+	metricsFormatOTLP        = "otlp"
+
+	metricsJobName       = "xcode_archive"
+	metricsServiceName   = "steps-xcode-archive"
+	metricsExportTimeout = 10 * time.Second
+
+	pushgatewayJobPath = "/metrics/job/"
+	otlpMetricsPath    = "/v1/metrics"
+)
+
+type metricLabel struct {
+	Name  string
+	Value string
+}
+
+// metricPoint is a gauge value of a Step run, exported in the Prometheus text format or as an OTLP gauge data point.
+type metricPoint struct {
+	Name   string
+	Help   string
+	Unit   string
+	Value  float64
+	Labels []metricLabel
+}
+
+// MetricsExportOpts ...
+type MetricsExportOpts struct {
+	BuildInsightsOpts
+	URL     string
+	Format  string
+	Headers map[string]string
+	AppSlug string
+}
+
+// parseMetricsExportHeaders parses the newline separated list of Name: Value HTTP headers.
+func parseMetricsExportHeaders(list string) (map[string]string, error) {
+	headers := map[string]string{}
+	for i, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, value, found := strings.Cut(line, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !found || name == "" || strings.ContainsAny(name, " \t") {
+			// the line is not printed, as the header values are usually credentials
+			return nil, fmt.Errorf("invalid header on line %d, expected Name: Value format", i+1)
+		}
+		headers[http.CanonicalHeaderKey(name)] = value
+	}
+	return headers, nil
+}
+
+// validateMetricsExportURL ...
+func validateMetricsExportURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("expected an http or https URL: %s", rawURL)
+	}
+	return nil
+}
+
+// metricsExportURL returns the pushgateway group URL or the OTLP metrics endpoint of the configured URL,
+// a URL already pointing to a pushgateway group or an OTLP metrics endpoint is used as is.
+func metricsExportURL(rawURL, format, appSlug string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case metricsFormatOTLP:
+		if !strings.HasSuffix(u.Path, otlpMetricsPath) {
+			u.Path = strings.TrimSuffix(u.Path, "/") + otlpMetricsPath
+		}
+	default:
+		if !strings.Contains(u.Path, pushgatewayJobPath) {
+			// the app slug is a grouping key, so the metrics of the apps pushed to the same pushgateway don't replace each other
+			u.Path = strings.TrimSuffix(u.Path, "/") + pushgatewayJobPath + metricsJobName
+			if appSlug != "" {
+				u.Path += "/app_slug/" + appSlug
+			}
+		}
+	}
+	return u.String(), nil
+}
+
+// newMetricPoints returns the phase durations and the outcome of the Step run.
+func newMetricPoints(insights BuildInsights) []metricPoint {
+	labels := []metricLabel{
+		{Name: "xcode_version", Value: insights.XcodeVersion},
+		{Name: "export_method", Value: insights.ExportMethod},
+	}
+	withLabels := func(extra ...metricLabel) []metricLabel {
+		return append(append([]metricLabel{}, labels...), extra...)
+	}
+	succeeded := 0.0
+	if insights.Succeeded {
+		succeeded = 1
+	}
+
+	points := []metricPoint{
+		{Name: "xcode_archive_succeeded", Help: "Whether the Step succeeded (1) or failed (0).", Value: succeeded, Labels: withLabels(metricLabel{Name: "error_category", Value: insights.ErrorCategory})},
+		{Name: "xcode_archive_duration_seconds", Help: "The duration of the Step.", Unit: "s", Value: insights.DurationSeconds, Labels: withLabels()},
+		{Name: "xcode_archive_last_run_timestamp_seconds", Help: "The time of the Step run.", Unit: "s", Value: float64(insights.Timestamp.Unix()), Labels: withLabels()},
+	}
+	for _, phase := range insights.Phases {
+		points = append(points, metricPoint{
+			Name:   "xcode_archive_phase_duration_seconds",
+			Help:   "The duration of the Step phase.",
+			Unit:   "s",
+			Value:  phase.DurationSeconds,
+			Labels: withLabels(metricLabel{Name: "phase", Value: phase.Name}, metricLabel{Name: "failed", Value: strconv.FormatBool(phase.Failed)}),
+		})
+	}
+
+	operations := make([]string, 0, len(insights.Retries))
+	for operation := range insights.Retries {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+	for _, operation := range operations {
+		points = append(points, metricPoint{
+			Name:   "xcode_archive_retries",
+			Help:   "The number of retries of the operation.",
+			Value:  float64(insights.Retries[operation]),
+			Labels: withLabels(metricLabel{Name: "operation", Value: operation}),
+		})
+	}
+
+	if cache := insights.CompilationCache; cache != nil {
+		points = append(points, metricPoint{Name: "xcode_archive_compilation_cache_hit_rate", Help: "The compilation cache hit rate in percent.", Value: cache.HitRate, Labels: withLabels()})
+	}
+	return points
+}
+
+var prometheusLabelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// prometheusContent encodes the metric points in the Prometheus text exposition format,
+// the points of the same metric are expected to be consecutive.
+func prometheusContent(points []metricPoint) string {
+	var b strings.Builder
+	previous := ""
+	for _, point := range points {
+		if point.Name != previous {
+			fmt.Fprintf(&b, "# HELP %s %s\n", point.Name, point.Help)
+			fmt.Fprintf(&b, "# TYPE %s gauge\n", point.Name)
+			previous = point.Name
+		}
+
+		var labels []string
+		for _, label := range point.Labels {
+			if label.Value == "" {
+				continue
+			}
+			labels = append(labels, fmt.Sprintf(`%s="%s"`, label.Name, prometheusLabelValueEscaper.Replace(label.Value)))
+		}
+		b.WriteString(point.Name)
+		if len(labels) > 0 {
+			b.WriteString("{" + strings.Join(labels, ",") + "}")
+		}
+		b.WriteString(" " + strconv.FormatFloat(point.Value, 'f', -1, 64) + "\n")
+	}
+	return b.String()
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpDataPoint struct {
+	TimeUnixNano string          `json:"timeUnixNano"`
+	AsDouble     float64         `json:"asDouble"`
+	Attributes   []otlpAttribute `json:"attributes,omitempty"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpMetric struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Unit        string    `json:"unit,omitempty"`
+	Gauge       otlpGauge `json:"gauge"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+func otlpAttributes(labels []metricLabel) []otlpAttribute {
+	var attributes []otlpAttribute
+	for _, label := range labels {
+		if label.Value == "" {
+			continue
+		}
+		attributes = append(attributes, otlpAttribute{Key: label.Name, Value: otlpValue{StringValue: label.Value}})
+	}
+	return attributes
+}
+
+// otlpContent encodes the metric points as an OTLP/HTTP JSON export request,
+// the points of the same metric are expected to be consecutive.
+func otlpContent(points []metricPoint, appSlug string, now time.Time) ([]byte, error) {
+	var metrics []otlpMetric
+	for _, point := range points {
+		if len(metrics) == 0 || metrics[len(metrics)-1].Name != point.Name {
+			metrics = append(metrics, otlpMetric{Name: point.Name, Description: point.Help, Unit: point.Unit})
+		}
+		metric := &metrics[len(metrics)-1]
+		metric.Gauge.DataPoints = append(metric.Gauge.DataPoints, otlpDataPoint{
+			TimeUnixNano: strconv.FormatInt(now.UnixNano(), 10),
+			AsDouble:     point.Value,
+			Attributes:   otlpAttributes(point.Labels),
+		})
+	}
+
+	request := otlpMetricsRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource: otlpResource{Attributes: otlpAttributes([]metricLabel{
+			{Name: "service.name", Value: metricsServiceName},
+			{Name: "bitrise.app_slug", Value: appSlug},
+		})},
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpScope{Name: metricsServiceName}, Metrics: metrics}},
+	}}}
+	return json.Marshal(request)
+}
+
+func pushMetrics(client *http.Client, method, url, contentType string, headers map[string]string, content []byte) error {
+	req, err := http.NewRequest(method, url, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	return nil
+}
+
+// ExportMetrics pushes the phase durations and the outcome of the Step run to a Prometheus pushgateway or an OTLP/HTTP endpoint,
+// a failure is reported as a warning only.
+func (s XcodebuildArchiver) ExportMetrics(opts MetricsExportOpts) {
+	if opts.URL == "" {
+		return
+	}
+
+	now := time.Now()
+	points := newMetricPoints(newBuildInsights(opts.BuildInsightsOpts, now))
+
+	pushURL, err := metricsExportURL(opts.URL, opts.Format, opts.AppSlug)
+	if err != nil {
+		s.logger.Warnf("Failed to push the Step metrics: %s", err)
+		return
+	}
+
+	method, contentType := http.MethodPut, "text/plain; version=0.0.4"
+	content := []byte(prometheusContent(points))
+	if opts.Format == metricsFormatOTLP {
+		method, contentType = http.MethodPost, "application/json"
+		content, err = otlpContent(points, opts.AppSlug, now)
+		if err != nil {
+			s.logger.Warnf("Failed to encode the Step metrics: %s", err)
+			return
+		}
+	}
+
+	client := retry.NewHTTPClient().StandardClient()
+	client.Timeout = metricsExportTimeout
+	if err := pushMetrics(client, method, pushURL, contentType, opts.Headers, content); err != nil {
+		s.logger.Warnf("Failed to push the Step metrics: %s", err)
+		return
+	}
+	s.logger.Printf("Step metrics pushed (%s)", opts.Format)
+}
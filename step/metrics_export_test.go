@@ -0,0 +1,151 @@
+package step
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetricsExportHeaders(t *testing.T) {
+	headers, err := parseMetricsExportHeaders("authorization: Bearer token\n\n X-Scope-OrgID : team-a \n")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"Authorization": "Bearer token", "X-Scope-Orgid": "team-a"}, headers)
+
+	_, err = parseMetricsExportHeaders("Authorization: Bearer token\nsecret-token")
+	require.EqualError(t, err, "invalid header on line 2, expected Name: Value format")
+}
+
+func TestValidateMetricsExportURL(t *testing.T) {
+	require.NoError(t, validateMetricsExportURL("https://pushgateway.example.com:9091"))
+	require.Error(t, validateMetricsExportURL("pushgateway.example.com"))
+	require.Error(t, validateMetricsExportURL("ftp://pushgateway.example.com"))
+}
+
+func TestMetricsExportURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		format  string
+		appSlug string
+		want    string
+	}{
+		{url: "https://pushgateway.example.com/", format: metricsFormatPushgateway, appSlug: "app-slug", want: "https://pushgateway.example.com/metrics/job/xcode_archive/app_slug/app-slug"},
+		{url: "https://pushgateway.example.com", format: metricsFormatPushgateway, want: "https://pushgateway.example.com/metrics/job/xcode_archive"},
+		{url: "https://pushgateway.example.com/metrics/job/ios/team/a", format: metricsFormatPushgateway, appSlug: "app-slug", want: "https://pushgateway.example.com/metrics/job/ios/team/a"},
+		{url: "https://otel.example.com:4318", format: metricsFormatOTLP, want: "https://otel.example.com:4318/v1/metrics"},
+		{url: "https://otel.example.com/otlp/v1/metrics", format: metricsFormatOTLP, want: "https://otel.example.com/otlp/v1/metrics"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := metricsExportURL(tt.url, tt.format, tt.appSlug)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func sampleMetricsInsights() BuildInsights {
+	return BuildInsights{
+		Timestamp:        time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		ErrorCategory:    "archive",
+		DurationSeconds:  12.5,
+		Phases:           []PhaseTiming{{Name: "code signing", DurationSeconds: 2.5}, {Name: "archive", DurationSeconds: 10, Failed: true}},
+		Retries:          map[string]int{retryOperationCodesigning: 2, retryOperationArchive: 1},
+		CompilationCache: &buildInsightsCompilationCache{Hits: 3, Misses: 1, HitRate: 75},
+		XcodeVersion:     "15.4",
+		ExportMethod:     "app-store",
+	}
+}
+
+func TestPrometheusContent(t *testing.T) {
+	want := `# HELP xcode_archive_succeeded Whether the Step succeeded (1) or failed (0).
+# TYPE xcode_archive_succeeded gauge
+xcode_archive_succeeded{xcode_version="15.4",export_method="app-store",error_category="archive"} 0
+# HELP xcode_archive_duration_seconds The duration of the Step.
+# TYPE xcode_archive_duration_seconds gauge
+xcode_archive_duration_seconds{xcode_version="15.4",export_method="app-store"} 12.5
+# HELP xcode_archive_last_run_timestamp_seconds The time of the Step run.
+# TYPE xcode_archive_last_run_timestamp_seconds gauge
+xcode_archive_last_run_timestamp_seconds{xcode_version="15.4",export_method="app-store"} 1704164645
+# HELP xcode_archive_phase_duration_seconds The duration of the Step phase.
+# TYPE xcode_archive_phase_duration_seconds gauge
+xcode_archive_phase_duration_seconds{xcode_version="15.4",export_method="app-store",phase="code signing",failed="false"} 2.5
+xcode_archive_phase_duration_seconds{xcode_version="15.4",export_method="app-store",phase="archive",failed="true"} 10
+# HELP xcode_archive_retries The number of retries of the operation.
+# TYPE xcode_archive_retries gauge
+xcode_archive_retries{xcode_version="15.4",export_method="app-store",operation="archive"} 1
+xcode_archive_retries{xcode_version="15.4",export_method="app-store",operation="code_signing"} 2
+# HELP xcode_archive_compilation_cache_hit_rate The compilation cache hit rate in percent.
+# TYPE xcode_archive_compilation_cache_hit_rate gauge
+xcode_archive_compilation_cache_hit_rate{xcode_version="15.4",export_method="app-store"} 75
+`
+	require.Equal(t, want, prometheusContent(newMetricPoints(sampleMetricsInsights())))
+}
+
+func TestPrometheusContent_escaping(t *testing.T) {
+	points := []metricPoint{{Name: "xcode_archive_phase_duration_seconds", Help: "help", Value: 1, Labels: []metricLabel{{Name: "phase", Value: "a \"quoted\"\\phase"}, {Name: "empty"}}}}
+	require.Contains(t, prometheusContent(points), `xcode_archive_phase_duration_seconds{phase="a \"quoted\"\\phase"} 1`)
+}
+
+func TestOTLPContent(t *testing.T) {
+	now := time.Unix(1704164645, 0)
+	content, err := otlpContent(newMetricPoints(sampleMetricsInsights()), "app-slug", now)
+	require.NoError(t, err)
+
+	var request otlpMetricsRequest
+	require.NoError(t, json.Unmarshal(content, &request))
+	require.Len(t, request.ResourceMetrics, 1)
+	resourceMetrics := request.ResourceMetrics[0]
+	require.Equal(t, []otlpAttribute{
+		{Key: "service.name", Value: otlpValue{StringValue: metricsServiceName}},
+		{Key: "bitrise.app_slug", Value: otlpValue{StringValue: "app-slug"}},
+	}, resourceMetrics.Resource.Attributes)
+
+	metrics := resourceMetrics.ScopeMetrics[0].Metrics
+	require.Len(t, metrics, 6)
+	phases := metrics[3]
+	require.Equal(t, "xcode_archive_phase_duration_seconds", phases.Name)
+	require.Equal(t, "s", phases.Unit)
+	require.Len(t, phases.Gauge.DataPoints, 2)
+	require.Equal(t, otlpDataPoint{
+		TimeUnixNano: "1704164645000000000",
+		AsDouble:     10,
+		Attributes: []otlpAttribute{
+			{Key: "xcode_version", Value: otlpValue{StringValue: "15.4"}},
+			{Key: "export_method", Value: otlpValue{StringValue: "app-store"}},
+			{Key: "phase", Value: otlpValue{StringValue: "archive"}},
+			{Key: "failed", Value: otlpValue{StringValue: "true"}},
+		},
+	}, phases.Gauge.DataPoints[1])
+}
+
+func TestPushMetrics(t *testing.T) {
+	var method, body, authorization, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := io.ReadAll(r.Body)
+		method, body = r.Method, string(content)
+		authorization, contentType = r.Header.Get("Authorization"), r.Header.Get("Content-Type")
+	}))
+	defer server.Close()
+
+	headers := map[string]string{"Authorization": "Bearer token"}
+	require.NoError(t, pushMetrics(server.Client(), http.MethodPut, server.URL, "text/plain; version=0.0.4", headers, []byte("metric 1\n")))
+	require.Equal(t, http.MethodPut, method)
+	require.Equal(t, "metric 1\n", body)
+	require.Equal(t, "Bearer token", authorization)
+	require.Equal(t, "text/plain; version=0.0.4", contentType)
+}
+
+func TestPushMetrics_failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := pushMetrics(server.Client(), http.MethodPost, server.URL, "application/json", nil, []byte("{}"))
+	require.EqualError(t, err, "unexpected response status: 401 Unauthorized")
+}
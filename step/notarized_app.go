@@ -0,0 +1,83 @@
+package step
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/bitrise-io/go-xcode/plistutil"
+	"github.com/bitrise-io/go-xcode/xcodebuild"
+)
+
+const (
+	exportNotarizedAppAction = "-exportNotarizedApp"
+	notarizationPollInterval = time.Minute
+	destinationUpload        = "upload"
+)
+
+// validateNotarizationExportOptions checks that the export options upload the archive for notarization:
+// a developer-id export with the upload destination.
+func validateNotarizationExportOptions(content string) error {
+	options, err := plistutil.NewPlistDataFromContent(content)
+	if err != nil {
+		return fmt.Errorf("failed to parse the export options: %w", err)
+	}
+
+	if method, _ := options.GetString(exportoptions.MethodKey); method != string(exportoptions.MethodDeveloperID) {
+		return fmt.Errorf("%s should be %s to notarize the app, got: %s", exportoptions.MethodKey, exportoptions.MethodDeveloperID, method)
+	}
+	if destination, _ := options.GetString(exportoptions.DestinationKey); destination != destinationUpload {
+		return fmt.Errorf("%s should be %s to notarize the app, got: %s", exportoptions.DestinationKey, destinationUpload, destination)
+	}
+	return nil
+}
+
+func exportNotarizedAppArgs(archivePath, exportDir string, authOptions *xcodebuild.AuthenticationParams) []string {
+	args := []string{exportNotarizedAppAction, "-archivePath", archivePath, "-exportPath", exportDir}
+	if authOptions != nil {
+		args = append(args,
+			"-authenticationKeyPath", authOptions.KeyPath,
+			"-authenticationKeyID", authOptions.KeyID,
+			"-authenticationKeyIssuerID", authOptions.IsssuerID,
+		)
+	}
+	return args
+}
+
+// lastLine returns the last non-empty line of the output.
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// waitForNotarizedApp exports the notarized app of an archive uploaded for notarization.
+// xcodebuild -exportNotarizedApp fails until the notarization completes, so it is retried until the timeout.
+func (s XcodebuildArchiver) waitForNotarizedApp(archivePath, exportDir string, authOptions *xcodebuild.AuthenticationParams, timeout time.Duration, sleep func(time.Duration)) (string, error) {
+	s.logger.Println()
+	s.logger.Infof("Waiting for the notarization of the uploaded archive...")
+
+	args := exportNotarizedAppArgs(archivePath, exportDir, authOptions)
+	var waited time.Duration
+	for attempt := 1; ; attempt++ {
+		output, err := s.xcodeCommandRunner.Run("", args, []string{})
+		if err == nil && output.ExitCode == 0 {
+			appPath, err := findExportedApp(exportDir)
+			if err != nil {
+				return "", err
+			}
+			if appPath == "" {
+				return "", fmt.Errorf("no notarized app found in the export dir: %s", exportDir)
+			}
+			s.logger.Donef("The notarized app is exported: %s", appPath)
+			return appPath, nil
+		}
+
+		if waited+notarizationPollInterval > timeout {
+			return "", fmt.Errorf("the notarized app is not available after %s: %s", timeout, lastLine(string(output.RawOut)))
+		}
+		s.logger.Printf("The notarization is not completed yet (attempt %d), retrying in %s...", attempt, notarizationPollInterval)
+		sleep(notarizationPollInterval)
+		waited += notarizationPollInterval
+	}
+}
@@ -0,0 +1,30 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/xcodebuild"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_validateNotarizationExportOptions(t *testing.T) {
+	plist := func(method, destination string) string {
+		return `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict><key>method</key><string>` + method + `</string><key>destination</key><string>` + destination + `</string></dict></plist>`
+	}
+
+	require.NoError(t, validateNotarizationExportOptions(plist("developer-id", "upload")))
+	require.EqualError(t, validateNotarizationExportOptions(plist("developer-id", "export")), "destination should be upload to notarize the app, got: export")
+	require.EqualError(t, validateNotarizationExportOptions(plist("app-store", "upload")), "method should be developer-id to notarize the app, got: app-store")
+}
+
+func Test_exportNotarizedAppArgs(t *testing.T) {
+	require.Equal(t, []string{"-exportNotarizedApp", "-archivePath", "App.xcarchive", "-exportPath", "notarized"},
+		exportNotarizedAppArgs("App.xcarchive", "notarized", nil))
+
+	authOptions := &xcodebuild.AuthenticationParams{KeyID: "KEYID", IsssuerID: "ISSUER", KeyPath: "key.p8"}
+	require.Equal(t, []string{
+		"-exportNotarizedApp", "-archivePath", "App.xcarchive", "-exportPath", "notarized",
+		"-authenticationKeyPath", "key.p8", "-authenticationKeyID", "KEYID", "-authenticationKeyIssuerID", "ISSUER",
+	}, exportNotarizedAppArgs("App.xcarchive", "notarized", authOptions))
+}
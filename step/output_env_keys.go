@@ -0,0 +1,90 @@
+package step
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+)
+
+const (
+	defaultOutputEnvKeyPrefix = "BITRISE_"
+
+	// autoOutputEnvKeySuffix selects the suffix based on the number of earlier invocations of the Step in the Workflow:
+	// the first invocation exports the outputs without suffix, the later ones with _2, _3, ...
+	autoOutputEnvKeySuffix = "auto"
+	// invocationCountEnvKey counts the invocations of the Step in the Workflow, it is not an output so it has no prefix and suffix.
+	invocationCountEnvKey = "XCODE_ARCHIVE_INVOCATION_COUNT"
+)
+
+var (
+	envKeyPattern       = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	envKeySuffixPattern = regexp.MustCompile(`^[A-Za-z0-9_]*$`)
+)
+
+// OutputEnvKeys configures the keys the outputs are exported with.
+type OutputEnvKeys struct {
+	// Prefix replaces the BITRISE_ prefix of the output keys, the BITRISE_ prefix is kept if empty
+	Prefix string
+	// Suffix is appended to the output keys, the resolved output_suffix input (see Config.OutputEnvKeySuffix)
+	Suffix string
+	// SensitiveIdentifiers exports the outputs containing team or bundle IDs as sensitive outputs, set if the identifiers are redacted
+	SensitiveIdentifiers bool
+}
+
+// SetOutputEnvKeys sets the keys the outputs of the archiver are exported with.
+func (s *XcodebuildArchiver) SetOutputEnvKeys(keys OutputEnvKeys) {
+	s.outputEnvKeys = keys
+}
+
+// outputEnvKey returns the key an output is exported with.
+func (s XcodebuildArchiver) outputEnvKey(key string) string {
+	return s.outputEnvKeys.key(key)
+}
+
+// key returns the key an output is exported with.
+func (k OutputEnvKeys) key(key string) string {
+	if !strings.HasPrefix(key, defaultOutputEnvKeyPrefix) {
+		return key
+	}
+	prefix := k.Prefix
+	if prefix == "" {
+		prefix = defaultOutputEnvKeyPrefix
+	}
+	return prefix + strings.TrimPrefix(key, defaultOutputEnvKeyPrefix) + k.Suffix
+}
+
+func validateOutputEnvKeyPrefix(prefix string) error {
+	if !envKeyPattern.MatchString(prefix) {
+		return fmt.Errorf("invalid output Environment Variable key prefix (%s): only letters, digits and underscores are allowed, and it can not start with a digit", prefix)
+	}
+	return nil
+}
+
+// resolveOutputEnvKeySuffix returns the suffix of the exported Environment Variable keys,
+// invocationCount is the number of earlier invocations of the Step in the Workflow.
+func resolveOutputEnvKeySuffix(suffix string, invocationCount int) (string, error) {
+	if suffix == autoOutputEnvKeySuffix {
+		if invocationCount == 0 {
+			return "", nil
+		}
+		return "_" + strconv.Itoa(invocationCount+1), nil
+	}
+
+	if !envKeySuffixPattern.MatchString(suffix) {
+		return "", fmt.Errorf("invalid output Environment Variable key suffix (%s): only letters, digits and underscores are allowed", suffix)
+	}
+	return suffix, nil
+}
+
+// ExportInvocationCount exports the number of the Step's invocations in the Workflow (including the current one),
+// the next invocation selects its output key suffix based on it.
+// The invocations are counted only if the auto suffix is selected, a dry run is not counted.
+func ExportInvocationCount(cmdFactory command.Factory, config Config) error {
+	if config.OutputSuffix != autoOutputEnvKeySuffix || config.DryRun {
+		return nil
+	}
+	return exportEnvironmentWithEnvman(cmdFactory, OutputEnvKeys{}, invocationCountEnvKey, strconv.Itoa(config.InvocationCount+1))
+}
@@ -0,0 +1,68 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_outputEnvKey(t *testing.T) {
+	var archiver XcodebuildArchiver
+	require.Equal(t, "BITRISE_IPA_PATH", archiver.outputEnvKey(bitriseIPAPthEnvKey))
+
+	qaArchiver := XcodebuildArchiver{}
+	qaArchiver.SetOutputEnvKeys(OutputEnvKeys{Prefix: "QA_"})
+	require.Equal(t, "QA_IPA_PATH", qaArchiver.outputEnvKey(bitriseIPAPthEnvKey))
+	require.Equal(t, "QA_XCARCHIVE_PATH", qaArchiver.outputEnvKey(bitriseXCArchivePthEnvKey))
+	require.Equal(t, "BITRISE_IPA_PATH", archiver.outputEnvKey(bitriseIPAPthEnvKey), "the archivers don't share their output keys")
+
+	secondArchiver := XcodebuildArchiver{}
+	secondArchiver.SetOutputEnvKeys(OutputEnvKeys{Suffix: "_2"})
+	require.Equal(t, "BITRISE_IPA_PATH_2", secondArchiver.outputEnvKey(bitriseIPAPthEnvKey))
+	require.Equal(t, invocationCountEnvKey, secondArchiver.outputEnvKey(invocationCountEnvKey))
+	require.Equal(t, "BITRISE_IPA_PATH", archiver.outputEnvKey(bitriseIPAPthEnvKey))
+}
+
+func Test_resolveOutputEnvKeySuffix(t *testing.T) {
+	tests := []struct {
+		name            string
+		suffix          string
+		invocationCount int
+		want            string
+		wantErr         bool
+	}{
+		{name: "no suffix", suffix: "", invocationCount: 1, want: ""},
+		{name: "custom suffix", suffix: "_QA", invocationCount: 1, want: "_QA"},
+		{name: "auto, first invocation", suffix: "auto", invocationCount: 0, want: ""},
+		{name: "auto, second invocation", suffix: "auto", invocationCount: 1, want: "_2"},
+		{name: "auto, third invocation", suffix: "auto", invocationCount: 2, want: "_3"},
+		{name: "invalid suffix", suffix: "-QA", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveOutputEnvKeySuffix(tt.suffix, tt.invocationCount)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_validateOutputEnvKeyPrefix(t *testing.T) {
+	for _, prefix := range []string{"BITRISE_", "QA_", "_", "APP2_"} {
+		require.NoError(t, validateOutputEnvKeyPrefix(prefix), prefix)
+	}
+	for _, prefix := range []string{"", "2APP_", "QA-", "QA PREFIX_", "$QA_"} {
+		require.Error(t, validateOutputEnvKeyPrefix(prefix), prefix)
+	}
+}
+
+func TestExportInvocationCount(t *testing.T) {
+	// The invocations are not counted without the auto suffix and in dry runs, no envman call is made
+	require.NoError(t, ExportInvocationCount(nil, Config{}))
+	require.NoError(t, ExportInvocationCount(nil, Config{Inputs: Inputs{OutputSuffix: "_QA"}}))
+	require.NoError(t, ExportInvocationCount(nil, Config{Inputs: Inputs{OutputSuffix: autoOutputEnvKeySuffix, DryRun: true}}))
+}
@@ -0,0 +1,39 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	outputLayoutFlat   = "flat"
+	outputLayoutByType = "by_type"
+)
+
+type outputArtifactType string
+
+const (
+	outputArtifactArchive outputArtifactType = "archive"
+	outputArtifactIPA     outputArtifactType = "ipa"
+	outputArtifactDSYM    outputArtifactType = "dsym"
+	outputArtifactLogs    outputArtifactType = "logs"
+)
+
+// artifactOutputDir returns the directory the given type of artifact is exported to.
+// With the by_type layout every artifact type gets its own sub-directory under the output dir.
+func artifactOutputDir(outputDir, layout string, artifactType outputArtifactType) string {
+	if layout != outputLayoutByType {
+		return outputDir
+	}
+	return filepath.Join(outputDir, string(artifactType))
+}
+
+// outputDirForArtifact is like artifactOutputDir, but it also creates the directory if needed.
+func outputDirForArtifact(outputDir, layout string, artifactType outputArtifactType) (string, error) {
+	dir := artifactOutputDir(outputDir, layout, artifactType)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return "", fmt.Errorf("failed to create output sub-directory (%s), error: %s", dir, err)
+	}
+	return dir, nil
+}
@@ -0,0 +1,46 @@
+package step
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_outputDirForArtifact(t *testing.T) {
+	outputDir := t.TempDir()
+
+	tests := []struct {
+		name         string
+		layout       string
+		artifactType outputArtifactType
+		want         string
+	}{
+		{
+			name:         "flat layout",
+			layout:       outputLayoutFlat,
+			artifactType: outputArtifactIPA,
+			want:         outputDir,
+		},
+		{
+			name:         "empty layout defaults to flat",
+			layout:       "",
+			artifactType: outputArtifactDSYM,
+			want:         outputDir,
+		},
+		{
+			name:         "by type layout",
+			layout:       outputLayoutByType,
+			artifactType: outputArtifactLogs,
+			want:         filepath.Join(outputDir, "logs"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := outputDirForArtifact(outputDir, tt.layout, tt.artifactType)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			require.DirExists(t, got)
+		})
+	}
+}
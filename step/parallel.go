@@ -0,0 +1,40 @@
+package step
+
+import "sync"
+
+const maxParallelPackagingTasks = 3
+
+// runInParallel runs the tasks concurrently, with at most maxWorkers running at the same time.
+// It waits for every task to finish and returns the error of the first failed task (in the order of the tasks).
+func runInParallel(maxWorkers int, tasks ...func() error) error {
+	if maxWorkers < 1 {
+		maxWorkers = 1
+	}
+
+	errs := make([]error, len(tasks))
+	semaphore := make(chan struct{}, maxWorkers)
+	var wg sync.WaitGroup
+
+	for i, task := range tasks {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(i int, task func() error) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			errs[i] = task()
+		}(i, task)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
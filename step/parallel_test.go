@@ -0,0 +1,45 @@
+package step
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runInParallel(t *testing.T) {
+	var running, maxRunning, finished int32
+	task := func() error {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			observed := atomic.LoadInt32(&maxRunning)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxRunning, observed, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&finished, 1)
+		return nil
+	}
+
+	require.NoError(t, runInParallel(2, task, task, task, task, task))
+	require.Equal(t, int32(5), finished)
+	require.LessOrEqual(t, maxRunning, int32(2))
+}
+
+func Test_runInParallel_ReturnsFirstError(t *testing.T) {
+	firstErr := errors.New("first")
+	secondErr := errors.New("second")
+
+	var finished int32
+	err := runInParallel(3,
+		func() error { atomic.AddInt32(&finished, 1); return nil },
+		func() error { time.Sleep(10 * time.Millisecond); atomic.AddInt32(&finished, 1); return firstErr },
+		func() error { atomic.AddInt32(&finished, 1); return secondErr },
+	)
+	require.Equal(t, firstErr, err)
+	require.Equal(t, int32(3), finished)
+}
@@ -0,0 +1,92 @@
+package step
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const bitriseStepPhaseTimingsPthEnvKey = "BITRISE_STEP_PHASE_TIMINGS_PATH"
+
+// PhaseTiming is the timing event of a Step phase.
+type PhaseTiming struct {
+	Name            string    `json:"name"`
+	StartTime       time.Time `json:"start_time"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Failed          bool      `json:"failed"`
+}
+
+// PhaseTracker records the timing of the Step's consecutive phases (for example input processing, archive, export),
+// a nil PhaseTracker records nothing.
+type PhaseTracker struct {
+	timings []PhaseTiming
+	current *PhaseTiming
+	now     func() time.Time
+}
+
+// NewPhaseTracker ...
+func NewPhaseTracker() *PhaseTracker {
+	return &PhaseTracker{now: time.Now}
+}
+
+// Begin starts a new phase, ending the current one.
+func (t *PhaseTracker) Begin(name string) {
+	if t == nil {
+		return
+	}
+	t.End(false)
+	t.current = &PhaseTiming{Name: name, StartTime: t.now()}
+}
+
+// End ends the current phase, failed marks the phase as the cause of the Step failure.
+func (t *PhaseTracker) End(failed bool) {
+	if t == nil || t.current == nil {
+		return
+	}
+	t.current.DurationSeconds = t.now().Sub(t.current.StartTime).Seconds()
+	t.current.Failed = failed
+	t.timings = append(t.timings, *t.current)
+	t.current = nil
+}
+
+// Timings returns the ended phases.
+func (t *PhaseTracker) Timings() []PhaseTiming {
+	if t == nil {
+		return nil
+	}
+	return t.timings
+}
+
+// ExportPhaseTimings prints the phase timings and writes them as a JSON file to the logs output dir.
+func (s XcodebuildArchiver) ExportPhaseTimings(timings []PhaseTiming, outputDir, outputLayout string) error {
+	s.logger.Println()
+	s.logger.Infof("Step phase timings:")
+	for _, timing := range timings {
+		status := ""
+		if timing.Failed {
+			status = " (failed)"
+		}
+		s.logger.Printf("- %s: %.1f seconds%s", timing.Name, timing.DurationSeconds, status)
+	}
+
+	content, err := json.MarshalIndent(timings, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	logsOutputDir, err := outputDirForArtifact(outputDir, outputLayout, outputArtifactLogs)
+	if err != nil {
+		return err
+	}
+	pth := filepath.Join(logsOutputDir, "step-phase-timings.json")
+	if err := os.WriteFile(pth, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", pth, err)
+	}
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseStepPhaseTimingsPthEnvKey, pth); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseStepPhaseTimingsPthEnvKey), err)
+	}
+	s.logger.Donef("The Step phase timings path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseStepPhaseTimingsPthEnvKey), pth)
+	return nil
+}
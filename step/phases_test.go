@@ -0,0 +1,33 @@
+package step
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhaseTracker(t *testing.T) {
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	tracker := &PhaseTracker{now: func() time.Time { return now }}
+
+	tracker.Begin("input processing")
+	now = now.Add(2 * time.Second)
+	tracker.Begin("archive")
+	now = now.Add(90 * time.Second)
+	tracker.End(true)
+	tracker.End(false)
+
+	require.Equal(t, []PhaseTiming{
+		{Name: "input processing", StartTime: start, DurationSeconds: 2},
+		{Name: "archive", StartTime: start.Add(2 * time.Second), DurationSeconds: 90, Failed: true},
+	}, tracker.Timings())
+}
+
+func TestPhaseTracker_nil(t *testing.T) {
+	var tracker *PhaseTracker
+	tracker.Begin("archive")
+	tracker.End(false)
+	require.Nil(t, tracker.Timings())
+}
@@ -22,11 +22,18 @@ const (
 	visionOS Platform = "visionOS"
 )
 
-func OpenArchivableProject(pth, schemeName, configurationName string) (*xcodeproj.XcodeProj, *xcscheme.Scheme, string, error) {
+// OpenArchivableProject opens the project of the scheme's main application target: the given target if set,
+// otherwise the scheme's first archivable application target.
+func OpenArchivableProject(pth, schemeName, configurationName, targetName string) (*xcodeproj.XcodeProj, *xcscheme.Scheme, string, error) {
 	scheme, schemeContainerDir, err := schemeint.Scheme(pth, schemeName)
 	if err != nil {
 		return nil, nil, "", fmt.Errorf("could not get scheme (%s) from path (%s): %s", schemeName, pth, err)
 	}
+	if targetName != "" {
+		if err := selectApplicationTarget(scheme, targetName); err != nil {
+			return nil, nil, "", err
+		}
+	}
 	if configurationName == "" {
 		configurationName = scheme.ArchiveAction.BuildConfiguration
 	}
@@ -52,6 +59,34 @@ func OpenArchivableProject(pth, schemeName, configurationName string) (*xcodepro
 	return &xcodeProj, scheme, configurationName, nil
 }
 
+// archivableApplicationTargets returns the names of the application targets built by the scheme's archive action.
+func archivableApplicationTargets(scheme *xcscheme.Scheme) []string {
+	var names []string
+	for _, entry := range scheme.BuildAction.BuildActionEntries {
+		if entry.BuildForArchiving == "YES" && entry.BuildableReference.IsAppReference() {
+			names = append(names, entry.BuildableReference.BlueprintName)
+		}
+	}
+	return names
+}
+
+// selectApplicationTarget makes the given application target the scheme's main target, by moving its entry
+// to the front of the build action entries, as the first archivable application entry is considered as the main target.
+func selectApplicationTarget(scheme *xcscheme.Scheme, targetName string) error {
+	entries := scheme.BuildAction.BuildActionEntries
+	for i, entry := range entries {
+		if entry.BuildForArchiving != "YES" || !entry.BuildableReference.IsAppReference() || entry.BuildableReference.BlueprintName != targetName {
+			continue
+		}
+
+		reordered := []xcscheme.BuildActionEntry{entry}
+		reordered = append(reordered, entries[:i]...)
+		scheme.BuildAction.BuildActionEntries = append(reordered, entries[i+1:]...)
+		return nil
+	}
+	return fmt.Errorf("no archivable application target (%s) found in the scheme (%s), application targets: %s", targetName, scheme.Name, strings.Join(archivableApplicationTargets(scheme), ", "))
+}
+
 type TargetBuildSettingsProvider interface {
 	TargetBuildSettings(xcodeProj *xcodeproj.XcodeProj, target, configuration string, customOptions ...string) (serialized.Object, error)
 }
@@ -182,3 +182,32 @@ func Test_getPlatform(t *testing.T) {
 		})
 	}
 }
+
+func Test_selectApplicationTarget(t *testing.T) {
+	newScheme := func() *xcscheme.Scheme {
+		return &xcscheme.Scheme{
+			Name: "App",
+			BuildAction: xcscheme.BuildAction{
+				BuildActionEntries: []xcscheme.BuildActionEntry{
+					{BuildForArchiving: "YES", BuildableReference: xcscheme.BuildableReference{BuildableName: "Core.framework", BlueprintName: "Core", BlueprintIdentifier: "Core_id"}},
+					{BuildForArchiving: "YES", BuildableReference: xcscheme.BuildableReference{BuildableName: "App.app", BlueprintName: "App", BlueprintIdentifier: "App_id"}},
+					{BuildForArchiving: "NO", BuildableReference: xcscheme.BuildableReference{BuildableName: "Sample.app", BlueprintName: "Sample", BlueprintIdentifier: "Sample_id"}},
+					{BuildForArchiving: "YES", BuildableReference: xcscheme.BuildableReference{BuildableName: "Watch.app", BlueprintName: "Watch", BlueprintIdentifier: "Watch_id"}},
+				},
+			},
+		}
+	}
+
+	scheme := newScheme()
+	require.Equal(t, []string{"App", "Watch"}, archivableApplicationTargets(scheme))
+
+	require.NoError(t, selectApplicationTarget(scheme, "Watch"))
+	entry, ok := scheme.AppBuildActionEntry()
+	require.True(t, ok)
+	require.Equal(t, "Watch", entry.BuildableReference.BlueprintName)
+	require.Len(t, scheme.BuildAction.BuildActionEntries, 4)
+	require.Equal(t, []string{"Watch", "App"}, archivableApplicationTargets(scheme))
+
+	require.EqualError(t, selectApplicationTarget(newScheme(), "Sample"), "no archivable application target (Sample) found in the scheme (App), application targets: App, Watch")
+	require.Error(t, selectApplicationTarget(newScheme(), "Core"))
+}
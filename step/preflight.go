@@ -0,0 +1,154 @@
+package step
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/certificateutil"
+	"github.com/bitrise-io/go-xcode/profileutil"
+)
+
+const profileExpiryWarningPeriod = 7 * 24 * time.Hour
+
+type simulator struct {
+	Runtime string
+	Name    string
+	UDID    string
+	State   string
+}
+
+type preflightReport struct {
+	Xcodes        []xcodeInstallation
+	Identities    []certificateutil.CertificateInfoModel
+	Profiles      []profileutil.ProvisioningProfileInfoModel
+	FreeDiskBytes uint64
+	Simulators    []simulator
+	Architecture  hostArchitecture
+}
+
+// parseSimulators parses the output of `xcrun simctl list devices available --json`.
+func parseSimulators(simctlOutput string) ([]simulator, error) {
+	var list struct {
+		Devices map[string][]struct {
+			Name  string `json:"name"`
+			UDID  string `json:"udid"`
+			State string `json:"state"`
+		} `json:"devices"`
+	}
+	if err := json.Unmarshal([]byte(simctlOutput), &list); err != nil {
+		return nil, err
+	}
+
+	var simulators []simulator
+	for runtime, devices := range list.Devices {
+		runtimeName := strings.TrimPrefix(runtime, "com.apple.CoreSimulator.SimRuntime.")
+		for _, device := range devices {
+			simulators = append(simulators, simulator{Runtime: runtimeName, Name: device.Name, UDID: device.UDID, State: device.State})
+		}
+	}
+	sort.SliceStable(simulators, func(i, j int) bool {
+		if simulators[i].Runtime != simulators[j].Runtime {
+			return simulators[i].Runtime < simulators[j].Runtime
+		}
+		return simulators[i].Name < simulators[j].Name
+	})
+	return simulators, nil
+}
+
+// expiryStatus describes the validity of a certificate or profile expiring at the given time.
+func expiryStatus(expiry, now time.Time) string {
+	switch {
+	case expiry.Before(now):
+		return "EXPIRED"
+	case expiry.Before(now.Add(profileExpiryWarningPeriod)):
+		return fmt.Sprintf("expires in %d hours", int(expiry.Sub(now).Hours()))
+	default:
+		return fmt.Sprintf("expires in %d days", int(expiry.Sub(now).Hours()/24))
+	}
+}
+
+func formatBytes(bytes uint64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := uint64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+func freeDiskBytes(pth string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(pth, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
+
+// collectPreflightReport gathers the signing environment of the machine, failing checks are logged as warnings.
+func (s XcodebuildArchiveConfigParser) collectPreflightReport(workDir string) preflightReport {
+	var report preflightReport
+	var err error
+
+	if report.Xcodes, err = installedXcodes(xcodeApplicationsDir); err != nil {
+		s.logger.Warnf("Failed to list the installed Xcodes: %s", err)
+	}
+	if report.Identities, err = certificateutil.InstalledCodesigningCertificateInfos(); err != nil {
+		s.logger.Warnf("Failed to list the installed codesigning identities: %s", err)
+	}
+	if report.Profiles, err = profileutil.InstalledProvisioningProfileInfos(profileutil.ProfileTypeIos); err != nil {
+		s.logger.Warnf("Failed to list the installed provisioning profiles: %s", err)
+	}
+	if report.FreeDiskBytes, err = freeDiskBytes(workDir); err != nil {
+		s.logger.Warnf("Failed to check the free disk space: %s", err)
+	}
+
+	cmd := s.cmdFactory.Create("xcrun", []string{"simctl", "list", "devices", "available", "--json"}, nil)
+	out, err := cmd.RunAndReturnTrimmedOutput()
+	if err == nil {
+		report.Simulators, err = parseSimulators(out)
+	}
+	if err != nil {
+		s.logger.Warnf("Failed to list the available simulators: %s", err)
+	}
+
+	return report
+}
+
+func printPreflightReport(report preflightReport, now time.Time, logger log.Logger) {
+	logger.Println()
+	logger.Infof("Preflight report:")
+
+	logger.Printf("Installed Xcodes (%d):", len(report.Xcodes))
+	for _, xcode := range report.Xcodes {
+		logger.Printf("- %s: %s", xcode.Version, xcode.Path)
+	}
+
+	logger.Printf("Codesigning identities (%d):", len(report.Identities))
+	for _, identity := range report.Identities {
+		logger.Printf("- %s [%s] (%s)", identity.CommonName, identity.Serial, expiryStatus(identity.EndDate, now))
+	}
+
+	logger.Printf("Provisioning profiles (%d):", len(report.Profiles))
+	for _, profile := range report.Profiles {
+		logger.Printf("- %s (%s) %s, team: %s, bundle ID: %s (%s)", profile.Name, profile.UUID, profile.ExportType, profile.TeamID, profile.BundleID, expiryStatus(profile.ExpirationDate, now))
+	}
+
+	logger.Printf("Free disk space: %s", formatBytes(report.FreeDiskBytes))
+
+	printHostArchitecture(report.Architecture, logger)
+
+	logger.Printf("Available simulators (%d):", len(report.Simulators))
+	for _, sim := range report.Simulators {
+		logger.Printf("- %s: %s (%s) %s", sim.Runtime, sim.Name, sim.UDID, sim.State)
+	}
+	logger.Println()
+}
@@ -0,0 +1,54 @@
+package step
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseSimulators(t *testing.T) {
+	out := `{
+  "devices" : {
+    "com.apple.CoreSimulator.SimRuntime.iOS-17-5" : [
+      {
+        "state" : "Shutdown",
+        "isAvailable" : true,
+        "name" : "iPhone 15",
+        "udid" : "B0B2F1A4-0C3A-4D6B-9A0E-6B3C1F6E2D11"
+      },
+      {
+        "state" : "Booted",
+        "isAvailable" : true,
+        "name" : "iPad Air 11-inch (M2)",
+        "udid" : "5E1A3C6F-2B4D-4E8A-8C1F-9D2B7A3E4F22"
+      }
+    ],
+    "com.apple.CoreSimulator.SimRuntime.watchOS-10-5" : []
+  }
+}`
+
+	simulators, err := parseSimulators(out)
+	require.NoError(t, err)
+	require.Equal(t, []simulator{
+		{Runtime: "iOS-17-5", Name: "iPad Air 11-inch (M2)", UDID: "5E1A3C6F-2B4D-4E8A-8C1F-9D2B7A3E4F22", State: "Booted"},
+		{Runtime: "iOS-17-5", Name: "iPhone 15", UDID: "B0B2F1A4-0C3A-4D6B-9A0E-6B3C1F6E2D11", State: "Shutdown"},
+	}, simulators)
+
+	_, err = parseSimulators("No devices")
+	require.Error(t, err)
+}
+
+func Test_expiryStatus(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	require.Equal(t, "EXPIRED", expiryStatus(now.Add(-time.Hour), now))
+	require.Equal(t, "expires in 30 hours", expiryStatus(now.Add(30*time.Hour), now))
+	require.Equal(t, "expires in 90 days", expiryStatus(now.AddDate(0, 0, 90), now))
+}
+
+func Test_formatBytes(t *testing.T) {
+	require.Equal(t, "512 B", formatBytes(512))
+	require.Equal(t, "1.5 KB", formatBytes(1536))
+	require.Equal(t, "42.0 GB", formatBytes(42*1024*1024*1024))
+}
@@ -0,0 +1,79 @@
+package step
+
+import (
+	"sync"
+
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcodeproj"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcscheme"
+)
+
+// archivableProjectKey identifies an opened project by the arguments of OpenArchivableProject.
+type archivableProjectKey struct {
+	path          string
+	scheme        string
+	configuration string
+	target        string
+}
+
+type archivableProject struct {
+	xcodeProj     *xcodeproj.XcodeProj
+	scheme        *xcscheme.Scheme
+	configuration string
+}
+
+// archivableProjectCache stores the parsed projects of a single XcodebuildArchiver.Run, parsing a large project can take minutes.
+// The project is not modified during the run, so it is shared, but every caller gets its own copy of the scheme.
+// A new cache is created for every run, so a project edited between runs is parsed again.
+type archivableProjectCache struct {
+	mu       sync.Mutex
+	projects map[archivableProjectKey]archivableProject
+}
+
+func newArchivableProjectCache() *archivableProjectCache {
+	return &archivableProjectCache{projects: map[archivableProjectKey]archivableProject{}}
+}
+
+// open opens the project like OpenArchivableProject, the parsed project is reused by the later calls with the same arguments.
+// A nil cache opens the project on every call.
+func (c *archivableProjectCache) open(pth, schemeName, configurationName, targetName string) (*xcodeproj.XcodeProj, *xcscheme.Scheme, string, error) {
+	if c == nil {
+		return OpenArchivableProject(pth, schemeName, configurationName, targetName)
+	}
+
+	key := archivableProjectKey{path: pth, scheme: schemeName, configuration: configurationName, target: targetName}
+	if xcodeProj, scheme, configuration, ok := c.get(key); ok {
+		return xcodeProj, scheme, configuration, nil
+	}
+
+	xcodeProj, scheme, configuration, err := OpenArchivableProject(pth, schemeName, configurationName, targetName)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	c.set(key, xcodeProj, scheme, configuration)
+	return xcodeProj, copyScheme(scheme), configuration, nil
+}
+
+func (c *archivableProjectCache) get(key archivableProjectKey) (*xcodeproj.XcodeProj, *xcscheme.Scheme, string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	project, ok := c.projects[key]
+	if !ok {
+		return nil, nil, "", false
+	}
+	return project.xcodeProj, copyScheme(project.scheme), project.configuration, true
+}
+
+func (c *archivableProjectCache) set(key archivableProjectKey, xcodeProj *xcodeproj.XcodeProj, scheme *xcscheme.Scheme, configuration string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.projects[key] = archivableProject{xcodeProj: xcodeProj, scheme: copyScheme(scheme), configuration: configuration}
+}
+
+// copyScheme copies the scheme with its build action entries, which are reordered when selecting the application target.
+func copyScheme(scheme *xcscheme.Scheme) *xcscheme.Scheme {
+	schemeCopy := *scheme
+	schemeCopy.BuildAction.BuildActionEntries = append([]xcscheme.BuildActionEntry(nil), scheme.BuildAction.BuildActionEntries...)
+	return &schemeCopy
+}
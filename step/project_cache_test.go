@@ -0,0 +1,133 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeSampleProject writes a project with the given number of application targets and a shared scheme archiving all of them.
+func writeSampleProject(t testing.TB, targetCount int) string {
+	projectPth := filepath.Join(t.TempDir(), "Sample.xcodeproj")
+	schemesDir := filepath.Join(projectPth, "xcshareddata", "xcschemes")
+	require.NoError(t, os.MkdirAll(schemesDir, 0755))
+
+	var objects, targetIDs, entries []string
+	objects = append(objects,
+		`"CL0" = { isa = XCConfigurationList; buildConfigurations = ("BC0"); defaultConfigurationName = Release; };`,
+		`"BC0" = { isa = XCBuildConfiguration; buildSettings = { SDKROOT = iphoneos; }; name = Release; };`,
+	)
+	for i := 0; i < targetCount; i++ {
+		id := fmt.Sprintf("T%d", i)
+		targetIDs = append(targetIDs, fmt.Sprintf("%q", id))
+		objects = append(objects,
+			fmt.Sprintf(`"%s" = { isa = PBXNativeTarget; buildConfigurationList = "CL%d"; buildPhases = (); dependencies = (); name = App%d; productName = App%d; productReference = "P%d"; productType = "com.apple.product-type.application"; };`, id, i+1, i, i, i),
+			fmt.Sprintf(`"P%d" = { isa = PBXFileReference; explicitFileType = wrapper.application; path = App%d.app; sourceTree = BUILT_PRODUCTS_DIR; };`, i, i),
+			fmt.Sprintf(`"CL%d" = { isa = XCConfigurationList; buildConfigurations = ("TBC%d"); defaultConfigurationName = Release; };`, i+1, i),
+			fmt.Sprintf(`"TBC%d" = { isa = XCBuildConfiguration; buildSettings = { PRODUCT_BUNDLE_IDENTIFIER = io.bitrise.App%d; SDKROOT = iphoneos; }; name = Release; };`, i, i),
+		)
+		entries = append(entries, fmt.Sprintf(`<BuildActionEntry buildForTesting="YES" buildForRunning="YES" buildForProfiling="YES" buildForArchiving="YES" buildForAnalyzing="YES">
+<BuildableReference BuildableIdentifier="primary" BlueprintIdentifier="%s" BuildableName="App%d.app" BlueprintName="App%d" ReferencedContainer="container:Sample.xcodeproj"></BuildableReference>
+</BuildActionEntry>`, id, i, i))
+	}
+	objects = append(objects, fmt.Sprintf(`"R0" = { isa = PBXProject; attributes = { TargetAttributes = { }; }; buildConfigurationList = "CL0"; targets = (%s); };`, strings.Join(targetIDs, ", ")))
+
+	pbxproj := fmt.Sprintf("// !$*UTF8*$!\n{ archiveVersion = 1; classes = { }; objectVersion = 54; objects = {\n%s\n}; rootObject = \"R0\"; }\n", strings.Join(objects, "\n"))
+	require.NoError(t, os.WriteFile(filepath.Join(projectPth, "project.pbxproj"), []byte(pbxproj), 0644))
+
+	scheme := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<Scheme LastUpgradeVersion="1500" version="1.7">
+<BuildAction parallelizeBuildables="YES" buildImplicitDependencies="YES">
+<BuildActionEntries>
+%s
+</BuildActionEntries>
+</BuildAction>
+<ArchiveAction buildConfiguration="Release" revealArchiveInOrganizer="YES"></ArchiveAction>
+</Scheme>
+`, strings.Join(entries, "\n"))
+	require.NoError(t, os.WriteFile(filepath.Join(schemesDir, "Sample.xcscheme"), []byte(scheme), 0644))
+
+	return projectPth
+}
+
+func TestArchivableProjectCache_open(t *testing.T) {
+	projects := newArchivableProjectCache()
+	projectPth := writeSampleProject(t, 3)
+
+	xcodeProj, scheme, configuration, err := projects.open(projectPth, "Sample", "", "App1")
+	require.NoError(t, err)
+	require.Equal(t, "Release", configuration)
+	require.Equal(t, []string{"App1", "App0", "App2"}, archivableApplicationTargets(scheme))
+
+	// The project is not parsed again
+	cachedProj, cachedScheme, cachedConfiguration, err := projects.open(projectPth, "Sample", "", "App1")
+	require.NoError(t, err)
+	require.Same(t, xcodeProj, cachedProj)
+	require.Equal(t, configuration, cachedConfiguration)
+	require.Equal(t, scheme, cachedScheme)
+
+	// Changing the returned scheme doesn't change the cached one
+	require.NoError(t, selectApplicationTarget(cachedScheme, "App2"))
+	_, cachedScheme, _, err = projects.open(projectPth, "Sample", "", "App1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"App1", "App0", "App2"}, archivableApplicationTargets(cachedScheme))
+
+	// Other arguments open the project again
+	_, otherScheme, _, err := projects.open(projectPth, "Sample", "", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"App0", "App1", "App2"}, archivableApplicationTargets(otherScheme))
+
+	// Errors are not cached
+	_, _, _, err = projects.open(projectPth, "Missing", "", "")
+	require.Error(t, err)
+	require.Len(t, projects.projects, 2)
+}
+
+func TestArchivableProjectCache_open_perRun(t *testing.T) {
+	projectPth := writeSampleProject(t, 3)
+
+	xcodeProj, _, _, err := newArchivableProjectCache().open(projectPth, "Sample", "", "")
+	require.NoError(t, err)
+
+	// A project edited after a run is parsed again by the next run
+	editedPth := writeSampleProject(t, 2)
+	require.NoError(t, os.Rename(filepath.Join(editedPth, "project.pbxproj"), filepath.Join(projectPth, "project.pbxproj")))
+
+	editedProj, _, _, err := newArchivableProjectCache().open(projectPth, "Sample", "", "")
+	require.NoError(t, err)
+	require.NotSame(t, xcodeProj, editedProj)
+	require.Len(t, editedProj.Proj.Targets, 2)
+
+	// A nil cache opens the project on every call
+	var noCache *archivableProjectCache
+	first, _, _, err := noCache.open(projectPth, "Sample", "", "")
+	require.NoError(t, err)
+	second, _, _, err := noCache.open(projectPth, "Sample", "", "")
+	require.NoError(t, err)
+	require.NotSame(t, first, second)
+}
+
+func BenchmarkOpenArchivableProject(b *testing.B) {
+	projectPth := writeSampleProject(b, 500)
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, _, _, err := OpenArchivableProject(projectPth, "Sample", "", ""); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		projects := newArchivableProjectCache()
+		for i := 0; i < b.N; i++ {
+			if _, _, _, err := projects.open(projectPth, "Sample", "", ""); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package step
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	v1pathutil "github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcworkspace"
+)
+
+// projectDetectionSkippedDirs are not searched for projects, as they contain dependencies or build products.
+var projectDetectionSkippedDirs = map[string]bool{
+	".git":           true,
+	".build":         true,
+	".swiftpm":       true,
+	"Pods":           true,
+	"Carthage":       true,
+	"node_modules":   true,
+	"DerivedData":    true,
+	"SourcePackages": true,
+}
+
+// projectCandidates lists the Xcode workspaces and projects of the root dir, ignoring the dependency dirs,
+// the workspaces embedded in projects and the projects of Swift packages.
+func projectCandidates(rootDir string) ([]string, error) {
+	var candidates []string
+	err := filepath.WalkDir(rootDir, func(pth string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || pth == rootDir {
+			return nil
+		}
+
+		if projectDetectionSkippedDirs[d.Name()] || strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		switch filepath.Ext(pth) {
+		case ".xcworkspace", ".xcodeproj":
+			if !isInSwiftPackage(rootDir, pth) {
+				candidates = append(candidates, pth)
+			}
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+// isInSwiftPackage returns true if a Package.swift exists in the project's dir, or in any of its parent dirs below the root dir.
+func isInSwiftPackage(rootDir, projectPath string) bool {
+	for dir := filepath.Dir(projectPath); dir != rootDir && strings.HasPrefix(dir, rootDir); dir = filepath.Dir(dir) {
+		if exist, err := v1pathutil.IsPathExists(filepath.Join(dir, "Package.swift")); err == nil && exist {
+			return true
+		}
+	}
+	return false
+}
+
+// detectProject selects the project to archive from the candidates: workspaces are preferred over projects,
+// and the one closest to the root dir is selected.
+func detectProject(rootDir string, candidates []string) (string, error) {
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no .xcworkspace or .xcodeproj found in: %s", rootDir)
+	}
+
+	sorted := append([]string{}, candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		iWorkspace, jWorkspace := filepath.Ext(sorted[i]) == ".xcworkspace", filepath.Ext(sorted[j]) == ".xcworkspace"
+		if iWorkspace != jWorkspace {
+			return iWorkspace
+		}
+		return pathDepth(rootDir, sorted[i]) < pathDepth(rootDir, sorted[j])
+	})
+
+	if len(sorted) > 1 && filepath.Ext(sorted[0]) == filepath.Ext(sorted[1]) && pathDepth(rootDir, sorted[0]) == pathDepth(rootDir, sorted[1]) {
+		return "", fmt.Errorf("multiple projects found, set the project to archive in the Project path (project_path) input: %s", strings.Join(sorted, ", "))
+	}
+	return sorted[0], nil
+}
+
+func pathDepth(rootDir, pth string) int {
+	rel, err := filepath.Rel(rootDir, pth)
+	if err != nil {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator))
+}
+
+// archivableProjects filters out the dependency projects (for example Pods.xcodeproj) of the workspace's projects.
+func archivableProjects(projectPaths []string) []string {
+	var archivable []string
+	for _, pth := range projectPaths {
+		if isDependencyPath(pth) {
+			continue
+		}
+		archivable = append(archivable, pth)
+	}
+	return archivable
+}
+
+func isDependencyPath(pth string) bool {
+	for _, component := range strings.Split(filepath.ToSlash(pth), "/") {
+		if projectDetectionSkippedDirs[component] {
+			return true
+		}
+	}
+	return false
+}
+
+// validateWorkspace checks if the workspace contains an archivable project,
+// workspaces containing only Swift packages or dependency projects can't be archived.
+func validateWorkspace(workspacePath, rootDir string) error {
+	workspacePath, err := filepath.Abs(workspacePath)
+	if err != nil {
+		return err
+	}
+	workspace, err := xcworkspace.Open(workspacePath)
+	if err != nil {
+		return fmt.Errorf("failed to open workspace: %w", err)
+	}
+	projectPaths, err := workspace.ProjectFileLocations()
+	if err != nil {
+		return fmt.Errorf("failed to list the projects of the workspace: %w", err)
+	}
+	if len(archivableProjects(projectPaths)) > 0 {
+		return nil
+	}
+
+	candidates, err := projectCandidates(rootDir)
+	if err != nil {
+		return fmt.Errorf("failed to search for projects: %w", err)
+	}
+	return noArchivableProjectError(workspacePath, projectPaths, candidates)
+}
+
+func noArchivableProjectError(workspacePath string, projectPaths, candidates []string) error {
+	message := fmt.Sprintf("the workspace (%s) contains no archivable project", workspacePath)
+	if len(projectPaths) == 0 {
+		message += ", it only references Swift packages or other files"
+	} else {
+		message += fmt.Sprintf(", it only references dependency projects: %s", strings.Join(projectPaths, ", "))
+	}
+
+	var others []string
+	for _, candidate := range candidates {
+		if candidate != workspacePath {
+			others = append(others, candidate)
+		}
+	}
+	if len(others) == 0 {
+		return fmt.Errorf("%s", message)
+	}
+	return fmt.Errorf("%s, archivable candidates found in the repository: %s", message, strings.Join(others, ", "))
+}
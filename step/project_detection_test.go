@@ -0,0 +1,112 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createDirs(t *testing.T, rootDir string, pths ...string) {
+	for _, pth := range pths {
+		require.NoError(t, os.MkdirAll(filepath.Join(rootDir, pth), 0755))
+	}
+}
+
+func Test_projectCandidates(t *testing.T) {
+	rootDir := t.TempDir()
+	createDirs(t, rootDir,
+		"App.xcodeproj/project.xcworkspace",
+		"App.xcworkspace",
+		"Pods/Pods.xcodeproj",
+		"Carthage/Checkouts/Lib/Lib.xcodeproj",
+		"Packages/Network/Example/Example.xcodeproj",
+		"Tools/Tool.xcodeproj",
+	)
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "Packages/Network/Package.swift"), nil, 0644))
+
+	candidates, err := projectCandidates(rootDir)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{
+		filepath.Join(rootDir, "App.xcodeproj"),
+		filepath.Join(rootDir, "App.xcworkspace"),
+		filepath.Join(rootDir, "Tools/Tool.xcodeproj"),
+	}, candidates)
+}
+
+func Test_detectProject(t *testing.T) {
+	rootDir := "/bitrise/src"
+	tests := []struct {
+		name       string
+		candidates []string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:    "no candidates",
+			wantErr: true,
+		},
+		{
+			name:       "workspace is preferred",
+			candidates: []string{"/bitrise/src/App.xcodeproj", "/bitrise/src/ios/App.xcworkspace"},
+			want:       "/bitrise/src/ios/App.xcworkspace",
+		},
+		{
+			name:       "shallowest project is selected",
+			candidates: []string{"/bitrise/src/Tools/Tool.xcodeproj", "/bitrise/src/App.xcodeproj"},
+			want:       "/bitrise/src/App.xcodeproj",
+		},
+		{
+			name:       "ambiguous projects",
+			candidates: []string{"/bitrise/src/App.xcodeproj", "/bitrise/src/Widget.xcodeproj"},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := detectProject(rootDir, tt.candidates)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_archivableProjects(t *testing.T) {
+	projects := []string{"/bitrise/src/App.xcodeproj", "/bitrise/src/Pods/Pods.xcodeproj", "/bitrise/src/Carthage/Checkouts/Lib/Lib.xcodeproj"}
+	require.Equal(t, []string{"/bitrise/src/App.xcodeproj"}, archivableProjects(projects))
+}
+
+func Test_validateWorkspace(t *testing.T) {
+	rootDir := t.TempDir()
+	createDirs(t, rootDir, "Package.xcworkspace", "Pods.xcworkspace", "Pods/Pods.xcodeproj", "App/App.xcodeproj", "App.xcworkspace")
+
+	writeWorkspace := func(name string, locations ...string) string {
+		content := `<?xml version="1.0" encoding="UTF-8"?>
+<Workspace version = "1.0">
+`
+		for _, location := range locations {
+			content += `<FileRef location = "` + location + `"></FileRef>
+`
+		}
+		content += `</Workspace>
+`
+		pth := filepath.Join(rootDir, name)
+		require.NoError(t, os.WriteFile(filepath.Join(pth, "contents.xcworkspacedata"), []byte(content), 0644))
+		return pth
+	}
+
+	err := validateWorkspace(writeWorkspace("Package.xcworkspace", "group:Sources/Network"), rootDir)
+	require.EqualError(t, err, "the workspace ("+filepath.Join(rootDir, "Package.xcworkspace")+") contains no archivable project, it only references Swift packages or other files, archivable candidates found in the repository: "+
+		filepath.Join(rootDir, "App/App.xcodeproj")+", "+filepath.Join(rootDir, "App.xcworkspace")+", "+filepath.Join(rootDir, "Pods.xcworkspace"))
+
+	err = validateWorkspace(writeWorkspace("Pods.xcworkspace", "group:Pods/Pods.xcodeproj"), rootDir)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "it only references dependency projects: "+filepath.Join(rootDir, "Pods/Pods.xcodeproj"))
+
+	require.NoError(t, validateWorkspace(writeWorkspace("App.xcworkspace", "group:App/App.xcodeproj", "group:Pods/Pods.xcodeproj"), rootDir))
+}
@@ -0,0 +1,431 @@
+package step
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/xcarchive"
+	"github.com/bitrise-io/go-xcode/xcodeproject/serialized"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcodeproj"
+	"github.com/kballard/go-shellquote"
+)
+
+const (
+	redactedTeamID   = "[REDACTED_TEAM_ID]"
+	redactedBundleID = "[REDACTED_BUNDLE_ID]"
+
+	// minRedactedIdentifierLength keeps the too short values (for example a mistyped team ID) from masking unrelated text
+	minRedactedIdentifierLength = 4
+)
+
+// identifierOutputEnvKeys are the outputs containing team or bundle IDs, exported as sensitive outputs if the identifiers are redacted.
+var identifierOutputEnvKeys = map[string]bool{
+	bitriseAppBundleIDEnvKey:            true,
+	bitriseAppTeamIDEnvKey:              true,
+	bitriseAppEntitlementsEnvKey:        true,
+	bitriseDevelopmentTeamEnvKey:        true,
+	bitriseArchiveBundleIDEnvKey:        true,
+	bitriseArchiveSigningIdentityEnvKey: true,
+	bitriseArchiveTeamIDEnvKey:          true,
+	bitriseArchivePropertiesEnvKey:      true,
+}
+
+// IdentifierRedactor masks the registered team and bundle IDs, it is enabled while the inputs are processed
+// (see XcodebuildArchiveConfigParser.SetIdentifierRedactor), and the identifiers are registered as they are discovered (inputs, project, archive, ipa).
+// A nil IdentifierRedactor is disabled.
+type IdentifierRedactor struct {
+	mu       sync.RWMutex
+	active   bool
+	masks    map[string]string
+	replacer *strings.Replacer
+}
+
+// NewIdentifierRedactor returns a disabled redactor, shared by the logger, the command factories and the archiver of a Step run.
+func NewIdentifierRedactor() *IdentifierRedactor {
+	return &IdentifierRedactor{masks: map[string]string{}}
+}
+
+func (r *IdentifierRedactor) enable() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.active = true
+}
+
+func (r *IdentifierRedactor) enabled() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active
+}
+
+func (r *IdentifierRedactor) add(mask string, values ...string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.active {
+		return
+	}
+
+	changed := false
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		// wildcard bundle IDs (io.bitrise.*) are masked by their prefix
+		value = strings.TrimSuffix(strings.TrimSuffix(value, "*"), ".")
+		if len(value) < minRedactedIdentifierLength || r.masks[value] != "" {
+			continue
+		}
+		r.masks[value] = mask
+		changed = true
+	}
+	if !changed {
+		return
+	}
+
+	values = make([]string, 0, len(r.masks))
+	for value := range r.masks {
+		values = append(values, value)
+	}
+	// the longer values are replaced first, so an extension's bundle ID is masked as a whole, not as the app's bundle ID with a suffix
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+	var oldNew []string
+	for _, value := range values {
+		oldNew = append(oldNew, value, r.masks[value])
+	}
+	r.replacer = strings.NewReplacer(oldNew...)
+}
+
+// redact masks the registered team and bundle IDs of the text.
+func (r *IdentifierRedactor) redact(s string) string {
+	if r == nil {
+		return s
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.replacer == nil {
+		return s
+	}
+	return r.replacer.Replace(s)
+}
+
+// addTeamIDs registers the team IDs masked in the logs, if the redaction is enabled.
+func (r *IdentifierRedactor) addTeamIDs(teamIDs ...string) {
+	r.add(redactedTeamID, teamIDs...)
+}
+
+// addBundleIDs registers the bundle IDs masked in the logs, if the redaction is enabled.
+func (r *IdentifierRedactor) addBundleIDs(bundleIDs ...string) {
+	r.add(redactedBundleID, bundleIDs...)
+}
+
+// addInputIdentifiers registers the team and bundle IDs set by the inputs,
+// including the DEVELOPMENT_TEAM and PRODUCT_BUNDLE_IDENTIFIER build settings of the xcodebuild options.
+func (r *IdentifierRedactor) addInputIdentifiers(inputs Inputs) {
+	r.addTeamIDs(inputs.ExportDevelopmentTeam, inputs.ForceTeamID)
+	r.addBundleIDs(inputs.DistributionBundleIdentifier)
+
+	// an invalid list is reported by the input processing
+	overrides, _ := parseBundleIDTeamOverrides(inputs.ExportTeamOverrides)
+	for bundleID, teamID := range overrides {
+		r.addBundleIDs(bundleID)
+		r.addTeamIDs(teamID)
+	}
+
+	options, _ := shellquote.Split(inputs.XcodebuildOptions)
+	for _, option := range options {
+		name, value, _ := strings.Cut(option, "=")
+		switch name {
+		case "DEVELOPMENT_TEAM":
+			r.addTeamIDs(value)
+		case "PRODUCT_BUNDLE_IDENTIFIER":
+			r.addBundleIDs(value)
+		}
+	}
+}
+
+// addBuildSettingIdentifiers registers the team and bundle IDs of the target's build settings.
+func (r *IdentifierRedactor) addBuildSettingIdentifiers(settings serialized.Object) {
+	teamID, _ := settings.String("DEVELOPMENT_TEAM")
+	r.addTeamIDs(teamID)
+	bundleID, _ := settings.String("PRODUCT_BUNDLE_IDENTIFIER")
+	r.addBundleIDs(bundleID)
+}
+
+// addArchiveIdentifiers registers the bundle IDs of the archive's bundles and the team IDs of their provisioning profiles.
+func (r *IdentifierRedactor) addArchiveIdentifiers(archive xcarchive.IosArchive) {
+	for bundleID, profile := range archive.BundleIDProfileInfoMap() {
+		r.addBundleIDs(bundleID)
+		r.addTeamIDs(profile.TeamID)
+	}
+	if teamID, err := archive.TeamID(); err == nil {
+		r.addTeamIDs(teamID)
+	}
+}
+
+// redactedStructuredLog returns a copy of the structured log lines with the registered identifiers masked.
+func (r *IdentifierRedactor) redactedStructuredLog(lines []StructuredLogLine) []StructuredLogLine {
+	if lines == nil {
+		return nil
+	}
+	redacted := make([]StructuredLogLine, len(lines))
+	for i, line := range lines {
+		line.Line = r.redact(line.Line)
+		redacted[i] = line
+	}
+	return redacted
+}
+
+// redactedInputs returns the inputs with the registered identifiers masked in the string inputs, for printing.
+func (r *IdentifierRedactor) redactedInputs(inputs Inputs) Inputs {
+	v := reflect.ValueOf(&inputs).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if field := v.Field(i); field.Kind() == reflect.String && field.CanSet() {
+			field.SetString(r.redact(field.String()))
+		}
+	}
+	return inputs
+}
+
+// SetIdentifierRedactor sets the redactor enabled by the redact_identifiers input, it should be the one the logger of the parser
+// is wrapped with (see NewRedactingLogger), so the messages printed while processing the inputs are masked too.
+func (s *XcodebuildArchiveConfigParser) SetIdentifierRedactor(redactor *IdentifierRedactor) {
+	s.redactor = redactor
+}
+
+// SetIdentifierRedactor sets the redactor the identifiers discovered by the archiver (project, archive, ipa) are registered to.
+func (s *XcodebuildArchiver) SetIdentifierRedactor(redactor *IdentifierRedactor) {
+	s.redactor = redactor
+}
+
+// identifierRegisteringProvider registers the team and bundle IDs of the build settings it returns.
+type identifierRegisteringProvider struct {
+	TargetBuildSettingsProvider
+	redactor *IdentifierRedactor
+}
+
+// TargetBuildSettings ...
+func (p identifierRegisteringProvider) TargetBuildSettings(xcodeProj *xcodeproj.XcodeProj, target, configuration string, customOptions ...string) (serialized.Object, error) {
+	settings, err := p.TargetBuildSettingsProvider.TargetBuildSettings(xcodeProj, target, configuration, customOptions...)
+	if err == nil {
+		p.redactor.addBuildSettingIdentifiers(settings)
+	}
+	return settings, err
+}
+
+type redactingLogger struct {
+	log.Logger
+	redactor *IdentifierRedactor
+}
+
+// NewRedactingLogger wraps the logger, so the team and bundle IDs are masked in the printed messages if the redaction is enabled.
+func NewRedactingLogger(logger log.Logger, redactor *IdentifierRedactor) log.Logger {
+	return redactingLogger{Logger: logger, redactor: redactor}
+}
+
+func (l redactingLogger) redactf(format string, v ...interface{}) (string, interface{}) {
+	return "%s", l.redactor.redact(fmt.Sprintf(format, v...))
+}
+
+// Infof ...
+func (l redactingLogger) Infof(format string, v ...interface{}) {
+	l.Logger.Infof(l.redactf(format, v...))
+}
+
+// Warnf ...
+func (l redactingLogger) Warnf(format string, v ...interface{}) {
+	l.Logger.Warnf(l.redactf(format, v...))
+}
+
+// Printf ...
+func (l redactingLogger) Printf(format string, v ...interface{}) {
+	l.Logger.Printf(l.redactf(format, v...))
+}
+
+// Donef ...
+func (l redactingLogger) Donef(format string, v ...interface{}) {
+	l.Logger.Donef(l.redactf(format, v...))
+}
+
+// Debugf ...
+func (l redactingLogger) Debugf(format string, v ...interface{}) {
+	l.Logger.Debugf(l.redactf(format, v...))
+}
+
+// Errorf ...
+func (l redactingLogger) Errorf(format string, v ...interface{}) {
+	l.Logger.Errorf(l.redactf(format, v...))
+}
+
+// TInfof ...
+func (l redactingLogger) TInfof(format string, v ...interface{}) {
+	l.Logger.TInfof(l.redactf(format, v...))
+}
+
+// TWarnf ...
+func (l redactingLogger) TWarnf(format string, v ...interface{}) {
+	l.Logger.TWarnf(l.redactf(format, v...))
+}
+
+// TPrintf ...
+func (l redactingLogger) TPrintf(format string, v ...interface{}) {
+	l.Logger.TPrintf(l.redactf(format, v...))
+}
+
+// TDonef ...
+func (l redactingLogger) TDonef(format string, v ...interface{}) {
+	l.Logger.TDonef(l.redactf(format, v...))
+}
+
+// TDebugf ...
+func (l redactingLogger) TDebugf(format string, v ...interface{}) {
+	l.Logger.TDebugf(l.redactf(format, v...))
+}
+
+// TErrorf ...
+func (l redactingLogger) TErrorf(format string, v ...interface{}) {
+	l.Logger.TErrorf(l.redactf(format, v...))
+}
+
+// redactingWriter masks the identifiers of the complete lines written to it, the last unterminated line is written by flush.
+type redactingWriter struct {
+	mu       sync.Mutex
+	writer   io.Writer
+	redactor *IdentifierRedactor
+	partial  bytes.Buffer
+}
+
+func (w *redactingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial.Write(p)
+	if i := bytes.LastIndexByte(w.partial.Bytes(), '\n'); i >= 0 {
+		lines := string(w.partial.Next(i + 1))
+		if _, err := io.WriteString(w.writer, w.redactor.redact(lines)); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *redactingWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.partial.Len() > 0 {
+		_, _ = io.WriteString(w.writer, w.redactor.redact(w.partial.String()))
+		w.partial.Reset()
+	}
+}
+
+// redactingCommand flushes the redacting writers of the command when it finishes,
+// and masks the identifiers in the output returned by the failed commands.
+type redactingCommand struct {
+	command.Command
+
+	redactor *IdentifierRedactor
+	writers  []*redactingWriter
+}
+
+func (c redactingCommand) flush() {
+	for _, w := range c.writers {
+		w.flush()
+	}
+}
+
+// Run ...
+func (c redactingCommand) Run() error {
+	defer c.flush()
+	return c.Command.Run()
+}
+
+// RunAndReturnExitCode ...
+func (c redactingCommand) RunAndReturnExitCode() (int, error) {
+	defer c.flush()
+	return c.Command.RunAndReturnExitCode()
+}
+
+// Wait ...
+func (c redactingCommand) Wait() error {
+	defer c.flush()
+	return c.Command.Wait()
+}
+
+// RunAndReturnTrimmedOutput ...
+func (c redactingCommand) RunAndReturnTrimmedOutput() (string, error) {
+	out, err := c.Command.RunAndReturnTrimmedOutput()
+	return c.redactFailedOutput(out, err), err
+}
+
+// RunAndReturnTrimmedCombinedOutput ...
+func (c redactingCommand) RunAndReturnTrimmedCombinedOutput() (string, error) {
+	out, err := c.Command.RunAndReturnTrimmedCombinedOutput()
+	return c.redactFailedOutput(out, err), err
+}
+
+// redactFailedOutput masks the output of a failed command, as it is reported in the error messages.
+// The output of a successful command is parsed (for example the signing identity or the entitlements), so it is kept as is,
+// it is masked by the redacting logger if printed.
+func (c redactingCommand) redactFailedOutput(out string, err error) string {
+	if err == nil {
+		return out
+	}
+	return c.redactor.redact(out)
+}
+
+// redactingFactory is a command.Factory masking the identifiers in the output of the created commands.
+type redactingFactory struct {
+	command.Factory
+	redactor *IdentifierRedactor
+}
+
+// NewRedactingFactory wraps the command factory of the xcodebuild and the log formatter commands,
+// so the team and bundle IDs are masked in their output (printed, captured as the raw log or reported in errors) if the redaction is enabled.
+func NewRedactingFactory(factory command.Factory, redactor *IdentifierRedactor) command.Factory {
+	return redactingFactory{Factory: factory, redactor: redactor}
+}
+
+// Create ...
+func (f redactingFactory) Create(name string, args []string, opts *command.Opts) command.Command {
+	if !f.redactor.enabled() {
+		return f.Factory.Create(name, args, opts)
+	}
+	if opts == nil {
+		return redactingCommand{Command: f.Factory.Create(name, args, opts), redactor: f.redactor}
+	}
+
+	redactedOpts := *opts
+	var writers []*redactingWriter
+	stdout, stderr := opts.Stdout, opts.Stderr
+	if stdout != nil {
+		w := &redactingWriter{writer: stdout, redactor: f.redactor}
+		redactedOpts.Stdout = w
+		writers = append(writers, w)
+	}
+	if stderr != nil {
+		if stderr == stdout {
+			// the combined output is redacted by the same writer, keeping the order of the lines
+			redactedOpts.Stderr = redactedOpts.Stdout
+		} else {
+			w := &redactingWriter{writer: stderr, redactor: f.redactor}
+			redactedOpts.Stderr = w
+			writers = append(writers, w)
+		}
+	}
+	return redactingCommand{Command: f.Factory.Create(name, args, &redactedOpts), redactor: f.redactor, writers: writers}
+}
@@ -0,0 +1,116 @@
+package step
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/env"
+	"github.com/stretchr/testify/require"
+)
+
+// newEnabledRedactor returns an enabled redactor with an empty identifier list.
+func newEnabledRedactor() *IdentifierRedactor {
+	redactor := NewIdentifierRedactor()
+	redactor.enable()
+	return redactor
+}
+
+func TestIdentifierRedactor_redact(t *testing.T) {
+	redactor := newEnabledRedactor()
+
+	redactor.addTeamIDs("72SA8V3WYL", "", "AB")
+	redactor.addBundleIDs("io.bitrise.Sample", "io.bitrise.Sample.widget", "io.bitrise.partner.*")
+
+	require.Equal(t,
+		"Signing [REDACTED_BUNDLE_ID] with [REDACTED_TEAM_ID] ([REDACTED_TEAM_ID].[REDACTED_BUNDLE_ID]), AB is kept",
+		redactor.redact("Signing io.bitrise.Sample.widget with 72SA8V3WYL (72SA8V3WYL.io.bitrise.Sample), AB is kept"),
+	)
+	require.Equal(t, "Profile: [REDACTED_BUNDLE_ID].app", redactor.redact("Profile: io.bitrise.partner.app"))
+}
+
+func TestIdentifierRedactor_redact_disabled(t *testing.T) {
+	for _, redactor := range []*IdentifierRedactor{NewIdentifierRedactor(), nil} {
+		redactor.addTeamIDs("72SA8V3WYL")
+		require.False(t, redactor.enabled())
+		require.Equal(t, "Team: 72SA8V3WYL", redactor.redact("Team: 72SA8V3WYL"))
+	}
+}
+
+func TestIdentifierRedactor_addInputIdentifiers(t *testing.T) {
+	redactor := newEnabledRedactor()
+
+	inputs := Inputs{
+		ExportDevelopmentTeam: "72SA8V3WYL",
+		ExportTeamOverrides:   "io.bitrise.Sample.widget=PARTNERTEAM",
+		XcodebuildOptions:     `PRODUCT_BUNDLE_IDENTIFIER=io.bitrise.Sample -quiet`,
+		Scheme:                "Sample io.bitrise.Sample",
+	}
+	redactor.addInputIdentifiers(inputs)
+
+	printed := redactor.redactedInputs(inputs)
+	require.Equal(t, "[REDACTED_TEAM_ID]", printed.ExportDevelopmentTeam)
+	require.Equal(t, "[REDACTED_BUNDLE_ID]=[REDACTED_TEAM_ID]", printed.ExportTeamOverrides)
+	require.Equal(t, "Sample [REDACTED_BUNDLE_ID]", printed.Scheme)
+	require.Equal(t, "72SA8V3WYL", inputs.ExportDevelopmentTeam, "the inputs are not modified")
+}
+
+func TestIdentifierRedactor_redactedStructuredLog(t *testing.T) {
+	redactor := newEnabledRedactor()
+	redactor.addTeamIDs("72SA8V3WYL")
+
+	lines := []StructuredLogLine{{Stream: streamStdout, Level: logLevelInfo, Line: "DEVELOPMENT_TEAM = 72SA8V3WYL"}}
+	require.Equal(t, []StructuredLogLine{{Stream: streamStdout, Level: logLevelInfo, Line: "DEVELOPMENT_TEAM = [REDACTED_TEAM_ID]"}}, redactor.redactedStructuredLog(lines))
+	require.Equal(t, "DEVELOPMENT_TEAM = 72SA8V3WYL", lines[0].Line)
+}
+
+func Test_redactingWriter(t *testing.T) {
+	redactor := newEnabledRedactor()
+	redactor.addTeamIDs("72SA8V3WYL")
+
+	var out bytes.Buffer
+	w := &redactingWriter{writer: &out, redactor: redactor}
+	_, err := w.Write([]byte("Team: 72SA"))
+	require.NoError(t, err)
+	require.Equal(t, "", out.String(), "the unterminated line is buffered")
+
+	_, err = w.Write([]byte("8V3WYL\nTeam: 72SA8V3WYL"))
+	require.NoError(t, err)
+	require.Equal(t, "Team: [REDACTED_TEAM_ID]\n", out.String())
+
+	w.flush()
+	require.Equal(t, "Team: [REDACTED_TEAM_ID]\nTeam: [REDACTED_TEAM_ID]", out.String())
+}
+
+func TestRedactingFactory(t *testing.T) {
+	redactor := newEnabledRedactor()
+	redactor.addBundleIDs("io.bitrise.Sample")
+
+	var out bytes.Buffer
+	factory := NewRedactingFactory(command.NewFactory(env.NewRepository()), redactor)
+	cmd := factory.Create("sh", []string{"-c", `echo "Bundle: io.bitrise.Sample"; printf "io.bitrise.Sample" >&2`}, &command.Opts{Stdout: &out, Stderr: &out})
+	require.NoError(t, cmd.Run())
+	require.Equal(t, "Bundle: [REDACTED_BUNDLE_ID]\n[REDACTED_BUNDLE_ID]", out.String())
+}
+
+func TestRedactingFactory_failedOutput(t *testing.T) {
+	redactor := newEnabledRedactor()
+	redactor.addTeamIDs("72SA8V3WYL")
+	factory := NewRedactingFactory(command.NewFactory(env.NewRepository()), redactor)
+
+	out, err := factory.Create("sh", []string{"-c", "echo Team: 72SA8V3WYL"}, nil).RunAndReturnTrimmedCombinedOutput()
+	require.NoError(t, err)
+	require.Equal(t, "Team: 72SA8V3WYL", out, "the output of a successful command is parsed, it is kept as is")
+
+	out, err = factory.Create("sh", []string{"-c", "echo Team: 72SA8V3WYL; exit 1"}, nil).RunAndReturnTrimmedOutput()
+	require.Error(t, err)
+	require.Equal(t, "Team: [REDACTED_TEAM_ID]", out)
+}
+
+func TestIdentifierRedactor_independent(t *testing.T) {
+	first, second := newEnabledRedactor(), newEnabledRedactor()
+	first.addTeamIDs("72SA8V3WYL")
+
+	require.Equal(t, "Team: [REDACTED_TEAM_ID]", first.redact("Team: 72SA8V3WYL"))
+	require.Equal(t, "Team: 72SA8V3WYL", second.redact("Team: 72SA8V3WYL"))
+}
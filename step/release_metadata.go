@@ -0,0 +1,195 @@
+package step
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-steputils/v2/stepconf"
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-xcode/xcarchive"
+)
+
+const (
+	bitriseReleaseMetadataPthEnvKey = "BITRISE_RELEASE_METADATA_PATH"
+	bitriseReleaseGitTagEnvKey      = "BITRISE_RELEASE_GIT_TAG"
+	gitCloneCommitHashEnvKey        = "GIT_CLONE_COMMIT_HASH"
+
+	releaseMetadataFilename = "release-metadata.json"
+)
+
+// ReleaseMetadataOpts ...
+type ReleaseMetadataOpts struct {
+	OutputDir    string
+	OutputLayout string
+	ArtifactName string
+	Archive      *xcarchive.IosArchive
+	ProjectPath  string
+	// IPAExportDir is the xcodebuild -exportArchive output dir, empty if the export was skipped
+	IPAExportDir string
+
+	Metadata        bool
+	GitTag          string
+	GitTagPushToken stepconf.Secret
+}
+
+type releaseMetadata struct {
+	BundleID    string `json:"bundle_id"`
+	Version     string `json:"version"`
+	Build       string `json:"build"`
+	Commit      string `json:"commit"`
+	IPAPath     string `json:"ipa_path,omitempty"`
+	IPASHA256   string `json:"ipa_sha256,omitempty"`
+	ArchivePath string `json:"archive_path"`
+}
+
+// releaseGitTagName resolves the {version} and {build} placeholders of the tag template.
+func releaseGitTagName(template string, properties archiveApplicationProperties) string {
+	return strings.NewReplacer("{version}", properties.Version, "{build}", properties.Build).Replace(template)
+}
+
+// gitBasicAuthHeader returns the http.extraHeader git config value authenticating with an access token.
+func gitBasicAuthHeader(token string) string {
+	return "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte("x-access-token:"+token))
+}
+
+// gitAuthConfigEnvs sets the authenticating http.extraHeader git config through the environment,
+// a -c command line option would expose the token in the process list.
+func gitAuthConfigEnvs(token string) []string {
+	return []string{
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=" + gitBasicAuthHeader(token),
+	}
+}
+
+func fileSHA256(pth string) (string, error) {
+	f, err := os.Open(pth)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// containsIPA returns true if xcodebuild exported an ipa into the dir (and not a macOS app).
+func containsIPA(exportDir string) bool {
+	if exportDir == "" {
+		return false
+	}
+	ipas, err := filepath.Glob(filepath.Join(exportDir, "*.ipa"))
+	return err == nil && len(ipas) > 0
+}
+
+func (s XcodebuildArchiver) runGit(dir string, args ...string) (string, error) {
+	return s.runGitWithEnvs(dir, nil, args...)
+}
+
+func (s XcodebuildArchiver) runGitWithEnvs(dir string, envs []string, args ...string) (string, error) {
+	cmd := s.cmdFactory.Create("git", args, &command.Opts{Dir: dir, Env: envs})
+	out, err := cmd.RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git command failed: %s: %w", out, err)
+	}
+	return out, nil
+}
+
+// headCommit returns the built commit: the one checked out by the Git Clone Step if available, otherwise the working tree's HEAD.
+func (s XcodebuildArchiver) headCommit(dir string) (string, error) {
+	if commit := os.Getenv(gitCloneCommitHashEnvKey); commit != "" {
+		return commit, nil
+	}
+	return s.runGit(dir, "rev-parse", "HEAD")
+}
+
+// ExportReleaseMetadata writes the release metadata of a successful export and optionally tags the built commit,
+// so the artifact producing Step is the source of truth for the release pointers.
+func (s XcodebuildArchiver) ExportReleaseMetadata(opts ReleaseMetadataOpts) error {
+	if opts.Archive == nil {
+		return fmt.Errorf("no archive found")
+	}
+
+	s.logger.Println()
+	s.logger.Infof("Exporting the release metadata...")
+
+	properties, err := readArchiveApplicationProperties(opts.Archive.InfoPlist)
+	if err != nil {
+		return err
+	}
+	sourceDir := filepath.Dir(opts.ProjectPath)
+	commit, err := s.headCommit(sourceDir)
+	if err != nil {
+		return fmt.Errorf("failed to determine the built commit: %w", err)
+	}
+
+	if opts.Metadata {
+		metadata := releaseMetadata{
+			BundleID:    properties.BundleID,
+			Version:     properties.Version,
+			Build:       properties.Build,
+			Commit:      commit,
+			ArchivePath: opts.Archive.Path,
+		}
+
+		ipaPath := filepath.Join(artifactOutputDir(opts.OutputDir, opts.OutputLayout, outputArtifactIPA), opts.ArtifactName+".ipa")
+		if _, err := os.Stat(ipaPath); err == nil {
+			metadata.IPAPath = ipaPath
+			if metadata.IPASHA256, err = fileSHA256(ipaPath); err != nil {
+				return fmt.Errorf("failed to calculate the ipa checksum: %w", err)
+			}
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to check the ipa: %w", err)
+		} else if containsIPA(opts.IPAExportDir) {
+			return fmt.Errorf("an ipa was exported, but not found at: %s", ipaPath)
+		}
+
+		content, err := json.MarshalIndent(metadata, "", "  ")
+		if err != nil {
+			return err
+		}
+		pth := filepath.Join(opts.OutputDir, releaseMetadataFilename)
+		if _, err := atomicWriteFile(pth, content); err != nil {
+			return fmt.Errorf("failed to write %s: %w", pth, err)
+		}
+		if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseReleaseMetadataPthEnvKey, pth); err != nil {
+			return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseReleaseMetadataPthEnvKey), err)
+		}
+		s.logger.Donef("The release metadata path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseReleaseMetadataPthEnvKey), pth)
+	}
+
+	if opts.GitTag == "" {
+		return nil
+	}
+
+	tag := releaseGitTagName(opts.GitTag, properties)
+	message := fmt.Sprintf("Release %s (%s)", properties.Version, properties.Build)
+	if _, err := s.runGit(sourceDir, "tag", "--annotate", tag, "--message", message, commit); err != nil {
+		return fmt.Errorf("failed to create the release tag: %w", err)
+	}
+	s.logger.Printf("Created the release tag %s on commit %s", tag, commit)
+
+	if opts.GitTagPushToken != "" {
+		if _, err := s.runGitWithEnvs(sourceDir, gitAuthConfigEnvs(string(opts.GitTagPushToken)), "push", "origin", "refs/tags/"+tag); err != nil {
+			return fmt.Errorf("failed to push the release tag: %w", err)
+		}
+		s.logger.Printf("Pushed the release tag %s to origin", tag)
+	}
+
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseReleaseGitTagEnvKey, tag); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseReleaseGitTagEnvKey), err)
+	}
+	s.logger.Donef("The release tag is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseReleaseGitTagEnvKey), tag)
+	return nil
+}
@@ -0,0 +1,64 @@
+package step
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	addressSanitizer           = "address"
+	threadSanitizer            = "thread"
+	undefinedBehaviorSanitizer = "undefined_behavior"
+)
+
+// sanitizerOptions maps the sanitizers to the xcodebuild options enabling them.
+var sanitizerOptions = map[string]string{
+	addressSanitizer:           "-enableAddressSanitizer",
+	threadSanitizer:            "-enableThreadSanitizer",
+	undefinedBehaviorSanitizer: "-enableUndefinedBehaviorSanitizer",
+}
+
+// parseSanitizers parses the comma or newline separated list of sanitizers.
+func parseSanitizers(input string) ([]string, error) {
+	var sanitizers []string
+	seen := map[string]bool{}
+	for _, field := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == '\n' }) {
+		sanitizer := strings.TrimSpace(field)
+		if sanitizer == "" || seen[sanitizer] {
+			continue
+		}
+		if _, ok := sanitizerOptions[sanitizer]; !ok {
+			var supported []string
+			for name := range sanitizerOptions {
+				supported = append(supported, name)
+			}
+			sort.Strings(supported)
+			return nil, fmt.Errorf("unknown sanitizer: %s, supported sanitizers: %s", sanitizer, strings.Join(supported, ", "))
+		}
+
+		seen[sanitizer] = true
+		sanitizers = append(sanitizers, sanitizer)
+	}
+
+	if seen[addressSanitizer] && seen[threadSanitizer] {
+		return nil, fmt.Errorf("the %s and %s sanitizers can't be enabled together", addressSanitizer, threadSanitizer)
+	}
+	return sanitizers, nil
+}
+
+// sanitizerXcodebuildOptions returns the xcodebuild options enabling the sanitizers,
+// an error is returned if a sanitizer option is already set in the xcodebuild options.
+func sanitizerXcodebuildOptions(sanitizers []string, xcodebuildOptions []string) ([]string, error) {
+	var options []string
+	for _, sanitizer := range sanitizers {
+		option := sanitizerOptions[sanitizer]
+		for _, existing := range xcodebuildOptions {
+			if existing == option {
+				return nil, fmt.Errorf("`%s` option found in XcodebuildOptions (`xcodebuild_options`), please remove the %s sanitizer from Sanitizers (`sanitizers`) input as only one can be set", option, sanitizer)
+			}
+		}
+		options = append(options, option, "YES")
+	}
+	return options, nil
+}
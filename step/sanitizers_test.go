@@ -0,0 +1,56 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseSanitizers(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:  "empty",
+			input: "",
+		},
+		{
+			name:  "comma and newline separated",
+			input: "address, undefined_behavior\naddress",
+			want:  []string{"address", "undefined_behavior"},
+		},
+		{
+			name:    "unknown sanitizer",
+			input:   "memory",
+			wantErr: "unknown sanitizer: memory, supported sanitizers: address, thread, undefined_behavior",
+		},
+		{
+			name:    "address and thread",
+			input:   "address,thread",
+			wantErr: "the address and thread sanitizers can't be enabled together",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSanitizers(tt.input)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_sanitizerXcodebuildOptions(t *testing.T) {
+	options, err := sanitizerXcodebuildOptions([]string{"thread", "undefined_behavior"}, []string{"-scmProvider", "system"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"-enableThreadSanitizer", "YES", "-enableUndefinedBehaviorSanitizer", "YES"}, options)
+
+	_, err = sanitizerXcodebuildOptions([]string{"address"}, []string{"-enableAddressSanitizer", "NO"})
+	require.Error(t, err)
+}
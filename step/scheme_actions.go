@@ -0,0 +1,189 @@
+package step
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcscheme"
+)
+
+const (
+	schemeActionScriptPreviewLines = 5
+
+	sendEmailActionType = "Xcode.IDEStandardExecutionActionsCore.ExecutionActionType.SendEmailAction"
+)
+
+type schemeExecutionActionXML struct {
+	ActionType    string `xml:"ActionType,attr"`
+	ActionContent struct {
+		Title                string `xml:"title,attr"`
+		ScriptText           string `xml:"scriptText,attr"`
+		ShellToInvoke        string `xml:"shellToInvoke,attr"`
+		EnvironmentBuildable struct {
+			BuildableReference struct {
+				BlueprintName string `xml:"BlueprintName,attr"`
+			}
+		}
+	}
+}
+
+type schemeActionsXML struct {
+	PreActions  []schemeExecutionActionXML `xml:"PreActions>ExecutionAction"`
+	PostActions []schemeExecutionActionXML `xml:"PostActions>ExecutionAction"`
+}
+
+type schemeEnvironmentVariableXML struct {
+	Key       string `xml:"key,attr"`
+	Value     string `xml:"value,attr"`
+	IsEnabled string `xml:"isEnabled,attr"`
+}
+
+// schemeXML is the part of the .xcscheme file not parsed by go-xcode's xcscheme package.
+type schemeXML struct {
+	BuildAction   schemeActionsXML
+	ArchiveAction schemeActionsXML
+	LaunchAction  struct {
+		EnvironmentVariables []schemeEnvironmentVariableXML `xml:"EnvironmentVariables>EnvironmentVariable"`
+	}
+}
+
+type schemeExecutionAction struct {
+	Phase             string
+	Title             string
+	Shell             string
+	Script            string
+	BuildSettingsFrom string
+	SendsEmail        bool
+}
+
+type schemeArchiveEnvironment struct {
+	// Actions are the pre and post actions run by the archive action (including the build action's actions).
+	Actions []schemeExecutionAction
+	// LaunchEnvironmentKeys are the enabled Run action environment variables, which are not applied to the archive action.
+	LaunchEnvironmentKeys []string
+	// LaunchEnvironment are the enabled Run action environment variables as KEY=VALUE pairs.
+	LaunchEnvironment []string
+}
+
+// parseSchemeArchiveEnvironment parses the scheme's pre and post actions and environment variables affecting, or expected to affect, the archive action.
+func parseSchemeArchiveEnvironment(content []byte) (schemeArchiveEnvironment, error) {
+	var scheme schemeXML
+	if err := xml.Unmarshal(content, &scheme); err != nil {
+		return schemeArchiveEnvironment{}, err
+	}
+
+	env := schemeArchiveEnvironment{}
+	for _, group := range []struct {
+		phase   string
+		actions []schemeExecutionActionXML
+	}{
+		{phase: "Build pre-action", actions: scheme.BuildAction.PreActions},
+		{phase: "Archive pre-action", actions: scheme.ArchiveAction.PreActions},
+		{phase: "Build post-action", actions: scheme.BuildAction.PostActions},
+		{phase: "Archive post-action", actions: scheme.ArchiveAction.PostActions},
+	} {
+		for _, action := range group.actions {
+			env.Actions = append(env.Actions, schemeExecutionAction{
+				Phase:             group.phase,
+				Title:             action.ActionContent.Title,
+				Shell:             action.ActionContent.ShellToInvoke,
+				Script:            action.ActionContent.ScriptText,
+				BuildSettingsFrom: action.ActionContent.EnvironmentBuildable.BuildableReference.BlueprintName,
+				SendsEmail:        action.ActionType == sendEmailActionType,
+			})
+		}
+	}
+
+	for _, variable := range scheme.LaunchAction.EnvironmentVariables {
+		if variable.IsEnabled != "NO" {
+			env.LaunchEnvironmentKeys = append(env.LaunchEnvironmentKeys, variable.Key)
+			env.LaunchEnvironment = append(env.LaunchEnvironment, variable.Key+"="+variable.Value)
+		}
+	}
+
+	return env, nil
+}
+
+func (a schemeExecutionAction) description() string {
+	title := a.Title
+	if title == "" {
+		title = "Run Script"
+	}
+	if a.SendsEmail {
+		return fmt.Sprintf("%s: %s (send email)", a.Phase, title)
+	}
+
+	var details []string
+	if a.Shell != "" {
+		details = append(details, a.Shell)
+	}
+	if a.BuildSettingsFrom != "" {
+		details = append(details, "build settings from "+a.BuildSettingsFrom)
+	}
+	if len(details) == 0 {
+		return fmt.Sprintf("%s: %s", a.Phase, title)
+	}
+	return fmt.Sprintf("%s: %s (%s)", a.Phase, title, strings.Join(details, ", "))
+}
+
+func printSchemeArchiveEnvironment(env schemeArchiveEnvironment, applyRunEnvironment bool, logger log.Logger) {
+	if len(env.Actions) > 0 {
+		logger.Println()
+		logger.Infof("The scheme's pre and post actions run by the archive action:")
+		for _, action := range env.Actions {
+			logger.Printf("- %s", action.description())
+
+			lines := strings.Split(strings.TrimSpace(action.Script), "\n")
+			for i, line := range lines {
+				if i == schemeActionScriptPreviewLines {
+					logger.Printf("    ... (%d more lines)", len(lines)-i)
+					break
+				}
+				if line != "" {
+					logger.Printf("    %s", line)
+				}
+			}
+		}
+	}
+
+	if len(env.LaunchEnvironmentKeys) > 0 {
+		logger.Println()
+		if applyRunEnvironment {
+			logger.Printf("The scheme's Run action environment variables (%s) are passed to the archive action as build settings.", strings.Join(env.LaunchEnvironmentKeys, ", "))
+		} else {
+			logger.Warnf("The scheme's Run action environment variables (%s) are not applied to the archive action.", strings.Join(env.LaunchEnvironmentKeys, ", "))
+			logger.Warnf("Set the Apply the scheme's Run action environment (apply_scheme_run_environment) input to pass them to the archive action.")
+		}
+	}
+}
+
+// printSchemeArchiveSettings prints the scheme settings affecting the archive action, which are easy to overlook
+// as they are applied by xcodebuild implicitly, like the scheme's pre and post actions, and returns the scheme's archive environment.
+func printSchemeArchiveSettings(scheme *xcscheme.Scheme, inputConfiguration string, applyRunEnvironment bool, logger log.Logger) schemeArchiveEnvironment {
+	schemeConfiguration := scheme.ArchiveAction.BuildConfiguration
+	if inputConfiguration == "" {
+		logger.Printf("Using the scheme's archive action Build Configuration: %s", schemeConfiguration)
+	} else if schemeConfiguration != "" && inputConfiguration != schemeConfiguration {
+		logger.Warnf("The Build Configuration input (%s) overrides the scheme's archive action Build Configuration (%s), unlike archiving in Xcode.", inputConfiguration, schemeConfiguration)
+		logger.Warnf("Clear the Build Configuration (configuration) input to use the scheme's Build Configuration.")
+	}
+
+	if scheme.Path == "" {
+		return schemeArchiveEnvironment{}
+	}
+	content, err := os.ReadFile(scheme.Path)
+	if err != nil {
+		logger.Warnf("Failed to read the scheme's pre and post actions: %s", err)
+		return schemeArchiveEnvironment{}
+	}
+	env, err := parseSchemeArchiveEnvironment(content)
+	if err != nil {
+		logger.Warnf("Failed to read the scheme's pre and post actions: %s", err)
+		return schemeArchiveEnvironment{}
+	}
+	printSchemeArchiveEnvironment(env, applyRunEnvironment, logger)
+	return env
+}
@@ -0,0 +1,59 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const schemeActionsTestScheme = `<?xml version="1.0" encoding="UTF-8"?>
+<Scheme LastUpgradeVersion = "1500" version = "1.7">
+   <BuildAction parallelizeBuildables = "YES" buildImplicitDependencies = "YES">
+      <PreActions>
+         <ExecutionAction ActionType = "Xcode.IDEStandardExecutionActionsCore.ExecutionActionType.ShellScriptAction">
+            <ActionContent title = "Generate secrets" scriptText = "cd &quot;$SRCROOT&quot;&#10;./generate.sh&#10;" shellToInvoke = "/bin/bash">
+               <EnvironmentBuildable>
+                  <BuildableReference BuildableIdentifier = "primary" BlueprintIdentifier = "1" BuildableName = "App.app" BlueprintName = "App" ReferencedContainer = "container:App.xcodeproj">
+                  </BuildableReference>
+               </EnvironmentBuildable>
+            </ActionContent>
+         </ExecutionAction>
+      </PreActions>
+   </BuildAction>
+   <LaunchAction buildConfiguration = "Debug">
+      <EnvironmentVariables>
+         <EnvironmentVariable key = "API_URL" value = "https://example.com" isEnabled = "YES">
+         </EnvironmentVariable>
+         <EnvironmentVariable key = "DISABLED" value = "1" isEnabled = "NO">
+         </EnvironmentVariable>
+      </EnvironmentVariables>
+   </LaunchAction>
+   <ArchiveAction buildConfiguration = "Release" revealArchiveInOrganizer = "YES">
+      <PostActions>
+         <ExecutionAction ActionType = "Xcode.IDEStandardExecutionActionsCore.ExecutionActionType.SendEmailAction">
+            <ActionContent title = "Notify" emailRecipient = "qa@example.com" attachLogToEmail = "NO">
+            </ActionContent>
+         </ExecutionAction>
+      </PostActions>
+   </ArchiveAction>
+</Scheme>`
+
+func Test_parseSchemeArchiveEnvironment(t *testing.T) {
+	env, err := parseSchemeArchiveEnvironment([]byte(schemeActionsTestScheme))
+	require.NoError(t, err)
+	require.Equal(t, schemeArchiveEnvironment{
+		Actions: []schemeExecutionAction{
+			{Phase: "Build pre-action", Title: "Generate secrets", Shell: "/bin/bash", Script: "cd \"$SRCROOT\"\n./generate.sh\n", BuildSettingsFrom: "App"},
+			{Phase: "Archive post-action", Title: "Notify", SendsEmail: true},
+		},
+		LaunchEnvironmentKeys: []string{"API_URL"},
+		LaunchEnvironment:     []string{"API_URL=https://example.com"},
+	}, env)
+
+	require.Equal(t, "Build pre-action: Generate secrets (/bin/bash, build settings from App)", env.Actions[0].description())
+	require.Equal(t, "Archive post-action: Notify (send email)", env.Actions[1].description())
+	require.Equal(t, "Build post-action: Run Script", schemeExecutionAction{Phase: "Build post-action"}.description())
+
+	_, err = parseSchemeArchiveEnvironment([]byte("<Scheme"))
+	require.Error(t, err)
+}
@@ -0,0 +1,121 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-xcode/xcodeproject/schemeint"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcodeproj"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcscheme"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcworkspace"
+)
+
+// productKinds names the products built by the non-archivable schemes, by their extension.
+var productKinds = map[string]string{
+	".xctest":    "test bundles",
+	".framework": "frameworks",
+	".a":         "static libraries",
+	".dylib":     "dynamic libraries",
+	".bundle":    "bundles",
+}
+
+// archivedProductsDescription describes the products built by the scheme's archive action, for example: frameworks (Core.framework).
+func archivedProductsDescription(scheme xcscheme.Scheme) string {
+	productsByKind := map[string][]string{}
+	for _, entry := range scheme.BuildAction.BuildActionEntries {
+		if entry.BuildForArchiving != "YES" {
+			continue
+		}
+		kind, ok := productKinds[filepath.Ext(entry.BuildableReference.BuildableName)]
+		if !ok {
+			kind = "non-application products"
+		}
+		productsByKind[kind] = append(productsByKind[kind], entry.BuildableReference.BuildableName)
+	}
+	if len(productsByKind) == 0 {
+		return ""
+	}
+
+	var kinds []string
+	for kind := range productsByKind {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	var descriptions []string
+	for _, kind := range kinds {
+		descriptions = append(descriptions, fmt.Sprintf("%s (%s)", kind, strings.Join(productsByKind[kind], ", ")))
+	}
+	return strings.Join(descriptions, " and ")
+}
+
+// projectSchemes returns the schemes of the project or workspace.
+func projectSchemes(projectPath string) ([]xcscheme.Scheme, error) {
+	if xcodeproj.IsXcodeProj(projectPath) {
+		project, err := xcodeproj.Open(projectPath)
+		if err != nil {
+			return nil, err
+		}
+		return project.Schemes()
+	}
+
+	workspace, err := xcworkspace.Open(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	schemesByContainer, err := workspace.Schemes()
+	if err != nil {
+		return nil, err
+	}
+	var schemes []xcscheme.Scheme
+	for _, containerSchemes := range schemesByContainer {
+		schemes = append(schemes, containerSchemes...)
+	}
+	return schemes, nil
+}
+
+// archivableSchemeNames returns the names of the schemes archiving an application.
+func archivableSchemeNames(schemes []xcscheme.Scheme) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, scheme := range schemes {
+		if _, ok := scheme.AppBuildActionEntry(); ok && !seen[scheme.Name] {
+			seen[scheme.Name] = true
+			names = append(names, scheme.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func nonArchivableSchemeError(scheme xcscheme.Scheme, archivableSchemes []string) error {
+	msg := fmt.Sprintf("scheme (%s) does not build any target for archiving", scheme.Name)
+	if products := archivedProductsDescription(scheme); products != "" {
+		msg = fmt.Sprintf("scheme (%s) only builds %s, no application", scheme.Name, products)
+	}
+
+	if len(archivableSchemes) == 0 {
+		return fmt.Errorf("%s, and no scheme archiving an application found in the project", msg)
+	}
+	return fmt.Errorf("%s, pick a scheme archiving an application: %s", msg, strings.Join(archivableSchemes, ", "))
+}
+
+// validateArchivableScheme fails if the scheme's archive action does not build an application, before spending time on building it.
+// Errors of reading the scheme are not reported here, as they are reported when opening the project for the archive action.
+func validateArchivableScheme(projectPath, schemeName string) error {
+	scheme, _, err := schemeint.Scheme(projectPath, schemeName)
+	if err != nil {
+		return nil
+	}
+	if _, ok := scheme.AppBuildActionEntry(); ok {
+		return nil
+	}
+
+	var archivableSchemes []string
+	if schemes, err := projectSchemes(projectPath); err == nil {
+		archivableSchemes = archivableSchemeNames(schemes)
+	}
+	return nonArchivableSchemeError(*scheme, archivableSchemes)
+}
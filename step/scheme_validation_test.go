@@ -0,0 +1,39 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcscheme"
+	"github.com/stretchr/testify/require"
+)
+
+func testScheme(name string, products ...string) xcscheme.Scheme {
+	scheme := xcscheme.Scheme{Name: name}
+	for _, product := range products {
+		scheme.BuildAction.BuildActionEntries = append(scheme.BuildAction.BuildActionEntries, xcscheme.BuildActionEntry{
+			BuildForArchiving:  "YES",
+			BuildableReference: xcscheme.BuildableReference{BuildableName: product, BlueprintIdentifier: product + "_id"},
+		})
+	}
+	return scheme
+}
+
+func Test_nonArchivableSchemeError(t *testing.T) {
+	schemes := []xcscheme.Scheme{
+		testScheme("AppUITests", "AppUITests.xctest"),
+		testScheme("Core", "Core.framework", "CoreTests.xctest"),
+		testScheme("App", "Core.framework", "App.app"),
+		testScheme("Sample", "Sample.app"),
+		testScheme("App", "App.app"),
+		testScheme("Empty"),
+	}
+	archivable := archivableSchemeNames(schemes)
+	require.Equal(t, []string{"App", "Sample"}, archivable)
+
+	require.EqualError(t, nonArchivableSchemeError(schemes[0], archivable),
+		"scheme (AppUITests) only builds test bundles (AppUITests.xctest), no application, pick a scheme archiving an application: App, Sample")
+	require.EqualError(t, nonArchivableSchemeError(schemes[1], archivable),
+		"scheme (Core) only builds frameworks (Core.framework) and test bundles (CoreTests.xctest), no application, pick a scheme archiving an application: App, Sample")
+	require.EqualError(t, nonArchivableSchemeError(schemes[5], nil),
+		"scheme (Empty) does not build any target for archiving, and no scheme archiving an application found in the project")
+}
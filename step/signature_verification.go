@@ -0,0 +1,142 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/sliceutil"
+)
+
+// simulatorArchitectures are the architectures only used by simulator builds of iOS, tvOS and watchOS apps.
+var simulatorArchitectures = []string{"i386", "x86_64"}
+
+// nestedBundlePatterns are the locations of the code bundles embedded into an application bundle.
+var nestedBundlePatterns = []string{
+	"Frameworks/*.framework",
+	"PlugIns/*.appex",
+	"Extensions/*.appex",
+	"Watch/*.app",
+	"AppClips/*.app",
+}
+
+// nestedCodeBundles returns the code bundles embedded into the app, recursively.
+func nestedCodeBundles(appPath string) ([]string, error) {
+	var bundles []string
+	for _, pattern := range nestedBundlePatterns {
+		pths, err := filepath.Glob(filepath.Join(appPath, pattern))
+		if err != nil {
+			return nil, err
+		}
+		for _, pth := range pths {
+			bundles = append(bundles, pth)
+
+			nested, err := nestedCodeBundles(pth)
+			if err != nil {
+				return nil, err
+			}
+			bundles = append(bundles, nested...)
+		}
+	}
+	return bundles, nil
+}
+
+// unsignedBundles returns the bundles without a code signature.
+func unsignedBundles(bundles []string) []string {
+	var unsigned []string
+	for _, bundle := range bundles {
+		if _, err := os.Stat(filepath.Join(bundle, "_CodeSignature", "CodeResources")); err != nil {
+			unsigned = append(unsigned, bundle)
+		}
+	}
+	return unsigned
+}
+
+// bundleExecutable returns the bundle's executable, assuming it is named after the bundle.
+func bundleExecutable(bundle string) string {
+	name := strings.TrimSuffix(filepath.Base(bundle), filepath.Ext(bundle))
+	return filepath.Join(bundle, name)
+}
+
+func simulatorSlices(architectures []string) []string {
+	var slices []string
+	for _, arch := range architectures {
+		if sliceutil.IsStringInSlice(arch, simulatorArchitectures) {
+			slices = append(slices, arch)
+		}
+	}
+	return slices
+}
+
+// verifyIPASignature extracts the exported ipa and checks the code signature of the app and its nested bundles,
+// to catch invalid signatures (for example ITMS-90035) before uploading the ipa.
+func (s XcodebuildArchiver) verifyIPASignature(ipaExportDir string) error {
+	s.logger.Println()
+	s.logger.Infof("Verifying the code signature of the exported ipa...")
+
+	ipaPaths, err := filepath.Glob(filepath.Join(ipaExportDir, "*.ipa"))
+	if err != nil {
+		return err
+	}
+	if len(ipaPaths) == 0 {
+		return fmt.Errorf("no .ipa file found at export dir: %s", ipaExportDir)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "verifyIPASignature")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	appPath, err := extractIPAApp(ipaPaths[0], tmpDir)
+	if err != nil {
+		return err
+	}
+
+	var issues []string
+
+	codesignCmd := s.cmdFactory.Create("codesign", []string{"--verify", "--deep", "--strict", "--verbose=2", appPath}, nil)
+	if out, err := codesignCmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+		issues = append(issues, fmt.Sprintf("codesign verification failed: %s", strings.TrimSpace(strings.ReplaceAll(out, tmpDir+"/", ""))))
+	}
+
+	bundles, err := nestedCodeBundles(appPath)
+	if err != nil {
+		return fmt.Errorf("failed to list the nested bundles: %w", err)
+	}
+	for _, bundle := range unsignedBundles(bundles) {
+		rel, _ := filepath.Rel(tmpDir, bundle)
+		issues = append(issues, fmt.Sprintf("missing code signature: %s", rel))
+	}
+
+	for _, bundle := range append([]string{appPath}, bundles...) {
+		executable := bundleExecutable(bundle)
+		if _, err := os.Stat(executable); err != nil {
+			continue
+		}
+
+		lipoCmd := s.cmdFactory.Create("lipo", []string{"-archs", executable}, nil)
+		out, err := lipoCmd.RunAndReturnTrimmedOutput()
+		if err != nil {
+			s.logger.Warnf("Failed to read the architectures of %s: %s", executable, err)
+			continue
+		}
+		if slices := simulatorSlices(strings.Fields(out)); len(slices) > 0 {
+			rel, _ := filepath.Rel(tmpDir, executable)
+			issues = append(issues, fmt.Sprintf("simulator slices (%s) found in: %s", strings.Join(slices, ", "), rel))
+		}
+	}
+
+	if len(issues) > 0 {
+		for _, issue := range issues {
+			s.logger.Errorf("- %s", issue)
+		}
+		return fmt.Errorf("code signature verification of the exported ipa failed: %d issue(s) found", len(issues))
+	}
+
+	s.logger.Donef("The code signature of the exported ipa is valid")
+	return nil
+}
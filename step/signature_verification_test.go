@@ -0,0 +1,48 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_nestedCodeBundles(t *testing.T) {
+	appPath := filepath.Join(t.TempDir(), "Payload", "Sample.app")
+	createDirs(t, appPath,
+		"_CodeSignature",
+		"Frameworks/Core.framework/_CodeSignature",
+		"Frameworks/Unsigned.framework",
+		"PlugIns/Widget.appex/_CodeSignature",
+		"PlugIns/Widget.appex/Frameworks/WidgetKit.framework",
+		"Resources/Bundle.bundle",
+	)
+	for _, bundle := range []string{"_CodeSignature", "Frameworks/Core.framework/_CodeSignature", "PlugIns/Widget.appex/_CodeSignature"} {
+		require.NoError(t, os.WriteFile(filepath.Join(appPath, bundle, "CodeResources"), nil, 0644))
+	}
+
+	bundles, err := nestedCodeBundles(appPath)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(appPath, "Frameworks/Core.framework"),
+		filepath.Join(appPath, "Frameworks/Unsigned.framework"),
+		filepath.Join(appPath, "PlugIns/Widget.appex"),
+		filepath.Join(appPath, "PlugIns/Widget.appex/Frameworks/WidgetKit.framework"),
+	}, bundles)
+
+	require.Equal(t, []string{
+		filepath.Join(appPath, "Frameworks/Unsigned.framework"),
+		filepath.Join(appPath, "PlugIns/Widget.appex/Frameworks/WidgetKit.framework"),
+	}, unsignedBundles(bundles))
+}
+
+func Test_bundleExecutable(t *testing.T) {
+	require.Equal(t, "/tmp/Payload/Sample.app/Sample", bundleExecutable("/tmp/Payload/Sample.app"))
+	require.Equal(t, "/tmp/Payload/Sample.app/Frameworks/Core.framework/Core", bundleExecutable("/tmp/Payload/Sample.app/Frameworks/Core.framework"))
+}
+
+func Test_simulatorSlices(t *testing.T) {
+	require.Nil(t, simulatorSlices([]string{"arm64"}))
+	require.Equal(t, []string{"x86_64"}, simulatorSlices([]string{"x86_64", "arm64"}))
+}
@@ -0,0 +1,202 @@
+package step
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/certificateutil"
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/bitrise-io/go-xcode/profileutil"
+	"github.com/bitrise-io/go-xcode/xcarchive"
+)
+
+const bitriseExportSigningReportPthEnvKey = "BITRISE_EXPORT_SIGNING_REPORT_PATH"
+
+// SigningReportEntry describes the code signing of a bundle of the export: the provisioning profile and the certificate
+// selected at export options generation, empty if not found among the installed ones.
+type SigningReportEntry struct {
+	Target          string `json:"target"`
+	BundleID        string `json:"bundle_id"`
+	ProfileName     string `json:"profile_name,omitempty"`
+	ProfileUUID     string `json:"profile_uuid,omitempty"`
+	ProfileExpiry   string `json:"profile_expiry,omitempty"`
+	CertificateName string `json:"certificate_name,omitempty"`
+	CertificateSHA1 string `json:"certificate_sha1,omitempty"`
+	SigningStyle    string `json:"signing_style"`
+}
+
+type archiveBundle struct {
+	Target   string
+	BundleID string
+}
+
+func newArchiveBundle(app xcarchive.IosBaseApplication) archiveBundle {
+	name := filepath.Base(app.Path)
+	return archiveBundle{Target: strings.TrimSuffix(name, filepath.Ext(name)), BundleID: app.BundleIdentifier()}
+}
+
+// archiveBundles lists the signed bundles of the archive: the application, its extensions, the watch and the clip applications.
+func archiveBundles(archive xcarchive.IosArchive) []archiveBundle {
+	bundles := []archiveBundle{newArchiveBundle(archive.Application.IosBaseApplication)}
+	for _, extension := range archive.Application.Extensions {
+		bundles = append(bundles, newArchiveBundle(extension.IosBaseApplication))
+	}
+	if watch := archive.Application.WatchApplication; watch != nil {
+		bundles = append(bundles, newArchiveBundle(watch.IosBaseApplication))
+		for _, extension := range watch.Extensions {
+			bundles = append(bundles, newArchiveBundle(extension.IosBaseApplication))
+		}
+	}
+	if clip := archive.Application.ClipApplication; clip != nil {
+		bundles = append(bundles, newArchiveBundle(clip.IosBaseApplication))
+	}
+	return bundles
+}
+
+func exportOptionsSigning(exportOpts exportoptions.ExportOptions) (exportoptions.Method, exportoptions.SigningStyle, string) {
+	switch options := exportOpts.(type) {
+	case exportoptions.AppStoreOptionsModel:
+		return exportoptions.MethodAppStore, options.SigningStyle, options.SigningCertificate
+	case exportoptions.NonAppStoreOptionsModel:
+		return options.Method, options.SigningStyle, options.SigningCertificate
+	}
+	return "", "", ""
+}
+
+// findProfileByNameOrUUID returns the installed profile referenced by the export options' provisioning profile mapping.
+func findProfileByNameOrUUID(teamID, nameOrUUID string, profiles []profileutil.ProvisioningProfileInfoModel) (profileutil.ProvisioningProfileInfoModel, bool) {
+	for _, profile := range profiles {
+		if (profile.UUID == nameOrUUID || profile.Name == nameOrUUID) && (teamID == "" || profile.TeamID == teamID) {
+			return profile, true
+		}
+	}
+	return profileutil.ProvisioningProfileInfoModel{}, false
+}
+
+// selectProfileCertificate returns the certificate of the profile installed in the keychain and matching the export options' signing certificate
+// (a certificate name, name prefix like Apple Distribution, or SHA1), the latest expiring one if more match.
+func selectProfileCertificate(profile profileutil.ProvisioningProfileInfoModel, installed []certificateutil.CertificateInfoModel, signingCertificate string) (certificateutil.CertificateInfoModel, bool) {
+	installedSHA1s := map[string]bool{}
+	for _, certificate := range installed {
+		installedSHA1s[strings.ToUpper(certificate.SHA1Fingerprint)] = true
+	}
+
+	var selected *certificateutil.CertificateInfoModel
+	for i, certificate := range profile.DeveloperCertificates {
+		if !installedSHA1s[strings.ToUpper(certificate.SHA1Fingerprint)] {
+			continue
+		}
+		if signingCertificate != "" && !strings.HasPrefix(certificate.CommonName, signingCertificate) && !strings.EqualFold(certificate.SHA1Fingerprint, signingCertificate) {
+			continue
+		}
+		if selected == nil || certificate.EndDate.After(selected.EndDate) {
+			selected = &profile.DeveloperCertificates[i]
+		}
+	}
+	if selected == nil {
+		return certificateutil.CertificateInfoModel{}, false
+	}
+	return *selected, true
+}
+
+// newSigningReport resolves the provisioning profile and the certificate of every bundle of the archive: the profiles of the export options'
+// mapping, otherwise (automatic signing) the installed profile of the team and export method.
+func newSigningReport(archive xcarchive.IosArchive, exportOpts exportoptions.ExportOptions, teamID string, profiles []profileutil.ProvisioningProfileInfoModel, certificates []certificateutil.CertificateInfoModel) []SigningReportEntry {
+	method, signingStyle, signingCertificate := exportOptionsSigning(exportOpts)
+	if signingStyle == "" {
+		signingStyle = exportoptions.SigningStyleAutomatic
+	}
+	mapping := bundleIDProvisioningProfilesOf(exportOpts)
+
+	var report []SigningReportEntry
+	for _, bundle := range archiveBundles(archive) {
+		entry := SigningReportEntry{Target: bundle.Target, BundleID: bundle.BundleID, SigningStyle: string(signingStyle)}
+
+		var profile profileutil.ProvisioningProfileInfoModel
+		found := false
+		if nameOrUUID, ok := mapping[bundle.BundleID]; ok {
+			entry.ProfileName = nameOrUUID
+			profile, found = findProfileByNameOrUUID(teamID, nameOrUUID, profiles)
+		} else {
+			profile, found = findExportProfile(teamID, method, bundle.BundleID, profiles)
+		}
+
+		if found {
+			entry.ProfileName = profile.Name
+			entry.ProfileUUID = profile.UUID
+			entry.ProfileExpiry = profile.ExpirationDate.UTC().Format(time.RFC3339)
+			if certificate, ok := selectProfileCertificate(profile, certificates, signingCertificate); ok {
+				entry.CertificateName = certificate.CommonName
+				entry.CertificateSHA1 = certificate.SHA1Fingerprint
+			}
+		}
+		report = append(report, entry)
+	}
+	return report
+}
+
+func orUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
+
+func printSigningReport(report []SigningReportEntry, logger log.Logger) {
+	logger.Println()
+	logger.Infof("Export code signing:")
+	for _, entry := range report {
+		logger.Printf("- %s (%s), %s signing", entry.Target, entry.BundleID, entry.SigningStyle)
+		profile := orUnknown(entry.ProfileName)
+		if entry.ProfileUUID != "" {
+			profile = fmt.Sprintf("%s (%s, expires %s)", entry.ProfileName, entry.ProfileUUID, entry.ProfileExpiry)
+		}
+		logger.Printf("  profile: %s", profile)
+		certificate := orUnknown(entry.CertificateName)
+		if entry.CertificateSHA1 != "" {
+			certificate = fmt.Sprintf("%s (%s)", entry.CertificateName, entry.CertificateSHA1)
+		}
+		logger.Printf("  certificate: %s", certificate)
+	}
+}
+
+// reportExportSigning prints the code signing of the export's bundles, the installed profiles and certificates are read
+// on a best effort basis, as a missing report should not fail the export.
+func (s XcodebuildArchiver) reportExportSigning(archive xcarchive.IosArchive, exportOpts exportoptions.ExportOptions, teamID string) []SigningReportEntry {
+	profiles, err := profileutil.InstalledProvisioningProfileInfos(profileutil.ProfileTypeIos)
+	if err != nil {
+		s.logger.Warnf("Failed to read the installed provisioning profiles for the signing report: %s", err)
+	}
+	certificates, err := certificateutil.InstalledCodesigningCertificateInfos()
+	if err != nil {
+		s.logger.Warnf("Failed to read the installed certificates for the signing report: %s", err)
+	}
+
+	report := newSigningReport(archive, exportOpts, teamID, profiles, certificates)
+	printSigningReport(report, s.logger)
+	return report
+}
+
+func writeSigningReport(report []SigningReportEntry, pth string) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the signing report: %w", err)
+	}
+	return os.WriteFile(pth, content, 0644)
+}
+
+// exportSigningReport writes the signing report as JSON into the ipa output dir.
+func (s XcodebuildArchiver) exportSigningReport(report []SigningReportEntry, pth string) {
+	if err := writeSigningReport(report, pth); err != nil {
+		s.logger.Warnf("Failed to write the signing report: %s", err)
+	} else if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseExportSigningReportPthEnvKey, pth); err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", s.outputEnvKey(bitriseExportSigningReportPthEnvKey), err)
+	} else {
+		s.logger.Donef("The export signing report path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseExportSigningReportPthEnvKey), pth)
+	}
+}
@@ -0,0 +1,68 @@
+package step
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-xcode/certificateutil"
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/bitrise-io/go-xcode/plistutil"
+	"github.com/bitrise-io/go-xcode/profileutil"
+	"github.com/bitrise-io/go-xcode/xcarchive"
+	"github.com/stretchr/testify/require"
+)
+
+func testSigningArchive() xcarchive.IosArchive {
+	return xcarchive.IosArchive{
+		Application: xcarchive.IosApplication{
+			IosBaseApplication: xcarchive.IosBaseApplication{
+				Path:      "/archive/Products/Applications/Sample.app",
+				InfoPlist: plistutil.PlistData{"CFBundleIdentifier": "io.bitrise.sample"},
+			},
+			Extensions: []xcarchive.IosExtension{{IosBaseApplication: xcarchive.IosBaseApplication{
+				Path:      "/archive/Products/Applications/Sample.app/PlugIns/Widget.appex",
+				InfoPlist: plistutil.PlistData{"CFBundleIdentifier": "io.bitrise.sample.widget"},
+			}}},
+		},
+	}
+}
+
+func Test_selectProfileCertificate(t *testing.T) {
+	development := certificateutil.CertificateInfoModel{CommonName: "Apple Development: Bitrise (ABCD)", SHA1Fingerprint: "aaa", EndDate: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)}
+	oldDistribution := certificateutil.CertificateInfoModel{CommonName: "Apple Distribution: Bitrise (TEAM)", SHA1Fingerprint: "bbb", EndDate: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	distribution := certificateutil.CertificateInfoModel{CommonName: "Apple Distribution: Bitrise (TEAM)", SHA1Fingerprint: "ccc", EndDate: time.Date(2027, 6, 1, 0, 0, 0, 0, time.UTC)}
+	notInstalled := certificateutil.CertificateInfoModel{CommonName: "Apple Distribution: Bitrise (TEAM)", SHA1Fingerprint: "ddd", EndDate: time.Date(2028, 1, 1, 0, 0, 0, 0, time.UTC)}
+	profile := profileutil.ProvisioningProfileInfoModel{DeveloperCertificates: []certificateutil.CertificateInfoModel{development, oldDistribution, distribution, notInstalled}}
+	installed := []certificateutil.CertificateInfoModel{development, oldDistribution, distribution}
+
+	certificate, ok := selectProfileCertificate(profile, installed, "Apple Distribution")
+	require.True(t, ok)
+	require.Equal(t, "ccc", certificate.SHA1Fingerprint)
+
+	certificate, ok = selectProfileCertificate(profile, installed, "BBB")
+	require.True(t, ok)
+	require.Equal(t, "bbb", certificate.SHA1Fingerprint)
+
+	_, ok = selectProfileCertificate(profile, nil, "")
+	require.False(t, ok)
+}
+
+func Test_newSigningReport(t *testing.T) {
+	certificate := certificateutil.CertificateInfoModel{CommonName: "Apple Distribution: Bitrise (TEAM)", SHA1Fingerprint: "CCC"}
+	expiry := time.Date(2027, 6, 1, 0, 0, 0, 0, time.UTC)
+	profiles := []profileutil.ProvisioningProfileInfoModel{
+		{Name: "Sample App Store", UUID: "uuid-1", TeamID: "TEAM", BundleID: "io.bitrise.sample", ExportType: exportoptions.MethodAppStore, ExpirationDate: expiry, DeveloperCertificates: []certificateutil.CertificateInfoModel{certificate}},
+		{Name: "Wildcard App Store", UUID: "uuid-2", TeamID: "TEAM", BundleID: "io.bitrise.*", ExportType: exportoptions.MethodAppStore, ExpirationDate: expiry},
+	}
+
+	options := exportoptions.NewAppStoreOptions()
+	options.SigningStyle = exportoptions.SigningStyleManual
+	options.SigningCertificate = "Apple Distribution"
+	options.BundleIDProvisioningProfileMapping = map[string]string{"io.bitrise.sample": "Sample App Store"}
+
+	report := newSigningReport(testSigningArchive(), options, "TEAM", profiles, []certificateutil.CertificateInfoModel{certificate})
+	require.Equal(t, []SigningReportEntry{
+		{Target: "Sample", BundleID: "io.bitrise.sample", ProfileName: "Sample App Store", ProfileUUID: "uuid-1", ProfileExpiry: "2027-06-01T00:00:00Z", CertificateName: "Apple Distribution: Bitrise (TEAM)", CertificateSHA1: "CCC", SigningStyle: "manual"},
+		{Target: "Widget", BundleID: "io.bitrise.sample.widget", ProfileName: "Wildcard App Store", ProfileUUID: "uuid-2", ProfileExpiry: "2027-06-01T00:00:00Z", SigningStyle: "manual"},
+	}, report)
+}
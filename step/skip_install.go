@@ -0,0 +1,123 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+const skipInstallBuildSetting = "SKIP_INSTALL"
+
+// dependencyProductTypes are the product types which are embedded into (or linked by) the application,
+// installing them into the archive makes it a generic Xcode archive instead of an iOS App Archive.
+var dependencyProductTypes = []string{
+	"com.apple.product-type.framework",
+	"com.apple.product-type.framework.static",
+	"com.apple.product-type.library.static",
+	"com.apple.product-type.library.dynamic",
+	"com.apple.product-type.bundle",
+}
+
+// dependencyProductExtensions are the extensions of the products installed into the archive by dependency targets.
+var dependencyProductExtensions = map[string]bool{
+	".framework": true,
+	".a":         true,
+	".dylib":     true,
+	".bundle":    true,
+}
+
+// skipInstallDependenciesBuildSettings returns the build settings setting SKIP_INSTALL=YES for the dependency product types,
+// while keeping the targets' own SKIP_INSTALL value for every other product type (like the application).
+// Build settings can't be overridden per target on the command line, so the value is selected by the target's PRODUCT_TYPE.
+func skipInstallDependenciesBuildSettings() []string {
+	settings := []string{fmt.Sprintf("%s=$(BITRISE_%s_$(PRODUCT_TYPE:identifier):default=$(inherited))", skipInstallBuildSetting, skipInstallBuildSetting)}
+	for _, productType := range dependencyProductTypes {
+		identifier := strings.NewReplacer(".", "_", "-", "_").Replace(productType)
+		settings = append(settings, fmt.Sprintf("BITRISE_%s_%s=YES", skipInstallBuildSetting, identifier))
+	}
+	return settings
+}
+
+// setsSkipInstall returns true if the SKIP_INSTALL build setting is set in the xcodebuild options.
+func setsSkipInstall(xcodebuildOptions []string) bool {
+	for _, option := range xcodebuildOptions {
+		if strings.HasPrefix(option, skipInstallBuildSetting+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// installedDependencyProducts returns the products installed into the archive by dependency targets (with SKIP_INSTALL=NO),
+// relative to the archive's Products dir.
+func installedDependencyProducts(archivePath string) ([]string, error) {
+	productsDir := filepath.Join(archivePath, "Products")
+	var products []string
+	err := filepath.Walk(productsDir, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(productsDir, pth)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && rel == "Applications" {
+			return filepath.SkipDir
+		}
+		if dependencyProductExtensions[filepath.Ext(pth)] {
+			products = append(products, rel)
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(products)
+	return products, nil
+}
+
+// dependencyProductTarget returns the probable name of the target building the product: libCore.a is built by the Core target.
+func dependencyProductTarget(product string) string {
+	name := filepath.Base(product)
+	ext := filepath.Ext(name)
+	name = strings.TrimSuffix(name, ext)
+	if ext == ".a" || ext == ".dylib" {
+		name = strings.TrimPrefix(name, "lib")
+	}
+	return name
+}
+
+// warnInstalledDependencyProducts prints the dependency products installed into the archive,
+// the classic reason of the archive containing no application or being a generic Xcode archive.
+func warnInstalledDependencyProducts(archivePath string, logger log.Logger) {
+	products, err := installedDependencyProducts(archivePath)
+	if err != nil {
+		logger.Warnf("Failed to check the products of the archive: %s", err)
+		return
+	}
+	if len(products) == 0 {
+		return
+	}
+
+	var targets []string
+	logger.Println()
+	logger.Warnf("The archive contains products of dependency targets:")
+	for _, product := range products {
+		logger.Warnf("- Products/%s", product)
+		targets = append(targets, dependencyProductTarget(product))
+	}
+	logger.Warnf("Set the Skip Install (SKIP_INSTALL) build setting to YES for the targets (%s),", strings.Join(targets, ", "))
+	logger.Warnf("or enable the Skip install dependencies (skip_install_dependencies) input, otherwise the archive is not an iOS App Archive and can not be exported.")
+}
@@ -0,0 +1,49 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_skipInstallDependenciesBuildSettings(t *testing.T) {
+	require.Equal(t, []string{
+		"SKIP_INSTALL=$(BITRISE_SKIP_INSTALL_$(PRODUCT_TYPE:identifier):default=$(inherited))",
+		"BITRISE_SKIP_INSTALL_com_apple_product_type_framework=YES",
+		"BITRISE_SKIP_INSTALL_com_apple_product_type_framework_static=YES",
+		"BITRISE_SKIP_INSTALL_com_apple_product_type_library_static=YES",
+		"BITRISE_SKIP_INSTALL_com_apple_product_type_library_dynamic=YES",
+		"BITRISE_SKIP_INSTALL_com_apple_product_type_bundle=YES",
+	}, skipInstallDependenciesBuildSettings())
+}
+
+func Test_setsSkipInstall(t *testing.T) {
+	require.True(t, setsSkipInstall([]string{"-quiet", "SKIP_INSTALL=NO"}))
+	require.False(t, setsSkipInstall([]string{"-quiet", "SKIP_INSTALL_FOO=NO"}))
+}
+
+func Test_installedDependencyProducts(t *testing.T) {
+	archivePath := t.TempDir()
+	createDirs(t, archivePath,
+		"Products/Applications/App.app/Frameworks/Embedded.framework",
+		"Products/Library/Frameworks/Core.framework/Headers",
+		"Products/usr/local/lib",
+	)
+	require.NoError(t, os.WriteFile(filepath.Join(archivePath, "Products", "usr", "local", "lib", "libNetworking.a"), []byte{}, 0644))
+
+	products, err := installedDependencyProducts(archivePath)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join("Library", "Frameworks", "Core.framework"),
+		filepath.Join("usr", "local", "lib", "libNetworking.a"),
+	}, products)
+
+	targets := []string{dependencyProductTarget(products[0]), dependencyProductTarget(products[1])}
+	require.Equal(t, []string{"Core", "Networking"}, targets)
+
+	products, err = installedDependencyProducts(filepath.Join(archivePath, "missing.xcarchive"))
+	require.NoError(t, err)
+	require.Nil(t, products)
+}
@@ -0,0 +1,32 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/go-xcode/plistutil"
+)
+
+const skipUnavailableActionsOption = "-skipUnavailableActions"
+
+// verifyMainApplicationArchived checks that the application product got archived, when the archive action skips the
+// targets not buildable for the destination an archive without the application (a generic Xcode archive) does not fail xcodebuild.
+func verifyMainApplicationArchived(archivePth, mainTargetName string) error {
+	infoPlist, err := plistutil.NewPlistDataFromFile(filepath.Join(archivePth, "Info.plist"))
+	if err != nil {
+		return fmt.Errorf("failed to read the archive's Info.plist: %w", err)
+	}
+
+	applicationPath := ""
+	if properties, found := infoPlist.GetMapStringInterface("ApplicationProperties"); found {
+		applicationPath, _ = properties.GetString("ApplicationPath")
+	}
+	if applicationPath == "" {
+		return fmt.Errorf("the main application target (%s) was not archived, the archive contains no application, the target might be unavailable for the archive destination", mainTargetName)
+	}
+	if _, err := os.Stat(filepath.Join(archivePth, "Products", applicationPath)); err != nil {
+		return fmt.Errorf("the main application target (%s) was not archived, the archived application is missing: %w", mainTargetName, err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeArchiveInfoPlist(t *testing.T, archivePth, applicationPath string) {
+	applicationProperties := ""
+	if applicationPath != "" {
+		applicationProperties = `<key>ApplicationProperties</key>
+	<dict>
+		<key>ApplicationPath</key>
+		<string>` + applicationPath + `</string>
+	</dict>`
+	}
+	require.NoError(t, os.MkdirAll(archivePth, 0777))
+	require.NoError(t, os.WriteFile(filepath.Join(archivePth, "Info.plist"), []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	`+applicationProperties+`
+	<key>Name</key>
+	<string>App</string>
+</dict>
+</plist>`), 0644))
+}
+
+func TestVerifyMainApplicationArchived(t *testing.T) {
+	t.Run("application archived", func(t *testing.T) {
+		archivePth := filepath.Join(t.TempDir(), "App.xcarchive")
+		writeArchiveInfoPlist(t, archivePth, "Applications/App.app")
+		require.NoError(t, os.MkdirAll(filepath.Join(archivePth, "Products", "Applications", "App.app"), 0777))
+
+		require.NoError(t, verifyMainApplicationArchived(archivePth, "App"))
+	})
+
+	t.Run("generic archive", func(t *testing.T) {
+		archivePth := filepath.Join(t.TempDir(), "App.xcarchive")
+		writeArchiveInfoPlist(t, archivePth, "")
+
+		err := verifyMainApplicationArchived(archivePth, "App")
+		require.EqualError(t, err, "the main application target (App) was not archived, the archive contains no application, the target might be unavailable for the archive destination")
+	})
+
+	t.Run("archived application missing", func(t *testing.T) {
+		archivePth := filepath.Join(t.TempDir(), "App.xcarchive")
+		writeArchiveInfoPlist(t, archivePth, "Applications/App.app")
+
+		require.Error(t, verifyMainApplicationArchived(archivePth, "App"))
+	})
+}
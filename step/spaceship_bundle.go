@@ -0,0 +1,84 @@
+package step
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+
+	"github.com/bitrise-io/go-steputils/cache"
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+const (
+	goXcodeModulePath   = "github.com/bitrise-io/go-xcode/v2"
+	bundlePathEnvKey    = "BUNDLE_PATH"
+	spaceshipBundlesDir = ".bitrise/cache/steps-xcode-archive/spaceship-bundle"
+)
+
+// goXcodeModuleVersion returns the version of the go-xcode module the Step was built with.
+// The embedded Developer Portal client (spaceship) and its Gemfile.lock are part of this module.
+func goXcodeModuleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path != goXcodeModulePath {
+			continue
+		}
+		if dep.Replace != nil {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return ""
+}
+
+func spaceshipBundlePath(homeDir, rubyVersion, goXcodeVersion string) string {
+	key := fmt.Sprintf("ruby:%s\n%s:%s", rubyVersion, goXcodeModulePath, goXcodeVersion)
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(key)))
+	return filepath.Join(homeDir, spaceshipBundlesDir, hash[:16])
+}
+
+// prepareSpaceshipBundleCache points bundler to a persistent gem location for the Developer Portal client,
+// keyed on the Ruby version and the client's version, and adds it to the Bitrise build cache.
+// This way repeated builds can skip installing the client's gems.
+func prepareSpaceshipBundleCache(cmdFactory command.Factory, logger log.Logger) error {
+	goXcodeVersion := goXcodeModuleVersion()
+	if goXcodeVersion == "" {
+		return fmt.Errorf("failed to determine the %s module version", goXcodeModulePath)
+	}
+
+	cmd := cmdFactory.Create("ruby", []string{"-e", "print RUBY_VERSION"}, nil)
+	rubyVersion, err := cmd.RunAndReturnTrimmedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to determine Ruby version: %w", err)
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+
+	bundlePath := spaceshipBundlePath(homeDir, rubyVersion, goXcodeVersion)
+	if err := os.MkdirAll(bundlePath, 0755); err != nil {
+		return fmt.Errorf("failed to create bundle directory: %w", err)
+	}
+
+	if err := os.Setenv(bundlePathEnvKey, bundlePath); err != nil {
+		return err
+	}
+	logger.Printf("Using Developer Portal client gems from: %s", bundlePath)
+
+	bundleCache := cache.New()
+	bundleCache.IncludePath(bundlePath)
+	if err := bundleCache.Commit(); err != nil {
+		return fmt.Errorf("failed to mark bundle directory to be cached: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,18 @@
+package step
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_spaceshipBundlePath(t *testing.T) {
+	path := spaceshipBundlePath("/Users/vagrant", "3.2.2", "v2.0.0-alpha.54")
+	require.True(t, strings.HasPrefix(path, filepath.Join("/Users/vagrant", spaceshipBundlesDir)))
+	require.Equal(t, path, spaceshipBundlePath("/Users/vagrant", "3.2.2", "v2.0.0-alpha.54"))
+
+	require.NotEqual(t, path, spaceshipBundlePath("/Users/vagrant", "3.3.0", "v2.0.0-alpha.54"))
+	require.NotEqual(t, path, spaceshipBundlePath("/Users/vagrant", "3.2.2", "v2.0.0-alpha.55"))
+}
@@ -0,0 +1,126 @@
+package step
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-steputils/v2/stepconf"
+	"github.com/bitrise-io/go-xcode/plistutil"
+)
+
+const (
+	bitriseSparkleAppcastItemPthEnvKey = "BITRISE_SPARKLE_APPCAST_ITEM_PATH"
+	bitriseSparkleEdSignatureEnvKey    = "BITRISE_SPARKLE_ED_SIGNATURE"
+)
+
+// parseSparklePrivateKey parses the base64 encoded EdDSA (ed25519) private key, as exported by Sparkle's `generate_keys -x` tool.
+func parseSparklePrivateKey(key string) (ed25519.PrivateKey, error) {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("the key is not base64 encoded: %w", err)
+	}
+
+	switch len(decoded) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(decoded), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.NewKeyFromSeed(decoded[:ed25519.SeedSize]), nil
+	default:
+		return nil, fmt.Errorf("unexpected key length: %d bytes, expected a %d byte seed", len(decoded), ed25519.SeedSize)
+	}
+}
+
+type sparkleEnclosure struct {
+	URL         string `xml:"url,attr"`
+	Length      int64  `xml:"length,attr"`
+	Type        string `xml:"type,attr"`
+	EdSignature string `xml:"sparkle:edSignature,attr"`
+}
+
+type sparkleAppcastItem struct {
+	XMLName              xml.Name         `xml:"item"`
+	Title                string           `xml:"title"`
+	PubDate              string           `xml:"pubDate"`
+	Version              string           `xml:"sparkle:version"`
+	ShortVersionString   string           `xml:"sparkle:shortVersionString,omitempty"`
+	MinimumSystemVersion string           `xml:"sparkle:minimumSystemVersion,omitempty"`
+	Enclosure            sparkleEnclosure `xml:"enclosure"`
+}
+
+// newSparkleAppcastItem signs the update archive and returns its appcast item,
+// the item uses the sparkle: namespace prefix, the appcast declaring it is not generated.
+func newSparkleAppcastItem(archivePath, appPath, downloadURLPrefix string, privateKey ed25519.PrivateKey, now time.Time) (sparkleAppcastItem, error) {
+	content, err := os.ReadFile(archivePath)
+	if err != nil {
+		return sparkleAppcastItem{}, err
+	}
+
+	infoPlist, err := plistutil.NewPlistDataFromFile(filepath.Join(appPath, "Contents", "Info.plist"))
+	if err != nil {
+		return sparkleAppcastItem{}, fmt.Errorf("failed to read the app's Info.plist: %w", err)
+	}
+	build, _ := infoPlist.GetString("CFBundleVersion")
+	version, _ := infoPlist.GetString("CFBundleShortVersionString")
+	minOS, _ := infoPlist.GetString("LSMinimumSystemVersion")
+	if build == "" {
+		return sparkleAppcastItem{}, fmt.Errorf("no CFBundleVersion found in the app's Info.plist")
+	}
+
+	title := version
+	if title == "" {
+		title = build
+	}
+
+	return sparkleAppcastItem{
+		Title:                title,
+		PubDate:              now.Format(time.RFC1123Z),
+		Version:              build,
+		ShortVersionString:   version,
+		MinimumSystemVersion: minOS,
+		Enclosure: sparkleEnclosure{
+			URL:         strings.TrimSuffix(downloadURLPrefix, "/") + "/" + filepath.Base(archivePath),
+			Length:      int64(len(content)),
+			Type:        "application/octet-stream",
+			EdSignature: base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, content)),
+		},
+	}, nil
+}
+
+// exportSparkleAppcastItem writes the appcast item of the update archive next to the archive and exports its path and EdDSA signature.
+func (s XcodebuildArchiver) exportSparkleAppcastItem(archivePath, appPath, downloadURLPrefix string, key stepconf.Secret) {
+	privateKey, err := parseSparklePrivateKey(string(key))
+	if err != nil {
+		s.logger.Warnf("Failed to parse the Sparkle EdDSA private key: %s", err)
+		return
+	}
+
+	item, err := newSparkleAppcastItem(archivePath, appPath, downloadURLPrefix, privateKey, time.Now())
+	if err != nil {
+		s.logger.Warnf("Failed to generate the Sparkle appcast item: %s", err)
+		return
+	}
+	content, err := xml.MarshalIndent(item, "", "  ")
+	if err != nil {
+		s.logger.Warnf("Failed to generate the Sparkle appcast item: %s", err)
+		return
+	}
+
+	itemPath := strings.TrimSuffix(archivePath, filepath.Ext(archivePath)) + "-appcast-item.xml"
+	if err := ExportOutputFileContent(s.cmdFactory, s.outputEnvKeys, string(content), itemPath, bitriseSparkleAppcastItemPthEnvKey); err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", s.outputEnvKey(bitriseSparkleAppcastItemPthEnvKey), err)
+		return
+	}
+	s.logger.Donef("The Sparkle appcast item path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseSparkleAppcastItemPthEnvKey), itemPath)
+
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseSparkleEdSignatureEnvKey, item.Enclosure.EdSignature); err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", s.outputEnvKey(bitriseSparkleEdSignatureEnvKey), err)
+		return
+	}
+	s.logger.Donef("The Sparkle EdDSA signature is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseSparkleEdSignatureEnvKey), item.Enclosure.EdSignature)
+}
@@ -0,0 +1,84 @@
+package step
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sparkleTestInfoPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleShortVersionString</key>
+	<string>1.2.0</string>
+	<key>CFBundleVersion</key>
+	<string>42</string>
+	<key>LSMinimumSystemVersion</key>
+	<string>12.0</string>
+</dict>
+</plist>`
+
+func Test_parseSparklePrivateKey(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	seed[0] = 1
+	want := ed25519.NewKeyFromSeed(seed)
+
+	key, err := parseSparklePrivateKey(base64.StdEncoding.EncodeToString(seed) + "\n")
+	require.NoError(t, err)
+	require.Equal(t, want, key)
+
+	key, err = parseSparklePrivateKey(base64.StdEncoding.EncodeToString(want))
+	require.NoError(t, err)
+	require.Equal(t, want, key)
+
+	_, err = parseSparklePrivateKey("not base64")
+	require.Error(t, err)
+	_, err = parseSparklePrivateKey(base64.StdEncoding.EncodeToString([]byte("short")))
+	require.Error(t, err)
+}
+
+func Test_newSparkleAppcastItem(t *testing.T) {
+	dir := t.TempDir()
+	appPath := filepath.Join(dir, "App.app")
+	createDirs(t, dir, "App.app/Contents")
+	require.NoError(t, os.WriteFile(filepath.Join(appPath, "Contents", "Info.plist"), []byte(sparkleTestInfoPlist), 0644))
+	zipPath := filepath.Join(dir, "App.zip")
+	require.NoError(t, os.WriteFile(zipPath, []byte("zip content"), 0644))
+
+	privateKey := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	item, err := newSparkleAppcastItem(zipPath, appPath, "https://example.com/downloads/", privateKey, now)
+	require.NoError(t, err)
+
+	signature, err := base64.StdEncoding.DecodeString(item.Enclosure.EdSignature)
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(privateKey.Public().(ed25519.PublicKey), []byte("zip content"), signature))
+
+	item.Enclosure.EdSignature = ""
+	require.Equal(t, sparkleAppcastItem{
+		Title:                "1.2.0",
+		PubDate:              "Mon, 06 May 2024 07:08:09 +0000",
+		Version:              "42",
+		ShortVersionString:   "1.2.0",
+		MinimumSystemVersion: "12.0",
+		Enclosure: sparkleEnclosure{
+			URL:    "https://example.com/downloads/App.zip",
+			Length: 11,
+			Type:   "application/octet-stream",
+		},
+	}, item)
+
+	content, err := xml.Marshal(item)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(content), "<item><title>1.2.0</title>"), string(content))
+	require.Contains(t, string(content), "<sparkle:version>42</sparkle:version>")
+}
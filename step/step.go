@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/bitrise-io/go-steputils/v2/stepconf"
-	v1command "github.com/bitrise-io/go-utils/command"
 	v1fileutil "github.com/bitrise-io/go-utils/fileutil"
 	logv1 "github.com/bitrise-io/go-utils/log"
 	v1pathutil "github.com/bitrise-io/go-utils/pathutil"
@@ -30,7 +29,6 @@ import (
 	"github.com/bitrise-io/go-xcode/v2/devportalservice"
 	"github.com/bitrise-io/go-xcode/v2/exportoptionsgenerator"
 	"github.com/bitrise-io/go-xcode/v2/xcconfig"
-	cache "github.com/bitrise-io/go-xcode/v2/xcodecache"
 	"github.com/bitrise-io/go-xcode/v2/xcodecommand"
 	"github.com/bitrise-io/go-xcode/xcarchive"
 	"github.com/bitrise-io/go-xcode/xcodebuild"
@@ -45,6 +43,7 @@ const (
 	bitriseXCArchiveZipPthEnvKey = "BITRISE_XCARCHIVE_ZIP_PATH"
 	bitriseDSYMPthEnvKey         = "BITRISE_DSYM_PATH"
 	bitriseIPAPthEnvKey          = "BITRISE_IPA_PATH"
+	bitriseDSYMZipPthListEnvKey  = "BITRISE_DSYM_ZIP_PATH_LIST"
 
 	// Deployed logs
 	xcodebuildArchiveLogPathEnvKey       = "BITRISE_XCODEBUILD_ARCHIVE_LOG_PATH"
@@ -54,9 +53,19 @@ const (
 	xcodebuildExportArchiveLogFilename   = "xcodebuild-export-archive.log"
 
 	// Env Outputs
-	bitriseAppDirPthEnvKey    = "BITRISE_APP_DIR_PATH"
-	bitriseDSYMDirPthEnvKey   = "BITRISE_DSYM_DIR_PATH"
-	bitriseXCArchivePthEnvKey = "BITRISE_XCARCHIVE_PATH"
+	bitriseAppDirPthEnvKey       = "BITRISE_APP_DIR_PATH"
+	bitriseDSYMDirPthEnvKey      = "BITRISE_DSYM_DIR_PATH"
+	bitriseXCArchivePthEnvKey    = "BITRISE_XCARCHIVE_PATH"
+	bitriseDevelopmentTeamEnvKey = "BITRISE_DEVELOPMENT_TEAM"
+
+	bitriseICloudContainerEnvironmentEnvKey = "BITRISE_ICLOUD_CONTAINER_ENVIRONMENT"
+
+	// Code Signing Style Override
+	codeSigningStyleAutoDetect = "auto-detect"
+
+	// dSYM zip modes
+	dsymZipModeSeparate = "separate"
+	dsymZipModeNone     = "none"
 
 	// Code Signing Authentication Source
 	codeSignSourceOff     = "off"
@@ -71,18 +80,45 @@ const (
 
 // Inputs ...
 type Inputs struct {
-	ProjectPath  string `env:"project_path,file"`
-	Scheme       string `env:"scheme,required"`
+	ProjectPath  string `env:"project_path"`
+	Scheme       string `env:"scheme"`
 	ExportMethod string `env:"distribution_method,opt[app-store,ad-hoc,enterprise,development]"`
 
 	// xcodebuild configuration
-	Configuration      string `env:"configuration"`
-	XcconfigContent    string `env:"xcconfig_content"`
-	PerformCleanAction bool   `env:"perform_clean_action,opt[yes,no]"`
-	XcodebuildOptions  string `env:"xcodebuild_options"`
+	XcodeSelectVersion        string          `env:"xcode_version"`
+	XcodeDeveloperDir         string          `env:"xcode_developer_dir"`
+	XcodeVersionFile          string          `env:"xcode_version_file,opt[off,verify,select]"`
+	Configuration             string          `env:"configuration"`
+	Target                    string          `env:"target"`
+	SchemeConfigurationMatrix string          `env:"scheme_configuration_matrix"`
+	XcconfigContent           string          `env:"xcconfig_content"`
+	PerformCleanAction        bool            `env:"perform_clean_action,opt[yes,no]"`
+	SkipUnavailableActions    bool            `env:"skip_unavailable_actions,opt[yes,no]"`
+	MacCatalystArchive        bool            `env:"mac_catalyst_archive,opt[yes,no]"`
+	XcodebuildOptions         string          `env:"xcodebuild_options"`
+	ForceTeamID               string          `env:"force_team_id"`
+	Toolchain                 string          `env:"toolchain"`
+	ForceARM64Xcodebuild      bool            `env:"force_arm64_xcodebuild,opt[yes,no]"`
+	Archs                     string          `env:"archs"`
+	ExcludedArchs             string          `env:"excluded_archs"`
+	OnlyActiveArch            string          `env:"only_active_arch,opt[default,yes,no]"`
+	Sanitizers                string          `env:"sanitizers"`
+	SkipInstallDependencies   bool            `env:"skip_install_dependencies,opt[yes,no]"`
+	ParallelizeTargets        bool            `env:"parallelize_targets,opt[yes,no]"`
+	Indexing                  string          `env:"indexing,opt[default,disabled,enabled]"`
+	BuildJobs                 int             `env:"build_jobs,range[0..512]"`
+	CodesignKeychainPath      string          `env:"codesign_keychain_path"`
+	CodesignKeychainPassword  stepconf.Secret `env:"codesign_keychain_password"`
+	UnsetEnvVars              string          `env:"unset_env_vars"`
+	ExtraEnvVars              stepconf.Secret `env:"extra_env_vars"`
+	ArchiveEnvironment        string          `env:"archive_environment"`
+	ApplySchemeRunEnvironment bool            `env:"apply_scheme_run_environment,opt[yes,no]"`
 
 	// xcodebuild log formatting
-	LogFormatter string `env:"log_formatter,opt[xcbeautify,xcodebuild,xcpretty]"`
+	LogFormatter      string `env:"log_formatter,opt[xcbeautify,xcodebuild,xcpretty]"`
+	HeartbeatInterval int    `env:"heartbeat_interval,range[0..3600]"`
+	NoOutputTimeout   int    `env:"no_output_timeout,range[0..600]"`
+	DiagnosticsAfter  int    `env:"xcodebuild_diagnostics_after,range[0..600]"`
 
 	// Automatic code signing
 	CodeSigningAuthSource           string          `env:"automatic_code_signing,opt[off,api-key,apple-id]"`
@@ -91,9 +127,13 @@ type Inputs struct {
 	MinDaysProfileValid             int             `env:"min_profile_validity,required"`
 	CertificateURLList              string          `env:"certificate_url_list"`
 	CertificatePassphraseList       stepconf.Secret `env:"passphrase_list"`
+	CertificateBase64List           stepconf.Secret `env:"certificate_base64_list"`
 	KeychainPath                    string          `env:"keychain_path"`
 	KeychainPassword                stepconf.Secret `env:"keychain_password"`
 	FallbackProvisioningProfileURLs string          `env:"fallback_provisioning_profile_url_list"`
+	CodesigningRetryCount           int             `env:"codesigning_retry_count,range[1..10]"`
+	CodesigningRetryBackoff         int             `env:"codesigning_retry_backoff,range[0..600]"`
+	CodesigningRetryableStatuses    string          `env:"codesigning_retryable_statuses"`
 
 	// IPA export configuration
 	ExportDevelopmentTeam         string `env:"export_development_team"`
@@ -101,36 +141,131 @@ type Inputs struct {
 	UploadBitcode                 bool   `env:"upload_bitcode,opt[yes,no]"`
 	ICloudContainerEnvironment    string `env:"icloud_container_environment"`
 	TestFlightInternalTestingOnly bool   `env:"testflight_internal_testing_only,opt[yes,no]"`
+	ManageVersionAndBuildNumber   bool   `env:"manage_version_and_build_number,opt[yes,no]"`
+	UploadSymbols                 bool   `env:"upload_symbols,opt[yes,no]"`
+	DistributionBundleIdentifier  string `env:"distribution_bundle_identifier"`
+	ExportTeamOverrides           string `env:"export_team_overrides"`
+	InfoPlistOverrides            string `env:"info_plist_overrides"`
+	IconBadge                     string `env:"icon_badge"`
+	WildcardProfiles              string `env:"wildcard_profiles,opt[allow,prefer-explicit,deny]"`
+	CodeSigningStyleOverride      string `env:"code_signing_style_override,opt[auto-detect,automatic,manual]"`
+	SigningCertificate            string `env:"export_signing_certificate"`
+	InstallerSigningCertificate   string `env:"export_installer_signing_certificate"`
 	ExportOptionsPlistContent     string `env:"export_options_plist_content"`
+	MacCatalystExportOptions      string `env:"mac_catalyst_export_options_plist_content"`
+	MacCatalystNotarize           bool   `env:"mac_catalyst_notarize,opt[yes,no]"`
+	NotarizationTimeout           int    `env:"notarization_timeout,range[1..1440]"`
+	ExpectedDeviceUDIDs           string `env:"expected_device_udids"`
+	PrintProvisionedDevices       bool   `env:"print_provisioned_devices,opt[yes,no]"`
+	VerifyIPASignature            bool   `env:"verify_ipa_signature,opt[yes,no]"`
+	RequiredLocalizations         string `env:"required_localizations"`
+	FailOnMissingLocalizations    bool   `env:"fail_on_missing_localizations,opt[yes,no]"`
+	VerifyNoTestBundles           bool   `env:"verify_no_test_bundles,opt[yes,no]"`
+	IPAContentPolicy              string `env:"ipa_content_policy,opt[off,warn,fail]"`
+	IPAContentDenyPatterns        string `env:"ipa_content_deny_patterns"`
 
 	// Step Output Export configuration
-	OutputDir      string `env:"output_dir,required"`
-	ExportAllDsyms bool   `env:"export_all_dsyms,opt[yes,no]"`
-	ArtifactName   string `env:"artifact_name"`
+	OutputDir          string `env:"output_dir,required"`
+	ArchivePath        string `env:"archive_path"`
+	ExistingArchive    string `env:"existing_archive,opt[replace,fail]"`
+	OutputLayout       string `env:"output_layout,opt[flat,by_type]"`
+	OutputEnvPrefix    string `env:"output_env_prefix,required"`
+	OutputSuffix       string `env:"output_suffix"`
+	ExportAllDsyms     bool   `env:"export_all_dsyms,opt[yes,no]"`
+	ExportXCArchiveZip bool   `env:"export_xcarchive_zip,opt[yes,no]"`
+	ExportAppDir       bool   `env:"export_app_dir,opt[yes,no]"`
+	ExportDSYMs        bool   `env:"export_dsyms,opt[yes,no]"`
+	ExportRawLogAlways bool   `env:"export_raw_log_always,opt[yes,no]"`
+	StructuredRawLog   bool   `env:"structured_raw_log,opt[yes,no]"`
+	DSYMZipMode        string `env:"dsym_zip_mode,opt[combined,separate,none]"`
+	CompressionLevel   int    `env:"compression_level,range[0..9]"`
+	Compression        string `env:"artifact_compression,opt[zip,zstd,none]"`
+	ArtifactName       string `env:"artifact_name"`
+	ExportActivityLog  bool   `env:"export_xcactivitylog,opt[yes,no]"`
+	ActivityLogJSON    bool   `env:"xcactivitylog_json,opt[yes,no]"`
+	BuildReport        bool   `env:"build_report,opt[yes,no]"`
+	BuildIssuesJUnit   bool   `env:"export_build_issues_junit,opt[yes,no]"`
+	PhaseTimings       bool   `env:"export_phase_timings,opt[yes,no]"`
+	BuildInsights      bool   `env:"enable_build_insights,opt[yes,no]"`
+	BuildSummary       bool   `env:"export_build_summary,opt[yes,no]"`
+	XcodeCloudEnvVars  bool   `env:"export_xcode_cloud_env_vars,opt[yes,no]"`
+	AdditionalLogPaths string `env:"additional_log_paths"`
+	BuildProductPaths  string `env:"build_product_paths"`
+	ExportMacOSZip     bool   `env:"export_macos_zip,opt[yes,no]"`
+	ExportEntitlements bool   `env:"export_entitlements,opt[yes,no]"`
+
+	MetricsExportURL     string          `env:"metrics_export_url"`
+	MetricsExportFormat  string          `env:"metrics_export_format,opt[pushgateway,otlp]"`
+	MetricsExportHeaders stepconf.Secret `env:"metrics_export_headers"`
+
+	SparklePrivateKey        stepconf.Secret `env:"sparkle_eddsa_private_key"`
+	SparkleDownloadURLPrefix string          `env:"sparkle_download_url_prefix"`
+
+	ResignSigningIdentity string `env:"resign_signing_identity"`
+	ResignProfilePaths    string `env:"resign_provisioning_profile_paths"`
+
+	ExportReleaseMetadata  bool            `env:"export_release_metadata,opt[yes,no]"`
+	ReleaseGitTag          string          `env:"release_git_tag"`
+	ReleaseGitTagPushToken stepconf.Secret `env:"release_git_tag_push_token"`
+
+	ExportDeployMetadata bool `env:"export_deploy_metadata,opt[yes,no]"`
 
 	// Caching
-	CacheLevel string `env:"cache_level,opt[none,swift_packages]"`
+	CacheLevel            string `env:"cache_level,opt[none,swift_packages]"`
+	ArchiveCacheDir       string `env:"archive_cache_dir"`
+	PrefetchSwiftPackages bool   `env:"prefetch_swift_packages,opt[yes,no]"`
+	SwiftPackagesPath     string `env:"swift_packages_path"`
+	CacheSpaceshipBundle  bool   `env:"cache_spaceship_bundle,opt[yes,no]"`
+
+	CompilationCaching            bool   `env:"compilation_caching,opt[yes,no]"`
+	CompilationCacheRemoteService string `env:"compilation_cache_remote_service"`
 
 	// App Store Connect connection override
 	APIKeyPath              stepconf.Secret `env:"api_key_path"`
+	APIKeyBase64            stepconf.Secret `env:"api_key_base64"`
 	APIKeyID                string          `env:"api_key_id"`
 	APIKeyIssuerID          string          `env:"api_key_issuer_id"`
 	APIKeyEnterpriseAccount bool            `env:"api_key_enterprise_account,opt[yes,no]"`
 
 	// Debugging
-	VerboseLog bool `env:"verbose_log,opt[yes,no]"`
+	VerboseLog      bool `env:"verbose_log,opt[yes,no]"`
+	PreflightReport bool `env:"preflight_report,opt[yes,no]"`
+	KeepTempDirs    bool `env:"debug_keep_temp_dirs,opt[yes,no]"`
+	DryRun          bool `env:"dry_run,opt[yes,no]"`
+
+	RedactIdentifiers bool `env:"redact_identifiers,opt[yes,no]"`
 
 	// Hidden inputs
 	BuildURL      string          `env:"BITRISE_BUILD_URL"`
 	BuildAPIToken stepconf.Secret `env:"BITRISE_BUILD_API_TOKEN"`
+	TestResultDir string          `env:"BITRISE_TEST_RESULT_DIR"`
+	AppSlug       string          `env:"BITRISE_APP_SLUG"`
+	// InvocationCount is the number of earlier invocations of the Step in the Workflow
+	InvocationCount int `env:"XCODE_ARCHIVE_INVOCATION_COUNT"`
 }
 
 // Config ...
 type Config struct {
 	Inputs
 	XcodeMajorVersion           int
+	XcodeVersion                string
+	XcodeIsBeta                 bool
 	XcodebuildAdditionalOptions []string
 	CodesignManager             *codesign.Manager // nil if automatic code signing is "off"
+	CodesignRetryPolicy         CodesignRetryPolicy
+	MatrixEntries               []MatrixEntry     // empty if no scheme/configuration matrix is provided
+	XcodebuildDiagnosticsDir    string            // empty if no xcodebuild diagnostics are captured
+	OutputEnvKeySuffix          string            // the resolved output_suffix input
+	BuildParallelism            string            // description of the build parallelism, for the build report
+	XcodebuildUnsetEnvs         []string          // environment variables removed from the xcodebuild commands' environment
+	XcodebuildExtraEnvs         []string          // KEY=VALUE environment variables added to the xcodebuild commands' environment
+	InlineSecretsDir            string            // empty if no inline certificate or API key content is provided
+	BundleIDTeamOverrides       map[string]string // bundle ID to export team ID, empty if no export team override is provided
+	MetricsHeaders              map[string]string // HTTP headers of the metrics export requests
+
+	AppInfoPlistOverrides []InfoPlistOverride // Info.plist keys set in the archived app, empty if no override is provided
+
+	IdentifierRedactor *IdentifierRedactor // nil if the identifiers are not redacted
 }
 
 type XcodebuildArchiveConfigParser struct {
@@ -139,11 +274,13 @@ type XcodebuildArchiveConfigParser struct {
 	fileManager          fileutil.FileManager
 	cmdFactory           command.Factory
 	logger               log.Logger
+	redactor             *IdentifierRedactor // the redactor of the Step run, created by processInputs if not set
 }
 
 // XcodebuildArchiver ...
 type XcodebuildArchiver struct {
 	xcodeCommandRunner xcodecommand.Runner
+	xcodebuildRunner   XcodebuildRunner // nil if the archive and export commands run locally
 	logFormatter       string
 	pathProvider       pathutil.PathProvider
 	pathChecker        pathutil.PathChecker
@@ -151,6 +288,8 @@ type XcodebuildArchiver struct {
 	fileManager        fileutil.FileManager
 	logger             log.Logger
 	cmdFactory         command.Factory
+	outputEnvKeys      OutputEnvKeys
+	redactor           *IdentifierRedactor // nil if the identifiers are not redacted
 }
 
 func NewXcodeArchiveConfigParser(stepInputParser stepconf.InputParser, xcodeVersionProvider XcodeVersionProvider, fileManager fileutil.FileManager, cmdFactory command.Factory, logger log.Logger) XcodebuildArchiveConfigParser {
@@ -177,17 +316,41 @@ func NewXcodebuildArchiver(xcodecommandRunner xcodecommand.Runner, logFormatter
 	}
 }
 
-// ProcessInputs ...
+// ProcessInputs parses the Step inputs from the environment, see ProcessConfig.
 func (s XcodebuildArchiveConfigParser) ProcessInputs() (Config, error) {
 	var inputs Inputs
 	if err := s.stepInputParser.Parse(&inputs); err != nil {
 		return Config{}, fmt.Errorf("issue with input: %s", err)
 	}
+	return s.processInputs(inputs)
+}
 
-	stepconf.Print(inputs)
+// ProcessConfig validates the inputs and resolves the Step configuration (Xcode version, code signing, xcodebuild options),
+// so the Step can be driven by an Inputs struct instead of the environment, for example when used as a library.
+// The inputs should be based on DefaultInputs, as the zero value of the unset inputs is not their step.yml default.
+func (s XcodebuildArchiveConfigParser) ProcessConfig(inputs Inputs) (Config, error) {
+	if err := validateInputs(inputs); err != nil {
+		return Config{}, fmt.Errorf("issue with input: %s", err)
+	}
+	return s.processInputs(inputs)
+}
+
+func (s XcodebuildArchiveConfigParser) processInputs(inputs Inputs) (Config, error) {
+	var redactor *IdentifierRedactor
+	if inputs.RedactIdentifiers {
+		redactor = s.redactor
+		if redactor == nil {
+			redactor = NewIdentifierRedactor()
+		}
+		redactor.enable()
+		redactor.addInputIdentifiers(inputs)
+		stepconf.Print(redactor.redactedInputs(inputs))
+	} else {
+		stepconf.Print(inputs)
+	}
 	s.logger.Println()
 
-	config := Config{Inputs: inputs}
+	config := Config{Inputs: inputs, IdentifierRedactor: redactor}
 
 	s.logger.EnableDebugLog(config.VerboseLog)
 	if config.VerboseLog {
@@ -200,6 +363,44 @@ func (s XcodebuildArchiveConfigParser) ProcessInputs() (Config, error) {
 		return Config{}, fmt.Errorf("provided XcodebuildOptions (%s) are not valid CLI parameters: %s", inputs.XcodebuildOptions, err)
 	}
 
+	if err := validateOutputEnvKeyPrefix(config.OutputEnvPrefix); err != nil {
+		return Config{}, fmt.Errorf("issue with input OutputEnvPrefix: %w", err)
+	}
+	if config.SparklePrivateKey != "" {
+		if !config.ExportMacOSZip {
+			return Config{}, fmt.Errorf("the Sparkle appcast item is generated for the macOS app zip, please set Export a notarization ready zip of the macOS app (`export_macos_zip`) input too")
+		}
+		if config.SparkleDownloadURLPrefix == "" {
+			return Config{}, fmt.Errorf("issue with input SparkleDownloadURLPrefix: required when Sparkle EdDSA private key (`sparkle_eddsa_private_key`) is set")
+		}
+		if _, err := parseSparklePrivateKey(string(config.SparklePrivateKey)); err != nil {
+			return Config{}, fmt.Errorf("issue with input SparklePrivateKey: %w", err)
+		}
+	}
+	if config.MacCatalystNotarize {
+		if !config.MacCatalystArchive || config.MacCatalystExportOptions == "" {
+			return Config{}, fmt.Errorf("notarizing the Mac Catalyst app requires Mac Catalyst archive (`mac_catalyst_archive`) and Mac Catalyst export options plist content (`mac_catalyst_export_options_plist_content`) inputs")
+		}
+		if err := validateNotarizationExportOptions(config.MacCatalystExportOptions); err != nil {
+			return Config{}, fmt.Errorf("issue with input MacCatalystExportOptions: %w", err)
+		}
+	}
+	if (config.ResignSigningIdentity == "") != (config.ResignProfilePaths == "") {
+		return Config{}, fmt.Errorf("both Re-sign signing identity (`resign_signing_identity`) and Re-sign provisioning profile paths (`resign_provisioning_profile_paths`) inputs are required to re-sign the ipa")
+	}
+	config.OutputEnvKeySuffix, err = resolveOutputEnvKeySuffix(config.OutputSuffix, config.InvocationCount)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input OutputSuffix: %w", err)
+	}
+	if err := validateIPAContentDenyPatterns(ParsePathPatterns(config.IPAContentDenyPatterns)); err != nil {
+		return Config{}, fmt.Errorf("issue with input IPAContentDenyPatterns: %w", err)
+	}
+	if config.Compression == artifactCompressionZstd {
+		if err := s.checkZstdInstalled(); err != nil {
+			return Config{}, fmt.Errorf("issue with input Compression: %w", err)
+		}
+	}
+
 	if strings.TrimSpace(config.XcconfigContent) == "" {
 		config.XcconfigContent = ""
 	}
@@ -207,17 +408,214 @@ func (s XcodebuildArchiveConfigParser) ProcessInputs() (Config, error) {
 		config.XcconfigContent != "" {
 		return Config{}, fmt.Errorf("`-xcconfig` option found in XcodebuildOptions (`xcodebuild_options`), please clear Build settings (xcconfig) (`xcconfig_content`) input as only one can be set")
 	}
+	archs, err := parseArchList(config.Archs)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input Archs: %s", err)
+	}
+	excludedArchs, err := parseArchList(config.ExcludedArchs)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input ExcludedArchs: %s", err)
+	}
+	if settings := (archSettings{Archs: archs, ExcludedArchs: excludedArchs, OnlyActiveArch: config.OnlyActiveArch}); settings.isSet() {
+		if sliceutil.IsStringInSlice("-xcconfig", config.XcodebuildAdditionalOptions) {
+			return Config{}, fmt.Errorf("`-xcconfig` option found in XcodebuildOptions (`xcodebuild_options`), please clear the Archs (`archs`), Excluded archs (`excluded_archs`) and Only active arch (`only_active_arch`) inputs as they are applied via xcconfig")
+		}
+		if config.ExportMethod == "app-store" {
+			if err := validateAppStoreArchs(settings, s.logger); err != nil {
+				return Config{}, fmt.Errorf("issue with input Archs: %s", err)
+			}
+		}
+		if config.XcconfigContent, err = xcconfigWithArchSettings(config.XcconfigContent, settings); err != nil {
+			return Config{}, fmt.Errorf("failed to apply the architecture build settings: %s", err)
+		}
+	}
+	if sliceutil.IsStringInSlice("-toolchain", config.XcodebuildAdditionalOptions) &&
+		config.Toolchain != "" {
+		return Config{}, fmt.Errorf("`-toolchain` option found in XcodebuildOptions (`xcodebuild_options`), please clear Toolchain (`toolchain`) input as only one can be set")
+	}
+	sanitizers, err := parseSanitizers(config.Sanitizers)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input Sanitizers: %w", err)
+	}
+	if len(sanitizers) > 0 {
+		options, err := sanitizerXcodebuildOptions(sanitizers, config.XcodebuildAdditionalOptions)
+		if err != nil {
+			return Config{}, err
+		}
+		config.XcodebuildAdditionalOptions = append(config.XcodebuildAdditionalOptions, options...)
+
+		s.logger.Println()
+		s.logger.Warnf("Sanitizers (%s) are enabled: the archive is for diagnostic purposes (for example internal QA builds) and is not distributable on the App Store.", strings.Join(sanitizers, ", "))
+		if config.ExportMethod == "app-store" {
+			s.logger.Warnf("Distribution method is app-store, App Store Connect rejects builds with sanitizers enabled.")
+		}
+	}
+	if config.SkipInstallDependencies {
+		if setsSkipInstall(config.XcodebuildAdditionalOptions) {
+			return Config{}, fmt.Errorf("`%s` build setting found in XcodebuildOptions (`xcodebuild_options`), please disable Skip install dependencies (`skip_install_dependencies`) input as only one can be set", skipInstallBuildSetting)
+		}
+		config.XcodebuildAdditionalOptions = append(config.XcodebuildAdditionalOptions, skipInstallDependenciesBuildSettings()...)
+	}
+	if config.SwiftPackagesPath != "" {
+		options, err := swiftPackagesPathOptions(config.SwiftPackagesPath, config.XcodebuildAdditionalOptions)
+		if err != nil {
+			return Config{}, err
+		}
+		config.XcodebuildAdditionalOptions = append(config.XcodebuildAdditionalOptions, options...)
+	}
+	indexingOptions, err := indexingXcodebuildOptions(config.Indexing, config.XcodebuildAdditionalOptions)
+	if err != nil {
+		return Config{}, err
+	}
+	config.XcodebuildAdditionalOptions = append(config.XcodebuildAdditionalOptions, indexingOptions...)
+
+	parallelismOptions, err := buildParallelismOptions(config.ParallelizeTargets, config.BuildJobs, config.XcodebuildAdditionalOptions)
+	if err != nil {
+		return Config{}, err
+	}
+	config.XcodebuildAdditionalOptions = append(config.XcodebuildAdditionalOptions, parallelismOptions...)
+	config.BuildParallelism = defaultBuildParallelismDescription(config.XcodebuildAdditionalOptions)
+	s.logger.Printf("Build parallelism: %s", config.BuildParallelism)
+
+	if config.CodesignKeychainPath != "" && setsOtherCodeSignFlags(config.XcodebuildAdditionalOptions, config.XcconfigContent) {
+		return Config{}, fmt.Errorf("`%s` build setting found in XcodebuildOptions (`xcodebuild_options`) or Build settings (xcconfig) (`xcconfig_content`), please clear Codesign keychain path (`codesign_keychain_path`) input as only one can be set", otherCodeSignFlagsSetting)
+	}
+
+	config.XcodebuildUnsetEnvs, err = parseUnsetEnvVars(config.UnsetEnvVars)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input UnsetEnvVars: %s", err)
+	}
+	config.XcodebuildExtraEnvs, err = parseExtraEnvVars(string(config.ExtraEnvVars))
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input ExtraEnvVars: %s", err)
+	}
+	archiveEnvironment, err := parseArchiveEnvironment(config.ArchiveEnvironment)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input ArchiveEnvironment: %w", err)
+	}
+	archiveEnvironmentOpts, err := archiveEnvironmentOptions(archiveEnvironment, config.XcodebuildAdditionalOptions)
+	if err != nil {
+		return Config{}, err
+	}
+	config.XcodebuildAdditionalOptions = append(config.XcodebuildAdditionalOptions, archiveEnvironmentOpts...)
+
+	config.BundleIDTeamOverrides, err = parseBundleIDTeamOverrides(config.ExportTeamOverrides)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input ExportTeamOverrides: %s", err)
+	}
+	if len(config.BundleIDTeamOverrides) > 0 && config.CodeSigningStyleOverride == string(exportoptions.SigningStyleAutomatic) {
+		return Config{}, fmt.Errorf("export team overrides (`export_team_overrides`) require manual code signing, but Code signing style override (`code_signing_style_override`) is set to automatic")
+	}
+
+	config.AppInfoPlistOverrides, err = parseInfoPlistOverrides(config.InfoPlistOverrides)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input InfoPlistOverrides: %w", err)
+	}
+	config.IconBadge, err = parseIconBadge(config.IconBadge)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input IconBadge: %w", err)
+	}
+
+	if config.MetricsExportURL != "" {
+		if err := validateMetricsExportURL(config.MetricsExportURL); err != nil {
+			return Config{}, fmt.Errorf("issue with input MetricsExportURL: %w", err)
+		}
+	}
+	config.MetricsHeaders, err = parseMetricsExportHeaders(string(config.MetricsExportHeaders))
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input MetricsExportHeaders: %w", err)
+	}
 
+	config.MatrixEntries, err = parseSchemeConfigurationMatrix(config.SchemeConfigurationMatrix)
+	if err != nil {
+		return Config{}, fmt.Errorf("issue with input SchemeConfigurationMatrix: %s", err)
+	}
+	if config.Scheme == "" && len(config.MatrixEntries) == 0 {
+		return Config{}, fmt.Errorf("issue with input Scheme: required variable is not present, set it or the Scheme and Configuration matrix (`scheme_configuration_matrix`)")
+	}
+
+	if config.ArchivePath != "" {
+		if len(config.MatrixEntries) > 0 {
+			return Config{}, fmt.Errorf("Archive path (`archive_path`) can't be set together with Scheme and Configuration matrix (`scheme_configuration_matrix`), as every matrix entry creates an archive")
+		}
+		if config.ArchivePath, err = resolveArchivePath(config.ArchivePath); err != nil {
+			return Config{}, fmt.Errorf("issue with input ArchivePath: %s", err)
+		}
+	}
+	if len(config.MatrixEntries) > 0 {
+		if unsupported := matrixUnsupportedInputs(config.Inputs); len(unsupported) > 0 {
+			return Config{}, fmt.Errorf("%s can't be set together with Scheme and Configuration matrix (`scheme_configuration_matrix`), as they describe a single archive", strings.Join(unsupported, ", "))
+		}
+	}
+
+	var customExportOptions map[string]interface{}
 	if config.ExportOptionsPlistContent != "" {
-		var options map[string]interface{}
-		if _, err := plist.Unmarshal([]byte(config.ExportOptionsPlistContent), &options); err != nil {
+		if _, err := plist.Unmarshal([]byte(config.ExportOptionsPlistContent), &customExportOptions); err != nil {
 			return Config{}, fmt.Errorf("issue with input ExportOptionsPlistContent: %s", err)
 		}
 	}
 
+	if config.ProjectPath == "" {
+		workDir, err := os.Getwd()
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to get the working directory: %w", err)
+		}
+		candidates, err := projectCandidates(workDir)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to search for projects: %w", err)
+		}
+		if config.ProjectPath, err = detectProject(workDir, candidates); err != nil {
+			return Config{}, fmt.Errorf("issue with input ProjectPath: %w", err)
+		}
+		s.logger.Printf("ProjectPath is not set, detected project: %s", config.ProjectPath)
+	} else if exist, err := v1pathutil.IsPathExists(config.ProjectPath); err != nil {
+		return Config{}, fmt.Errorf("failed to check if ProjectPath exists: %w", err)
+	} else if !exist {
+		return Config{}, fmt.Errorf("issue with input ProjectPath: %s does not exist", config.ProjectPath)
+	}
+
 	if filepath.Ext(config.ProjectPath) != ".xcodeproj" && filepath.Ext(config.ProjectPath) != ".xcworkspace" {
 		return Config{}, fmt.Errorf("issue with input ProjectPath: should be and .xcodeproj or .xcworkspace path")
 	}
+	if filepath.Ext(config.ProjectPath) == ".xcworkspace" {
+		workDir, err := os.Getwd()
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to get the working directory: %w", err)
+		}
+		if err := validateWorkspace(config.ProjectPath, workDir); err != nil {
+			return Config{}, fmt.Errorf("issue with input ProjectPath: %w", err)
+		}
+	}
+
+	schemes := []string{config.Scheme}
+	for _, entry := range config.MatrixEntries {
+		schemes = append(schemes, entry.Scheme)
+	}
+	for _, scheme := range schemes {
+		if scheme == "" {
+			continue
+		}
+		if err := validateArchivableScheme(config.ProjectPath, scheme); err != nil {
+			return Config{}, fmt.Errorf("issue with input Scheme: %w", err)
+		}
+	}
+
+	requiredXcodeVersion := ""
+	if config.XcodeVersionFile != "" && config.XcodeVersionFile != xcodeVersionFileOff {
+		if requiredXcodeVersion, err = s.requiredXcodeVersion(config.ProjectPath); err != nil {
+			return Config{}, fmt.Errorf("issue with input XcodeVersionFile: %w", err)
+		}
+	}
+
+	if err := s.selectConfiguredXcode(xcodeApplicationsDir, config, requiredXcodeVersion); err != nil {
+		return Config{}, err
+	}
+
+	if developerDir, err := activeDeveloperDir(s.cmdFactory); err != nil {
+		s.logger.Warnf("Failed to determine the used Xcode: %s", err)
+	} else {
+		s.logger.Printf("Using Xcode at: %s", xcodeAppPath(developerDir))
+	}
 
 	s.logger.Infof("Xcode version:")
 
@@ -233,6 +631,39 @@ func (s XcodebuildArchiveConfigParser) ProcessInputs() (Config, error) {
 		return Config{}, fmt.Errorf("invalid xcode major version (%d), should not be less then min supported: %d", xcodeMajorVersion, minSupportedXcodeMajorVersion)
 	}
 	config.XcodeMajorVersion = int(xcodeMajorVersion)
+	if requiredXcodeVersion != "" {
+		if err := checkXcodeVersionRequirement(xcodebuildVersion.Version, requiredXcodeVersion); err != nil {
+			if config.XcodeVersionFile == xcodeVersionFileVerify {
+				return Config{}, fmt.Errorf("issue with input XcodeVersionFile: %w", err)
+			}
+			s.logger.Warnf("%s", err)
+		}
+	}
+	config.XcodeVersion = fmt.Sprintf("%s (%s)", xcodebuildVersion.Version, xcodebuildVersion.BuildVersion)
+	config.XcodeIsBeta = isBetaXcode(xcodebuildVersion)
+	if config.XcodeIsBeta {
+		s.logger.Warnf("Xcode %d is a beta version (%s), the Step's features are not guaranteed to work with it.", xcodeMajorVersion, xcodebuildVersion.BuildVersion)
+	}
+
+	if config.NoOutputTimeout > 0 || config.DiagnosticsAfter > 0 {
+		if config.XcodebuildDiagnosticsDir, err = os.MkdirTemp("", "xcodebuild-diagnostics"); err != nil {
+			return Config{}, fmt.Errorf("failed to create the xcodebuild diagnostics dir: %w", err)
+		}
+	}
+
+	architecture := s.detectHostArchitecture()
+	warnArchitectureMismatch(architecture, config.ForceARM64Xcodebuild, s.logger)
+	if config.ForceARM64Xcodebuild {
+		if err := validateForceARM64(architecture); err != nil {
+			return Config{}, fmt.Errorf("issue with input ForceARM64Xcodebuild: %s", err)
+		}
+	}
+
+	if config.PreflightReport {
+		report := s.collectPreflightReport(filepath.Dir(config.ProjectPath))
+		report.Architecture = architecture
+		printPreflightReport(report, time.Now(), s.logger)
+	}
 
 	// Validation ExportOptionsPlistContent
 	exportOptionsPlistContent := strings.TrimSpace(config.ExportOptionsPlistContent)
@@ -250,6 +681,15 @@ func (s XcodebuildArchiveConfigParser) ProcessInputs() (Config, error) {
 		s.logger.Printf("- CompileBitcode: %s", config.CompileBitcode)
 		s.logger.Printf("- ExportDevelopmentTeam: %s", config.ExportDevelopmentTeam)
 		s.logger.Printf("- ICloudContainerEnvironment: %s", config.ICloudContainerEnvironment)
+		s.logger.Printf("- TestFlightInternalTestingOnly: %t", config.TestFlightInternalTestingOnly)
+		s.logger.Printf("- ManageVersionAndBuildNumber: %t", config.ManageVersionAndBuildNumber)
+		s.logger.Printf("- UploadSymbols: %t", config.UploadSymbols)
+		s.logger.Printf("- DistributionBundleIdentifier: %s", config.DistributionBundleIdentifier)
+		s.logger.Printf("- ExportTeamOverrides: %s", strings.ReplaceAll(strings.TrimSpace(config.ExportTeamOverrides), "\n", ", "))
+		s.logger.Printf("- WildcardProfiles: %s", config.WildcardProfiles)
+		s.logger.Printf("- CodeSigningStyleOverride: %s", config.CodeSigningStyleOverride)
+		s.logger.Printf("- SigningCertificate: %s", config.SigningCertificate)
+		s.logger.Printf("- InstallerSigningCertificate: %s", config.InstallerSigningCertificate)
 		s.logger.Println()
 	}
 	config.ExportOptionsPlistContent = exportOptionsPlistContent
@@ -260,6 +700,34 @@ func (s XcodebuildArchiveConfigParser) ProcessInputs() (Config, error) {
 		s.logger.Println()
 	}
 
+	if config.ExportMethod != "app-store" && !config.UploadSymbols {
+		s.logger.Println()
+		s.logger.Warnf("UploadSymbols is valid only for Distribution Method app-store.")
+		s.logger.Println()
+	}
+
+	if config.ExportMethod == "app-store" && config.DistributionBundleIdentifier != "" {
+		s.logger.Println()
+		s.logger.Warnf("DistributionBundleIdentifier is not valid for Distribution Method app-store, it will be ignored.")
+		s.logger.Println()
+	}
+
+	if config.ExportMethod == "app-store" && config.IconBadge != "" {
+		s.logger.Println()
+		s.logger.Warnf("IconBadge is not valid for Distribution Method app-store, it will be ignored.")
+		s.logger.Println()
+		config.IconBadge = ""
+	}
+
+	printConfigurationAdvice(configurationAdvice(config), config.XcodeMajorVersion, s.logger)
+
+	if !compilationCachingSupport.isSupported(config.XcodeMajorVersion) {
+		config.CompilationCaching = false
+	}
+	if !config.CompilationCaching && config.CompilationCacheRemoteService != "" {
+		s.logger.Warnf("CompilationCacheRemoteService is set, but CompilationCaching is disabled, it will be ignored.")
+	}
+
 	absProjectPath, err := filepath.Abs(config.ProjectPath)
 	if err != nil {
 		return Config{}, fmt.Errorf("failed to get absolute project path, error: %s", err)
@@ -282,11 +750,36 @@ func (s XcodebuildArchiveConfigParser) ProcessInputs() (Config, error) {
 	}
 
 	if config.CodeSigningAuthSource != codeSignSourceOff {
-		codesignManager, err := s.createCodesignManager(config)
+		retryableStatuses, err := parseRetryableStatuses(config.CodesigningRetryableStatuses)
 		if err != nil {
-			return Config{}, fmt.Errorf("failed to prepare automatic code signing: %w", err)
+			return Config{}, fmt.Errorf("issue with input CodesigningRetryableStatuses: %s", err)
+		}
+		config.CodesignRetryPolicy = CodesignRetryPolicy{
+			Attempts:          config.CodesigningRetryCount,
+			Backoff:           time.Duration(config.CodesigningRetryBackoff) * time.Second,
+			RetryableStatuses: retryableStatuses,
+		}
+
+		if config.CodeSigningAuthSource == codeSignSourceAppleID && config.CacheSpaceshipBundle {
+			if err := prepareSpaceshipBundleCache(s.cmdFactory, s.logger); err != nil {
+				s.logger.Warnf("Failed to set up Developer Portal client gem caching: %s", err)
+			}
+		}
+
+		if err := writeInlineSecrets(&config); err != nil {
+			_ = CleanupInlineSecrets(config)
+			return Config{}, err
+		}
+
+		// the matrix entries create their own code signing manager for their scheme, see ConfigForMatrixEntry
+		if len(config.MatrixEntries) == 0 {
+			codesignManager, err := s.createCodesignManager(config)
+			if err != nil {
+				_ = CleanupInlineSecrets(config)
+				return Config{}, fmt.Errorf("failed to prepare automatic code signing: %w", err)
+			}
+			config.CodesignManager = &codesignManager
 		}
-		config.CodesignManager = &codesignManager
 	}
 
 	return config, nil
@@ -316,17 +809,52 @@ type RunOpts struct {
 	ProjectPath       string
 	Scheme            string
 	Configuration     string
+	Target            string
 	XcodeMajorVersion int
+	XcodeVersion      string
 	ArtifactName      string
+	Toolchain         string
 
 	// Code signing, nil if automatic code signing is "off"
-	CodesignManager *codesign.Manager
+	CodesignManager     *codesign.Manager
+	CodesignRetryPolicy CodesignRetryPolicy
+
+	// Code signing inputs, part of the archive cache key
+	CodeSigningAuthSource           string
+	CertificateURLList              string
+	FallbackProvisioningProfileURLs string
 
 	// Archive
 	PerformCleanAction          bool
+	SkipUnavailableActions      bool
 	XcconfigContent             string
 	XcodebuildAdditionalOptions []string
+	ForceTeamID                 string
+	CodesignKeychainPath        string
+	CodesignKeychainPassword    stepconf.Secret
+	MacCatalystArchive          bool
+	ExportActivityLog           bool
+	BuildReport                 bool
+	ExportBuildProducts         bool
 	CacheLevel                  string
+	PrefetchSwiftPackages       bool
+	ArchiveCacheDir             string
+	// OutputDir is excluded from the archive cache key's project sources
+	OutputDir string
+	// ArchivePath is the path of the archive, a temp dir is used if empty
+	ArchivePath     string
+	ExistingArchive string
+
+	// KeychainPath and KeychainPassword are the keychain where the code signing certificates are installed,
+	// unlocked if codesign fails because the keychain is locked and no codesign keychain is set
+	KeychainPath     string
+	KeychainPassword stepconf.Secret
+
+	CompilationCaching            bool
+	CompilationCacheRemoteService string
+
+	// ApplySchemeRunEnvironment passes the scheme's Run action environment variables to the archive action as build settings
+	ApplySchemeRunEnvironment bool
 
 	// IPA Export
 	CustomExportOptionsPlistContent string
@@ -336,6 +864,38 @@ type RunOpts struct {
 	ExportDevelopmentTeam           string
 	UploadBitcode                   bool
 	CompileBitcode                  bool
+	ManageVersionAndBuildNumber     bool
+	UploadSymbols                   bool
+	DistributionBundleIdentifier    string
+	ExportTeamOverrides             map[string]string
+	WildcardProfiles                string
+	CodeSigningStyleOverride        string
+	SigningCertificate              string
+	InstallerSigningCertificate     string
+	MacCatalystExportOptions        string
+	MacCatalystNotarize             bool
+	NotarizationTimeout             time.Duration
+	VerifyIPASignature              bool
+	RequiredLocalizations           []string
+	FailOnMissingLocalizations      bool
+	VerifyNoTestBundles             bool
+	IPAContentPolicy                string
+	IPAContentDenyPatterns          []string
+	InfoPlistOverrides              []InfoPlistOverride
+	IconBadge                       string
+
+	// Phases records the timing of the Run phases, optional
+	Phases *PhaseTracker
+	// Cancellation stops the Run before starting a new phase if the Step was cancelled, optional
+	Cancellation *CancellationHandler
+	// Insights records the retries and cache lookups of the Run, optional
+	Insights *InsightsRecorder
+	// StructuredLog records the stdout and stderr lines of the archive and export xcodebuild commands, optional
+	StructuredLog *StructuredLogRecorder
+	// KeepTempDirs keeps the temp dir of a failed archive or export for debugging
+	KeepTempDirs bool
+	// DryRun resolves the project and prints the archive and export commands and the export options without executing them
+	DryRun bool
 }
 
 // RunResult ...
@@ -343,12 +903,24 @@ type RunResult struct {
 	Archive      *xcarchive.IosArchive
 	ArtifactName string
 
-	ExportOptionsPath string
-	IPAExportDir      string
+	ExportOptionsPath          string
+	IPAExportDir               string
+	ExportTeamID               string
+	ICloudContainerEnvironment string
+	SigningReport              []SigningReportEntry
 
 	XcodebuildArchiveLog       string
 	XcodebuildExportArchiveLog string
 	IDEDistrubutionLogsDir     string
+	XcodebuildExitCode         int // 0 unless an xcodebuild command failed
+	ArchiveActivityLogPath     string
+	ArchiveIntermediatesDir    string
+
+	// The xcodebuild output lines tagged with their stream, set if the structured log is recorded
+	XcodebuildArchiveStructuredLog       []StructuredLogLine
+	XcodebuildExportArchiveStructuredLog []StructuredLogLine
+
+	MacCatalyst MacCatalystResult
 }
 
 // Run ...
@@ -360,7 +932,23 @@ func (s XcodebuildArchiver) Run(opts RunOpts) (RunResult, error) {
 
 	s.logger.Println()
 
-	if opts.XcodeMajorVersion >= 11 {
+	opts.Phases.Begin("swift package resolution")
+	if opts.DryRun {
+		s.logger.Warnf("Dry run: the archive and export commands are printed without executing them.")
+		s.logger.Printf("Skipping the Swift package resolution.")
+	} else if opts.XcodeMajorVersion >= 11 && opts.PrefetchSwiftPackages {
+		s.logger.Infof("Prefetching Swift package dependencies")
+		prefetchOpts := swiftPackagesPrefetchOpts{
+			ProjectPath:       opts.ProjectPath,
+			Scheme:            opts.Scheme,
+			Configuration:     opts.Configuration,
+			AdditionalOptions: opts.XcodebuildAdditionalOptions,
+			CacheLevel:        opts.CacheLevel,
+		}
+		if err := prefetchSwiftPackages(s.cmdFactory, prefetchOpts, s.logger); err != nil {
+			return out, err
+		}
+	} else if opts.XcodeMajorVersion >= 11 {
 		s.logger.Infof("Running resolve Swift package dependencies")
 		// Resolve Swift package dependencies, so running -showBuildSettings later is faster later
 		// Specifying a scheme is required for workspaces
@@ -391,12 +979,19 @@ func (s XcodebuildArchiver) Run(opts RunOpts) (RunResult, error) {
 	}
 	out.ArtifactName = opts.ArtifactName
 
-	if opts.CodesignManager != nil {
+	if opts.Cancellation.Cancelled() {
+		return out, errCancelled
+	}
+	opts.Phases.Begin("code signing")
+	if opts.DryRun {
+		s.logger.Infof("Dry run: skipping the code signing assets (certificates, profiles) preparation")
+	} else if opts.CodesignManager != nil {
 		s.logger.Infof("Preparing code signing assets (certificates, profiles) before Archive action")
 
-		xcodebuildAuthParams, err := opts.CodesignManager.PrepareCodesigning()
+		preparer := &retryRecordingPreparer{codesignPreparer: opts.CodesignManager, recorder: opts.Insights}
+		xcodebuildAuthParams, err := prepareCodesigningWithRetry(preparer, opts.CodesignRetryPolicy, s.logger, time.Sleep)
 		if err != nil {
-			return RunResult{}, fmt.Errorf("failed to manage code signing: %s", err)
+			return RunResult{}, fmt.Errorf("failed to manage code signing: %w", wrapCodesignError(err))
 		}
 
 		if xcodebuildAuthParams != nil {
@@ -422,33 +1017,204 @@ func (s XcodebuildArchiver) Run(opts RunOpts) (RunResult, error) {
 	}
 	s.logger.Println()
 
+	// the project is parsed once per run, for the archive and the export
+	projects := newArchivableProjectCache()
+
 	archiveOpts := xcodeArchiveOpts{
 		ProjectPath:       opts.ProjectPath,
 		Scheme:            opts.Scheme,
 		Configuration:     opts.Configuration,
+		Target:            opts.Target,
 		XcodeMajorVersion: opts.XcodeMajorVersion,
 		ArtifactName:      opts.ArtifactName,
 		XcodeAuthOptions:  authOptions,
+		Toolchain:         opts.Toolchain,
+
+		PerformCleanAction:       opts.PerformCleanAction,
+		SkipUnavailableActions:   opts.SkipUnavailableActions,
+		VerifyNoTestBundles:      opts.VerifyNoTestBundles,
+		XcconfigContent:          opts.XcconfigContent,
+		AdditionalOptions:        opts.XcodebuildAdditionalOptions,
+		ForceTeamID:              opts.ForceTeamID,
+		CodesignKeychainPath:     opts.CodesignKeychainPath,
+		CodesignKeychainPassword: opts.CodesignKeychainPassword,
+		ArchivePath:              opts.ArchivePath,
+		ExistingArchive:          opts.ExistingArchive,
+		KeychainPath:             opts.KeychainPath,
+		KeychainPassword:         opts.KeychainPassword,
+		ExportActivityLog:        opts.ExportActivityLog,
+		BuildReport:              opts.BuildReport,
+		ExportBuildProducts:      opts.ExportBuildProducts,
+		CacheLevel:               opts.CacheLevel,
+
+		CompilationCaching:            opts.CompilationCaching,
+		CompilationCacheRemoteService: opts.CompilationCacheRemoteService,
+		ApplySchemeRunEnvironment:     opts.ApplySchemeRunEnvironment,
+		Insights:                      opts.Insights,
+		Projects:                      projects,
+		DryRun:                        opts.DryRun,
+	}
 
-		PerformCleanAction: opts.PerformCleanAction,
-		XcconfigContent:    opts.XcconfigContent,
-		AdditionalOptions:  opts.XcodebuildAdditionalOptions,
-		CacheLevel:         opts.CacheLevel,
+	if opts.Cancellation.Cancelled() {
+		return out, errCancelled
 	}
-	archiveOut, err := s.xcodeArchive(archiveOpts)
-	out.XcodebuildArchiveLog = archiveOut.XcodebuildArchiveLog
-	if err != nil {
-		return out, err
+	opts.Phases.Begin("archive")
+	var cacheKey, cachedArchivePath string
+	if opts.ArchiveCacheDir != "" && !opts.DryRun {
+		s.logger.Infof("Looking for a cached archive")
+
+		identities, err := s.codesigningIdentities()
+		if err == nil {
+			cacheKey, err = archiveCacheKey(archiveCacheKeyOpts{
+				ProjectPath:       opts.ProjectPath,
+				Scheme:            opts.Scheme,
+				Configuration:     opts.Configuration,
+				XcodeVersion:      opts.XcodeVersion,
+				XcconfigContent:   opts.XcconfigContent,
+				AdditionalOptions: opts.XcodebuildAdditionalOptions,
+				ForceTeamID:       opts.ForceTeamID,
+				Toolchain:         opts.Toolchain,
+
+				DistributionMethod:      opts.ExportMethod,
+				CodeSigningAuthSource:   opts.CodeSigningAuthSource,
+				CertificateURLList:      opts.CertificateURLList,
+				ProvisioningProfileURLs: opts.FallbackProvisioningProfileURLs,
+				CodesigningIdentities:   identities,
+
+				ExcludedPaths: []string{opts.ArchiveCacheDir, opts.OutputDir, opts.ArchivePath},
+			})
+		}
+		if err == nil {
+			s.logger.Printf("Archive cache key: %s", cacheKey)
+			cachedArchivePath, err = findCachedArchive(opts.ArchiveCacheDir, cacheKey)
+		}
+		if err != nil {
+			s.logger.Warnf("Failed to look up the archive cache: %s", err)
+			cacheKey = ""
+		}
+	}
+
+	if opts.ArchiveCacheDir != "" && !opts.DryRun {
+		opts.Insights.RecordArchiveCache(cachedArchivePath != "")
+	}
+
+	var archiveOut xcodeArchiveResult
+	if cachedArchivePath != "" {
+		s.logger.Donef("Identical inputs archived before, skipping the archive action and using: %s", cachedArchivePath)
+
+		if opts.ArchivePath != "" {
+			if err := prepareArchivePath(opts.ArchivePath, opts.ExistingArchive); err != nil {
+				return out, err
+			}
+			if err := copyDir(cachedArchivePath, opts.ArchivePath, true); err != nil {
+				return out, fmt.Errorf("failed to copy the cached archive to the archive path: %w", err)
+			}
+			cachedArchivePath = opts.ArchivePath
+		}
+
+		archive, err := xcarchive.NewIosArchive(cachedArchivePath)
+		if err != nil {
+			return out, fmt.Errorf("failed to parse cached archive: %w", err)
+		}
+		archiveOut.Archive = &archive
+	} else {
+		var err error
+		opts.StructuredLog.Start()
+		archiveOut, err = s.xcodeArchive(archiveOpts)
+		out.XcodebuildArchiveLog = archiveOut.XcodebuildArchiveLog
+		out.XcodebuildArchiveStructuredLog = opts.StructuredLog.Take()
+		out.ArchiveActivityLogPath = archiveOut.ActivityLogPath
+		out.ArchiveIntermediatesDir = archiveOut.IntermediatesDir
+		if err != nil {
+			out.XcodebuildExitCode = xcodebuildExitCode(err)
+			s.handleFailedTempDir(archiveOut.TempDir, opts.KeepTempDirs, bitriseDebugArchiveTempDirEnvKey)
+			return out, err
+		}
+
+		if cacheKey != "" {
+			if err := storeArchiveInCache(opts.ArchiveCacheDir, cacheKey, archiveOut.Archive.Path, s.logger); err != nil {
+				s.logger.Warnf("Failed to store the archive in the archive cache: %s", err)
+			}
+		}
+	}
+
+	if (len(opts.InfoPlistOverrides) > 0 || opts.IconBadge != "") && !opts.DryRun {
+		if cachedArchivePath != "" && opts.ArchivePath == "" {
+			// the cached archive is not modified in place, so the modifications don't leak into the next runs
+			tmpDir, err := os.MkdirTemp("", "modified-archive")
+			if err != nil {
+				return out, err
+			}
+			archivePath := filepath.Join(tmpDir, filepath.Base(cachedArchivePath))
+			if err := copyDir(cachedArchivePath, archivePath, true); err != nil {
+				return out, fmt.Errorf("failed to copy the cached archive: %w", err)
+			}
+			archive, err := xcarchive.NewIosArchive(archivePath)
+			if err != nil {
+				return out, fmt.Errorf("failed to parse cached archive: %w", err)
+			}
+			archiveOut.Archive = &archive
+		}
+
+		appPath := archiveOut.Archive.Application.Path
+		err := s.modifyArchivedApp(appPath, func() error {
+			if len(opts.InfoPlistOverrides) > 0 {
+				if err := s.overrideAppInfoPlist(appPath, opts.InfoPlistOverrides); err != nil {
+					return fmt.Errorf("failed to override the Info.plist of the archived app: %w", err)
+				}
+			}
+			if opts.IconBadge != "" {
+				if err := s.badgeAppIcons(appPath, opts.IconBadge); err != nil {
+					return fmt.Errorf("failed to badge the app icon: %w", err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return out, err
+		}
+
+		archive, err := xcarchive.NewIosArchive(archiveOut.Archive.Path)
+		if err != nil {
+			return out, fmt.Errorf("failed to parse the archive: %w", err)
+		}
+		archiveOut.Archive = &archive
 	}
 
 	out.Archive = archiveOut.Archive
+	if out.Archive != nil {
+		s.redactor.addArchiveIdentifiers(*out.Archive)
+	}
 
+	if len(opts.RequiredLocalizations) > 0 && !opts.DryRun {
+		s.logger.Println()
+		s.logger.Infof("Checking the app's localizations")
+		if err := s.checkRequiredLocalizations(archiveOut.Archive.Application.Path, opts.RequiredLocalizations, opts.FailOnMissingLocalizations); err != nil {
+			return out, err
+		}
+	}
+
+	if opts.VerifyNoTestBundles && !opts.DryRun {
+		s.logger.Println()
+		s.logger.Infof("Checking the archive for test bundles")
+		if err := verifyNoTestContent(archiveOut.Archive.Path); err != nil {
+			return out, err
+		}
+		s.logger.Donef("The archive contains no test bundles")
+	}
+
+	if opts.Cancellation.Cancelled() {
+		return out, errCancelled
+	}
+	opts.Phases.Begin("export")
 	IPAExportOpts := xcodeIPAExportOpts{
 		ProjectPath:       opts.ProjectPath,
 		Scheme:            opts.Scheme,
 		Configuration:     opts.Configuration,
+		Target:            opts.Target,
 		XcodeMajorVersion: opts.XcodeMajorVersion,
 		XcodeAuthOptions:  authOptions,
+		Toolchain:         opts.Toolchain,
 
 		Archive:                         *archiveOut.Archive,
 		CustomExportOptionsPlistContent: opts.CustomExportOptionsPlistContent,
@@ -458,34 +1224,154 @@ func (s XcodebuildArchiver) Run(opts RunOpts) (RunResult, error) {
 		ExportDevelopmentTeam:           opts.ExportDevelopmentTeam,
 		UploadBitcode:                   opts.UploadBitcode,
 		CompileBitcode:                  opts.CompileBitcode,
+		ManageVersionAndBuildNumber:     opts.ManageVersionAndBuildNumber,
+		UploadSymbols:                   opts.UploadSymbols,
+		DistributionBundleIdentifier:    opts.DistributionBundleIdentifier,
+		ExportTeamOverrides:             opts.ExportTeamOverrides,
+		WildcardProfiles:                opts.WildcardProfiles,
+		CodeSigningStyleOverride:        opts.CodeSigningStyleOverride,
+		SigningCertificate:              opts.SigningCertificate,
+		InstallerSigningCertificate:     opts.InstallerSigningCertificate,
+
+		Projects: projects,
+	}
+	if opts.DryRun {
+		s.dryRunExport(IPAExportOpts)
+		if opts.MacCatalystArchive {
+			s.logger.Warnf("Dry run: the Mac Catalyst archive is not previewed.")
+		}
+		return out, nil
 	}
+
+	opts.StructuredLog.Start()
 	exportOut, err := s.xcodeIPAExport(IPAExportOpts)
 	out.XcodebuildExportArchiveLog = exportOut.XcodebuildExportArchiveLog
+	out.XcodebuildExportArchiveStructuredLog = opts.StructuredLog.Take()
 	if err != nil {
 		out.IDEDistrubutionLogsDir = exportOut.IDEDistrubutionLogsDir
+		out.XcodebuildExitCode = xcodebuildExitCode(err)
+		s.handleFailedTempDir(exportOut.TempDir, opts.KeepTempDirs, bitriseDebugExportTempDirEnvKey)
 		return out, err
 	}
 
 	out.ExportOptionsPath = exportOut.ExportOptionsPath
 	out.IPAExportDir = exportOut.IPAExportDir
+	out.ExportTeamID = exportOut.TeamID
+	out.ICloudContainerEnvironment = exportOut.ICloudContainerEnvironment
+	out.SigningReport = exportOut.SigningReport
+
+	if opts.VerifyIPASignature {
+		opts.Phases.Begin("signature verification")
+		if err := s.verifyIPASignature(exportOut.IPAExportDir); err != nil {
+			return out, err
+		}
+	}
+
+	if opts.IPAContentPolicy != "" && opts.IPAContentPolicy != ipaContentPolicyOff {
+		if err := s.checkIPAContentPolicy(exportOut.IPAExportDir, opts.IPAContentPolicy, opts.IPAContentDenyPatterns); err != nil {
+			return out, err
+		}
+	}
+
+	if opts.MacCatalystArchive && !opts.Cancellation.Cancelled() {
+		opts.Phases.Begin("mac catalyst archive")
+		out.MacCatalyst, err = s.archiveMacCatalyst(macCatalystOpts{
+			ProjectPath:               opts.ProjectPath,
+			Scheme:                    opts.Scheme,
+			Configuration:             opts.Configuration,
+			ArtifactName:              opts.ArtifactName,
+			XcconfigContent:           opts.XcconfigContent,
+			AdditionalOptions:         append(append([]string{}, opts.XcodebuildAdditionalOptions...), archiveOut.SchemeRunEnvironment...),
+			Toolchain:                 opts.Toolchain,
+			XcodeAuthOptions:          authOptions,
+			ExportOptionsPlistContent: opts.MacCatalystExportOptions,
+			Notarize:                  opts.MacCatalystNotarize,
+			NotarizationTimeout:       opts.NotarizationTimeout,
+		})
+		if err != nil {
+			return out, err
+		}
+	}
 
 	return out, nil
 }
 
 // ExportOpts ...
 type ExportOpts struct {
-	OutputDir      string
-	ArtifactName   string
-	ExportAllDsyms bool
+	OutputDir        string
+	OutputLayout     string
+	ArtifactName     string
+	ExportAllDsyms   bool
+	DSYMZipMode      string
+	CompressionLevel int
+	Compression      string
+
+	// ExportXCArchiveZip, ExportAppDir and ExportDSYMs select the archive artifacts copied to the output dir,
+	// the raw xcodebuild logs are copied only for failed Runs, unless ExportRawLogAlways is set
+	ExportXCArchiveZip bool
+	ExportAppDir       bool
+	ExportDSYMs        bool
+	ExportRawLogAlways bool
 
 	Archive *xcarchive.IosArchive
 
 	ExportOptionsPath string
 	IPAExportDir      string
 
+	ExportTeamID               string
+	ICloudContainerEnvironment string
+	SigningReport              []SigningReportEntry
+
+	ExpectedDeviceUDIDs     []string
+	PrintProvisionedDevices bool
+
+	// XcodebuildArchiveStructuredLog and XcodebuildExportArchiveStructuredLog are written next to the raw xcodebuild logs
+	XcodebuildArchiveStructuredLog       []StructuredLogLine
+	XcodebuildExportArchiveStructuredLog []StructuredLogLine
+
 	XcodebuildArchiveLog       string
 	XcodebuildExportArchiveLog string
 	IDEDistrubutionLogsDir     string
+	XcodebuildExitCode         int
+	ArchiveActivityLogPath     string
+	ActivityLogJSON            bool
+	BuildReport                bool
+	BuildParallelism           string
+	BuildIssuesJUnit           bool
+	TestResultDir              string
+	ArchiveIntermediatesDir    string
+	BuildProductPatterns       []string
+	ExportMacOSZip             bool
+	ExportEntitlements         bool
+	SparklePrivateKey          stepconf.Secret
+	SparkleDownloadURLPrefix   string
+	ResignSigningIdentity      string
+	ResignProfilePaths         []string
+
+	MacCatalyst MacCatalystResult
+
+	XcodebuildDiagnosticsDir string
+	AdditionalLogPaths       []string
+	// RunFailed is true if the Run failed, the additional logs are collected only for failed Runs
+	RunFailed bool
+	// XcodeCloudEnvVars exports the Xcode Cloud compatible Environment Variables too, the Scheme is exported as CI_XCODE_SCHEME
+	XcodeCloudEnvVars bool
+	Scheme            string
+}
+
+// redactLogs masks the registered team and bundle IDs in the xcodebuild logs.
+func (opts *ExportOpts) redactLogs(redactor *IdentifierRedactor) {
+	opts.XcodebuildArchiveLog = redactor.redact(opts.XcodebuildArchiveLog)
+	opts.XcodebuildExportArchiveLog = redactor.redact(opts.XcodebuildExportArchiveLog)
+	opts.MacCatalyst.XcodebuildArchiveLog = redactor.redact(opts.MacCatalyst.XcodebuildArchiveLog)
+	opts.MacCatalyst.XcodebuildExportArchiveLog = redactor.redact(opts.MacCatalyst.XcodebuildExportArchiveLog)
+	opts.XcodebuildArchiveStructuredLog = redactor.redactedStructuredLog(opts.XcodebuildArchiveStructuredLog)
+	opts.XcodebuildExportArchiveStructuredLog = redactor.redactedStructuredLog(opts.XcodebuildExportArchiveStructuredLog)
+}
+
+// exportRawLogs returns if the raw xcodebuild logs are copied to the output dir.
+func (opts ExportOpts) exportRawLogs() bool {
+	return opts.ExportRawLogAlways || opts.RunFailed || opts.XcodebuildExitCode != 0
 }
 
 // ExportOutput ...
@@ -493,105 +1379,144 @@ func (s XcodebuildArchiver) ExportOutput(opts ExportOpts) error {
 	s.logger.Println()
 	s.logger.TInfof("Exporting outputs...")
 
-	cleanup := func(pth string) error {
-		if exist, err := v1pathutil.IsPathExists(pth); err != nil {
-			return fmt.Errorf("failed to check if path (%s) exist, error: %s", pth, err)
-		} else if exist {
-			if err := os.RemoveAll(pth); err != nil {
-				return fmt.Errorf("failed to remove path (%s), error: %s", pth, err)
-			}
-		}
-		return nil
+	if s.redactor.enabled() {
+		// the identifiers discovered after the commands ran (for example the bundle IDs of the archive) are masked in the exported logs too
+		opts.redactLogs(s.redactor)
 	}
 
 	if opts.Archive != nil {
 		archivePath := opts.Archive.Path
-		if err := ExportOutputDir(s.cmdFactory, archivePath, archivePath, bitriseXCArchivePthEnvKey, s.logger); err != nil {
-			return fmt.Errorf("failed to export %s, error: %s", bitriseXCArchivePthEnvKey, err)
+		if err := ExportOutputDir(s.cmdFactory, s.outputEnvKeys, archivePath, archivePath, bitriseXCArchivePthEnvKey, s.logger); err != nil {
+			return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseXCArchivePthEnvKey), err)
 		}
-		s.logger.Donef("The xcarchive path is now available in the Environment Variable: %s (value: %s)", bitriseXCArchivePthEnvKey, archivePath)
+		s.logger.Donef("The xcarchive path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseXCArchivePthEnvKey), archivePath)
 
-		archiveZipPath := filepath.Join(opts.OutputDir, opts.ArtifactName+".xcarchive.zip")
-		if err := cleanup(archiveZipPath); err != nil {
+		s.logger.Printf("Exporting the archive's application properties:")
+		if properties, err := readArchiveApplicationProperties(opts.Archive.InfoPlist); err != nil {
+			s.logger.Warnf("Failed to read the archive's application properties: %s", err)
+		} else if err := exportArchiveApplicationProperties(s.cmdFactory, s.outputEnvKeys, properties, s.logger); err != nil {
 			return err
 		}
 
-		if err := ExportOutputDirAsZip(s.cmdFactory, archivePath, archiveZipPath, bitriseXCArchiveZipPthEnvKey, s.logger); err != nil {
-			return fmt.Errorf("failed to export %s, error: %s", bitriseXCArchiveZipPthEnvKey, err)
-		}
-		s.logger.Donef("The xcarchive zip path is now available in the Environment Variable: %s (value: %s)", bitriseXCArchiveZipPthEnvKey, archiveZipPath)
+		// Packaging the artifacts is independent of each other, speed it up by running the steps concurrently.
+		var packagingTasks []func() error
+		if opts.ExportXCArchiveZip {
+			packagingTasks = append(packagingTasks, func() error {
+				archiveOutputDir, err := outputDirForArtifact(opts.OutputDir, opts.OutputLayout, outputArtifactArchive)
+				if err != nil {
+					return err
+				}
 
-		appPath := filepath.Join(opts.OutputDir, opts.ArtifactName+".app")
-		if err := cleanup(appPath); err != nil {
-			return err
-		}
+				archiveZipPath := filepath.Join(archiveOutputDir, opts.ArtifactName+".xcarchive"+artifactCompressionExtension(opts.Compression))
+				if err := ExportOutputDirAsArchive(s.cmdFactory, s.outputEnvKeys, archivePath, archiveZipPath, bitriseXCArchiveZipPthEnvKey, opts.Compression, opts.CompressionLevel, s.logger); err != nil {
+					return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseXCArchiveZipPthEnvKey), err)
+				}
+				s.logger.Donef("The xcarchive zip path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseXCArchiveZipPthEnvKey), archiveZipPath)
 
-		if err := ExportOutputDir(s.cmdFactory, opts.Archive.Application.Path, appPath, bitriseAppDirPthEnvKey, s.logger); err != nil {
-			return fmt.Errorf("failed to export %s, error: %s", bitriseAppDirPthEnvKey, err)
+				return nil
+			})
+		} else {
+			s.logger.Printf("Skipping the xcarchive zip export.")
 		}
-		s.logger.Donef("The app directory is now available in the Environment Variable: %s (value: %s)", bitriseAppDirPthEnvKey, appPath)
+		if opts.ExportAppDir {
+			packagingTasks = append(packagingTasks, func() error {
+				archiveOutputDir, err := outputDirForArtifact(opts.OutputDir, opts.OutputLayout, outputArtifactArchive)
+				if err != nil {
+					return err
+				}
 
-		s.logger.Printf("Looking for app and framework dSYMs.")
+				appPath := filepath.Join(archiveOutputDir, opts.ArtifactName+".app")
+				if err := ExportOutputDir(s.cmdFactory, s.outputEnvKeys, opts.Archive.Application.Path, appPath, bitriseAppDirPthEnvKey, s.logger); err != nil {
+					return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseAppDirPthEnvKey), err)
+				}
+				s.logger.Donef("The app directory is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseAppDirPthEnvKey), appPath)
 
-		appDSYMPaths, frameworkDSYMPaths, err := opts.Archive.FindDSYMs()
-		if err != nil {
-			return fmt.Errorf("failed to export dSYMs, error: %s", err)
+				return nil
+			})
+		} else {
+			s.logger.Printf("Skipping the app directory export.")
 		}
-
-		appDSYMPathsCount := len(appDSYMPaths)
-		frameworkDSYMPathsCount := len(frameworkDSYMPaths)
-
-		s.logger.Printf("Found %d app dSYMs and %d framework dSYMs.", appDSYMPathsCount, frameworkDSYMPathsCount)
-
-		if appDSYMPathsCount > 0 || frameworkDSYMPathsCount > 0 {
-			dsymDir, err := v1pathutil.NormalizedOSTempDirPath("__dsyms__")
-			if err != nil {
-				return fmt.Errorf("failed to create tmp dir, error: %s", err)
-			}
-
-			if appDSYMPathsCount > 0 {
-				if err := ExportDSYMs(dsymDir, appDSYMPaths); err != nil {
-					return fmt.Errorf("failed to export dSYMs: %v", err)
+		if opts.ExportDSYMs {
+			packagingTasks = append(packagingTasks, func() error {
+				if err := s.exportDSYMs(opts); err != nil {
+					return err
 				}
-			} else {
-				s.logger.Warnf("No app dSYMs found to export")
-			}
-
-			if opts.ExportAllDsyms && frameworkDSYMPathsCount > 0 {
-				if err := ExportDSYMs(dsymDir, frameworkDSYMPaths); err != nil {
-					return fmt.Errorf("failed to export dSYMs: %v", err)
+				if err := s.exportBCSymbolMaps(opts); err != nil {
+					s.logger.Warnf("Failed to export the BCSymbolMaps: %s", err)
 				}
-			}
-
-			if err := ExportOutputDir(s.cmdFactory, dsymDir, dsymDir, bitriseDSYMDirPthEnvKey, s.logger); err != nil {
-				return fmt.Errorf("failed to export %s, error: %s", bitriseDSYMDirPthEnvKey, err)
-			}
-			s.logger.Donef("The dSYM dir path is now available in the Environment Variable: %s (value: %s)", bitriseDSYMDirPthEnvKey, dsymDir)
-
-			dsymZipPath := filepath.Join(opts.OutputDir, opts.ArtifactName+".dSYM.zip")
-			if err := cleanup(dsymZipPath); err != nil {
-				return err
-			}
+				return nil
+			})
+		} else {
+			s.logger.Printf("Skipping the dSYM export.")
+		}
 
-			if err := ExportOutputDirAsZip(s.cmdFactory, dsymDir, dsymZipPath, bitriseDSYMPthEnvKey, s.logger); err != nil {
-				return fmt.Errorf("failed to export %s, error: %s", bitriseDSYMPthEnvKey, err)
-			}
-			s.logger.Donef("The dSYM zip path is now available in the Environment Variable: %s (value: %s)", bitriseDSYMPthEnvKey, dsymZipPath)
+		if err := runInParallel(maxParallelPackagingTasks, packagingTasks...); err != nil {
+			return err
 		}
 	}
 
+	ipaOutputDir, err := outputDirForArtifact(opts.OutputDir, opts.OutputLayout, outputArtifactIPA)
+	if err != nil {
+		return err
+	}
+	logsOutputDir, err := outputDirForArtifact(opts.OutputDir, opts.OutputLayout, outputArtifactLogs)
+	if err != nil {
+		return err
+	}
+	exportRawLogs := opts.exportRawLogs()
+	if !exportRawLogs {
+		s.logger.Printf("Skipping the raw xcodebuild log export, the Run succeeded.")
+	}
+
 	if opts.ExportOptionsPath != "" {
-		exportOptionsPath := filepath.Join(opts.OutputDir, "export_options.plist")
-		if err := cleanup(exportOptionsPath); err != nil {
+		exportOptionsPath := filepath.Join(ipaOutputDir, "export_options.plist")
+		if _, err := atomicCopyFile(opts.ExportOptionsPath, exportOptionsPath); err != nil {
 			return err
 		}
+	}
+
+	if opts.ExportTeamID != "" {
+		if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseDevelopmentTeamEnvKey, opts.ExportTeamID); err != nil {
+			return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseDevelopmentTeamEnvKey), err)
+		}
+		s.logger.Donef("The export team ID is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseDevelopmentTeamEnvKey), opts.ExportTeamID)
+	}
 
-		if err := v1command.CopyFile(opts.ExportOptionsPath, exportOptionsPath); err != nil {
+	if opts.ICloudContainerEnvironment != "" {
+		if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseICloudContainerEnvironmentEnvKey, opts.ICloudContainerEnvironment); err != nil {
+			return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseICloudContainerEnvironmentEnvKey), err)
+		}
+		s.logger.Donef("The iCloud container environment is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseICloudContainerEnvironmentEnvKey), opts.ICloudContainerEnvironment)
+	}
+
+	if len(opts.SigningReport) > 0 {
+		s.exportSigningReport(opts.SigningReport, filepath.Join(ipaOutputDir, opts.ArtifactName+"-signing-report.json"))
+	}
+
+	exportedMacOSApp, exportedIPAPath, appStoreIPA := "", "", false
+	if opts.IPAExportDir != "" && (opts.ExportMacOSZip || opts.ExportEntitlements) {
+		if exportedMacOSApp, err = findExportedApp(opts.IPAExportDir); err != nil {
 			return err
 		}
 	}
 
-	if opts.IPAExportDir != "" {
+	if exportedMacOSApp != "" {
+		if opts.ExportMacOSZip {
+			zipPath := filepath.Join(ipaOutputDir, opts.ArtifactName+".zip")
+			if err := s.exportMacOSZip(exportedMacOSApp, zipPath); err != nil {
+				return err
+			}
+			if opts.SparklePrivateKey != "" {
+				s.exportSparkleAppcastItem(zipPath, exportedMacOSApp, opts.SparkleDownloadURLPrefix, opts.SparklePrivateKey)
+			}
+		}
+
+		if opts.ExportEntitlements {
+			if err := s.exportEntitlements(exportedMacOSApp, ipaOutputDir, opts.ArtifactName); err != nil {
+				s.logger.Warnf("Failed to export the entitlements: %s", err)
+			}
+		}
+	} else if opts.IPAExportDir != "" {
 		fileList := []string{}
 		ipaFiles := []string{}
 		if walkErr := filepath.Walk(opts.IPAExportDir, func(pth string, info os.FileInfo, err error) error {
@@ -618,19 +1543,45 @@ func (s XcodebuildArchiver) ExportOutput(opts ExportOpts) error {
 			return fmt.Errorf("No .ipa file found at export dir: %s", opts.IPAExportDir)
 		}
 
-		ipaPath := filepath.Join(opts.OutputDir, opts.ArtifactName+".ipa")
-		if err := cleanup(ipaPath); err != nil {
-			return err
+		ipaPath := filepath.Join(ipaOutputDir, opts.ArtifactName+".ipa")
+		if err := ExportOutputFile(s.cmdFactory, s.outputEnvKeys, ipaFiles[0], ipaPath, bitriseIPAPthEnvKey); err != nil {
+			return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseIPAPthEnvKey), err)
 		}
+		s.logger.Donef("The ipa path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseIPAPthEnvKey), ipaPath)
+		exportedIPAPath = ipaPath
 
-		if err := ExportOutputFile(s.cmdFactory, ipaFiles[0], ipaPath, bitriseIPAPthEnvKey); err != nil {
-			return fmt.Errorf("failed to export %s, error: %s", bitriseIPAPthEnvKey, err)
+		s.logger.Printf("Exporting ipa metadata:")
+		if metadata, err := readIPAMetadata(ipaPath); err != nil {
+			s.logger.Warnf("Failed to read ipa metadata: %s", err)
+		} else {
+			s.redactor.addBundleIDs(metadata.BundleID)
+			s.redactor.addTeamIDs(metadata.TeamID)
+			if err := exportIPAMetadata(s.cmdFactory, s.outputEnvKeys, metadata, s.logger); err != nil {
+				return err
+			}
+
+			if metadata.ExportMethod == exportoptions.MethodAdHoc {
+				reportProvisionedDevices(metadata.ProvisionedDevices, opts.ExpectedDeviceUDIDs, opts.PrintProvisionedDevices, s.logger)
+			}
+			appStoreIPA = metadata.ExportMethod == exportoptions.MethodAppStore
+		}
+
+		if opts.ExportEntitlements {
+			if err := s.exportIPAEntitlements(ipaPath, ipaOutputDir, opts.ArtifactName); err != nil {
+				s.logger.Warnf("Failed to export the entitlements: %s", err)
+			}
+		}
+
+		if opts.ResignSigningIdentity != "" {
+			resignedIPAPath := filepath.Join(ipaOutputDir, opts.ArtifactName+resignedIPAArtifactSuffix+".ipa")
+			if err := s.resignIPA(ipaPath, resignedIPAPath, opts.ResignSigningIdentity, opts.ResignProfilePaths, opts.CompressionLevel); err != nil {
+				return err
+			}
 		}
-		s.logger.Donef("The ipa path is now available in the Environment Variable: %s (value: %s)", bitriseIPAPthEnvKey, ipaPath)
 
 		if len(ipaFiles) > 1 {
 			s.logger.Warnf("More than 1 .ipa file found, exporting first one: %s", ipaFiles[0])
-			s.logger.Warnf("Moving every ipa to the BITRISE_DEPLOY_DIR")
+			s.logger.Warnf("Moving every ipa to the output directory: %s", ipaOutputDir)
 
 			for i, pth := range ipaFiles {
 				if i == 0 {
@@ -638,51 +1589,122 @@ func (s XcodebuildArchiver) ExportOutput(opts ExportOpts) error {
 				}
 
 				base := filepath.Base(pth)
-				deployPth := filepath.Join(opts.OutputDir, base)
+				deployPth := filepath.Join(ipaOutputDir, base)
 
-				if err := v1command.CopyFile(pth, deployPth); err != nil {
+				if _, err := atomicCopyFile(pth, deployPth); err != nil {
 					return fmt.Errorf("failed to copy (%s) -> (%s), error: %s", pth, deployPth, err)
 				}
 			}
 		}
 	}
 
-	if opts.IDEDistrubutionLogsDir != "" {
-		ideDistributionLogsZipPath := filepath.Join(opts.OutputDir, "xcodebuild.xcdistributionlogs.zip")
-		if err := cleanup(ideDistributionLogsZipPath); err != nil {
+	if opts.Archive != nil && opts.ExportDSYMs {
+		if err := s.exportSymbols(opts, exportedIPAPath, appStoreIPA); err != nil {
+			s.logger.Warnf("Failed to export the symbols: %s", err)
+		}
+	}
+
+	if opts.MacCatalyst.XcodebuildArchiveLog != "" {
+		if err := s.exportMacCatalystOutputs(opts); err != nil {
 			return err
 		}
+	}
+
+	if opts.XcodebuildExitCode != 0 {
+		failedLog := opts.XcodebuildExportArchiveLog
+		if failedLog == "" {
+			failedLog = opts.XcodebuildArchiveLog
+		}
+
+		if err := exportXcodebuildFailure(s.cmdFactory, s.outputEnvKeys, parseXcodebuildFailure(opts.XcodebuildExitCode, failedLog), s.logger); err != nil {
+			s.logger.Warnf("Failed to export the xcodebuild failure details: %s", err)
+		}
 
-		if err := ExportOutputDirAsZip(s.cmdFactory, opts.IDEDistrubutionLogsDir, ideDistributionLogsZipPath, bitriseIDEDistributionLogsPthEnvKey, s.logger); err != nil {
-			s.logger.Warnf("Failed to export %s, error: %s", bitriseIDEDistributionLogsPthEnvKey, err)
+		if codes := parseITMSErrorCodes(opts.XcodebuildExportArchiveLog, readDistributionLogs(opts.IDEDistrubutionLogsDir)); len(codes) > 0 {
+			printITMSErrors(codes, s.logger)
+			if err := exportITMSErrorCodes(s.cmdFactory, s.outputEnvKeys, codes, s.logger); err != nil {
+				s.logger.Warnf("Failed to export %s, error: %s", s.outputEnvKey(bitriseITMSErrorCodesEnvKey), err)
+			}
+		}
+	}
+
+	if opts.RunFailed && len(opts.AdditionalLogPaths) > 0 {
+		s.exportAdditionalLogs(opts.AdditionalLogPaths, filepath.Join(logsOutputDir, opts.ArtifactName+"-additional-logs.zip"), opts.CompressionLevel)
+	}
+
+	if opts.XcodebuildDiagnosticsDir != "" {
+		s.exportXcodebuildDiagnostics(opts.XcodebuildDiagnosticsDir, filepath.Join(logsOutputDir, opts.ArtifactName+"-xcodebuild-diagnostics.zip"), opts.CompressionLevel)
+	}
+
+	if opts.IDEDistrubutionLogsDir != "" {
+		ideDistributionLogsZipPath := filepath.Join(logsOutputDir, "xcodebuild.xcdistributionlogs.zip")
+		if err := ExportOutputDirAsZip(s.cmdFactory, s.outputEnvKeys, opts.IDEDistrubutionLogsDir, ideDistributionLogsZipPath, bitriseIDEDistributionLogsPthEnvKey, opts.CompressionLevel, s.logger); err != nil {
+			s.logger.Warnf("Failed to export %s, error: %s", s.outputEnvKey(bitriseIDEDistributionLogsPthEnvKey), err)
 		} else {
-			s.logger.Donef("The xcdistributionlogs zip path is now available in the Environment Variable: %s (value: %s)", bitriseIDEDistributionLogsPthEnvKey, ideDistributionLogsZipPath)
+			s.logger.Donef("The xcdistributionlogs zip path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseIDEDistributionLogsPthEnvKey), ideDistributionLogsZipPath)
 		}
 	}
 
-	if opts.XcodebuildArchiveLog != "" {
-		xcodebuildArchiveLogPath := filepath.Join(opts.OutputDir, xcodebuildArchiveLogFilename)
-		if err := cleanup(xcodebuildArchiveLogPath); err != nil {
-			return err
+	if opts.XcodebuildArchiveLog != "" && exportRawLogs {
+		xcodebuildArchiveLogPath := filepath.Join(logsOutputDir, xcodebuildArchiveLogFilename)
+		if err := ExportOutputFileContent(s.cmdFactory, s.outputEnvKeys, opts.XcodebuildArchiveLog, xcodebuildArchiveLogPath, xcodebuildArchiveLogPathEnvKey); err != nil {
+			s.logger.Warnf("Failed to export %s, error: %s", s.outputEnvKey(xcodebuildArchiveLogPathEnvKey), err)
+		} else {
+			s.logger.Donef("The xcodebuild archive log path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(xcodebuildArchiveLogPathEnvKey), xcodebuildArchiveLogPath)
 		}
+	}
+
+	if len(opts.XcodebuildArchiveStructuredLog) > 0 && exportRawLogs {
+		pth := filepath.Join(logsOutputDir, xcodebuildArchiveStructuredLogFilename)
+		s.exportStructuredLog(opts.XcodebuildArchiveStructuredLog, pth, xcodebuildArchiveStructuredLogPathEnvKey)
+	}
 
-		if err := ExportOutputFileContent(s.cmdFactory, opts.XcodebuildArchiveLog, xcodebuildArchiveLogPath, xcodebuildArchiveLogPathEnvKey); err != nil {
-			s.logger.Warnf("Failed to export %s, error: %s", xcodebuildArchiveLogPathEnvKey, err)
+	if opts.ArchiveActivityLogPath != "" {
+		s.exportActivityLog(opts, logsOutputDir)
+	}
+
+	if opts.BuildReport && opts.XcodebuildArchiveLog != "" {
+		buildReportPath := filepath.Join(logsOutputDir, opts.ArtifactName+"-build-report.html")
+		report := parseBuildReport(opts.ArtifactName+" archive build report", opts.XcodebuildArchiveLog)
+		report.Parallelism = opts.BuildParallelism
+		if err := writeBuildReport(report, buildReportPath); err != nil {
+			s.logger.Warnf("Failed to write the build report: %s", err)
+		} else if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, bitriseBuildReportPthEnvKey, buildReportPath); err != nil {
+			s.logger.Warnf("Failed to export %s, error: %s", s.outputEnvKey(bitriseBuildReportPthEnvKey), err)
 		} else {
-			s.logger.Donef("The xcodebuild archive log path is now available in the Environment Variable: %s (value: %s)", xcodebuildArchiveLogPathEnvKey, xcodebuildArchiveLogPath)
+			s.logger.Donef("The build report path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseBuildReportPthEnvKey), buildReportPath)
 		}
 	}
 
-	if opts.XcodebuildExportArchiveLog != "" {
-		xcodebuildExportArchiveLogPath := filepath.Join(opts.OutputDir, xcodebuildExportArchiveLogFilename)
-		if err := cleanup(xcodebuildExportArchiveLogPath); err != nil {
+	if opts.BuildIssuesJUnit && opts.XcodebuildArchiveLog != "" {
+		s.exportBuildIssuesJUnit(opts)
+	}
+
+	if opts.ArchiveIntermediatesDir != "" && len(opts.BuildProductPatterns) > 0 {
+		archiveOutputDir, err := outputDirForArtifact(opts.OutputDir, opts.OutputLayout, outputArtifactArchive)
+		if err != nil {
 			return err
 		}
+		s.exportBuildProducts(opts.ArchiveIntermediatesDir, opts.BuildProductPatterns, filepath.Join(archiveOutputDir, opts.ArtifactName+"-build-products"))
+	}
 
-		if err := ExportOutputFileContent(s.cmdFactory, opts.XcodebuildExportArchiveLog, xcodebuildExportArchiveLogPath, xcodebuildExportArchiveLogPathEnvKey); err != nil {
-			s.logger.Warnf("Failed to export %s, error: %s", xcodebuildExportArchiveLogPathEnvKey, err)
+	if opts.XcodebuildExportArchiveLog != "" && exportRawLogs {
+		xcodebuildExportArchiveLogPath := filepath.Join(logsOutputDir, xcodebuildExportArchiveLogFilename)
+		if err := ExportOutputFileContent(s.cmdFactory, s.outputEnvKeys, opts.XcodebuildExportArchiveLog, xcodebuildExportArchiveLogPath, xcodebuildExportArchiveLogPathEnvKey); err != nil {
+			s.logger.Warnf("Failed to export %s, error: %s", s.outputEnvKey(xcodebuildExportArchiveLogPathEnvKey), err)
 		} else {
-			s.logger.Donef("The xcodebuild -exportArchive log path is now available in the Environment Variable: %s (value: %s)", xcodebuildExportArchiveLogPathEnvKey, xcodebuildExportArchiveLogPath)
+			s.logger.Donef("The xcodebuild -exportArchive log path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(xcodebuildExportArchiveLogPathEnvKey), xcodebuildExportArchiveLogPath)
+		}
+	}
+
+	if len(opts.XcodebuildExportArchiveStructuredLog) > 0 && exportRawLogs {
+		pth := filepath.Join(logsOutputDir, xcodebuildExportArchiveStructuredLogFilename)
+		s.exportStructuredLog(opts.XcodebuildExportArchiveStructuredLog, pth, xcodebuildExportArchiveStructuredLogPathEnvKey)
+	}
+
+	if opts.XcodeCloudEnvVars {
+		if err := s.exportXcodeCloudEnvs(opts); err != nil {
+			return err
 		}
 	}
 
@@ -769,6 +1791,10 @@ func (s XcodebuildArchiveConfigParser) createCodesignManager(config Config) (cod
 		testDevices = serviceConnection.TestDevices
 	}
 
+	if config.RegisterTestDevices {
+		testDevices = appendExpectedTestDevices(testDevices, ParseDeviceUDIDs(config.ExpectedDeviceUDIDs), time.Now())
+	}
+
 	return codesign.NewManagerWithProject(
 		opts,
 		appleAuthCredentials,
@@ -787,20 +1813,56 @@ type xcodeArchiveOpts struct {
 	ProjectPath       string
 	Scheme            string
 	Configuration     string
+	Target            string
 	XcodeMajorVersion int
 	ArtifactName      string
 	XcodeAuthOptions  *xcodebuild.AuthenticationParams
+	Toolchain         string
 
 	PerformCleanAction bool
 	XcconfigContent    string
 	AdditionalOptions  []string
+	ForceTeamID        string
+	ExportActivityLog  bool
+	BuildReport        bool
+	// ExportBuildProducts is true if the build products are exported from DerivedData
+	ExportBuildProducts bool
+	// SkipUnavailableActions skips the targets not buildable for the archive destination instead of failing
+	SkipUnavailableActions bool
+	// VerifyNoTestBundles warns about the test targets built for archiving
+	VerifyNoTestBundles bool
+	// ApplySchemeRunEnvironment passes the scheme's Run action environment variables as build settings
+	ApplySchemeRunEnvironment bool
+
+	CodesignKeychainPath     string
+	CodesignKeychainPassword stepconf.Secret
+	ArchivePath              string
+	ExistingArchive          string
+	KeychainPath             string
+	KeychainPassword         stepconf.Secret
 
 	CacheLevel string
+
+	CompilationCaching            bool
+	CompilationCacheRemoteService string
+
+	Insights *InsightsRecorder
+	// Projects caches the parsed projects of the run, shared with the export, optional
+	Projects *archivableProjectCache
+	// DryRun prints the archive command instead of executing it
+	DryRun bool
 }
 
 type xcodeArchiveResult struct {
 	Archive              *xcarchive.IosArchive
 	XcodebuildArchiveLog string
+	ActivityLogPath      string
+	// IntermediatesDir is the scheme's archive build products and intermediates dir in DerivedData
+	IntermediatesDir string
+	// TempDir contains the archive, empty if the archive path is set
+	TempDir string
+	// SchemeRunEnvironment are the build settings of the scheme's Run action environment variables passed to the archive action
+	SchemeRunEnvironment []string
 }
 
 func (s XcodebuildArchiver) xcodeArchive(opts xcodeArchiveOpts) (xcodeArchiveResult, error) {
@@ -809,14 +1871,22 @@ func (s XcodebuildArchiver) xcodeArchive(opts xcodeArchiveOpts) (xcodeArchiveRes
 	// Open Xcode project
 	s.logger.TInfof("Opening xcode project at path: %s for scheme: %s", opts.ProjectPath, opts.Scheme)
 
-	xcodeProj, scheme, configuration, err := OpenArchivableProject(opts.ProjectPath, opts.Scheme, opts.Configuration)
+	xcodeProj, scheme, configuration, err := opts.Projects.open(opts.ProjectPath, opts.Scheme, opts.Configuration, opts.Target)
 	if err != nil {
 		return out, fmt.Errorf("failed to open project: %s: %s", opts.ProjectPath, err)
 	}
+	schemeEnvironment := printSchemeArchiveSettings(scheme, opts.Configuration, opts.ApplySchemeRunEnvironment, s.logger)
+	if opts.VerifyNoTestBundles {
+		warnArchivedTestTargets(scheme, s.logger)
+	}
+	if targets := archivableApplicationTargets(scheme); opts.Target == "" && len(targets) > 1 {
+		s.logger.Warnf("The scheme builds multiple application targets (%s), using the first one: %s", strings.Join(targets, ", "), targets[0])
+		s.logger.Warnf("Set the Target (target) input to select another one.")
+	}
 
 	s.logger.TInfof("Reading xcode project")
 
-	platform, err := BuildableTargetPlatform(xcodeProj, scheme, configuration, opts.AdditionalOptions, XcodeBuild{}, s.logger)
+	platform, err := BuildableTargetPlatform(xcodeProj, scheme, configuration, opts.AdditionalOptions, identifierRegisteringProvider{TargetBuildSettingsProvider: XcodeBuild{}, redactor: s.redactor}, s.logger)
 	if err != nil {
 		return out, fmt.Errorf("failed to read project platform: %s: %s", opts.ProjectPath, err)
 	}
@@ -858,11 +1928,21 @@ and use 'Export iOS and tvOS Xcode archive' step to export an App Clip.`, opts.S
 		archiveCmd.SetXCConfigPath(xcconfigPath)
 	}
 
-	tmpDir, err := v1pathutil.NormalizedOSTempDirPath("xcodeArchive")
-	if err != nil {
-		return out, fmt.Errorf("failed to create temp dir, error: %s", err)
+	archivePth := opts.ArchivePath
+	if archivePth != "" && opts.DryRun {
+		s.logger.Printf("Dry run: the existing archive is not checked at the archive path.")
+	} else if archivePth != "" {
+		if err := prepareArchivePath(archivePth, opts.ExistingArchive); err != nil {
+			return out, err
+		}
+	} else {
+		tmpDir, err := v1pathutil.NormalizedOSTempDirPath("xcodeArchive")
+		if err != nil {
+			return out, fmt.Errorf("failed to create temp dir, error: %s", err)
+		}
+		archivePth = filepath.Join(tmpDir, opts.ArtifactName+".xcarchive")
+		out.TempDir = tmpDir
 	}
-	archivePth := filepath.Join(tmpDir, opts.ArtifactName+".xcarchive")
 
 	archiveCmd.SetArchivePath(archivePth)
 	if opts.XcodeAuthOptions != nil {
@@ -870,22 +1950,82 @@ and use 'Export iOS and tvOS Xcode archive' step to export an App Clip.`, opts.S
 	}
 
 	additionalOptions := generateAdditionalOptions(string(platform), opts.AdditionalOptions)
+	if opts.ForceTeamID != "" {
+		s.logger.Printf("Archiving with the forced development team: %s", opts.ForceTeamID)
+		additionalOptions = append(additionalOptions, forceTeamIDBuildSetting(opts.ForceTeamID))
+	}
+	additionalOptions = append(additionalOptions, toolchainOptions(opts.Toolchain)...)
+	if opts.CodesignKeychainPath != "" {
+		if opts.CodesignKeychainPassword != "" && !opts.DryRun {
+			if err := s.unlockKeychain(opts.CodesignKeychainPath, opts.CodesignKeychainPassword); err != nil {
+				return out, err
+			}
+		}
+		s.logger.Printf("Code signing with the keychain: %s", opts.CodesignKeychainPath)
+		additionalOptions = append(additionalOptions, keychainCodeSignFlagsSetting(opts.CodesignKeychainPath))
+	}
+	if opts.BuildReport && !sliceutil.IsStringInSlice(showBuildTimingSummaryOption, additionalOptions) {
+		additionalOptions = append(additionalOptions, showBuildTimingSummaryOption)
+	}
+	if opts.CompilationCaching {
+		additionalOptions = append(additionalOptions, compilationCachingBuildSettings(opts.CompilationCacheRemoteService)...)
+	}
+	if opts.SkipUnavailableActions && !sliceutil.IsStringInSlice(skipUnavailableActionsOption, additionalOptions) {
+		additionalOptions = append(additionalOptions, skipUnavailableActionsOption)
+	}
+	if opts.ApplySchemeRunEnvironment {
+		var skipped []string
+		out.SchemeRunEnvironment, skipped = schemeRunEnvironmentOptions(schemeEnvironment, additionalOptions)
+		if len(skipped) > 0 {
+			s.logger.Warnf("The scheme's Run action environment variables set by the xcodebuild options or not usable as a build setting are not passed to the archive action: %s", strings.Join(skipped, ", "))
+		}
+		additionalOptions = append(additionalOptions, out.SchemeRunEnvironment...)
+	}
 	archiveCmd.SetCustomOptions(additionalOptions)
 
+	if opts.DryRun {
+		s.logger.Println()
+		s.logger.Infof("Archive command (dry run):")
+		s.logger.Printf("%s", archiveCmd.PrintableCmd())
+		if opts.XcconfigContent != "" {
+			s.logger.Printf("xcconfig content:")
+			s.logger.Printf("%s", opts.XcconfigContent)
+		}
+		out.Archive = &xcarchive.IosArchive{Path: archivePth}
+		return out, nil
+	}
+
 	var swiftPackagesPath string
 	if opts.XcodeMajorVersion >= 11 {
 		var err error
-		if swiftPackagesPath, err = cache.NewSwiftPackageCache().SwiftPackagesPath(opts.ProjectPath); err != nil {
+		if swiftPackagesPath, err = projectSwiftPackagesPath(opts.ProjectPath, opts.AdditionalOptions); err != nil {
 			return out, fmt.Errorf("failed to get Swift Packages path, error: %s", err)
 		}
 	}
 
-	xcodebuildLog, err := runArchiveCommandWithRetry(s.xcodeCommandRunner, s.logFormatter, archiveCmd, swiftPackagesPath, s.logger)
+	archiveArgs := archiveCmd.CommandArgs()
+	archiveStartTime := time.Now()
+	xcodebuildLog, err := runArchiveCommandWithRetry(s.runner(), archiveArgs, swiftPackagesPath, opts.Insights, s.logger)
 	out.XcodebuildArchiveLog = xcodebuildLog
+	if opts.ExportActivityLog {
+		out.ActivityLogPath = s.findArchiveActivityLog(opts.ProjectPath, opts.AdditionalOptions, archiveStartTime)
+	}
+	if opts.ExportBuildProducts {
+		out.IntermediatesDir = s.findArchiveIntermediatesDir(opts.ProjectPath, opts.Scheme, opts.AdditionalOptions)
+	}
+	if err != nil && isKeychainLockedFailure(xcodebuildLog) {
+		xcodebuildLog, err = s.retryArchiveWithUnlockedKeychain(opts, archiveArgs, xcodebuildLog, err)
+		out.XcodebuildArchiveLog = xcodebuildLog
+	}
 	if err != nil {
 		return out, fmt.Errorf("failed to archive the project: %w", err)
 	}
 
+	if opts.CompilationCaching {
+		printCompilationCacheSummary(xcodebuildLog, s.logger)
+		opts.Insights.RecordCompilationCache(xcodebuildLog)
+	}
+
 	// Ensure xcarchive exists
 	if exist, err := v1pathutil.IsPathExists(archivePth); err != nil {
 		return out, fmt.Errorf("failed to check if archive exist, error: %s", err)
@@ -893,6 +2033,14 @@ and use 'Export iOS and tvOS Xcode archive' step to export an App Clip.`, opts.S
 		return out, fmt.Errorf("no archive generated at: %s", archivePth)
 	}
 
+	if opts.SkipUnavailableActions || sliceutil.IsStringInSlice(skipUnavailableActionsOption, opts.AdditionalOptions) {
+		if err := verifyMainApplicationArchived(archivePth, mainTarget.Name); err != nil {
+			return out, err
+		}
+	}
+
+	warnInstalledDependencyProducts(archivePth, s.logger)
+
 	archive, err := xcarchive.NewIosArchive(archivePth)
 	if err != nil {
 		return out, fmt.Errorf("failed to parse archive, error: %s", err)
@@ -909,8 +2057,8 @@ and use 'Export iOS and tvOS Xcode archive' step to export an App Clip.`, opts.S
 	s.logger.Printf("xcode managed profile: %v", profileutil.IsXcodeManaged(mainApplication.ProvisioningProfile.Name))
 
 	// Cache swift PM
-	if opts.XcodeMajorVersion >= 11 && opts.CacheLevel == "swift_packages" {
-		if err := cache.NewSwiftPackageCache().CollectSwiftPackages(opts.ProjectPath); err != nil {
+	if opts.XcodeMajorVersion >= 11 && opts.CacheLevel == swiftPackagesCacheLevel {
+		if err := collectSwiftPackages(opts.ProjectPath, opts.AdditionalOptions, s.logger); err != nil {
 			s.logger.Warnf("Failed to mark swift packages for caching, error: %s", err)
 		}
 	}
@@ -922,8 +2070,10 @@ type xcodeIPAExportOpts struct {
 	ProjectPath       string
 	Scheme            string
 	Configuration     string
+	Target            string
 	XcodeMajorVersion int
 	XcodeAuthOptions  *xcodebuild.AuthenticationParams
+	Toolchain         string
 
 	Archive                         xcarchive.IosArchive
 	CustomExportOptionsPlistContent string
@@ -933,36 +2083,187 @@ type xcodeIPAExportOpts struct {
 	ExportDevelopmentTeam           string
 	UploadBitcode                   bool
 	CompileBitcode                  bool
+	ManageVersionAndBuildNumber     bool
+	UploadSymbols                   bool
+	DistributionBundleIdentifier    string
+	ExportTeamOverrides             map[string]string
+	WildcardProfiles                string
+	CodeSigningStyleOverride        string
+	SigningCertificate              string
+	InstallerSigningCertificate     string
+
+	// Projects is the parsed project cache of the run, shared with the archive, optional
+	Projects *archivableProjectCache
 }
 
 type xcodeIPAExportResult struct {
 	ExportOptionsPath          string
 	IPAExportDir               string
+	TeamID                     string
+	ICloudContainerEnvironment string
 	XcodebuildExportArchiveLog string
 	IDEDistrubutionLogsDir     string
+	// TempDir contains the export options and the exported ipa
+	TempDir string
+	// SigningReport is the code signing of the bundles, set if the export options are generated
+	SigningReport []SigningReportEntry
 }
 
-func (s XcodebuildArchiver) xcodeIPAExport(opts xcodeIPAExportOpts) (xcodeIPAExportResult, error) {
-	out := xcodeIPAExportResult{}
+func (s XcodebuildArchiver) generateExportOptions(opts xcodeIPAExportOpts) (exportoptions.ExportOptions, string, error) {
+	archiveExportMethod := opts.Archive.Application.ProvisioningProfile.ExportType
 
-	// Exporting the ipa with Xcode Command Line tools
+	exportMethod, err := determineExportMethod(opts.ExportMethod, archiveExportMethod, s.logger)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.logger.TPrintf("Opening Xcode project at path: %s.", opts.ProjectPath)
 
-	/*
-		You'll get an "Error Domain=IDEDistributionErrorDomain Code=14 "No applicable devices found."" error
-		if $GEM_HOME is set and the project's directory includes a Gemfile - to fix this
-		we'll unset GEM_HOME as that's not required for xcodebuild anyway.
-		This probably fixes the RVM issue too, but that still should be tested.
-		See also:
-		- http://stackoverflow.com/questions/33041109/xcodebuild-no-applicable-devices-found-when-exporting-archive
-		- https://gist.github.com/claybridges/cea5d4afd24eda268164
-	*/
-	envsToUnset := []string{"GEM_HOME", "GEM_PATH", "RUBYLIB", "RUBYOPT", "BUNDLE_BIN_PATH", "_ORIGINAL_GEM_PATH", "BUNDLE_GEMFILE"}
-	for _, key := range envsToUnset {
-		if err := os.Unsetenv(key); err != nil {
-			return out, fmt.Errorf("failed to unset (%s), error: %s", key, err)
+	xcodeProj, scheme, configuration, err := opts.Projects.open(opts.ProjectPath, opts.Scheme, opts.Configuration, opts.Target)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open project: %s: %s", opts.ProjectPath, err)
+	}
+
+	archiveCodeSignIsXcodeManaged := opts.Archive.IsXcodeManaged()
+	signingStyle := exportoptions.SigningStyleManual
+	if opts.XcodeAuthOptions != nil {
+		signingStyle = exportoptions.SigningStyleAutomatic
+	}
+	isSigningStyleOverridden := opts.CodeSigningStyleOverride != "" && opts.CodeSigningStyleOverride != codeSigningStyleAutoDetect
+	if isSigningStyleOverridden {
+		signingStyle = exportoptions.SigningStyle(opts.CodeSigningStyleOverride)
+		s.logger.Printf("Code signing style override provided, using %s signing style for export.", signingStyle)
+	}
+
+	teamID := opts.ExportDevelopmentTeam
+	if teamID == "" {
+		teamID = archiveTeamID(opts.Archive)
+		if teamID != "" {
+			s.logger.Printf("No Developer Portal team ID provided, using the archive's team: %s", teamID)
+		}
+	} else if archiveTeam := archiveTeamID(opts.Archive); archiveTeam != "" && archiveTeam != teamID {
+		s.logger.Printf("Exporting with team %s, the archive was signed with team %s.", teamID, archiveTeam)
+
+		if signingStyle == exportoptions.SigningStyleManual {
+			profiles, err := profileutil.InstalledProvisioningProfileInfos(profileutil.ProfileTypeIos)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to read installed provisioning profiles: %w", err)
+			}
+
+			var bundleIDs []string
+			for bundleID := range opts.Archive.BundleIDEntitlementsMap() {
+				bundleIDs = append(bundleIDs, bundleID)
+			}
+			if err := validateExportTeamProfiles(teamID, exportMethod, withoutBundleIDs(bundleIDs, opts.ExportTeamOverrides), profiles); err != nil {
+				return nil, "", err
+			}
 		}
 	}
 
+	iCloudContainerEnvironment := opts.ICloudContainerEnvironment
+	if iCloudContainerEnvironment == "" {
+		iCloudContainerEnvironment = detectICloudContainerEnvironment(opts.Archive, exportMethod, s.logger)
+	} else if err := validateICloudContainerEnvironment(iCloudContainerEnvironment, exportMethod, opts.Archive.BundleIDEntitlementsMap(), opts.Archive.BundleIDProfileInfoMap(), s.logger); err != nil {
+		return nil, "", fmt.Errorf("issue with input icloud_container_environment: %w", err)
+	}
+
+	generator := exportoptionsgenerator.New(xcodeProj, scheme, configuration, s.logger)
+	exportOptions, err := generator.GenerateApplicationExportOptions(exportMethod, iCloudContainerEnvironment, teamID,
+		opts.UploadBitcode, opts.CompileBitcode, archiveCodeSignIsXcodeManaged, signingStyle, int64(opts.XcodeMajorVersion), opts.TestFlightInternalTestingOnly)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if opts.XcodeMajorVersion >= 13 {
+		exportOptions = setManageAppVersion(exportOptions, opts.ManageVersionAndBuildNumber)
+	}
+	exportOptions = setUploadSymbols(exportOptions, opts.UploadSymbols)
+
+	if len(opts.ExportTeamOverrides) > 0 {
+		if signingStyle != exportoptions.SigningStyleManual {
+			return nil, "", fmt.Errorf("export team overrides (`export_team_overrides`) require manual code signing, the export uses %s code signing", signingStyle)
+		}
+
+		profiles, err := profileutil.InstalledProvisioningProfileInfos(profileutil.ProfileTypeIos)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read installed provisioning profiles: %w", err)
+		}
+		bundleIDs := sortedKeys(opts.Archive.BundleIDEntitlementsMap())
+		for _, bundleID := range sortedKeys(opts.ExportTeamOverrides) {
+			if !sliceutil.IsStringInSlice(bundleID, bundleIDs) {
+				s.logger.Warnf("The archive contains no bundle with the overridden bundle ID: %s", bundleID)
+			}
+		}
+
+		mapping, err := teamOverrideProfiles(teamID, opts.ExportTeamOverrides, exportMethod, bundleIDs, bundleIDProvisioningProfilesOf(exportOptions), profiles)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, bundleID := range sortedKeys(opts.ExportTeamOverrides) {
+			if profile, ok := mapping[bundleID]; ok {
+				s.logger.Printf("Exporting %s with team %s, profile: %s", bundleID, opts.ExportTeamOverrides[bundleID], profile)
+			}
+		}
+		exportOptions = setBundleIDProvisioningProfiles(exportOptions, mapping)
+	}
+
+	wildcardProfiles := opts.WildcardProfiles
+	if wildcardProfiles == "" {
+		wildcardProfiles = wildcardProfilesAllow
+	}
+	if wildcardProfiles != wildcardProfilesAllow && signingStyle != exportoptions.SigningStyleManual {
+		s.logger.Warnf("Wildcard profiles (wildcard_profiles) is set to %s, but Xcode selects the provisioning profiles with %s code signing.", wildcardProfiles, signingStyle)
+	} else if mapping := bundleIDProvisioningProfilesOf(exportOptions); len(mapping) > 0 {
+		profiles, err := profileutil.InstalledProvisioningProfileInfos(profileutil.ProfileTypeIos)
+		if err != nil && wildcardProfiles != wildcardProfilesAllow {
+			return nil, "", fmt.Errorf("failed to read installed provisioning profiles: %w", err)
+		} else if err != nil {
+			s.logger.Warnf("Failed to read installed provisioning profiles, the selected wildcard profiles are not reported: %s", err)
+		} else {
+			if mapping, err = applyWildcardProfilePolicy(wildcardProfiles, exportMethod, mapping, profiles, s.logger); err != nil {
+				return nil, "", err
+			}
+			exportOptions = setBundleIDProvisioningProfiles(exportOptions, mapping)
+		}
+	}
+
+	if opts.DistributionBundleIdentifier != "" && exportMethod != exportoptions.MethodAppStore {
+		var profileName string
+		if signingStyle == exportoptions.SigningStyleManual {
+			profiles, err := profileutil.InstalledProvisioningProfileInfos(profileutil.ProfileTypeIos)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to read installed provisioning profiles: %w", err)
+			}
+
+			profile, found := findExportProfile(teamID, exportMethod, opts.DistributionBundleIdentifier, profiles)
+			if !found {
+				return nil, "", fmt.Errorf("no installed %s provisioning profile found for the team (%s) and the distribution bundle ID: %s", exportMethod, teamID, opts.DistributionBundleIdentifier)
+			}
+			profileName = profile.Name
+		}
+
+		s.logger.Printf("Rewriting the app's bundle ID to %s at export.", opts.DistributionBundleIdentifier)
+		exportOptions = setDistributionBundleIdentifier(exportOptions, opts.DistributionBundleIdentifier, opts.Archive.Application.BundleIdentifier(), profileName)
+	}
+
+	if isSigningStyleOverridden {
+		exportOptions = setSigningStyle(exportOptions, signingStyle)
+	}
+
+	if opts.InstallerSigningCertificate != "" && exportMethod != exportoptions.MethodAppStore {
+		s.logger.Warnf("InstallerSigningCertificate is valid only for Distribution Method app-store, ignoring it.")
+	}
+	exportOptions = setSigningCertificates(exportOptions, opts.SigningCertificate, opts.InstallerSigningCertificate)
+
+	return exportOptions, teamID, nil
+}
+
+func (s XcodebuildArchiver) xcodeIPAExport(opts xcodeIPAExportOpts) (xcodeIPAExportResult, error) {
+	out := xcodeIPAExportResult{}
+
+	// Exporting the ipa with Xcode Command Line tools
+	// The Ruby environment (GEM_HOME, ...) is cleared from the xcodebuild commands' environment by the unset_env_vars input's default value.
+
 	s.logger.Println()
 	s.logger.Infof("Collecting export options...")
 
@@ -970,6 +2271,7 @@ func (s XcodebuildArchiver) xcodeIPAExport(opts xcodeIPAExportOpts) (xcodeIPAExp
 	if err != nil {
 		return out, fmt.Errorf("failed to create temp dir, error: %s", err)
 	}
+	out.TempDir = tmpDir
 
 	exportOptionsPath := filepath.Join(tmpDir, "export_options.plist")
 
@@ -980,36 +2282,18 @@ func (s XcodebuildArchiver) xcodeIPAExport(opts xcodeIPAExportOpts) (xcodeIPAExp
 		if err := v1fileutil.WriteStringToFile(exportOptionsPath, opts.CustomExportOptionsPlistContent); err != nil {
 			return out, fmt.Errorf("failed to write export options to file, error: %s", err)
 		}
+
+		s.printCustomExportOptionsDiff(opts)
 	} else {
 		s.logger.Printf("No custom export options content provided, generating export options...")
 
-		archiveExportMethod := opts.Archive.Application.ProvisioningProfile.ExportType
-
-		exportMethod, err := determineExportMethod(opts.ExportMethod, archiveExportMethod, s.logger)
-		if err != nil {
-			return out, err
-		}
-
-		s.logger.TPrintf("Opening Xcode project at path: %s.", opts.ProjectPath)
-
-		xcodeProj, scheme, configuration, err := OpenArchivableProject(opts.ProjectPath, opts.Scheme, opts.Configuration)
-		if err != nil {
-			return out, fmt.Errorf("failed to open project: %s: %s", opts.ProjectPath, err)
-		}
-
-		archiveCodeSignIsXcodeManaged := opts.Archive.IsXcodeManaged()
-		signingStyle := exportoptions.SigningStyleManual
-		if opts.XcodeAuthOptions != nil {
-			signingStyle = exportoptions.SigningStyleAutomatic
-		}
-
-		generator := exportoptionsgenerator.New(xcodeProj, scheme, configuration, s.logger)
-		exportOptions, err := generator.GenerateApplicationExportOptions(exportMethod, opts.ICloudContainerEnvironment, opts.ExportDevelopmentTeam,
-			opts.UploadBitcode, opts.CompileBitcode, archiveCodeSignIsXcodeManaged, signingStyle, int64(opts.XcodeMajorVersion), opts.TestFlightInternalTestingOnly)
+		exportOptions, teamID, err := s.generateExportOptions(opts)
 		if err != nil {
 			return out, err
 		}
-
+		out.TeamID = teamID
+		out.ICloudContainerEnvironment = iCloudContainerEnvironmentOf(exportOptions)
+		out.SigningReport = s.reportExportSigning(opts.Archive, exportOptions, teamID)
 		s.logger.Println()
 		s.logger.Printf("generated export options content:")
 		s.logger.Println()
@@ -1036,7 +2320,7 @@ func (s XcodebuildArchiver) xcodeIPAExport(opts xcodeIPAExportOpts) (xcodeIPAExp
 
 	s.logger.Println()
 	s.logger.Infof("Exporting IPA from the archive...")
-	exportArchiveLog, exportErr := runIPAExportCommand(s.xcodeCommandRunner, s.logFormatter, exportCmd, s.logger)
+	exportArchiveLog, exportErr := s.runner().ExportArchive(append(exportCmd.CommandArgs(), toolchainOptions(opts.Toolchain)...))
 	out.XcodebuildExportArchiveLog = exportArchiveLog
 	if exportErr != nil {
 		s.logger.Println()
@@ -1044,7 +2328,7 @@ func (s XcodebuildArchiver) xcodeIPAExport(opts xcodeIPAExportOpts) (xcodeIPAExp
 		if !isRawLogOutput {
 			s.logger.Warnf(`If you can't find the reason of the error in the log, please check the %s
 The log file will be stored in $BITRISE_DEPLOY_DIR, and its full path
-will be available in the $%s environment variable`, xcodebuildExportArchiveLogFilename, xcodebuildExportArchiveLogPathEnvKey)
+will be available in the $%s environment variable`, xcodebuildExportArchiveLogFilename, s.outputEnvKey(xcodebuildExportArchiveLogPathEnvKey))
 		}
 
 		// xcdistributionlogs
@@ -1063,14 +2347,17 @@ will be available in the $%s environment variable`, xcodebuildExportArchiveLogFi
 			if !isRawLogOutput {
 				s.logger.Warnf(`Also please check the xcdistributionlogs
 The logs directory is stored in $BITRISE_DEPLOY_DIR, and its full path
-is available in the $BITRISE_IDEDISTRIBUTION_LOGS_PATH environment variable`)
+is available in the $%s environment variable`, s.outputEnvKey(bitriseIDEDistributionLogsPthEnvKey))
 			} else {
 				s.logger.Warnf(`If you can't find the reason of the error in the log, please check the xcdistributionlogs
 The logs directory is stored in $BITRISE_DEPLOY_DIR, and its full path
-is available in the $BITRISE_IDEDISTRIBUTION_LOGS_PATH environment variable`)
+is available in the $%s environment variable`, s.outputEnvKey(bitriseIDEDistributionLogsPthEnvKey))
 			}
 		}
 
+		if isKeychainLockedFailure(exportArchiveLog) {
+			exportErr = keychainLockedError{err: exportErr}
+		}
 		return out, fmt.Errorf("failed to export IPA: %w", exportErr)
 	}
 
@@ -135,3 +135,10 @@ func (r MockEnvRepository) Set(key, value string) error {
 func (r MockEnvRepository) Get(key string) string {
 	return r.envs[key]
 }
+
+func TestExportOpts_exportRawLogs(t *testing.T) {
+	require.True(t, ExportOpts{ExportRawLogAlways: true}.exportRawLogs())
+	require.False(t, ExportOpts{}.exportRawLogs())
+	require.True(t, ExportOpts{RunFailed: true}.exportRawLogs())
+	require.True(t, ExportOpts{XcodebuildExitCode: 65}.exportRawLogs())
+}
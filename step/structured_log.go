@@ -0,0 +1,198 @@
+package step
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+)
+
+const (
+	xcodebuildArchiveStructuredLogPathEnvKey       = "BITRISE_XCODEBUILD_ARCHIVE_STRUCTURED_LOG_PATH"
+	xcodebuildExportArchiveStructuredLogPathEnvKey = "BITRISE_XCODEBUILD_EXPORT_ARCHIVE_STRUCTURED_LOG_PATH"
+	xcodebuildArchiveStructuredLogFilename         = "xcodebuild-archive.jsonl"
+	xcodebuildExportArchiveStructuredLogFilename   = "xcodebuild-export-archive.jsonl"
+
+	streamStdout = "stdout"
+	streamStderr = "stderr"
+
+	logLevelError   = "error"
+	logLevelWarning = "warning"
+	logLevelNote    = "note"
+	logLevelInfo    = "info"
+
+	structuredLogTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+)
+
+var noteLinePattern = regexp.MustCompile(`(^|:\s*)note:\s`)
+
+// StructuredLogLine is a line of the xcodebuild output tagged with its stream, time and log level.
+type StructuredLogLine struct {
+	Time   string `json:"time"`
+	Stream string `json:"stream"`
+	Level  string `json:"level"`
+	Line   string `json:"line"`
+}
+
+// logLevel classifies the xcodebuild output line by the compiler diagnostic prefixes.
+func logLevel(line string) string {
+	switch {
+	case errorLinePattern.MatchString(line):
+		return logLevelError
+	case buildWarningPattern.MatchString(line):
+		return logLevelWarning
+	case noteLinePattern.MatchString(line):
+		return logLevelNote
+	default:
+		return logLevelInfo
+	}
+}
+
+// StructuredLogRecorder records the stdout and stderr lines of the xcodebuild commands separately,
+// a nil StructuredLogRecorder records nothing.
+type StructuredLogRecorder struct {
+	mu      sync.Mutex
+	lines   []StructuredLogLine
+	partial map[string]*bytes.Buffer
+	now     func() time.Time
+}
+
+// NewStructuredLogRecorder ...
+func NewStructuredLogRecorder() *StructuredLogRecorder {
+	return &StructuredLogRecorder{partial: map[string]*bytes.Buffer{}, now: time.Now}
+}
+
+// Start drops the recorded lines, so the next Take returns the lines of the commands run after Start.
+func (r *StructuredLogRecorder) Start() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lines = nil
+	r.partial = map[string]*bytes.Buffer{}
+}
+
+// Take returns the lines recorded since Start, including the unterminated last line of the streams.
+func (r *StructuredLogRecorder) Take() []StructuredLogLine {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stream := range []string{streamStdout, streamStderr} {
+		if buf := r.partial[stream]; buf != nil && buf.Len() > 0 {
+			r.add(stream, buf.String())
+			buf.Reset()
+		}
+	}
+
+	lines := r.lines
+	r.lines = nil
+	return lines
+}
+
+func (r *StructuredLogRecorder) write(stream string, p []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	buf := r.partial[stream]
+	if buf == nil {
+		buf = &bytes.Buffer{}
+		r.partial[stream] = buf
+	}
+	buf.Write(p)
+
+	for {
+		i := bytes.IndexByte(buf.Bytes(), '\n')
+		if i < 0 {
+			return
+		}
+		line := string(buf.Next(i + 1))
+		r.add(stream, strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+	}
+}
+
+func (r *StructuredLogRecorder) add(stream, line string) {
+	r.lines = append(r.lines, StructuredLogLine{
+		Time:   r.now().Format(structuredLogTimeFormat),
+		Stream: stream,
+		Level:  logLevel(line),
+		Line:   line,
+	})
+}
+
+type structuredLogWriter struct {
+	writer   io.Writer
+	stream   string
+	recorder *StructuredLogRecorder
+}
+
+func (w structuredLogWriter) Write(p []byte) (int, error) {
+	w.recorder.write(w.stream, p)
+	return w.writer.Write(p)
+}
+
+// structuredLogFactory is a command.Factory recording the output of the created xcodebuild commands.
+type structuredLogFactory struct {
+	command.Factory
+
+	recorder *StructuredLogRecorder
+}
+
+// NewStructuredLogFactory wraps the command factory used by the xcodebuild runners, a nil recorder leaves the factory as is.
+func NewStructuredLogFactory(factory command.Factory, recorder *StructuredLogRecorder) command.Factory {
+	if recorder == nil {
+		return factory
+	}
+	return structuredLogFactory{Factory: factory, recorder: recorder}
+}
+
+// Create ...
+func (f structuredLogFactory) Create(name string, args []string, opts *command.Opts) command.Command {
+	if name != "xcodebuild" || opts == nil {
+		return f.Factory.Create(name, args, opts)
+	}
+
+	recordedOpts := *opts
+	if opts.Stdout != nil {
+		recordedOpts.Stdout = structuredLogWriter{writer: opts.Stdout, stream: streamStdout, recorder: f.recorder}
+	}
+	if opts.Stderr != nil {
+		recordedOpts.Stderr = structuredLogWriter{writer: opts.Stderr, stream: streamStderr, recorder: f.recorder}
+	}
+	return f.Factory.Create(name, args, &recordedOpts)
+}
+
+// structuredLogContent encodes the lines as JSON Lines.
+func structuredLogContent(lines []StructuredLogLine) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, line := range lines {
+		if err := encoder.Encode(line); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+// exportStructuredLog writes the structured xcodebuild log, a failure is reported as a warning only.
+func (s XcodebuildArchiver) exportStructuredLog(lines []StructuredLogLine, pth, envKey string) {
+	content, err := structuredLogContent(lines)
+	if err != nil {
+		s.logger.Warnf("Failed to encode the structured xcodebuild log: %s", err)
+		return
+	}
+	if err := ExportOutputFileContent(s.cmdFactory, s.outputEnvKeys, content, pth, envKey); err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", s.outputEnvKey(envKey), err)
+		return
+	}
+	s.logger.Donef("The structured xcodebuild log path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(envKey), pth)
+}
@@ -0,0 +1,74 @@
+package step
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_logLevel(t *testing.T) {
+	require.Equal(t, logLevelError, logLevel("/src/App/View.swift:12:5: error: cannot find 'foo' in scope"))
+	require.Equal(t, logLevelError, logLevel("error: Signing for \"App\" requires a development team."))
+	require.Equal(t, logLevelWarning, logLevel("/src/App/View.swift:3:1: warning: 'UIWebView' is deprecated"))
+	require.Equal(t, logLevelNote, logLevel("/src/App/View.swift:3:1: note: use 'WKWebView' instead"))
+	require.Equal(t, logLevelInfo, logLevel("CompileSwift normal arm64 /src/App/View.swift"))
+	require.Equal(t, logLevelInfo, logLevel("** ARCHIVE SUCCEEDED **"))
+}
+
+func TestStructuredLogRecorder(t *testing.T) {
+	recorder := NewStructuredLogRecorder()
+	recorder.now = func() time.Time { return time.Date(2024, 5, 6, 7, 8, 9, 123000000, time.UTC) }
+
+	var out bytes.Buffer
+	stdout := structuredLogWriter{writer: &out, stream: streamStdout, recorder: recorder}
+	stderr := structuredLogWriter{writer: &out, stream: streamStderr, recorder: recorder}
+
+	recorder.Start()
+	_, err := stdout.Write([]byte("Build settings from command line:\n    SDKROOT = iphoneos\n** ARCH"))
+	require.NoError(t, err)
+	_, err = stderr.Write([]byte("warning: Run script build phase will be run during every build\r\n"))
+	require.NoError(t, err)
+	_, err = stdout.Write([]byte("IVE SUCCEEDED **"))
+	require.NoError(t, err)
+
+	// The output is passed through unchanged
+	require.Equal(t, "Build settings from command line:\n    SDKROOT = iphoneos\n** ARCHwarning: Run script build phase will be run during every build\r\nIVE SUCCEEDED **", out.String())
+
+	lines := recorder.Take()
+	require.Equal(t, []StructuredLogLine{
+		{Time: "2024-05-06T07:08:09.123Z", Stream: streamStdout, Level: logLevelInfo, Line: "Build settings from command line:"},
+		{Time: "2024-05-06T07:08:09.123Z", Stream: streamStdout, Level: logLevelInfo, Line: "    SDKROOT = iphoneos"},
+		{Time: "2024-05-06T07:08:09.123Z", Stream: streamStderr, Level: logLevelWarning, Line: "warning: Run script build phase will be run during every build"},
+		{Time: "2024-05-06T07:08:09.123Z", Stream: streamStdout, Level: logLevelInfo, Line: "** ARCHIVE SUCCEEDED **"},
+	}, lines)
+	require.Empty(t, recorder.Take())
+
+	// Start drops the lines of the previous commands
+	_, err = stdout.Write([]byte("previous command\n"))
+	require.NoError(t, err)
+	recorder.Start()
+	_, err = stderr.Write([]byte("error: export failed\n"))
+	require.NoError(t, err)
+	require.Equal(t, []StructuredLogLine{
+		{Time: "2024-05-06T07:08:09.123Z", Stream: streamStderr, Level: logLevelError, Line: "error: export failed"},
+	}, recorder.Take())
+}
+
+func TestStructuredLogRecorder_nil(t *testing.T) {
+	var recorder *StructuredLogRecorder
+	recorder.Start()
+	require.Nil(t, recorder.Take())
+}
+
+func Test_structuredLogContent(t *testing.T) {
+	content, err := structuredLogContent([]StructuredLogLine{
+		{Time: "2024-05-06T07:08:09.123Z", Stream: streamStdout, Level: logLevelInfo, Line: "** ARCHIVE SUCCEEDED **"},
+		{Time: "2024-05-06T07:08:10.456Z", Stream: streamStderr, Level: logLevelError, Line: `error: "App" failed`},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `{"time":"2024-05-06T07:08:09.123Z","stream":"stdout","level":"info","line":"** ARCHIVE SUCCEEDED **"}
+{"time":"2024-05-06T07:08:10.456Z","stream":"stderr","level":"error","line":"error: \"App\" failed"}
+`, content)
+}
@@ -0,0 +1,206 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	stepcache "github.com/bitrise-io/go-steputils/cache"
+	"github.com/bitrise-io/go-utils/sliceutil"
+	"github.com/bitrise-io/go-utils/stringutil"
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+	cache "github.com/bitrise-io/go-xcode/v2/xcodecache"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcworkspace"
+)
+
+const (
+	swiftPackagesCacheLevel = "swift_packages"
+
+	clonedSourcePackagesDirPathOption = "-clonedSourcePackagesDirPath"
+	sourcePackagesDirName             = "SourcePackages"
+	swiftPackagesManifestDB           = "manifest.db"
+)
+
+// swiftPackagesPathOptions returns the xcodebuild options checking out the Swift packages into the given dir.
+func swiftPackagesPathOptions(swiftPackagesPath string, additionalOptions []string) ([]string, error) {
+	if sliceutil.IsStringInSlice(clonedSourcePackagesDirPathOption, additionalOptions) {
+		return nil, fmt.Errorf("`%s` option found in XcodebuildOptions (`xcodebuild_options`), please clear Swift packages path (`swift_packages_path`) input as only one can be set", clonedSourcePackagesDirPathOption)
+	}
+
+	pth, err := filepath.Abs(swiftPackagesPath)
+	if err != nil {
+		return nil, err
+	}
+	return []string{clonedSourcePackagesDirPathOption, pth}, nil
+}
+
+// optionValue returns the value of the given xcodebuild option, empty if the option is not set.
+func optionValue(options []string, option string) string {
+	for i, opt := range options {
+		if opt == option && i+1 < len(options) {
+			return options[i+1]
+		}
+	}
+	return ""
+}
+
+// projectSwiftPackagesPath returns the Swift packages checkout dir of the project: the -clonedSourcePackagesDirPath option's value if set,
+// otherwise the SourcePackages dir of the -derivedDataPath option's dir or of the project's default DerivedData dir.
+func projectSwiftPackagesPath(projectPath string, additionalOptions []string) (string, error) {
+	if pth := optionValue(additionalOptions, clonedSourcePackagesDirPathOption); pth != "" {
+		return filepath.Abs(pth)
+	}
+	if pth := optionValue(additionalOptions, derivedDataPathOption); pth != "" {
+		absPth, err := filepath.Abs(pth)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(absPth, sourcePackagesDirName), nil
+	}
+	return cache.NewSwiftPackageCache().SwiftPackagesPath(projectPath)
+}
+
+// workspaceProjectsSwiftPackagesPaths returns the existing Swift packages checkout dirs of the workspace's projects' default DerivedData dirs,
+// the packages of a project built on its own (for example by a previous Step) are checked out there, not into the workspace's DerivedData dir.
+func workspaceProjectsSwiftPackagesPaths(workspacePath string) ([]string, error) {
+	workspace, err := xcworkspace.Open(workspacePath)
+	if err != nil {
+		return nil, err
+	}
+	projectPaths, err := workspace.ProjectFileLocations()
+	if err != nil {
+		return nil, err
+	}
+
+	var pths []string
+	for _, projectPath := range projectPaths {
+		pth, err := cache.NewSwiftPackageCache().SwiftPackagesPath(projectPath)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(pth); err == nil {
+			pths = append(pths, pth)
+		}
+	}
+	return pths, nil
+}
+
+// collectSwiftPackagesPaths returns the Swift packages checkout dirs to cache: the project's dir,
+// and for a workspace without a custom checkout dir, the dirs of the workspace's projects too.
+func collectSwiftPackagesPaths(projectPath string, additionalOptions []string, logger log.Logger) ([]string, error) {
+	mainPath, err := projectSwiftPackagesPath(projectPath, additionalOptions)
+	if err != nil {
+		return nil, err
+	}
+	pths := []string{mainPath}
+	if filepath.Ext(projectPath) != ".xcworkspace" || optionValue(additionalOptions, clonedSourcePackagesDirPathOption) != "" {
+		return pths, nil
+	}
+
+	projectPths, err := workspaceProjectsSwiftPackagesPaths(projectPath)
+	if err != nil {
+		logger.Warnf("Failed to find the Swift packages of the workspace's projects: %s", err)
+		return pths, nil
+	}
+	for _, pth := range projectPths {
+		if !sliceutil.IsStringInSlice(pth, pths) {
+			pths = append(pths, pth)
+		}
+	}
+	return pths, nil
+}
+
+// collectSwiftPackages marks the Swift packages checkout dirs of the project to be added to the cache.
+func collectSwiftPackages(projectPath string, additionalOptions []string, logger log.Logger) error {
+	pths, err := collectSwiftPackagesPaths(projectPath, additionalOptions, logger)
+	if err != nil {
+		return fmt.Errorf("failed to get Swift packages path, error %s", err)
+	}
+
+	c := stepcache.New()
+	for _, pth := range pths {
+		logger.Debugf("Collecting Swift packages for caching: %s", pth)
+		c.IncludePath(pth)
+		// Excluding manifest.db results in a stable cache, as this file is modified in every build.
+		c.ExcludePath("!" + filepath.Join(pth, swiftPackagesManifestDB))
+	}
+	if err := c.Commit(); err != nil {
+		return fmt.Errorf("failed to commit cache, error: %s", err)
+	}
+	return nil
+}
+
+type swiftPackagesPrefetchOpts struct {
+	ProjectPath       string
+	Scheme            string
+	Configuration     string
+	AdditionalOptions []string
+	CacheLevel        string
+}
+
+func resolvePackagesCommandArgs(projectPath, scheme, configuration string, customOptions []string) []string {
+	var args []string
+	if filepath.Ext(projectPath) == ".xcworkspace" {
+		args = append(args, "-workspace", projectPath)
+	} else {
+		args = append(args, "-project", projectPath)
+	}
+	if scheme != "" {
+		args = append(args, "-scheme", scheme)
+	}
+	if configuration != "" {
+		args = append(args, "-configuration", configuration)
+	}
+	args = append(args, "-resolvePackageDependencies")
+	args = append(args, customOptions...)
+
+	return args
+}
+
+// prefetchSwiftPackages resolves the Swift package dependencies in a dedicated xcodebuild invocation,
+// so that resolution failures are reported separately from the archive action.
+func prefetchSwiftPackages(cmdFactory command.Factory, opts swiftPackagesPrefetchOpts, logger log.Logger) error {
+	swiftPackagesPath, err := projectSwiftPackagesPath(opts.ProjectPath, opts.AdditionalOptions)
+	if err != nil {
+		return fmt.Errorf("failed to get Swift Packages path, error: %s", err)
+	}
+
+	args := resolvePackagesCommandArgs(opts.ProjectPath, opts.Scheme, opts.Configuration, opts.AdditionalOptions)
+	out, err := runResolvePackagesCommand(cmdFactory, args, logger)
+	if err != nil && strings.Contains(out, cache.SwiftPackagesStateInvalid) {
+		logger.Warnf("Resolving Swift package dependencies failed, swift packages cache is in an invalid state, error: %s", err)
+		if err := os.RemoveAll(swiftPackagesPath); err != nil {
+			return fmt.Errorf("failed to remove invalid Swift package caches, error: %s", err)
+		}
+
+		logger.Printf("Retrying with a clean Swift package cache")
+		out, err = runResolvePackagesCommand(cmdFactory, args, logger)
+	}
+	if err != nil {
+		logger.Println()
+		logger.Infof("Last lines of the package resolution log:")
+		logger.Printf("%s", stringutil.LastNLines(out, 20))
+		logger.Println()
+
+		return fmt.Errorf("failed to resolve Swift package dependencies: %w", err)
+	}
+
+	logger.Donef("Swift package dependencies resolved")
+
+	if opts.CacheLevel == swiftPackagesCacheLevel {
+		if err := collectSwiftPackages(opts.ProjectPath, opts.AdditionalOptions, logger); err != nil {
+			logger.Warnf("Failed to mark swift packages for caching, error: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func runResolvePackagesCommand(cmdFactory command.Factory, args []string, logger log.Logger) (string, error) {
+	cmd := cmdFactory.Create("xcodebuild", args, nil)
+	logger.TPrintf("$ %s", cmd.PrintableCommandArgs())
+
+	return cmd.RunAndReturnTrimmedCombinedOutput()
+}
@@ -0,0 +1,117 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	cache "github.com/bitrise-io/go-xcode/v2/xcodecache"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_resolvePackagesCommandArgs(t *testing.T) {
+	tests := []struct {
+		name          string
+		projectPath   string
+		scheme        string
+		configuration string
+		customOptions []string
+		want          []string
+	}{
+		{
+			name:        "project",
+			projectPath: "/tmp/Sample.xcodeproj",
+			scheme:      "Sample",
+			want:        []string{"-project", "/tmp/Sample.xcodeproj", "-scheme", "Sample", "-resolvePackageDependencies"},
+		},
+		{
+			name:          "workspace with configuration and custom options",
+			projectPath:   "/tmp/Sample.xcworkspace",
+			scheme:        "Sample",
+			configuration: "Release",
+			customOptions: []string{"-scmProvider", "system"},
+			want:          []string{"-workspace", "/tmp/Sample.xcworkspace", "-scheme", "Sample", "-configuration", "Release", "-resolvePackageDependencies", "-scmProvider", "system"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolvePackagesCommandArgs(tt.projectPath, tt.scheme, tt.configuration, tt.customOptions)
+
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_swiftPackagesPathOptions(t *testing.T) {
+	options, err := swiftPackagesPathOptions("/tmp/packages", []string{"-scmProvider", "system"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"-clonedSourcePackagesDirPath", "/tmp/packages"}, options)
+
+	_, err = swiftPackagesPathOptions("/tmp/packages", []string{"-clonedSourcePackagesDirPath", "/tmp/other"})
+	require.Error(t, err)
+}
+
+func Test_projectSwiftPackagesPath(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	projectPath := "/tmp/Sample.xcodeproj"
+	defaultPath, err := cache.NewSwiftPackageCache().SwiftPackagesPath(projectPath)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name              string
+		additionalOptions []string
+		want              string
+	}{
+		{name: "default DerivedData", want: defaultPath},
+		{name: "custom DerivedData", additionalOptions: []string{"-derivedDataPath", "/tmp/DerivedData"}, want: "/tmp/DerivedData/SourcePackages"},
+		{name: "custom checkout dir", additionalOptions: []string{"-derivedDataPath", "/tmp/DerivedData", "-clonedSourcePackagesDirPath", "/tmp/packages"}, want: "/tmp/packages"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := projectSwiftPackagesPath(projectPath, tt.additionalOptions)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_collectSwiftPackagesPaths(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repoDir := t.TempDir()
+	workspacePath := filepath.Join(repoDir, "Sample.xcworkspace")
+	require.NoError(t, os.MkdirAll(workspacePath, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(workspacePath, "contents.xcworkspacedata"), []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Workspace version = "1.0">
+   <FileRef location = "group:App/App.xcodeproj"></FileRef>
+   <FileRef location = "group:Feature/Feature.xcodeproj"></FileRef>
+   <FileRef location = "group:Tools/Tools.xcodeproj"></FileRef>
+</Workspace>
+`), 0644))
+
+	workspacePackages, err := cache.NewSwiftPackageCache().SwiftPackagesPath(workspacePath)
+	require.NoError(t, err)
+	appPackages, err := cache.NewSwiftPackageCache().SwiftPackagesPath(filepath.Join(repoDir, "App", "App.xcodeproj"))
+	require.NoError(t, err)
+	featurePackages, err := cache.NewSwiftPackageCache().SwiftPackagesPath(filepath.Join(repoDir, "Feature", "Feature.xcodeproj"))
+	require.NoError(t, err)
+	// The Tools project was never built on its own, it has no checkout dir
+	require.NoError(t, os.MkdirAll(appPackages, 0755))
+	require.NoError(t, os.MkdirAll(featurePackages, 0755))
+
+	logger := log.NewLogger()
+
+	pths, err := collectSwiftPackagesPaths(workspacePath, nil, logger)
+	require.NoError(t, err)
+	require.Equal(t, []string{workspacePackages, appPackages, featurePackages}, pths)
+
+	// A custom checkout dir is shared by every project
+	pths, err = collectSwiftPackagesPaths(workspacePath, []string{"-clonedSourcePackagesDirPath", "/tmp/packages"}, logger)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/tmp/packages"}, pths)
+
+	// A project has a single checkout dir
+	pths, err = collectSwiftPackagesPaths(filepath.Join(repoDir, "App", "App.xcodeproj"), nil, logger)
+	require.NoError(t, err)
+	require.Equal(t, []string{appPackages}, pths)
+}
@@ -0,0 +1,167 @@
+package step
+
+import (
+	archivezip "archive/zip"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	v1command "github.com/bitrise-io/go-utils/command"
+	v1pathutil "github.com/bitrise-io/go-utils/pathutil"
+)
+
+const (
+	bitriseSymbolsPthEnvKey = "BITRISE_SYMBOLS_PATH"
+
+	symbolsDirName   = "Symbols"
+	symbolsExtension = ".symbols"
+)
+
+// findArchiveSymbols lists the .symbols files of the archive's Symbols dir.
+func findArchiveSymbols(archivePath string) ([]string, error) {
+	dir := filepath.Join(archivePath, symbolsDirName)
+	var pths []string
+	err := filepath.WalkDir(dir, func(pth string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && pth == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !entry.IsDir() && filepath.Ext(pth) == symbolsExtension {
+			pths = append(pths, pth)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for symbols in %s: %w", dir, err)
+	}
+	sort.Strings(pths)
+	return pths, nil
+}
+
+// extractIPASymbols extracts the .symbols files of the ipa's Symbols dir (App Store exports with the uploadSymbols export option)
+// into the dir, the files already in the dir are kept. Returns the names of the extracted files.
+func extractIPASymbols(ipaPath, dir string) ([]string, error) {
+	reader, err := archivezip.OpenReader(ipaPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ipa: %w", err)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	var names []string
+	for _, file := range reader.File {
+		if path.Dir(file.Name) != symbolsDirName || path.Ext(file.Name) != symbolsExtension {
+			continue
+		}
+		pth := filepath.Join(dir, path.Base(file.Name))
+		if _, err := os.Stat(pth); err == nil {
+			continue
+		}
+
+		content, err := readZipFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+		if err := os.WriteFile(pth, content, 0644); err != nil {
+			return nil, err
+		}
+		names = append(names, path.Base(file.Name))
+	}
+	return names, nil
+}
+
+// emptySymbolsFiles lists the empty .symbols files of the dir, these can't be used for symbolication.
+func emptySymbolsFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var empty []string
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		if info.Size() == 0 {
+			empty = append(empty, entry.Name())
+		}
+	}
+	return empty, nil
+}
+
+// exportSymbols collects the symbols of the archive's and the App Store ipa's Symbols dir into the dSYM output dir,
+// App Store Connect uses these to symbolicate the crash reports. Warns if the App Store export has no symbols,
+// or the archive has no app dSYM the symbols are generated from.
+func (s XcodebuildArchiver) exportSymbols(opts ExportOpts, ipaPath string, appStoreIPA bool) error {
+	if appStoreIPA {
+		appDSYMPaths, _, err := opts.Archive.FindDSYMs()
+		if err != nil {
+			return err
+		}
+		if len(appDSYMPaths) == 0 {
+			s.logger.Warnf("The archive has no app dSYM, the symbols of the app can't be generated and the App Store Connect crash reports can't be symbolicated.")
+			s.logger.Warnf("Make sure the app is built with DEBUG_INFORMATION_FORMAT = dwarf-with-dsym for the archive's configuration.")
+		}
+	}
+
+	archiveSymbols, err := findArchiveSymbols(opts.Archive.Path)
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := v1pathutil.NormalizedOSTempDirPath("__symbols__")
+	if err != nil {
+		return fmt.Errorf("failed to create tmp dir, error: %s", err)
+	}
+	symbolsDir := filepath.Join(tmpDir, symbolsDirName)
+	if err := os.MkdirAll(symbolsDir, 0755); err != nil {
+		return err
+	}
+	for _, pth := range archiveSymbols {
+		if err := v1command.CopyFile(pth, filepath.Join(symbolsDir, filepath.Base(pth))); err != nil {
+			return fmt.Errorf("failed to copy (%s) to directory (%s): %s", pth, symbolsDir, err)
+		}
+	}
+
+	var ipaSymbols []string
+	if ipaPath != "" {
+		if ipaSymbols, err = extractIPASymbols(ipaPath, symbolsDir); err != nil {
+			return err
+		}
+	}
+
+	if len(archiveSymbols) == 0 && len(ipaSymbols) == 0 {
+		if appStoreIPA {
+			s.logger.Warnf("The App Store ipa has no Symbols folder: symbol generation is disabled (the Upload symbols input or the uploadSymbols export option is set to no),")
+			s.logger.Warnf("the App Store Connect crash reports of this build can't be symbolicated.")
+		}
+		return nil
+	}
+	s.logger.Printf("Found %d symbols files in the archive and %d in the ipa.", len(archiveSymbols), len(ipaSymbols))
+
+	empty, err := emptySymbolsFiles(symbolsDir)
+	if err != nil {
+		return err
+	}
+	for _, name := range empty {
+		s.logger.Warnf("Empty symbols file, it can't be used for symbolication: %s", name)
+	}
+
+	dsymOutputDir, err := outputDirForArtifact(opts.OutputDir, opts.OutputLayout, outputArtifactDSYM)
+	if err != nil {
+		return err
+	}
+	symbolsZipPath := filepath.Join(dsymOutputDir, opts.ArtifactName+"."+symbolsDirName+artifactCompressionExtension(opts.Compression))
+	if err := ExportOutputDirAsArchive(s.cmdFactory, s.outputEnvKeys, symbolsDir, symbolsZipPath, bitriseSymbolsPthEnvKey, opts.Compression, opts.CompressionLevel, s.logger); err != nil {
+		return fmt.Errorf("failed to export %s, error: %s", s.outputEnvKey(bitriseSymbolsPthEnvKey), err)
+	}
+	s.logger.Donef("The symbols zip path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseSymbolsPthEnvKey), symbolsZipPath)
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package step
+
+import (
+	archivezip "archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_findArchiveSymbols(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "Sample.xcarchive")
+	for _, pth := range []string{
+		filepath.Join(archivePath, "Symbols", "B2C3.symbols"),
+		filepath.Join(archivePath, "Symbols", "A1B2.symbols"),
+		filepath.Join(archivePath, "Symbols", "README"),
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(pth), 0755))
+		require.NoError(t, os.WriteFile(pth, []byte("symbols"), 0644))
+	}
+
+	symbols, err := findArchiveSymbols(archivePath)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(archivePath, "Symbols", "A1B2.symbols"),
+		filepath.Join(archivePath, "Symbols", "B2C3.symbols"),
+	}, symbols)
+
+	symbols, err = findArchiveSymbols(t.TempDir())
+	require.NoError(t, err)
+	require.Nil(t, symbols)
+}
+
+func Test_extractIPASymbols(t *testing.T) {
+	ipaPath := filepath.Join(t.TempDir(), "Sample.ipa")
+	ipa, err := os.Create(ipaPath)
+	require.NoError(t, err)
+	writer := archivezip.NewWriter(ipa)
+	for name, content := range map[string]string{
+		"Payload/Sample.app/Sample":               "binary",
+		"Symbols/A1B2.symbols":                    "app symbols",
+		"Symbols/C3D4.symbols":                    "",
+		"Payload/Sample.app/Symbols/E5F6.symbols": "nested",
+	} {
+		w, err := writer.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	require.NoError(t, ipa.Close())
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "C3D4.symbols"), []byte("archive symbols"), 0644))
+
+	names, err := extractIPASymbols(ipaPath, dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"A1B2.symbols"}, names)
+
+	content, err := os.ReadFile(filepath.Join(dir, "A1B2.symbols"))
+	require.NoError(t, err)
+	require.Equal(t, "app symbols", string(content))
+	content, err = os.ReadFile(filepath.Join(dir, "C3D4.symbols"))
+	require.NoError(t, err)
+	require.Equal(t, "archive symbols", string(content), "the symbols already collected are kept")
+}
+
+func Test_emptySymbolsFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "A1B2.symbols"), []byte("symbols"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "C3D4.symbols"), nil, 0644))
+
+	empty, err := emptySymbolsFiles(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"C3D4.symbols"}, empty)
+}
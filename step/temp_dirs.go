@@ -0,0 +1,31 @@
+package step
+
+import "os"
+
+const (
+	bitriseDebugArchiveTempDirEnvKey = "BITRISE_DEBUG_ARCHIVE_TEMP_DIR"
+	bitriseDebugExportTempDirEnvKey  = "BITRISE_DEBUG_EXPORT_TEMP_DIR"
+)
+
+// handleFailedTempDir removes the temp dir of a failed archive or export, as nothing refers to its content,
+// or if keep is set, keeps it for debugging and exports its path.
+func (s XcodebuildArchiver) handleFailedTempDir(dir string, keep bool, envKey string) {
+	if dir == "" {
+		return
+	}
+
+	if !keep {
+		if err := os.RemoveAll(dir); err != nil {
+			s.logger.Warnf("Failed to remove the temp dir (%s): %s", dir, err)
+		}
+		return
+	}
+
+	s.logger.Println()
+	s.logger.Warnf("Keeping the temp dir of the failed action for debugging: %s", dir)
+	if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, envKey, dir); err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", s.outputEnvKey(envKey), err)
+		return
+	}
+	s.logger.Donef("The temp dir path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(envKey), dir)
+}
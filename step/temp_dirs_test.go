@@ -0,0 +1,23 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXcodebuildArchiver_handleFailedTempDir(t *testing.T) {
+	archiver := XcodebuildArchiver{logger: log.NewLogger()}
+
+	dir := filepath.Join(t.TempDir(), "xcodeIPAExport")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "exported"), 0755))
+
+	archiver.handleFailedTempDir(dir, false, bitriseDebugExportTempDirEnvKey)
+	_, err := os.Stat(dir)
+	require.True(t, os.IsNotExist(err))
+
+	archiver.handleFailedTempDir("", false, bitriseDebugExportTempDirEnvKey)
+}
@@ -0,0 +1,85 @@
+package step
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcscheme"
+)
+
+const testBundleExtension = ".xctest"
+
+// testSupportContent are the XCTest frameworks and libraries embedded into the test hosts, they should not ship in a release build.
+var testSupportContent = map[string]bool{
+	"XCTest.framework":               true,
+	"XCTestCore.framework":           true,
+	"XCTestSupport.framework":        true,
+	"XCUIAutomation.framework":       true,
+	"XCUnit.framework":               true,
+	"XCTAutomationSupport.framework": true,
+	"libXCTestSwiftSupport.dylib":    true,
+	"libXCTestBundleInject.dylib":    true,
+}
+
+// archivedTestTargets returns the test targets of the scheme built by the archive action, when the scheme's build action
+// has the Archive checkbox set for a test target.
+func archivedTestTargets(scheme *xcscheme.Scheme) []string {
+	var names []string
+	for _, entry := range scheme.BuildAction.BuildActionEntries {
+		if entry.BuildForArchiving == "YES" && filepath.Ext(entry.BuildableReference.BuildableName) == testBundleExtension {
+			names = append(names, entry.BuildableReference.BlueprintName)
+		}
+	}
+	return names
+}
+
+func warnArchivedTestTargets(scheme *xcscheme.Scheme, logger log.Logger) {
+	targets := archivedTestTargets(scheme)
+	if len(targets) == 0 {
+		return
+	}
+	logger.Warnf("The scheme builds test targets for archiving (%s), uncheck Archive for them in the scheme's Build action.", strings.Join(targets, ", "))
+}
+
+// findTestContent returns the test bundles and the XCTest frameworks and libraries of the archived products, relative to the products dir.
+func findTestContent(productsDir string) ([]string, error) {
+	var content []string
+	err := filepath.WalkDir(productsDir, func(pth string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if filepath.Ext(pth) != testBundleExtension && !testSupportContent[entry.Name()] {
+			return nil
+		}
+
+		rel, err := filepath.Rel(productsDir, pth)
+		if err != nil {
+			return err
+		}
+		content = append(content, rel)
+		if entry.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for test content in %s: %w", productsDir, err)
+	}
+	return content, nil
+}
+
+// verifyNoTestContent fails if the archived products contain test bundles or XCTest frameworks, which are shipped
+// in the ipa when a test target is archived or a test host's content ends up in the app.
+func verifyNoTestContent(archivePth string) error {
+	content, err := findTestContent(filepath.Join(archivePth, "Products"))
+	if err != nil {
+		return err
+	}
+	if len(content) > 0 {
+		return fmt.Errorf("the archive contains test content: %s", strings.Join(content, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcscheme"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_archivedTestTargets(t *testing.T) {
+	scheme := &xcscheme.Scheme{BuildAction: xcscheme.BuildAction{BuildActionEntries: []xcscheme.BuildActionEntry{
+		{BuildForArchiving: "YES", BuildableReference: xcscheme.BuildableReference{BuildableName: "Sample.app", BlueprintName: "Sample"}},
+		{BuildForArchiving: "YES", BuildableReference: xcscheme.BuildableReference{BuildableName: "SampleTests.xctest", BlueprintName: "SampleTests"}},
+		{BuildForArchiving: "NO", BuildableReference: xcscheme.BuildableReference{BuildableName: "SampleUITests.xctest", BlueprintName: "SampleUITests"}},
+	}}}
+
+	require.Equal(t, []string{"SampleTests"}, archivedTestTargets(scheme))
+}
+
+func Test_verifyNoTestContent(t *testing.T) {
+	archivePth := filepath.Join(t.TempDir(), "Sample.xcarchive")
+	appPath := filepath.Join(archivePth, "Products", "Applications", "Sample.app")
+	require.NoError(t, os.MkdirAll(filepath.Join(appPath, "Frameworks", "Alamofire.framework"), 0755))
+	require.NoError(t, verifyNoTestContent(archivePth))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(appPath, "PlugIns", "SampleTests.xctest", "Frameworks"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(appPath, "Frameworks", "XCTest.framework"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(appPath, "Frameworks", "libXCTestSwiftSupport.dylib"), nil, 0644))
+
+	require.EqualError(t, verifyNoTestContent(archivePth), "the archive contains test content: "+
+		"Applications/Sample.app/Frameworks/XCTest.framework, Applications/Sample.app/Frameworks/libXCTestSwiftSupport.dylib, Applications/Sample.app/PlugIns/SampleTests.xctest")
+}
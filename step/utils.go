@@ -11,6 +11,7 @@ import (
 	"github.com/bitrise-io/go-utils/stringutil"
 	"github.com/bitrise-io/go-utils/v2/log"
 	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/bitrise-io/go-xcode/xcarchive"
 )
 
 func generateAdditionalOptions(platform string, customOptions []string) []string {
@@ -31,6 +32,14 @@ func generateAdditionalOptions(platform string, customOptions []string) []string
 	return options
 }
 
+// toolchainOptions returns the xcodebuild options selecting the given toolchain, nil if no toolchain is set.
+func toolchainOptions(toolchain string) []string {
+	if toolchain == "" {
+		return nil
+	}
+	return []string{"-toolchain", toolchain}
+}
+
 func determineExportMethod(desiredExportMethod string, archiveExportMethod exportoptions.Method, logger log.Logger) (exportoptions.Method, error) {
 	if desiredExportMethod == "auto-detect" {
 		logger.Printf("auto-detect export method specified: using the archive profile's export method: %s", archiveExportMethod)
@@ -46,7 +55,17 @@ func determineExportMethod(desiredExportMethod string, archiveExportMethod expor
 	return exportMethod, nil
 }
 
-func printLastLinesOfXcodebuildLog(logger log.Logger, xcodebuildLog string, isXcodebuildSuccess bool) {
+// archiveTeamID returns the development team the archive's main application was signed with.
+func archiveTeamID(archive xcarchive.IosArchive) string {
+	if teamID := archive.Application.ProvisioningProfile.TeamID; teamID != "" {
+		return teamID
+	}
+
+	teamID, _ := archive.Application.Entitlements.GetString("com.apple.developer.team-identifier")
+	return teamID
+}
+
+func printLastLinesOfXcodebuildLog(logger log.Logger, outputEnvKeys OutputEnvKeys, xcodebuildLog string, isXcodebuildSuccess bool) {
 	const lastLinesMsg = "\nLast lines of the Xcode log:"
 	if isXcodebuildSuccess {
 		logger.Infof(lastLinesMsg)
@@ -65,7 +84,7 @@ func printLastLinesOfXcodebuildLog(logger log.Logger, xcodebuildLog string, isXc
 The log file is stored in $BITRISE_DEPLOY_DIR, and its full path
 is available in the $%s environment variable.
 
-Deploy to Bitrise.io Step can attach the file to your build as an artifact.`, xcodebuildArchiveLogPathEnvKey)))
+Deploy to Bitrise.io Step can attach the file to your build as an artifact.`, outputEnvKeys.key(xcodebuildArchiveLogPathEnvKey))))
 }
 
 func findIDEDistrubutionLogsPath(output string, logger log.Logger) (string, error) {
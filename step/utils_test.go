@@ -4,6 +4,9 @@ import (
 	"testing"
 
 	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/plistutil"
+	"github.com/bitrise-io/go-xcode/profileutil"
+	"github.com/bitrise-io/go-xcode/xcarchive"
 	"github.com/stretchr/testify/require"
 )
 
@@ -41,6 +44,11 @@ func Test_generateAdditionalOptions(t *testing.T) {
 	}
 }
 
+func Test_toolchainOptions(t *testing.T) {
+	require.Nil(t, toolchainOptions(""))
+	require.Equal(t, []string{"-toolchain", "org.swift.59202404101a"}, toolchainOptions("org.swift.59202404101a"))
+}
+
 func Test_findIDEDistrubutionLogsPath(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -74,3 +82,37 @@ func Test_findIDEDistrubutionLogsPath(t *testing.T) {
 		})
 	}
 }
+
+func Test_archiveTeamID(t *testing.T) {
+	tests := []struct {
+		name    string
+		archive xcarchive.IosArchive
+		want    string
+	}{
+		{
+			name: "team from provisioning profile",
+			archive: xcarchive.IosArchive{Application: xcarchive.IosApplication{IosBaseApplication: xcarchive.IosBaseApplication{
+				ProvisioningProfile: profileutil.ProvisioningProfileInfoModel{TeamID: "PROFILETEAM"},
+				Entitlements:        plistutil.PlistData{"com.apple.developer.team-identifier": "ENTITLEMENTTEAM"},
+			}}},
+			want: "PROFILETEAM",
+		},
+		{
+			name: "team from entitlements",
+			archive: xcarchive.IosArchive{Application: xcarchive.IosApplication{IosBaseApplication: xcarchive.IosBaseApplication{
+				Entitlements: plistutil.PlistData{"com.apple.developer.team-identifier": "ENTITLEMENTTEAM"},
+			}}},
+			want: "ENTITLEMENTTEAM",
+		},
+		{
+			name:    "no team",
+			archive: xcarchive.IosArchive{},
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, archiveTeamID(tt.archive))
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package step
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/bitrise-io/go-xcode/profileutil"
+)
+
+// Wildcard provisioning profile policies
+const (
+	wildcardProfilesAllow          = "allow"
+	wildcardProfilesPreferExplicit = "prefer-explicit"
+	wildcardProfilesDeny           = "deny"
+)
+
+func isWildcardProfile(profile profileutil.ProvisioningProfileInfoModel) bool {
+	return strings.HasSuffix(profile.BundleID, "*")
+}
+
+// findExplicitExportProfile returns the explicit provisioning profile of the given team and export method for the bundle ID.
+func findExplicitExportProfile(teamID string, exportMethod exportoptions.Method, bundleID string, profiles []profileutil.ProvisioningProfileInfoModel) (profileutil.ProvisioningProfileInfoModel, bool) {
+	for _, profile := range profiles {
+		if profile.TeamID == teamID && profile.ExportType == exportMethod && profile.BundleID == bundleID {
+			return profile, true
+		}
+	}
+	return profileutil.ProvisioningProfileInfoModel{}, false
+}
+
+// applyWildcardProfilePolicy replaces the wildcard provisioning profiles selected by the export options generator with the explicit profiles
+// of the same team, unless the policy allows wildcard profiles; the deny policy fails if a bundle has no explicit profile.
+func applyWildcardProfilePolicy(policy string, exportMethod exportoptions.Method, mapping map[string]string, profiles []profileutil.ProvisioningProfileInfoModel, logger log.Logger) (map[string]string, error) {
+	updated := map[string]string{}
+	var denied []string
+	for _, bundleID := range sortedKeys(mapping) {
+		updated[bundleID] = mapping[bundleID]
+
+		profile, found := findProfileByNameOrUUID("", mapping[bundleID], profiles)
+		if !found || !isWildcardProfile(profile) {
+			continue
+		}
+
+		if policy != wildcardProfilesAllow {
+			if explicit, ok := findExplicitExportProfile(profile.TeamID, exportMethod, bundleID, profiles); ok {
+				logger.Printf("Using the explicit provisioning profile %s instead of the wildcard profile %s for %s", explicit.Name, profile.Name, bundleID)
+				updated[bundleID] = explicit.Name
+				continue
+			}
+		}
+
+		if policy == wildcardProfilesDeny {
+			denied = append(denied, fmt.Sprintf("%s (%s)", bundleID, profile.Name))
+			continue
+		}
+		logger.Warnf("Wildcard provisioning profile selected for %s: %s (%s)", bundleID, profile.Name, profile.BundleID)
+	}
+
+	if len(denied) > 0 {
+		return nil, fmt.Errorf("wildcard provisioning profiles are denied, but no explicit %s provisioning profile is installed for: %s", exportMethod, strings.Join(denied, ", "))
+	}
+	return updated, nil
+}
@@ -0,0 +1,37 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/exportoptions"
+	"github.com/bitrise-io/go-xcode/profileutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_applyWildcardProfilePolicy(t *testing.T) {
+	profiles := []profileutil.ProvisioningProfileInfoModel{
+		{Name: "Wildcard App Store", UUID: "uuid-1", TeamID: "TEAM", BundleID: "io.bitrise.*", ExportType: exportoptions.MethodAppStore},
+		{Name: "Sample App Store", UUID: "uuid-2", TeamID: "TEAM", BundleID: "io.bitrise.sample", ExportType: exportoptions.MethodAppStore},
+		{Name: "Widget Ad Hoc", UUID: "uuid-3", TeamID: "TEAM", BundleID: "io.bitrise.sample.widget", ExportType: exportoptions.MethodAdHoc},
+	}
+	mapping := map[string]string{
+		"io.bitrise.sample":        "Wildcard App Store",
+		"io.bitrise.sample.widget": "uuid-1",
+	}
+	logger := log.NewLogger()
+
+	got, err := applyWildcardProfilePolicy(wildcardProfilesAllow, exportoptions.MethodAppStore, mapping, profiles, logger)
+	require.NoError(t, err)
+	require.Equal(t, mapping, got)
+
+	got, err = applyWildcardProfilePolicy(wildcardProfilesPreferExplicit, exportoptions.MethodAppStore, mapping, profiles, logger)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"io.bitrise.sample":        "Sample App Store",
+		"io.bitrise.sample.widget": "uuid-1",
+	}, got)
+
+	_, err = applyWildcardProfilePolicy(wildcardProfilesDeny, exportoptions.MethodAppStore, mapping, profiles, logger)
+	require.EqualError(t, err, "wildcard provisioning profiles are denied, but no explicit app-store provisioning profile is installed for: io.bitrise.sample.widget (Wildcard App Store)")
+}
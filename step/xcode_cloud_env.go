@@ -0,0 +1,87 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Environment Variables of Xcode Cloud, exported for the scripts shared between Xcode Cloud and Bitrise,
+// see https://developer.apple.com/documentation/xcode/environment-variable-reference
+const (
+	xcodeCloudArchivePathEnvKey              = "CI_ARCHIVE_PATH"
+	xcodeCloudProductEnvKey                  = "CI_PRODUCT"
+	xcodeCloudSchemeEnvKey                   = "CI_XCODE_SCHEME"
+	xcodeCloudBundleIDEnvKey                 = "CI_BUNDLE_ID"
+	xcodeCloudXcodebuildActionEnvKey         = "CI_XCODEBUILD_ACTION"
+	xcodeCloudAppStoreSignedAppPathEnvKey    = "CI_APP_STORE_SIGNED_APP_PATH"
+	xcodeCloudAdHocSignedAppPathEnvKey       = "CI_AD_HOC_SIGNED_APP_PATH"
+	xcodeCloudDevelopmentSignedAppPathEnvKey = "CI_DEVELOPMENT_SIGNED_APP_PATH"
+	xcodeCloudDeveloperIDSignedAppPathEnvKey = "CI_DEVELOPER_ID_SIGNED_APP_PATH"
+	xcodeCloudArchiveXcodebuildActionValue   = "archive"
+)
+
+// xcodeCloudSignedAppPathEnvKey returns the Xcode Cloud Environment Variable of the signed app directory for the distribution method,
+// Xcode Cloud has no enterprise distribution, so it has no such variable.
+func xcodeCloudSignedAppPathEnvKey(method string) string {
+	switch method {
+	case "app-store", "app-store-connect", "mac-application":
+		return xcodeCloudAppStoreSignedAppPathEnvKey
+	case "ad-hoc", "release-testing":
+		return xcodeCloudAdHocSignedAppPathEnvKey
+	case "development", "debugging":
+		return xcodeCloudDevelopmentSignedAppPathEnvKey
+	case "developer-id":
+		return xcodeCloudDeveloperIDSignedAppPathEnvKey
+	}
+	return ""
+}
+
+type xcodeCloudEnv struct {
+	key   string
+	value string
+}
+
+// xcodeCloudEnvs returns the Xcode Cloud compatible Environment Variables of the Step run, the signed app path is the export directory,
+// which contains the exported ipa or app, like in Xcode Cloud.
+func xcodeCloudEnvs(opts ExportOpts) []xcodeCloudEnv {
+	envs := []xcodeCloudEnv{
+		{xcodeCloudXcodebuildActionEnvKey, xcodeCloudArchiveXcodebuildActionValue},
+		{xcodeCloudSchemeEnvKey, opts.Scheme},
+	}
+
+	if opts.Archive != nil {
+		envs = append(envs,
+			xcodeCloudEnv{xcodeCloudArchivePathEnvKey, opts.Archive.Path},
+			xcodeCloudEnv{xcodeCloudProductEnvKey, strings.TrimSuffix(filepath.Base(opts.Archive.Application.Path), ".app")},
+			xcodeCloudEnv{xcodeCloudBundleIDEnvKey, opts.Archive.Application.BundleIdentifier()},
+		)
+	}
+
+	if opts.IPAExportDir != "" {
+		if key := xcodeCloudSignedAppPathEnvKey(exportMethodOf(opts.ExportOptionsPath)); key != "" {
+			envs = append(envs, xcodeCloudEnv{key, opts.IPAExportDir})
+		}
+	}
+
+	var nonEmpty []xcodeCloudEnv
+	for _, env := range envs {
+		if env.value != "" {
+			nonEmpty = append(nonEmpty, env)
+		}
+	}
+	return nonEmpty
+}
+
+// exportXcodeCloudEnvs exports the Xcode Cloud compatible Environment Variables, they are exported as is,
+// without the output Environment Variable key prefix and suffix.
+func (s XcodebuildArchiver) exportXcodeCloudEnvs(opts ExportOpts) error {
+	s.logger.Printf("Exporting the Xcode Cloud compatible Environment Variables:")
+	for _, env := range xcodeCloudEnvs(opts) {
+		if err := exportEnvironmentWithEnvman(s.cmdFactory, s.outputEnvKeys, env.key, env.value); err != nil {
+			return fmt.Errorf("failed to export %s: %w", env.key, err)
+		}
+		s.logger.Printf("- %s: %s", env.key, env.value)
+	}
+	return nil
+}
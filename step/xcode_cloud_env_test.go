@@ -0,0 +1,56 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/plistutil"
+	"github.com/bitrise-io/go-xcode/xcarchive"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXcodeCloudSignedAppPathEnvKey(t *testing.T) {
+	require.Equal(t, xcodeCloudAppStoreSignedAppPathEnvKey, xcodeCloudSignedAppPathEnvKey("app-store"))
+	require.Equal(t, xcodeCloudAdHocSignedAppPathEnvKey, xcodeCloudSignedAppPathEnvKey("release-testing"))
+	require.Equal(t, xcodeCloudDevelopmentSignedAppPathEnvKey, xcodeCloudSignedAppPathEnvKey("development"))
+	require.Equal(t, xcodeCloudDeveloperIDSignedAppPathEnvKey, xcodeCloudSignedAppPathEnvKey("developer-id"))
+	require.Equal(t, "", xcodeCloudSignedAppPathEnvKey("enterprise"))
+}
+
+func TestXcodeCloudEnvs(t *testing.T) {
+	exportOptionsPath := filepath.Join(t.TempDir(), "export_options.plist")
+	require.NoError(t, os.WriteFile(exportOptionsPath, []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>method</key>
+	<string>app-store</string>
+</dict>
+</plist>`), 0644))
+
+	archive := &xcarchive.IosArchive{
+		Path: "/tmp/Bitrise.xcarchive",
+		Application: xcarchive.IosApplication{IosBaseApplication: xcarchive.IosBaseApplication{
+			Path:      "/tmp/Bitrise.xcarchive/Products/Applications/Bitrise.app",
+			InfoPlist: plistutil.PlistData{"CFBundleIdentifier": "io.bitrise.app"},
+		}},
+	}
+
+	envs := xcodeCloudEnvs(ExportOpts{
+		Scheme:            "Bitrise",
+		Archive:           archive,
+		ExportOptionsPath: exportOptionsPath,
+		IPAExportDir:      "/tmp/export/exported",
+	})
+	require.Equal(t, []xcodeCloudEnv{
+		{xcodeCloudXcodebuildActionEnvKey, "archive"},
+		{xcodeCloudSchemeEnvKey, "Bitrise"},
+		{xcodeCloudArchivePathEnvKey, "/tmp/Bitrise.xcarchive"},
+		{xcodeCloudProductEnvKey, "Bitrise"},
+		{xcodeCloudBundleIDEnvKey, "io.bitrise.app"},
+		{xcodeCloudAppStoreSignedAppPathEnvKey, "/tmp/export/exported"},
+	}, envs)
+
+	require.Equal(t, []xcodeCloudEnv{{xcodeCloudXcodebuildActionEnvKey, "archive"}}, xcodeCloudEnvs(ExportOpts{}))
+}
@@ -0,0 +1,203 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"howett.net/plist"
+)
+
+const (
+	xcodeApplicationsDir = "/Applications"
+	developerDirEnvKey   = "DEVELOPER_DIR"
+)
+
+type xcodeInstallation struct {
+	Path    string
+	Version string
+}
+
+// DeveloperDir returns the value of DEVELOPER_DIR selecting this Xcode.
+func (x xcodeInstallation) DeveloperDir() string {
+	return filepath.Join(x.Path, "Contents", "Developer")
+}
+
+// installedXcodes lists the Xcode.app bundles of the applications dir.
+func installedXcodes(applicationsDir string) ([]xcodeInstallation, error) {
+	pths, err := filepath.Glob(filepath.Join(applicationsDir, "Xcode*.app"))
+	if err != nil {
+		return nil, err
+	}
+
+	var xcodes []xcodeInstallation
+	for _, pth := range pths {
+		content, err := os.ReadFile(filepath.Join(pth, "Contents", "version.plist"))
+		if err != nil {
+			continue
+		}
+
+		var versionPlist struct {
+			ShortVersion string `plist:"CFBundleShortVersionString"`
+		}
+		if _, err := plist.Unmarshal(content, &versionPlist); err != nil || versionPlist.ShortVersion == "" {
+			continue
+		}
+
+		xcodes = append(xcodes, xcodeInstallation{Path: pth, Version: versionPlist.ShortVersion})
+	}
+	return xcodes, nil
+}
+
+// findXcode returns the newest installed Xcode matching the requested version:
+// "15" matches every 15.x version, "15.4" matches 15.4 and 15.4.x.
+func findXcode(xcodes []xcodeInstallation, version string) (xcodeInstallation, error) {
+	var matching []xcodeInstallation
+	for _, xcode := range xcodes {
+		if xcode.Version == version || strings.HasPrefix(xcode.Version, version+".") {
+			matching = append(matching, xcode)
+		}
+	}
+
+	if len(matching) == 0 {
+		var installed []string
+		for _, xcode := range xcodes {
+			installed = append(installed, xcode.Version)
+		}
+		sort.Strings(installed)
+		return xcodeInstallation{}, fmt.Errorf("Xcode %s is not installed, installed versions: %s", version, strings.Join(installed, ", "))
+	}
+
+	sort.SliceStable(matching, func(i, j int) bool {
+		return compareVersions(matching[i].Version, matching[j].Version) > 0
+	})
+	return matching[0], nil
+}
+
+// compareVersions compares dot separated numeric versions, returns a positive number if a is newer than b.
+func compareVersions(a, b string) int {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aNum, bNum int
+		if i < len(aParts) {
+			aNum, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bNum, _ = strconv.Atoi(bParts[i])
+		}
+		if aNum != bNum {
+			return aNum - bNum
+		}
+	}
+	return 0
+}
+
+// selectXcode points DEVELOPER_DIR to the requested Xcode version, so every subsequent xcodebuild command uses it.
+func selectXcode(applicationsDir, version string) (xcodeInstallation, error) {
+	xcodes, err := installedXcodes(applicationsDir)
+	if err != nil {
+		return xcodeInstallation{}, fmt.Errorf("failed to list installed Xcodes: %w", err)
+	}
+
+	xcode, err := findXcode(xcodes, version)
+	if err != nil {
+		return xcodeInstallation{}, err
+	}
+
+	if err := os.Setenv(developerDirEnvKey, xcode.DeveloperDir()); err != nil {
+		return xcodeInstallation{}, fmt.Errorf("failed to set %s: %w", developerDirEnvKey, err)
+	}
+	return xcode, nil
+}
+
+// selectConfiguredXcode selects a single Xcode: the xcode_version and xcode_developer_dir inputs (only one of them can be set)
+// take precedence over the Xcode version file's required version, which is selected only with xcode_version_file: select.
+func (s XcodebuildArchiveConfigParser) selectConfiguredXcode(applicationsDir string, config Config, requiredXcodeVersion string) error {
+	selectRequiredXcode := requiredXcodeVersion != "" && config.XcodeVersionFile == xcodeVersionFileSelect
+
+	switch {
+	case config.XcodeSelectVersion != "" && config.XcodeDeveloperDir != "":
+		return fmt.Errorf("both XcodeVersion (`xcode_version`) and XcodeDeveloperDir (`xcode_developer_dir`) inputs are set, only one can be used")
+	case config.XcodeDeveloperDir != "":
+		if _, err := selectDeveloperDir(config.XcodeDeveloperDir); err != nil {
+			return fmt.Errorf("issue with input XcodeDeveloperDir: %w", err)
+		}
+	case config.XcodeSelectVersion != "":
+		xcode, err := selectXcode(applicationsDir, config.XcodeSelectVersion)
+		if err != nil {
+			return fmt.Errorf("issue with input XcodeVersion: %w", err)
+		}
+		s.logger.Printf("Selected Xcode %s at: %s", xcode.Version, xcode.Path)
+	case selectRequiredXcode:
+		xcode, err := selectXcode(applicationsDir, requiredXcodeVersion)
+		if err != nil {
+			return fmt.Errorf("issue with input XcodeVersionFile: %w", err)
+		}
+		s.logger.Printf("Selected Xcode %s at: %s", xcode.Version, xcode.Path)
+		return nil
+	}
+
+	if selectRequiredXcode && (config.XcodeSelectVersion != "" || config.XcodeDeveloperDir != "") {
+		s.logger.Warnf("The Xcode version (xcode_version) or the Xcode Developer dir (xcode_developer_dir) input is set, the Xcode required by the %s file is not selected.", xcodeVersionFileName)
+	}
+	return nil
+}
+
+// xcodeDeveloperDir returns the Developer dir of the given Xcode.app or Developer dir path.
+func xcodeDeveloperDir(pth string) (string, error) {
+	developerDir := pth
+	if filepath.Ext(strings.TrimSuffix(pth, "/")) == ".app" {
+		developerDir = xcodeInstallation{Path: pth}.DeveloperDir()
+	}
+
+	xcodebuildPath := filepath.Join(developerDir, "usr", "bin", "xcodebuild")
+	if _, err := os.Stat(xcodebuildPath); err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("not an Xcode Developer dir, xcodebuild not found at: %s", xcodebuildPath)
+		}
+		return "", err
+	}
+	return filepath.Clean(developerDir), nil
+}
+
+// selectDeveloperDir points DEVELOPER_DIR to the given Xcode.app or Developer dir, so every subsequent xcodebuild command uses it.
+func selectDeveloperDir(pth string) (string, error) {
+	developerDir, err := xcodeDeveloperDir(pth)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.Setenv(developerDirEnvKey, developerDir); err != nil {
+		return "", fmt.Errorf("failed to set %s: %w", developerDirEnvKey, err)
+	}
+	return developerDir, nil
+}
+
+// xcodeAppPath returns the Xcode.app path of a Developer dir,
+// or the Developer dir itself if it is not inside an Xcode.app (for example the Command Line Tools).
+func xcodeAppPath(developerDir string) string {
+	appPath := strings.TrimSuffix(filepath.Clean(developerDir), string(filepath.Separator)+filepath.Join("Contents", "Developer"))
+	if filepath.Ext(appPath) != ".app" {
+		return developerDir
+	}
+	return appPath
+}
+
+// activeDeveloperDir returns the Developer dir used by the xcodebuild commands:
+// DEVELOPER_DIR if set, otherwise the one selected by xcode-select.
+func activeDeveloperDir(cmdFactory command.Factory) (string, error) {
+	if developerDir := os.Getenv(developerDirEnvKey); developerDir != "" {
+		return developerDir, nil
+	}
+
+	cmd := cmdFactory.Create("xcode-select", []string{"--print-path"}, nil)
+	out, err := cmd.RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to print the selected Xcode path: %s: %w", out, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,143 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_installedXcodes(t *testing.T) {
+	applicationsDir := t.TempDir()
+	writeXcode := func(name, version string) {
+		contentsDir := filepath.Join(applicationsDir, name, "Contents")
+		require.NoError(t, os.MkdirAll(contentsDir, 0755))
+		content := `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict><key>CFBundleShortVersionString</key><string>` + version + `</string></dict></plist>`
+		require.NoError(t, os.WriteFile(filepath.Join(contentsDir, "version.plist"), []byte(content), 0644))
+	}
+	writeXcode("Xcode.app", "16.0")
+	writeXcode("Xcode-15.4.app", "15.4")
+	require.NoError(t, os.MkdirAll(filepath.Join(applicationsDir, "Xcode-broken.app"), 0755))
+
+	xcodes, err := installedXcodes(applicationsDir)
+	require.NoError(t, err)
+	require.Equal(t, []xcodeInstallation{
+		{Path: filepath.Join(applicationsDir, "Xcode-15.4.app"), Version: "15.4"},
+		{Path: filepath.Join(applicationsDir, "Xcode.app"), Version: "16.0"},
+	}, xcodes)
+}
+
+func Test_findXcode(t *testing.T) {
+	xcodes := []xcodeInstallation{
+		{Path: "/Applications/Xcode-15.2.app", Version: "15.2"},
+		{Path: "/Applications/Xcode-15.4.app", Version: "15.4"},
+		{Path: "/Applications/Xcode-15.10.app", Version: "15.10"},
+		{Path: "/Applications/Xcode.app", Version: "16.0"},
+	}
+
+	tests := []struct {
+		name    string
+		version string
+		want    string
+		wantErr string
+	}{
+		{name: "exact version", version: "15.4", want: "/Applications/Xcode-15.4.app"},
+		{name: "newest of major version", version: "15", want: "/Applications/Xcode-15.10.app"},
+		{name: "minor version is not a prefix match", version: "15.1", wantErr: "Xcode 15.1 is not installed, installed versions: 15.10, 15.2, 15.4, 16.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findXcode(xcodes, tt.version)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got.Path)
+			require.Equal(t, filepath.Join(tt.want, "Contents", "Developer"), got.DeveloperDir())
+		})
+	}
+}
+
+func Test_xcodeDeveloperDir(t *testing.T) {
+	xcodePath := filepath.Join(t.TempDir(), "Xcode-16.2.app")
+	developerDir := filepath.Join(xcodePath, "Contents", "Developer")
+	require.NoError(t, os.MkdirAll(filepath.Join(developerDir, "usr", "bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(developerDir, "usr", "bin", "xcodebuild"), nil, 0755))
+
+	got, err := xcodeDeveloperDir(xcodePath)
+	require.NoError(t, err)
+	require.Equal(t, developerDir, got)
+
+	got, err = xcodeDeveloperDir(developerDir + "/")
+	require.NoError(t, err)
+	require.Equal(t, developerDir, got)
+
+	_, err = xcodeDeveloperDir(filepath.Dir(xcodePath))
+	require.EqualError(t, err, "not an Xcode Developer dir, xcodebuild not found at: "+filepath.Join(filepath.Dir(xcodePath), "usr", "bin", "xcodebuild"))
+}
+
+func Test_xcodeAppPath(t *testing.T) {
+	require.Equal(t, "/Applications/Xcode-16.2.app", xcodeAppPath("/Applications/Xcode-16.2.app/Contents/Developer"))
+	require.Equal(t, "/Library/Developer/CommandLineTools", xcodeAppPath("/Library/Developer/CommandLineTools"))
+}
+
+func TestXcodebuildArchiveConfigParser_selectConfiguredXcode(t *testing.T) {
+	applicationsDir := t.TempDir()
+	for name, version := range map[string]string{"Xcode-15.4.app": "15.4", "Xcode.app": "16.0"} {
+		contentsDir := filepath.Join(applicationsDir, name, "Contents")
+		require.NoError(t, os.MkdirAll(contentsDir, 0755))
+		content := `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict><key>CFBundleShortVersionString</key><string>` + version + `</string></dict></plist>`
+		require.NoError(t, os.WriteFile(filepath.Join(contentsDir, "version.plist"), []byte(content), 0644))
+	}
+	s := XcodebuildArchiveConfigParser{logger: log.NewLogger()}
+
+	tests := []struct {
+		name                 string
+		inputs               Inputs
+		requiredXcodeVersion string
+		wantDeveloperDir     string
+		wantErr              string
+	}{
+		{
+			name:                 "the exclusive inputs are rejected before selecting an Xcode",
+			inputs:               Inputs{XcodeSelectVersion: "15.4", XcodeDeveloperDir: "/Applications/Xcode.app", XcodeVersionFile: xcodeVersionFileSelect},
+			requiredXcodeVersion: "16.0",
+			wantErr:              "both XcodeVersion (`xcode_version`) and XcodeDeveloperDir (`xcode_developer_dir`) inputs are set, only one can be used",
+		},
+		{
+			name:                 "xcode_version takes precedence over the Xcode version file",
+			inputs:               Inputs{XcodeSelectVersion: "15.4", XcodeVersionFile: xcodeVersionFileSelect},
+			requiredXcodeVersion: "16.0",
+			wantDeveloperDir:     xcodeInstallation{Path: filepath.Join(applicationsDir, "Xcode-15.4.app")}.DeveloperDir(),
+		},
+		{
+			name:                 "the Xcode version file selects",
+			inputs:               Inputs{XcodeVersionFile: xcodeVersionFileSelect},
+			requiredXcodeVersion: "16",
+			wantDeveloperDir:     xcodeInstallation{Path: filepath.Join(applicationsDir, "Xcode.app")}.DeveloperDir(),
+		},
+		{
+			name:                 "the Xcode version file only verifies",
+			inputs:               Inputs{XcodeVersionFile: xcodeVersionFileVerify},
+			requiredXcodeVersion: "16",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(developerDirEnvKey, "")
+
+			err := s.selectConfiguredXcode(applicationsDir, Config{Inputs: tt.inputs}, tt.requiredXcodeVersion)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.wantDeveloperDir, os.Getenv(developerDirEnvKey))
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	xcodeVersionFileName = ".xcode-version"
+
+	xcodeVersionFileOff    = "off"
+	xcodeVersionFileVerify = "verify"
+	xcodeVersionFileSelect = "select"
+)
+
+var xcodeVersionNumberPattern = regexp.MustCompile(`^\d+(\.\d+)*$`)
+
+// findXcodeVersionFile looks for the .xcode-version file in the project's dir and its parents, up to the repository root.
+func findXcodeVersionFile(projectPath string) (string, error) {
+	absProjectPath, err := filepath.Abs(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Dir(absProjectPath)
+	for {
+		pth := filepath.Join(dir, xcodeVersionFileName)
+		if _, err := os.Stat(pth); err == nil {
+			return pth, nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// readXcodeVersionFile reads the Xcode version of the .xcode-version file, for example 15.4 or 16.0 (a beta suffix is ignored).
+func readXcodeVersionFile(pth string) (string, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		version := fields[0]
+		if !xcodeVersionNumberPattern.MatchString(version) {
+			return "", fmt.Errorf("invalid Xcode version in %s: %s", pth, version)
+		}
+		return normalizeXcodeVersion(version), nil
+	}
+	return "", fmt.Errorf("no Xcode version in %s", pth)
+}
+
+// normalizeXcodeVersion drops the trailing zero patch versions, as Xcode reports 15.0 for 15.0.0.
+func normalizeXcodeVersion(version string) string {
+	parts := strings.Split(version, ".")
+	for len(parts) > 2 && parts[len(parts)-1] == "0" {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, ".")
+}
+
+// xcodeVersionNumber returns the version number of the xcodebuild -version output's version line, for example 16.0 of "Xcode 16.0 beta 3".
+func xcodeVersionNumber(xcodebuildVersion string) string {
+	fields := strings.Fields(xcodebuildVersion)
+	if len(fields) < 2 {
+		return ""
+	}
+	return normalizeXcodeVersion(fields[1])
+}
+
+// xcodeVersionMatches returns if the active Xcode version matches the required one:
+// "15" matches every 15.x version, "15.4" matches 15.4 and 15.4.x.
+func xcodeVersionMatches(active, required string) bool {
+	return active == required || strings.HasPrefix(active, required+".")
+}
+
+// requiredXcodeVersion returns the Xcode version of the project's .xcode-version file, empty if the project has no such file.
+func (s XcodebuildArchiveConfigParser) requiredXcodeVersion(projectPath string) (string, error) {
+	pth, err := findXcodeVersionFile(projectPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to look for the %s file: %w", xcodeVersionFileName, err)
+	}
+	if pth == "" {
+		s.logger.Warnf("No %s file found for the project, the Xcode version is not checked.", xcodeVersionFileName)
+		return "", nil
+	}
+
+	version, err := readXcodeVersionFile(pth)
+	if err != nil {
+		return "", err
+	}
+	s.logger.Printf("Xcode %s is required by: %s", version, pth)
+	return version, nil
+}
+
+// checkXcodeVersionRequirement checks that the active Xcode (the version line of xcodebuild -version) is the required version.
+func checkXcodeVersionRequirement(xcodebuildVersion, required string) error {
+	if active := xcodeVersionNumber(xcodebuildVersion); !xcodeVersionMatches(active, required) {
+		return fmt.Errorf("the project requires Xcode %s (%s file), but %s is used", required, xcodeVersionFileName, xcodebuildVersion)
+	}
+	return nil
+}
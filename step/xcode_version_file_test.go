@@ -0,0 +1,94 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_findXcodeVersionFile(t *testing.T) {
+	repoDir := t.TempDir()
+	projectDir := filepath.Join(repoDir, "ios", "App")
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "App.xcodeproj"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(repoDir, ".git"), 0755))
+	projectPth := filepath.Join(projectDir, "App.xcodeproj")
+
+	pth, err := findXcodeVersionFile(projectPth)
+	require.NoError(t, err)
+	require.Empty(t, pth)
+
+	// The file of the repository root is found
+	rootFile := filepath.Join(repoDir, xcodeVersionFileName)
+	require.NoError(t, os.WriteFile(rootFile, []byte("15.4\n"), 0644))
+	pth, err = findXcodeVersionFile(projectPth)
+	require.NoError(t, err)
+	require.Equal(t, rootFile, pth)
+
+	// The closest file is found
+	projectFile := filepath.Join(projectDir, xcodeVersionFileName)
+	require.NoError(t, os.WriteFile(projectFile, []byte("16.0\n"), 0644))
+	pth, err = findXcodeVersionFile(projectPth)
+	require.NoError(t, err)
+	require.Equal(t, projectFile, pth)
+}
+
+func Test_readXcodeVersionFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{name: "version", content: "15.4\n", want: "15.4"},
+		{name: "major version", content: "16", want: "16"},
+		{name: "zero patch version", content: "15.0.0\n", want: "15.0"},
+		{name: "patch version", content: "15.4.1", want: "15.4.1"},
+		{name: "beta suffix", content: "16.0 beta 3\n", want: "16.0"},
+		{name: "comments and empty lines", content: "# pinned Xcode\n\n  15.3  \n", want: "15.3"},
+		{name: "invalid version", content: "latest\n", wantErr: true},
+		{name: "empty file", content: "\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pth := filepath.Join(t.TempDir(), xcodeVersionFileName)
+			require.NoError(t, os.WriteFile(pth, []byte(tt.content), 0644))
+
+			got, err := readXcodeVersionFile(pth)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_checkXcodeVersionRequirement(t *testing.T) {
+	tests := []struct {
+		name              string
+		xcodebuildVersion string
+		required          string
+		wantErr           bool
+	}{
+		{name: "same version", xcodebuildVersion: "Xcode 15.4", required: "15.4"},
+		{name: "major version", xcodebuildVersion: "Xcode 16.2", required: "16"},
+		{name: "patch version of the required version", xcodebuildVersion: "Xcode 15.4.1", required: "15.4"},
+		{name: "beta version", xcodebuildVersion: "Xcode 16.0 beta 3", required: "16.0"},
+		{name: "other minor version", xcodebuildVersion: "Xcode 15.3", required: "15.4", wantErr: true},
+		{name: "version prefix is not a match", xcodebuildVersion: "Xcode 15.40", required: "15.4", wantErr: true},
+		{name: "other major version", xcodebuildVersion: "Xcode 16.0", required: "15", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkXcodeVersionRequirement(tt.xcodebuildVersion, tt.required)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
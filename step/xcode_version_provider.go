@@ -1,10 +1,18 @@
 package step
 
 import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
 	"github.com/bitrise-io/go-xcode/models"
-	"github.com/bitrise-io/go-xcode/utility"
 )
 
+// xcodeVersionPattern matches the first line of the xcodebuild -version output, for example "Xcode 15.4" or "Xcode 16.0 beta 3".
+var xcodeVersionPattern = regexp.MustCompile(`^Xcode (\d+)(?:\.\d+)*(?: [Bb]eta(?: \d+)?)?$`)
+
 type XcodeVersionProvider interface {
 	GetXcodeVersion() (models.XcodebuildVersionModel, error)
 }
@@ -18,5 +26,45 @@ func NewXcodebuildXcodeVersionProvider() XcodeVersionProvider {
 
 // GetXcodeVersion ...
 func (p xcodebuildXcodeVersionProvider) GetXcodeVersion() (models.XcodebuildVersionModel, error) {
-	return utility.GetXcodeVersion()
+	cmd := command.New("xcodebuild", "-version")
+	out, err := cmd.RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return models.XcodebuildVersionModel{}, fmt.Errorf("xcodebuild -version failed, err: %s, details: %s", err, out)
+	}
+	return parseXcodebuildVersion(out)
+}
+
+// parseXcodebuildVersion parses the xcodebuild -version output, including the beta versions' "Xcode 16.0 beta 3" format.
+// Warnings printed before the version are skipped.
+func parseXcodebuildVersion(out string) (models.XcodebuildVersionModel, error) {
+	lines := strings.Split(out, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		match := xcodeVersionPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		majorVersion, err := strconv.ParseInt(match[1], 10, 32)
+		if err != nil {
+			return models.XcodebuildVersionModel{}, fmt.Errorf("failed to parse xcodebuild version output (%s), error: %s", out, err)
+		}
+
+		var buildVersion string
+		if i+1 < len(lines) {
+			buildVersion = strings.TrimSpace(lines[i+1])
+		}
+
+		return models.XcodebuildVersionModel{
+			Version:      line,
+			BuildVersion: buildVersion,
+			MajorVersion: majorVersion,
+		}, nil
+	}
+	return models.XcodebuildVersionModel{}, fmt.Errorf("couldn't find Xcode version in output: %s", out)
+}
+
+// isBetaXcode returns true if the xcodebuild version is a beta version.
+func isBetaXcode(version models.XcodebuildVersionModel) bool {
+	return strings.Contains(strings.ToLower(version.Version), " beta")
 }
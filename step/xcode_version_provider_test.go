@@ -0,0 +1,52 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-xcode/models"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseXcodebuildVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		out      string
+		want     models.XcodebuildVersionModel
+		wantBeta bool
+		wantErr  string
+	}{
+		{
+			name: "release",
+			out:  "Xcode 15.4\nBuild version 15F31d",
+			want: models.XcodebuildVersionModel{Version: "Xcode 15.4", BuildVersion: "Build version 15F31d", MajorVersion: 15},
+		},
+		{
+			name:     "beta",
+			out:      "Xcode 16.0 beta 3\nBuild version 16A5202i",
+			want:     models.XcodebuildVersionModel{Version: "Xcode 16.0 beta 3", BuildVersion: "Build version 16A5202i", MajorVersion: 16},
+			wantBeta: true,
+		},
+		{
+			name: "warnings before the version",
+			out:  "objc[123]: Class AMSupportURLConnectionDelegate is implemented in both\nXcode 16.2\nBuild version 16C5032a",
+			want: models.XcodebuildVersionModel{Version: "Xcode 16.2", BuildVersion: "Build version 16C5032a", MajorVersion: 16},
+		},
+		{
+			name:    "no version",
+			out:     "xcode-select: error: tool 'xcodebuild' requires Xcode",
+			wantErr: "couldn't find Xcode version in output: xcode-select: error: tool 'xcodebuild' requires Xcode",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseXcodebuildVersion(tt.out)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantBeta, isBetaXcode(got))
+		})
+	}
+}
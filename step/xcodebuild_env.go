@@ -0,0 +1,96 @@
+package step
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/env"
+)
+
+// xcodebuildEnvRepository is the environment of the archive and export xcodebuild commands:
+// the Step's environment without the unset variables and with the extra variables,
+// the Step process' own environment is not modified.
+type xcodebuildEnvRepository struct {
+	env.Repository
+	unset []string
+	extra []string
+}
+
+// NewXcodebuildEnvRepository ...
+func NewXcodebuildEnvRepository(repository env.Repository, unset, extra []string) env.Repository {
+	return xcodebuildEnvRepository{
+		Repository: repository,
+		unset:      unset,
+		extra:      extra,
+	}
+}
+
+// List ...
+func (r xcodebuildEnvRepository) List() []string {
+	removed := map[string]bool{}
+	for _, key := range r.unset {
+		removed[key] = true
+	}
+	for _, variable := range r.extra {
+		key, _, _ := strings.Cut(variable, "=")
+		removed[key] = true
+	}
+
+	var envs []string
+	for _, variable := range r.Repository.List() {
+		key, _, _ := strings.Cut(variable, "=")
+		if !removed[key] {
+			envs = append(envs, variable)
+		}
+	}
+	return append(envs, r.extra...)
+}
+
+// Get ...
+func (r xcodebuildEnvRepository) Get(key string) string {
+	for i := len(r.extra) - 1; i >= 0; i-- {
+		if k, value, _ := strings.Cut(r.extra[i], "="); k == key {
+			return value
+		}
+	}
+	for _, k := range r.unset {
+		if k == key {
+			return ""
+		}
+	}
+	return r.Repository.Get(key)
+}
+
+// parseUnsetEnvVars parses the newline separated list of environment variable names.
+func parseUnsetEnvVars(list string) ([]string, error) {
+	var keys []string
+	for _, line := range strings.Split(list, "\n") {
+		key := strings.TrimSpace(line)
+		if key == "" {
+			continue
+		}
+		if strings.ContainsAny(key, "= \t") {
+			return nil, fmt.Errorf("invalid environment variable name: %s", key)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// parseExtraEnvVars parses the newline separated list of KEY=VALUE environment variables.
+func parseExtraEnvVars(list string) ([]string, error) {
+	var envs []string
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("invalid environment variable, expected KEY=VALUE format: %s", line)
+		}
+		envs = append(envs, key+"="+value)
+	}
+	return envs, nil
+}
@@ -0,0 +1,70 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEnvRepository struct {
+	envs map[string]string
+}
+
+func (r fakeEnvRepository) List() []string {
+	var list []string
+	for key, value := range r.envs {
+		list = append(list, key+"="+value)
+	}
+	return list
+}
+
+func (r fakeEnvRepository) Unset(key string) error {
+	delete(r.envs, key)
+	return nil
+}
+
+func (r fakeEnvRepository) Get(key string) string {
+	return r.envs[key]
+}
+
+func (r fakeEnvRepository) Set(key, value string) error {
+	r.envs[key] = value
+	return nil
+}
+
+func TestXcodebuildEnvRepository(t *testing.T) {
+	base := fakeEnvRepository{envs: map[string]string{
+		"GEM_HOME": "/gems",
+		"PATH":     "/usr/bin",
+		"LANG":     "en_US.UTF-8",
+	}}
+	repository := NewXcodebuildEnvRepository(base, []string{"GEM_HOME"}, []string{"LANG=C", "FASTLANE_SKIP_UPDATE_CHECK=true"})
+
+	require.ElementsMatch(t, []string{"PATH=/usr/bin", "LANG=C", "FASTLANE_SKIP_UPDATE_CHECK=true"}, repository.List())
+	require.Equal(t, "", repository.Get("GEM_HOME"))
+	require.Equal(t, "C", repository.Get("LANG"))
+	require.Equal(t, "/usr/bin", repository.Get("PATH"))
+
+	require.Equal(t, "/gems", base.Get("GEM_HOME"), "the Step's environment is not modified")
+}
+
+func TestParseUnsetEnvVars(t *testing.T) {
+	keys, err := parseUnsetEnvVars("GEM_HOME\n\n  RUBYOPT \n")
+	require.NoError(t, err)
+	require.Equal(t, []string{"GEM_HOME", "RUBYOPT"}, keys)
+
+	_, err = parseUnsetEnvVars("GEM_HOME=/gems")
+	require.Error(t, err)
+}
+
+func TestParseExtraEnvVars(t *testing.T) {
+	envs, err := parseExtraEnvVars("FASTLANE_SKIP_UPDATE_CHECK=true\n\nOPTS=a=b\nEMPTY=\n")
+	require.NoError(t, err)
+	require.Equal(t, []string{"FASTLANE_SKIP_UPDATE_CHECK=true", "OPTS=a=b", "EMPTY="}, envs)
+
+	_, err = parseExtraEnvVars("FASTLANE_SKIP_UPDATE_CHECK")
+	require.Error(t, err)
+
+	_, err = parseExtraEnvVars("=value")
+	require.Error(t, err)
+}
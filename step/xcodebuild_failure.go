@@ -0,0 +1,118 @@
+package step
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+const (
+	bitriseXcodebuildExitCodeEnvKey     = "BITRISE_XCODEBUILD_EXIT_CODE"
+	bitriseXcodebuildFailedTargetEnvKey = "BITRISE_XCODEBUILD_FAILED_TARGET"
+	bitriseXcodebuildFailedPhaseEnvKey  = "BITRISE_XCODEBUILD_FAILED_PHASE"
+	bitriseXcodebuildErrorLinesEnvKey   = "BITRISE_XCODEBUILD_ERROR_LINES"
+
+	xcodebuildErrorLinesLimit = 10
+)
+
+var (
+	failedTargetPattern = regexp.MustCompile(`\(in target '([^']+)'`)
+	errorLinePattern    = regexp.MustCompile(`(^|:\s*)error:\s`)
+)
+
+// xcodebuildExitError is returned when an xcodebuild command exits with a non-zero exit code.
+type xcodebuildExitError struct {
+	ExitCode int
+	err      error
+}
+
+func (e xcodebuildExitError) Error() string {
+	return e.err.Error()
+}
+
+func (e xcodebuildExitError) Unwrap() error {
+	return e.err
+}
+
+func newXcodebuildExitError(exitCode int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return xcodebuildExitError{ExitCode: exitCode, err: err}
+}
+
+// xcodebuildExitCode returns the exit code of the failed xcodebuild command wrapped in err, 0 if there is none.
+func xcodebuildExitCode(err error) int {
+	var exitErr xcodebuildExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode
+	}
+	return 0
+}
+
+type xcodebuildFailure struct {
+	ExitCode     int
+	FailedTarget string
+	FailedPhase  string
+	ErrorLines   []string
+}
+
+// parseXcodebuildFailure collects the failing build command and the first error lines of a failed xcodebuild command's log.
+func parseXcodebuildFailure(exitCode int, xcodebuildLog string) xcodebuildFailure {
+	failure := xcodebuildFailure{ExitCode: exitCode}
+
+	lines := strings.Split(xcodebuildLog, "\n")
+	inFailedCommands := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "The following build commands failed:") {
+			inFailedCommands = true
+			continue
+		}
+		if inFailedCommands && failure.FailedPhase == "" && trimmed != "" {
+			if fields := strings.Fields(trimmed); len(fields) > 0 {
+				failure.FailedPhase = fields[0]
+			}
+			if match := failedTargetPattern.FindStringSubmatch(trimmed); len(match) == 2 {
+				failure.FailedTarget = match[1]
+			}
+			inFailedCommands = false
+		}
+
+		if errorLinePattern.MatchString(trimmed) && len(failure.ErrorLines) < xcodebuildErrorLinesLimit {
+			failure.ErrorLines = append(failure.ErrorLines, trimmed)
+		}
+	}
+
+	return failure
+}
+
+func exportXcodebuildFailure(cmdFactory command.Factory, outputEnvKeys OutputEnvKeys, failure xcodebuildFailure, logger log.Logger) error {
+	envs := []struct {
+		key, value string
+	}{
+		{bitriseXcodebuildExitCodeEnvKey, strconv.Itoa(failure.ExitCode)},
+		{bitriseXcodebuildFailedTargetEnvKey, failure.FailedTarget},
+		{bitriseXcodebuildFailedPhaseEnvKey, failure.FailedPhase},
+		{bitriseXcodebuildErrorLinesEnvKey, strings.Join(failure.ErrorLines, "\n")},
+	}
+	for _, env := range envs {
+		if env.value == "" {
+			continue
+		}
+		if err := exportEnvironmentWithEnvman(cmdFactory, outputEnvKeys, env.key, env.value); err != nil {
+			return fmt.Errorf("failed to export %s, error: %s", outputEnvKeys.key(env.key), err)
+		}
+	}
+
+	logger.Donef("The xcodebuild failure details are now available in the Environment Variables: %s, %s, %s and %s",
+		outputEnvKeys.key(bitriseXcodebuildExitCodeEnvKey), outputEnvKeys.key(bitriseXcodebuildFailedTargetEnvKey), outputEnvKeys.key(bitriseXcodebuildFailedPhaseEnvKey), outputEnvKeys.key(bitriseXcodebuildErrorLinesEnvKey))
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package step
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_xcodebuildExitCode(t *testing.T) {
+	require.Equal(t, 0, xcodebuildExitCode(nil))
+	require.Equal(t, 0, xcodebuildExitCode(errors.New("failed")))
+	require.Nil(t, newXcodebuildExitError(65, nil))
+
+	err := fmt.Errorf("failed to archive the project: %w", newXcodebuildExitError(65, errors.New("exit status 65")))
+	require.Equal(t, 65, xcodebuildExitCode(err))
+	require.EqualError(t, err, "failed to archive the project: exit status 65")
+}
+
+func Test_parseXcodebuildFailure(t *testing.T) {
+	xcodebuildLog := `CompileSwift normal arm64 /Users/vagrant/git/App/ContentView.swift (in target 'App' from project 'App')
+/Users/vagrant/git/App/ContentView.swift:12:5: error: cannot find 'foo' in scope
+        foo()
+        ^~~
+/Users/vagrant/git/App/ContentView.swift:13:5: error: cannot find 'bar' in scope
+warning: the error: prefix is not an error line
+
+** ARCHIVE FAILED **
+
+
+The following build commands failed:
+	SwiftCompile normal arm64 /Users/vagrant/git/App/ContentView.swift (in target 'App' from project 'App')
+	SwiftCompile normal arm64 Compiling\ ContentView.swift (in target 'Widget' from project 'App')
+(2 failures)`
+
+	got := parseXcodebuildFailure(65, xcodebuildLog)
+	require.Equal(t, xcodebuildFailure{
+		ExitCode:     65,
+		FailedTarget: "App",
+		FailedPhase:  "SwiftCompile",
+		ErrorLines: []string{
+			"/Users/vagrant/git/App/ContentView.swift:12:5: error: cannot find 'foo' in scope",
+			"/Users/vagrant/git/App/ContentView.swift:13:5: error: cannot find 'bar' in scope",
+		},
+	}, got)
+}
+
+func Test_parseXcodebuildFailure_exportLog(t *testing.T) {
+	got := parseXcodebuildFailure(70, `error: exportArchive: No signing certificate "iOS Distribution" found
+
+** EXPORT FAILED **`)
+	require.Equal(t, xcodebuildFailure{
+		ExitCode:   70,
+		ErrorLines: []string{`error: exportArchive: No signing certificate "iOS Distribution" found`},
+	}, got)
+}
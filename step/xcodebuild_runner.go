@@ -0,0 +1,109 @@
+package step
+
+import (
+	"bytes"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/v2/errorfinder"
+	"github.com/bitrise-io/go-xcode/v2/xcodecommand"
+	version "github.com/hashicorp/go-version"
+)
+
+var unbufferedIOEnv = []string{"NSUnbufferedIO=YES"}
+
+// rawXcodebuildRunner runs xcodebuild without a log formatter.
+// Unlike xcodecommand's raw runner it prints no fixed progress ticker, the progress is reported by the xcodebuild watchdog's heartbeat.
+type rawXcodebuildRunner struct {
+	logger         log.Logger
+	commandFactory command.Factory
+}
+
+// NewRawXcodebuildRunner ...
+func NewRawXcodebuildRunner(logger log.Logger, commandFactory command.Factory) xcodecommand.Runner {
+	return rawXcodebuildRunner{
+		logger:         logger,
+		commandFactory: commandFactory,
+	}
+}
+
+// Run ...
+func (r rawXcodebuildRunner) Run(workDir string, args []string, _ []string) (xcodecommand.Output, error) {
+	var outBuffer bytes.Buffer
+
+	cmd := r.commandFactory.Create("xcodebuild", args, &command.Opts{
+		Stdout:      &outBuffer,
+		Stderr:      &outBuffer,
+		Env:         unbufferedIOEnv,
+		Dir:         workDir,
+		ErrorFinder: errorfinder.FindXcodebuildErrors,
+	})
+
+	r.logger.TPrintf("$ %s", cmd.PrintableCommandArgs())
+
+	exitCode, err := cmd.RunAndReturnExitCode()
+
+	return xcodecommand.Output{
+		RawOut:   outBuffer.Bytes(),
+		ExitCode: exitCode,
+	}, err
+}
+
+// CheckInstall does nothing as no log formatter is used.
+func (r rawXcodebuildRunner) CheckInstall() (*version.Version, error) {
+	return nil, nil
+}
+
+// XcodebuildRunner runs the archive and export xcodebuild commands and returns their log,
+// a failed command's error is an xcodebuildExitError if the exit code is known.
+// The default runner runs the commands locally through the selected log formatter,
+// alternative runners can run them remotely (for example on a separate signing Mac) or fake them in tests.
+type XcodebuildRunner interface {
+	Archive(args []string) (string, error)
+	ExportArchive(args []string) (string, error)
+}
+
+// localXcodebuildRunner runs the xcodebuild commands with the log formatter's command runner.
+type localXcodebuildRunner struct {
+	xcodeCommandRunner xcodecommand.Runner
+	logFormatter       string
+	outputEnvKeys      OutputEnvKeys
+	logger             log.Logger
+}
+
+// Archive ...
+func (r localXcodebuildRunner) Archive(args []string) (string, error) {
+	output, err := r.xcodeCommandRunner.Run("", args, []string{})
+	if r.logFormatter == XcodebuildTool || err != nil {
+		printLastLinesOfXcodebuildLog(r.logger, r.outputEnvKeys, string(output.RawOut), err == nil)
+	}
+
+	return string(output.RawOut), newXcodebuildExitError(output.ExitCode, err)
+}
+
+// ExportArchive ...
+func (r localXcodebuildRunner) ExportArchive(args []string) (string, error) {
+	output, err := r.xcodeCommandRunner.Run("", args, []string{})
+	if r.logFormatter == XcodebuildTool {
+		// xcodecommand does not output to stdout for xcodebuild log formatter.
+		// The export log is short, so we print it in entirety.
+		r.logger.Printf("%s", output.RawOut)
+	}
+
+	return string(output.RawOut), newXcodebuildExitError(output.ExitCode, err)
+}
+
+// SetXcodebuildRunner replaces the local execution of the archive and export commands (the Mac Catalyst ones included),
+// the package resolution, the build settings and the notarized app export commands still run locally.
+func (s *XcodebuildArchiver) SetXcodebuildRunner(runner XcodebuildRunner) {
+	s.xcodebuildRunner = runner
+}
+
+// runner returns the injected xcodebuild runner, or the local one of the selected log formatter
+// (EnsureDependencies may fall back to the raw xcodebuild formatter).
+func (s XcodebuildArchiver) runner() XcodebuildRunner {
+	if s.xcodebuildRunner != nil {
+		return s.xcodebuildRunner
+	}
+	return localXcodebuildRunner{xcodeCommandRunner: s.xcodeCommandRunner, logFormatter: s.logFormatter, outputEnvKeys: s.outputEnvKeys, logger: s.logger}
+}
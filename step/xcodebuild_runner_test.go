@@ -0,0 +1,108 @@
+package step
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/v2/xcodecommand"
+	version "github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeXcodebuildOutput struct {
+	log string
+	err error
+}
+
+// fakeXcodebuildRunner returns the queued outputs of the archive and export commands, recording their arguments.
+type fakeXcodebuildRunner struct {
+	outputs []fakeXcodebuildOutput
+	calls   [][]string
+}
+
+func (r *fakeXcodebuildRunner) run(args []string) (string, error) {
+	r.calls = append(r.calls, args)
+	if len(r.outputs) == 0 {
+		return "", nil
+	}
+	output := r.outputs[0]
+	r.outputs = r.outputs[1:]
+	return output.log, output.err
+}
+
+func (r *fakeXcodebuildRunner) Archive(args []string) (string, error) {
+	return r.run(args)
+}
+
+func (r *fakeXcodebuildRunner) ExportArchive(args []string) (string, error) {
+	return r.run(args)
+}
+
+type fakeXcodeCommandRunner struct {
+	output xcodecommand.Output
+	err    error
+}
+
+func (r fakeXcodeCommandRunner) Run(string, []string, []string) (xcodecommand.Output, error) {
+	return r.output, r.err
+}
+
+func (r fakeXcodeCommandRunner) CheckInstall() (*version.Version, error) {
+	return nil, nil
+}
+
+func TestRunArchiveCommandWithRetry_invalidSwiftPackages(t *testing.T) {
+	swiftPackagesPath := filepath.Join(t.TempDir(), "SourcePackages")
+	require.NoError(t, os.MkdirAll(swiftPackagesPath, 0755))
+
+	runner := &fakeXcodebuildRunner{outputs: []fakeXcodebuildOutput{
+		{log: "xcodebuild: error: Could not resolve package dependencies:", err: errors.New("exit status 74")},
+		{log: "** ARCHIVE SUCCEEDED **"},
+	}}
+	recorder := NewInsightsRecorder()
+
+	output, err := runArchiveCommandWithRetry(runner, []string{"archive"}, swiftPackagesPath, recorder, log.NewLogger())
+	require.NoError(t, err)
+	require.Equal(t, "** ARCHIVE SUCCEEDED **", output)
+	require.Equal(t, [][]string{{"archive"}, {"archive"}}, runner.calls)
+	require.NoDirExists(t, swiftPackagesPath)
+	require.Equal(t, map[string]int{retryOperationArchive: 1}, recorder.retries)
+}
+
+func TestRunArchiveCommandWithRetry_otherFailure(t *testing.T) {
+	runner := &fakeXcodebuildRunner{outputs: []fakeXcodebuildOutput{
+		{log: "error: No signing certificate found", err: errors.New("exit status 65")},
+	}}
+
+	_, err := runArchiveCommandWithRetry(runner, []string{"archive"}, t.TempDir(), nil, log.NewLogger())
+	require.EqualError(t, err, "exit status 65")
+	require.Len(t, runner.calls, 1)
+}
+
+func TestLocalXcodebuildRunner(t *testing.T) {
+	runner := localXcodebuildRunner{
+		xcodeCommandRunner: fakeXcodeCommandRunner{output: xcodecommand.Output{RawOut: []byte("** EXPORT FAILED **"), ExitCode: 70}, err: errors.New("exit status 70")},
+		logFormatter:       XcodebuildTool,
+		logger:             log.NewLogger(),
+	}
+
+	output, err := runner.ExportArchive([]string{"-exportArchive"})
+	require.Equal(t, "** EXPORT FAILED **", output)
+	require.Equal(t, 70, xcodebuildExitCode(err))
+
+	output, err = runner.Archive([]string{"archive"})
+	require.Equal(t, "** EXPORT FAILED **", output)
+	require.Equal(t, 70, xcodebuildExitCode(err))
+}
+
+func TestXcodebuildArchiver_SetXcodebuildRunner(t *testing.T) {
+	archiver := NewXcodebuildArchiver(fakeXcodeCommandRunner{}, XcprettyTool, nil, nil, nil, nil, nil, log.NewLogger())
+	require.IsType(t, localXcodebuildRunner{}, archiver.runner())
+
+	runner := &fakeXcodebuildRunner{}
+	archiver.SetXcodebuildRunner(runner)
+	require.Equal(t, runner, archiver.runner())
+}
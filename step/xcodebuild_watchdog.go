@@ -0,0 +1,339 @@
+package step
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+const (
+	bitriseXcodebuildDiagnosticsPthEnvKey = "BITRISE_XCODEBUILD_DIAGNOSTICS_PATH"
+
+	watchdogCheckInterval = 5 * time.Second
+	sampleDurationSeconds = 10
+)
+
+// outputActivity records the time of the last output of a command.
+type outputActivity struct {
+	mu         sync.Mutex
+	lastOutput time.Time
+	now        func() time.Time
+}
+
+func (a *outputActivity) record() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lastOutput = a.now()
+}
+
+func (a *outputActivity) last() time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.lastOutput
+}
+
+type activityWriter struct {
+	writer   io.Writer
+	activity *outputActivity
+}
+
+func (w activityWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		w.activity.record()
+	}
+	return w.writer.Write(p)
+}
+
+// watchState decides when to print a heartbeat, when a command is considered hung and when it runs for too long.
+type watchState struct {
+	start         time.Time
+	lastHeartbeat time.Time
+	opts          XcodebuildWatchdogOpts
+	hung          bool
+	slow          bool
+}
+
+func newWatchState(start time.Time, opts XcodebuildWatchdogOpts) *watchState {
+	return &watchState{
+		start:         start,
+		lastHeartbeat: start,
+		opts:          opts,
+	}
+}
+
+// check returns whether a heartbeat should be printed, whether the command just exceeded the no output timeout
+// and whether it just exceeded the diagnostics runtime threshold.
+// If the command's output is visible in the log, the heartbeat is printed only after heartbeatInterval without output.
+func (s *watchState) check(now, lastOutput time.Time) (heartbeat, hung, slow bool) {
+	if lastOutput.IsZero() {
+		lastOutput = s.start
+	}
+
+	if s.opts.NoOutputTimeout > 0 && !s.hung && now.Sub(lastOutput) >= s.opts.NoOutputTimeout {
+		s.hung = true
+		hung = true
+	}
+
+	if s.opts.DiagnosticsRuntimeThreshold > 0 && !s.slow && now.Sub(s.start) >= s.opts.DiagnosticsRuntimeThreshold {
+		s.slow = true
+		slow = true
+	}
+
+	if s.opts.HeartbeatInterval > 0 {
+		reference := s.lastHeartbeat
+		if s.opts.OutputVisible && lastOutput.After(reference) {
+			reference = lastOutput
+		}
+		if now.Sub(reference) >= s.opts.HeartbeatInterval {
+			s.lastHeartbeat = now
+			heartbeat = true
+		}
+	}
+
+	return heartbeat, hung, slow
+}
+
+// XcodebuildWatchdogOpts ...
+type XcodebuildWatchdogOpts struct {
+	HeartbeatInterval time.Duration // 0 disables the heartbeat
+	NoOutputTimeout   time.Duration // 0 disables the timeout
+	// OutputVisible is false if the xcodebuild output is not printed to the build log (xcodebuild log formatter),
+	// in this case the heartbeat is printed periodically.
+	OutputVisible bool
+
+	// DiagnosticsRuntimeThreshold captures diagnostics of an xcodebuild command running for longer, 0 disables it
+	DiagnosticsRuntimeThreshold time.Duration
+	// DiagnosticsDir is the directory of the captured sample and spindump reports
+	DiagnosticsDir string
+}
+
+// xcodebuildWatchdog is a command.Factory observing the output of the created xcodebuild commands:
+// it prints a heartbeat while xcodebuild runs, captures diagnostics of a slow xcodebuild command,
+// and captures diagnostics of and stops the xcodebuild command producing no output for the no output timeout.
+type xcodebuildWatchdog struct {
+	command.Factory
+
+	opts   XcodebuildWatchdogOpts
+	logger log.Logger
+	now    func() time.Time
+}
+
+// NewXcodebuildWatchdog wraps the command factory used by the xcodebuild runners.
+func NewXcodebuildWatchdog(factory command.Factory, opts XcodebuildWatchdogOpts, logger log.Logger) command.Factory {
+	return xcodebuildWatchdog{
+		Factory: factory,
+		opts:    opts,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// Create ...
+func (w xcodebuildWatchdog) Create(name string, args []string, opts *command.Opts) command.Command {
+	if name != "xcodebuild" || opts == nil || (w.opts.HeartbeatInterval == 0 && w.opts.NoOutputTimeout == 0 && w.opts.DiagnosticsRuntimeThreshold == 0) {
+		return w.Factory.Create(name, args, opts)
+	}
+
+	activity := &outputActivity{now: w.now}
+	watchedOpts := *opts
+	if opts.Stdout != nil {
+		watchedOpts.Stdout = activityWriter{writer: opts.Stdout, activity: activity}
+	}
+	if opts.Stderr != nil {
+		watchedOpts.Stderr = activityWriter{writer: opts.Stderr, activity: activity}
+	}
+
+	return &watchedCommand{
+		Command:  w.Factory.Create(name, args, &watchedOpts),
+		watchdog: w,
+		activity: activity,
+	}
+}
+
+type watchedCommand struct {
+	command.Command
+
+	watchdog xcodebuildWatchdog
+	activity *outputActivity
+	stop     func() bool
+}
+
+// Run ...
+func (c *watchedCommand) Run() error {
+	stop := c.watch()
+	err := c.Command.Run()
+	return c.wrapError(stop(), err)
+}
+
+// RunAndReturnExitCode ...
+func (c *watchedCommand) RunAndReturnExitCode() (int, error) {
+	stop := c.watch()
+	exitCode, err := c.Command.RunAndReturnExitCode()
+	return exitCode, c.wrapError(stop(), err)
+}
+
+// Start ...
+func (c *watchedCommand) Start() error {
+	err := c.Command.Start()
+	if err == nil {
+		c.stop = c.watch()
+	}
+	return err
+}
+
+// Wait ...
+func (c *watchedCommand) Wait() error {
+	err := c.Command.Wait()
+	if c.stop == nil {
+		return err
+	}
+	return c.wrapError(c.stop(), err)
+}
+
+func (c *watchedCommand) wrapError(hung bool, err error) error {
+	if hung && err != nil {
+		return fmt.Errorf("xcodebuild produced no output for %s and was stopped: %w", c.watchdog.opts.NoOutputTimeout, err)
+	}
+	return err
+}
+
+// watch starts observing the command, the returned function stops observing and returns whether the command was stopped as hung.
+func (c *watchedCommand) watch() func() bool {
+	w := c.watchdog
+	state := newWatchState(w.now(), w.opts)
+	done := make(chan struct{})
+	finished := make(chan bool)
+
+	go func() {
+		ticker := time.NewTicker(watchdogCheckInterval)
+		defer ticker.Stop()
+
+		hungDetected := false
+		for {
+			select {
+			case <-done:
+				finished <- hungDetected
+				return
+			case <-ticker.C:
+				now := w.now()
+				lastOutput := c.activity.last()
+				heartbeat, hung, slow := state.check(now, lastOutput)
+				if lastOutput.IsZero() {
+					lastOutput = state.start
+				}
+
+				if heartbeat {
+					w.logger.Printf("xcodebuild is running for %s, last output %s ago", now.Sub(state.start).Round(time.Second), now.Sub(lastOutput).Round(time.Second))
+				}
+				if slow && !hung {
+					w.logger.Println()
+					w.logger.Warnf("xcodebuild is running for more than %s, capturing diagnostics", w.opts.DiagnosticsRuntimeThreshold)
+					if pid, err := w.xcodebuildPID(); err != nil {
+						w.logger.Warnf("Failed to find the xcodebuild process: %s", err)
+					} else {
+						w.captureDiagnostics(pid, "slow")
+					}
+				}
+				if hung {
+					hungDetected = true
+					w.stopHungXcodebuild()
+				}
+			}
+		}
+	}()
+
+	return func() bool {
+		close(done)
+		return <-finished
+	}
+}
+
+func (w xcodebuildWatchdog) stopHungXcodebuild() {
+	w.logger.Println()
+	w.logger.Errorf("xcodebuild produced no output for %s, it is considered hung", w.opts.NoOutputTimeout)
+
+	pid, err := w.xcodebuildPID()
+	if err != nil {
+		w.logger.Warnf("Failed to find the xcodebuild process: %s", err)
+		return
+	}
+
+	w.captureDiagnostics(pid, "hung")
+
+	w.logger.Warnf("Stopping xcodebuild (pid: %s)", pid)
+	if out, err := w.Factory.Create("kill", []string{"-TERM", pid}, nil).RunAndReturnTrimmedCombinedOutput(); err != nil {
+		w.logger.Warnf("Failed to stop xcodebuild: %s: %s", out, err)
+	}
+}
+
+// xcodebuildPID returns the process ID of the xcodebuild command started by the Step.
+func (w xcodebuildWatchdog) xcodebuildPID() (string, error) {
+	out, err := w.Factory.Create("pgrep", []string{"-P", strconv.Itoa(os.Getpid()), "-x", "xcodebuild"}, nil).RunAndReturnTrimmedOutput()
+	if err != nil {
+		return "", err
+	}
+
+	pids := strings.Fields(out)
+	if len(pids) == 0 {
+		return "", fmt.Errorf("no running xcodebuild process found")
+	}
+	return pids[0], nil
+}
+
+// captureDiagnostics captures the call stacks of the process with the sample and spindump tools into the diagnostics dir.
+// spindump requires root privileges, it is run with non-interactive sudo.
+func (w xcodebuildWatchdog) captureDiagnostics(pid, reason string) {
+	if w.opts.DiagnosticsDir == "" {
+		return
+	}
+	if err := os.MkdirAll(w.opts.DiagnosticsDir, 0755); err != nil {
+		w.logger.Warnf("Failed to create the diagnostics dir: %s", err)
+		return
+	}
+
+	prefix := fmt.Sprintf("xcodebuild-%s-%s", reason, w.now().Format("20060102-150405"))
+	samplePath := filepath.Join(w.opts.DiagnosticsDir, prefix+"-sample.txt")
+	spindumpPath := filepath.Join(w.opts.DiagnosticsDir, prefix+"-spindump.txt")
+	duration := strconv.Itoa(sampleDurationSeconds)
+
+	tools := []struct {
+		name string
+		pth  string
+		cmd  command.Command
+	}{
+		{"sample", samplePath, w.Factory.Create("sample", []string{pid, duration, "-file", samplePath}, nil)},
+		{"spindump", spindumpPath, w.Factory.Create("sudo", []string{"-n", "spindump", pid, duration, "-file", spindumpPath}, nil)},
+	}
+	for _, tool := range tools {
+		if out, err := tool.cmd.RunAndReturnTrimmedCombinedOutput(); err != nil {
+			w.logger.Warnf("Failed to capture %s of the xcodebuild process: %s: %s", tool.name, out, err)
+			continue
+		}
+		w.logger.Printf("Captured %s of the xcodebuild process: %s", tool.name, tool.pth)
+	}
+}
+
+func (s XcodebuildArchiver) exportXcodebuildDiagnostics(diagnosticsDir, zipPath string, compressionLevel int) {
+	entries, err := os.ReadDir(diagnosticsDir)
+	if err != nil || len(entries) == 0 {
+		return
+	}
+
+	if err := os.RemoveAll(zipPath); err != nil {
+		s.logger.Warnf("Failed to remove path (%s), error: %s", zipPath, err)
+		return
+	}
+	if err := ExportOutputDirAsZip(s.cmdFactory, s.outputEnvKeys, diagnosticsDir, zipPath, bitriseXcodebuildDiagnosticsPthEnvKey, compressionLevel, s.logger); err != nil {
+		s.logger.Warnf("Failed to export %s, error: %s", s.outputEnvKey(bitriseXcodebuildDiagnosticsPthEnvKey), err)
+		return
+	}
+	s.logger.Donef("The xcodebuild diagnostics zip path is now available in the Environment Variable: %s (value: %s)", s.outputEnvKey(bitriseXcodebuildDiagnosticsPthEnvKey), zipPath)
+}
@@ -0,0 +1,87 @@
+package step
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_activityWriter(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	activity := &outputActivity{now: func() time.Time { return now }}
+	var buffer bytes.Buffer
+	writer := activityWriter{writer: &buffer, activity: activity}
+
+	_, err := writer.Write(nil)
+	require.NoError(t, err)
+	require.True(t, activity.last().IsZero())
+
+	_, err = writer.Write([]byte("CompileSwift normal arm64"))
+	require.NoError(t, err)
+	require.Equal(t, now, activity.last())
+	require.Equal(t, "CompileSwift normal arm64", buffer.String())
+}
+
+func Test_watchState_check(t *testing.T) {
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	t.Run("periodic heartbeat for invisible output", func(t *testing.T) {
+		state := newWatchState(start, XcodebuildWatchdogOpts{HeartbeatInterval: time.Minute})
+
+		heartbeat, hung, _ := state.check(at(30*time.Second), at(25*time.Second))
+		require.False(t, heartbeat)
+		require.False(t, hung)
+
+		heartbeat, _, _ = state.check(at(time.Minute), at(55*time.Second))
+		require.True(t, heartbeat)
+
+		heartbeat, _, _ = state.check(at(90*time.Second), at(85*time.Second))
+		require.False(t, heartbeat)
+
+		heartbeat, _, _ = state.check(at(2*time.Minute), at(115*time.Second))
+		require.True(t, heartbeat)
+	})
+
+	t.Run("heartbeat after silence for visible output", func(t *testing.T) {
+		state := newWatchState(start, XcodebuildWatchdogOpts{HeartbeatInterval: time.Minute, OutputVisible: true})
+
+		heartbeat, _, _ := state.check(at(time.Minute), at(50*time.Second))
+		require.False(t, heartbeat)
+
+		heartbeat, _, _ = state.check(at(110*time.Second), at(50*time.Second))
+		require.True(t, heartbeat)
+	})
+
+	t.Run("no output timeout", func(t *testing.T) {
+		state := newWatchState(start, XcodebuildWatchdogOpts{NoOutputTimeout: 10 * time.Minute, OutputVisible: true})
+
+		_, hung, _ := state.check(at(9*time.Minute), time.Time{})
+		require.False(t, hung)
+
+		_, hung, _ = state.check(at(15*time.Minute), at(6*time.Minute))
+		require.False(t, hung)
+
+		_, hung, _ = state.check(at(16*time.Minute), at(6*time.Minute))
+		require.True(t, hung)
+
+		_, hung, _ = state.check(at(17*time.Minute), at(6*time.Minute))
+		require.False(t, hung, "hang is reported once")
+	})
+
+	t.Run("diagnostics runtime threshold", func(t *testing.T) {
+		state := newWatchState(start, XcodebuildWatchdogOpts{DiagnosticsRuntimeThreshold: 30 * time.Minute})
+
+		_, _, slow := state.check(at(29*time.Minute), at(29*time.Minute))
+		require.False(t, slow)
+
+		_, hung, slow := state.check(at(30*time.Minute), at(30*time.Minute))
+		require.False(t, hung)
+		require.True(t, slow)
+
+		_, _, slow = state.check(at(31*time.Minute), at(31*time.Minute))
+		require.False(t, slow, "slow command is reported once")
+	})
+}
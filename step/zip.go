@@ -0,0 +1,198 @@
+package step
+
+import (
+	archivezip "archive/zip"
+	"compress/flate"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// zipModTime is the modification time of every zip entry, to make the zips reproducible.
+// The MS-DOS date format of zip can't represent earlier dates.
+var zipModTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// zipDir creates a reproducible zip of the source directory: the directory itself is the zip's root entry,
+// entries are written in lexical order with fixed timestamps, and symlinks are stored as links.
+// A compression level of 0 stores the files without compression.
+func zipDir(sourceDir, destinationZipPth string, compressionLevel int) error {
+	return writeZip(sourceDir, filepath.Dir(sourceDir), destinationZipPth, compressionLevel)
+}
+
+// zipDirContent creates a reproducible zip of the source directory's content, like zipDir, but the directory's entries are the zip's root entries
+// (for example the Payload dir of an ipa).
+func zipDirContent(sourceDir, destinationZipPth string, compressionLevel int) error {
+	return writeZip(sourceDir, sourceDir, destinationZipPth, compressionLevel)
+}
+
+// writeZip zips the source directory with the entry names relative to the root dir.
+func writeZip(sourceDir, rootDir, destinationZipPth string, compressionLevel int) (err error) {
+	if compressionLevel < flate.HuffmanOnly || compressionLevel > flate.BestCompression {
+		return fmt.Errorf("invalid compression level: %d", compressionLevel)
+	}
+
+	zipFile, err := os.Create(destinationZipPth)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := zipFile.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	writer := archivezip.NewWriter(zipFile)
+	writer.RegisterCompressor(archivezip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(out, compressionLevel)
+	})
+
+	method := archivezip.Deflate
+	if compressionLevel == flate.NoCompression {
+		method = archivezip.Store
+	}
+
+	walkErr := filepath.WalkDir(sourceDir, func(pth string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(rootDir, pth)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		header, err := archivezip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relPath)
+		header.Modified = zipModTime
+		header.Method = method
+
+		switch {
+		case entry.IsDir():
+			header.Name += "/"
+			header.Method = archivezip.Store
+			_, err := writer.CreateHeader(header)
+			return err
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(pth)
+			if err != nil {
+				return err
+			}
+
+			header.Method = archivezip.Store
+			w, err := writer.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write([]byte(target))
+			return err
+		case info.Mode().IsRegular():
+			w, err := writer.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			return copyFileContent(w, pth)
+		default:
+			return nil
+		}
+	})
+	if walkErr != nil {
+		return walkErr
+	}
+
+	return writer.Close()
+}
+
+func copyFileContent(w io.Writer, pth string) error {
+	f, err := os.Open(pth)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// unzip extracts the zip into the destination dir, restoring the file modes and the symlinks.
+// Entries pointing outside of the destination dir are rejected.
+func unzip(zipPth, destinationDir string) error {
+	reader, err := archivezip.OpenReader(zipPth)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	destinationDir, err = filepath.Abs(destinationDir)
+	if err != nil {
+		return err
+	}
+	for _, file := range reader.File {
+		pth := filepath.Join(destinationDir, filepath.FromSlash(file.Name))
+		if pth != destinationDir && !strings.HasPrefix(pth, destinationDir+string(filepath.Separator)) {
+			return fmt.Errorf("invalid zip entry, outside of the destination dir: %s", file.Name)
+		}
+
+		if err := unzipFile(file, pth); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", file.Name, err)
+		}
+	}
+	return nil
+}
+
+func unzipFile(file *archivezip.File, pth string) error {
+	mode := file.Mode()
+	if mode.IsDir() {
+		return os.MkdirAll(pth, 0755)
+	}
+	if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+		return err
+	}
+
+	if mode&os.ModeSymlink != 0 {
+		target, err := readZipFile(file)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(string(target), pth)
+	}
+	if !mode.IsRegular() {
+		return nil
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	f, err := os.OpenFile(pth, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, rc); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,122 @@
+package step
+
+import (
+	archivezip "archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_zipDir(t *testing.T) {
+	sourceDir := filepath.Join(t.TempDir(), "Sample.xcarchive")
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "Products", "Applications"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "Info.plist"), []byte("info"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "Products", "Applications", "binary"), []byte("binary content"), 0755))
+	require.NoError(t, os.Symlink("binary", filepath.Join(sourceDir, "Products", "Applications", "link")))
+
+	firstZip := filepath.Join(t.TempDir(), "first.zip")
+	require.NoError(t, zipDir(sourceDir, firstZip, 9))
+
+	// Touching the files must not change the zip
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(sourceDir, "Info.plist"), later, later))
+
+	secondZip := filepath.Join(t.TempDir(), "second.zip")
+	require.NoError(t, zipDir(sourceDir, secondZip, 9))
+
+	firstContent, err := os.ReadFile(firstZip)
+	require.NoError(t, err)
+	secondContent, err := os.ReadFile(secondZip)
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(firstContent, secondContent), "zips are not reproducible")
+
+	reader, err := archivezip.OpenReader(firstZip)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, reader.Close())
+	}()
+
+	var names []string
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+		require.True(t, file.Modified.Equal(zipModTime), file.Name)
+	}
+	require.Equal(t, []string{
+		"Sample.xcarchive/",
+		"Sample.xcarchive/Info.plist",
+		"Sample.xcarchive/Products/",
+		"Sample.xcarchive/Products/Applications/",
+		"Sample.xcarchive/Products/Applications/binary",
+		"Sample.xcarchive/Products/Applications/link",
+	}, names)
+
+	link := reader.File[5]
+	require.NotZero(t, link.Mode()&os.ModeSymlink)
+	rc, err := link.Open()
+	require.NoError(t, err)
+	target, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	require.Equal(t, "binary", string(target))
+}
+
+func Test_zipDir_InvalidCompressionLevel(t *testing.T) {
+	require.Error(t, zipDir(t.TempDir(), filepath.Join(t.TempDir(), "out.zip"), 10))
+}
+
+func Test_zipDirContent_unzip(t *testing.T) {
+	sourceDir := t.TempDir()
+	appDir := filepath.Join(sourceDir, "Payload", "Sample.app")
+	require.NoError(t, os.MkdirAll(appDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(appDir, "Sample"), []byte("binary content"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(appDir, "Info.plist"), []byte("info"), 0644))
+	require.NoError(t, os.Symlink("Sample", filepath.Join(appDir, "link")))
+
+	ipaPath := filepath.Join(t.TempDir(), "Sample.ipa")
+	require.NoError(t, zipDirContent(sourceDir, ipaPath, 6))
+
+	reader, err := archivezip.OpenReader(ipaPath)
+	require.NoError(t, err)
+	var names []string
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+	}
+	require.NoError(t, reader.Close())
+	require.Equal(t, []string{
+		"Payload/",
+		"Payload/Sample.app/",
+		"Payload/Sample.app/Info.plist",
+		"Payload/Sample.app/Sample",
+		"Payload/Sample.app/link",
+	}, names)
+
+	extractDir := t.TempDir()
+	appPath, err := extractIPAApp(ipaPath, extractDir)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(extractDir, "Payload", "Sample.app"), appPath)
+
+	info, err := os.Stat(filepath.Join(appPath, "Sample"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	target, err := os.Readlink(filepath.Join(appPath, "link"))
+	require.NoError(t, err)
+	require.Equal(t, "Sample", target)
+}
+
+func Test_unzip_entryOutsideOfDestination(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "evil.zip")
+	f, err := os.Create(zipPath)
+	require.NoError(t, err)
+	writer := archivezip.NewWriter(f)
+	_, err = writer.Create("../evil")
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	require.NoError(t, f.Close())
+
+	require.EqualError(t, unzip(zipPath, t.TempDir()), "invalid zip entry, outside of the destination dir: ../evil")
+}